@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/Alina9496/tool/pkg/postgres"
+)
+
+// The exit codes of docs-admin.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	// exitCheck means the command ran and found a problem, e.g. a document
+	// whose content does not match its checksum.
+	exitCheck = 3
+)
+
+const defaultConfig = "../../config/config.yml"
+
+var errCheckFailed = errors.New("check failed")
+
+// reportedError has already been written to stderr by the command.
+type reportedError struct {
+	error
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.error
+}
+
+// usageError is a mistake in the command line.
+type usageError struct {
+	text string
+}
+
+func (e *usageError) Error() string {
+	return e.text
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{text: fmt.Sprintf(format, args...)}
+}
+
+type command struct {
+	usage string
+	run   func(c *cli, ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"migrate":          {usage: "migrate up [N] | down [-all] [N] | force VERSION | status", run: (*cli).migrate},
+	"user":             {usage: "user create [-p PASSWORD] LOGIN | disable LOGIN | enable LOGIN", run: (*cli).user},
+	"rehash-passwords": {usage: "rehash-passwords", run: (*cli).rehashPasswords},
+	"purge-tokens":     {usage: "purge-tokens", run: (*cli).purgeTokens},
+	"purge-trash":      {usage: "purge-trash", run: (*cli).purgeTrash},
+	"usage":            {usage: "usage", run: (*cli).storageUsage},
+	"verify-checksums": {usage: "verify-checksums [-batch N]", run: (*cli).verifyChecksums},
+}
+
+// cli holds what the commands share: the streams, the config and the
+// connection to Postgres opened by the first command that needs it.
+type cli struct {
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	cfg        *config.Config
+	migrations string
+	verbose    bool
+	pg         *postgres.Postgres
+	// command is the usage of the running command.
+	command string
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	path := os.Getenv("DOCS_ADMIN_CONFIG")
+	if path == "" {
+		path = defaultConfig
+	}
+
+	flags := flag.NewFlagSet("docs-admin", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&path, "config", path, "the config file of the service")
+	flags.StringVar(&c.migrations, "migrations", "", "the migrations directory, migrations.path of the config by default")
+	flags.BoolVar(&c.verbose, "v", false, "write the log of the service to stdout")
+	flags.Usage = func() { c.usage(flags) }
+
+	err := flags.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		c.usage(flags)
+		return exitUsage
+	}
+
+	c.cfg, err = config.Load(path)
+	if err != nil {
+		return c.fail(err)
+	}
+	if c.migrations == "" {
+		c.migrations = c.cfg.Migrations.Path
+	}
+	defer c.close()
+
+	c.command = cmd.usage
+	err = cmd.run(c, ctx, flags.Args()[1:])
+	if err != nil {
+		return c.fail(err)
+	}
+
+	return exitOK
+}
+
+func (c *cli) usage(flags *flag.FlagSet) {
+	fmt.Fprintln(c.stderr, "usage: docs-admin [-config FILE] [-migrations DIR] [-v] COMMAND [ARGS]")
+	fmt.Fprintln(c.stderr, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(c.stderr, "  "+commands[name].usage)
+	}
+
+	fmt.Fprintln(c.stderr, "\noptions:")
+	flags.PrintDefaults()
+}
+
+func (c *cli) fail(err error) int {
+	var reported *reportedError
+	if !errors.As(err, &reported) {
+		fmt.Fprintln(c.stderr, "docs-admin: "+err.Error())
+	}
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, errCheckFailed):
+		return exitCheck
+	default:
+		return exitError
+	}
+}
+
+// service connects to Postgres and returns the service the maintenance runs
+// in. Its actions are audited with docs-admin as the user agent.
+func (c *cli) service(ctx context.Context) (*service.Service, context.Context, error) {
+	l := logger.New(c.cfg.Log.Level)
+	if !c.verbose {
+		// the logger of the service writes to stdout, the errors are
+		// reported on stderr by docs-admin
+		zerolog.SetGlobalLevel(zerolog.Disabled)
+	}
+
+	if c.pg == nil {
+		pg, err := postgres.New(c.cfg.PG.URL, postgres.MaxPoolSize(2), postgres.ConnAttempts(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		c.pg = pg
+	}
+
+	s, err := service.New(
+		repo.New(c.pg, l),
+		cache.New(c.cfg.DefaultExpiration, c.cfg.CleanupInterval),
+		l,
+		c.cfg,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s, context.WithValue(ctx, service.ClientKey, service.Client{UserAgent: "docs-admin"}), nil
+}
+
+func (c *cli) close() {
+	if c.pg != nil {
+		c.pg.Close()
+	}
+}
+
+// newFlags returns the flags of a command, a mistake in them is a usage error.
+func (c *cli) newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(c.stderr, "usage: docs-admin "+c.command)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil {
+		return &usageError{text: err.Error()}
+	}
+	return nil
+}
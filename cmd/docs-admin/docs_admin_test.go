@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_listMigrations(t *testing.T) {
+	migrations, err := listMigrations("../../migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, uint(i+1), m.version, "the versions have no gaps")
+		assert.True(t, m.up, "%d_%s has an up migration", m.version, m.name)
+		assert.True(t, m.down, "%d_%s has a down migration", m.version, m.name)
+	}
+}
+
+func Test_listMigrations_files(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"000002_b.up.sql", "000001_a.up.sql", "000001_a.down.sql", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+
+	migrations, err := listMigrations(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []migration{
+		{version: 1, name: "a", up: true, down: true},
+		{version: 2, name: "b", up: true},
+	}, migrations)
+}
+
+// Test_usage covers the mistakes found before Postgres is connected to.
+func Test_usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "unknown command", args: []string{"vacuum"}},
+		{name: "migrate without command", args: []string{"migrate"}, err: "up, down, force or status expected"},
+		{name: "unknown migrate command", args: []string{"migrate", "sideways"}, err: `unknown command "sideways"`},
+		{name: "invalid number", args: []string{"migrate", "up", "0"}, err: `invalid number of migrations "0"`},
+		{name: "all with a number", args: []string{"migrate", "down", "-all", "2"}, err: "can not be used together"},
+		{name: "force without version", args: []string{"migrate", "force"}, err: "force needs a version"},
+		{name: "user without login", args: []string{"user", "disable"}, err: "disable needs a login"},
+		{name: "create without login", args: []string{"user", "create", "-p", "Passw_345"}, err: "create needs a login"},
+		{name: "invalid batch", args: []string{"verify-checksums", "-batch", "0"}, err: "invalid arguments"},
+		{name: "unknown flag", args: []string{"usage", "-all"}, err: "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, exitUsage, code)
+			assert.Contains(t, stderr.String(), tt.err)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+func Test_exitCode(t *testing.T) {
+	assert.Equal(t, exitOK, exitCode(nil))
+	assert.Equal(t, exitUsage, exitCode(usagef("bad")))
+	assert.Equal(t, exitCheck, exitCode(&reportedError{errCheckFailed}))
+	assert.Equal(t, exitError, exitCode(os.ErrNotExist))
+}
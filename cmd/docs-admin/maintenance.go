@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+)
+
+func (c *cli) rehashPasswords(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usagef("rehash-passwords: too many arguments")
+	}
+
+	s, ctx, err := c.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	rehashed, err := s.RehashPasswords(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "rehashed %d passwords\n", rehashed)
+	return nil
+}
+
+func (c *cli) purgeTokens(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usagef("purge-tokens: too many arguments")
+	}
+
+	s, ctx, err := c.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	purged, err := s.PurgeTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "purged %d tokens\n", purged)
+	return nil
+}
+
+// purgeTrash deletes the documents that stayed in the trash longer than
+// trash.retention, the documents under legal hold are kept.
+func (c *cli) purgeTrash(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usagef("purge-trash: too many arguments")
+	}
+
+	s, ctx, err := c.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	purged, err := s.PurgeTrash(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "purged %d documents\n", purged)
+	return nil
+}
+
+// storageUsage counts the documents of the users and prints their storage
+// usage.
+func (c *cli) storageUsage(ctx context.Context, args []string) error {
+	flags := c.newFlags("usage")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("usage: too many arguments")
+	}
+
+	s, ctx, err := c.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage, err := s.GetStorageUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tLOGIN\tDOCUMENTS\tBYTES\tTRASH DOCUMENTS\tTRASH BYTES")
+	for _, u := range usage {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n",
+			u.UserID, u.Login, u.Documents, u.Bytes, u.TrashDocuments, u.TrashBytes)
+	}
+	return w.Flush()
+}
+
+// verifyChecksums prints the documents whose content does not match their
+// checksum and fails when there are any.
+func (c *cli) verifyChecksums(ctx context.Context, args []string) error {
+	flags := c.newFlags("verify-checksums")
+	batch := flags.Int("batch", 100, "how many documents are read at once")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *batch < 1 {
+		return usagef("verify-checksums: invalid arguments")
+	}
+
+	s, ctx, err := c.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.VerifyChecksums(ctx, *batch)
+	if err != nil {
+		return err
+	}
+
+	if len(report.Mismatches) > 0 {
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOCUMENT\tUSER\tNAME\tEXPECTED\tACTUAL")
+		for _, m := range report.Mismatches {
+			actual := m.Actual
+			if actual == "" {
+				actual = "invalid content"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", m.DocumentID, m.UserID, strconv.Quote(m.Name), m.Expected, actual)
+		}
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "checked %d documents, %d mismatches\n", report.Checked, len(report.Mismatches))
+	if len(report.Mismatches) > 0 {
+		return &reportedError{errCheckFailed}
+	}
+	return nil
+}
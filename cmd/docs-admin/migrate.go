@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/golang-migrate/migrate/v4"
+	// migrate tools
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migration is a version in the migrations directory.
+type migration struct {
+	version uint
+	name    string
+	up      bool
+	down    bool
+}
+
+// listMigrations returns the migrations of the directory in the order they
+// are applied in.
+func listMigrations(dir string) ([]migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[uint]*migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		m, ok := versions[uint(version)]
+		if !ok {
+			m = &migration{version: uint(version), name: match[2]}
+			versions[uint(version)] = m
+		}
+		if match[3] == "up" {
+			m.up = true
+		} else {
+			m.down = true
+		}
+	}
+
+	migrations := make([]migration, 0, len(versions))
+	for _, m := range versions {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	return migrations, nil
+}
+
+func (c *cli) migrate(_ context.Context, args []string) error {
+	if len(args) == 0 {
+		return usagef("migrate: up, down, force or status expected")
+	}
+
+	switch args[0] {
+	case "up":
+		return c.migrateUp(args[1:])
+	case "down":
+		return c.migrateDown(args[1:])
+	case "force":
+		return c.migrateForce(args[1:])
+	case "status":
+		return c.migrateStatus(args[1:])
+	default:
+		return usagef("migrate: unknown command %q", args[0])
+	}
+}
+
+// newMigrate opens the migrations directory and the database.
+func (c *cli) newMigrate() (*migrate.Migrate, error) {
+	path, err := filepath.Abs(c.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.New("file://"+filepath.ToSlash(path), c.cfg.PG.URL)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	return m, nil
+}
+
+// steps parses the optional number of migrations, zero when it is not given.
+func steps(flags []string) (int, error) {
+	switch len(flags) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(flags[0])
+		if err != nil || n < 1 {
+			return 0, usagef("migrate: invalid number of migrations %q", flags[0])
+		}
+		return n, nil
+	default:
+		return 0, usagef("migrate: too many arguments")
+	}
+}
+
+// migrateUp applies all pending migrations or the next N of them.
+func (c *cli) migrateUp(args []string) error {
+	flags := c.newFlags("up")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	n, err := steps(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	m, err := c.newMigrate()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if n == 0 {
+		err = m.Up()
+	} else {
+		err = m.Steps(n)
+	}
+	return c.reportMigration(m, err)
+}
+
+// migrateDown reverts the last migration, the last N of them or all with -all.
+func (c *cli) migrateDown(args []string) error {
+	flags := c.newFlags("down")
+	all := flags.Bool("all", false, "revert all migrations")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	n, err := steps(flags.Args())
+	if err != nil {
+		return err
+	}
+	if *all && n > 0 {
+		return usagef("migrate: -all and a number of migrations can not be used together")
+	}
+
+	m, err := c.newMigrate()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch {
+	case *all:
+		err = m.Down()
+	case n == 0:
+		err = m.Steps(-1)
+	default:
+		err = m.Steps(-n)
+	}
+	return c.reportMigration(m, err)
+}
+
+// migrateForce sets the version without running a migration, to clear the
+// dirty state after a failed migration was repaired by hand.
+func (c *cli) migrateForce(args []string) error {
+	if len(args) != 1 {
+		return usagef("migrate: force needs a version")
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < -1 {
+		return usagef("migrate: invalid version %q", args[0])
+	}
+
+	m, err := c.newMigrate()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return c.reportMigration(m, m.Force(version))
+}
+
+func (c *cli) reportMigration(m *migrate.Migrate, err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Fprintln(c.stdout, "no change")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Fprintln(c.stdout, "no migrations applied")
+	case err != nil:
+		return fmt.Errorf("migrate: %w", err)
+	case dirty:
+		fmt.Fprintf(c.stdout, "version %d (dirty)\n", version)
+	default:
+		fmt.Fprintf(c.stdout, "version %d\n", version)
+	}
+	return nil
+}
+
+// migrateStatus lists the migrations of the directory as applied or pending.
+func (c *cli) migrateStatus(args []string) error {
+	if len(args) != 0 {
+		return usagef("migrate: too many arguments")
+	}
+
+	migrations, err := listMigrations(c.migrations)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	m, err := c.newMigrate()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, migration := range migrations {
+		state := "pending"
+		switch {
+		case migration.version == current && dirty:
+			state = "dirty"
+		case migration.version <= current:
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", migration.version, migration.name, state)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migrate: version %d is dirty, repair it and run migrate force: %w", current, errCheckFailed)
+	}
+	return nil
+}
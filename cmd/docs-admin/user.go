@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Alina9496/documents/internal/domain"
+)
+
+func (c *cli) user(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usagef("user: create, disable or enable expected")
+	}
+
+	switch args[0] {
+	case "create":
+		return c.createUser(ctx, args[1:])
+	case "disable", "enable":
+		if len(args) != 2 {
+			return usagef("user: %s needs a login", args[0])
+		}
+		return c.setUserDisabled(ctx, args[1], args[0] == "disable")
+	default:
+		return usagef("user: unknown command %q", args[0])
+	}
+}
+
+// createUser registers a user with the password of -p, $DOCS_ADMIN_PASSWORD
+// or the first line of stdin.
+func (c *cli) createUser(ctx context.Context, args []string) error {
+	flags := c.newFlags("create")
+	password := flags.String("p", "", "the password")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("user: create needs a login")
+	}
+
+	if *password == "" {
+		*password = os.Getenv("DOCS_ADMIN_PASSWORD")
+	}
+	if *password == "" {
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	s, ctx, err := c.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	login, err := s.Registration(ctx, &domain.User{Login: flags.Arg(0), Password: *password})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "created %s\n", login)
+	return nil
+}
+
+// setUserDisabled disables a user and revokes their tokens or enables them.
+func (c *cli) setUserDisabled(ctx context.Context, login string, disable bool) error {
+	s, ctx, err := c.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	done, do := "enabled", s.EnableUser
+	if disable {
+		done, do = "disabled", s.DisableUser
+	}
+
+	err = do(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "%s %s\n", done, login)
+	return nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Alina9496/documents/pkg/api/v1/client"
+)
+
+// The exit codes of docsctl.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitAuth      = 3
+	exitForbidden = 4
+	exitNotFound  = 5
+	exitConflict  = 6
+	exitPartial   = 7
+)
+
+const defaultURL = "http://localhost:8080"
+
+var (
+	errNotLoggedIn = errors.New("not logged in, run docsctl login")
+	errPartial     = errors.New("some of the operations failed")
+)
+
+// reportedError has already been written to stderr by the command.
+type reportedError struct {
+	error
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.error
+}
+
+// usageError is a mistake in the command line.
+type usageError struct {
+	text string
+}
+
+func (e *usageError) Error() string {
+	return e.text
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{text: fmt.Sprintf(format, args...)}
+}
+
+type command struct {
+	usage string
+	run   func(c *cli, ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"login":    {usage: "login -u LOGIN [-p PASSWORD]", run: (*cli).login},
+	"logout":   {usage: "logout", run: (*cli).logout},
+	"upload":   {usage: "upload [-r] [-folder ID] [-mime TYPE] [-tag TAG]... [-public] PATH|GLOB...", run: (*cli).upload},
+	"download": {usage: "download [-dir DIR] ID...", run: (*cli).download},
+	"ls":       {usage: "ls [-scope SCOPE] [-tag TAG]... [-mime TYPE] [-name PREFIX] [-owner LOGIN] [-public true|false] [-limit N] [-all]", run: (*cli).ls},
+	"rm":       {usage: "rm ID...", run: (*cli).rm},
+	"share":    {usage: "share [-folder] [-permission read|comment|edit] ID LOGIN", run: (*cli).share},
+	"unshare":  {usage: "unshare [-folder] ID LOGIN", run: (*cli).unshare},
+}
+
+// cli holds what the commands share: the streams, the output format and the
+// settings stored by login.
+type cli struct {
+	stdin    io.Reader
+	stdout   io.Writer
+	stderr   io.Writer
+	output   string
+	path     string
+	settings settings
+	url      string
+	// command is the usage of the running command.
+	command string
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("docsctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&c.path, "config", os.Getenv("DOCSCTL_CONFIG"), "the file the token is stored in")
+	flags.StringVar(&c.url, "url", os.Getenv("DOCSCTL_URL"), "the address of the service, "+defaultURL+" by default")
+	flags.StringVar(&c.output, "o", "table", "the output format, table or json")
+	flags.Usage = func() { c.usage(flags) }
+
+	err := flags.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+	if c.output != "table" && c.output != "json" {
+		return c.fail(usagef("unknown output format %q", c.output))
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		c.usage(flags)
+		return exitUsage
+	}
+
+	err = c.load()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	c.command = cmd.usage
+	err = cmd.run(c, ctx, flags.Args()[1:])
+	if err != nil {
+		return c.fail(err)
+	}
+
+	return exitOK
+}
+
+func (c *cli) usage(flags *flag.FlagSet) {
+	fmt.Fprintln(c.stderr, "usage: docsctl [-config FILE] [-url URL] [-o table|json] COMMAND [ARGS]")
+	fmt.Fprintln(c.stderr, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(c.stderr, "  "+commands[name].usage)
+	}
+
+	fmt.Fprintln(c.stderr, "\noptions:")
+	flags.PrintDefaults()
+}
+
+func (c *cli) fail(err error) int {
+	var reported *reportedError
+	if !errors.As(err, &reported) {
+		fmt.Fprintln(c.stderr, "docsctl: "+err.Error())
+	}
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, errPartial):
+		return exitPartial
+	case errors.Is(err, errNotLoggedIn), errors.Is(err, client.ErrUnauthorized):
+		return exitAuth
+	case errors.Is(err, client.ErrForbidden):
+		return exitForbidden
+	case errors.Is(err, client.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, client.ErrConflict),
+		errors.Is(err, client.ErrPreconditionFailed),
+		errors.Is(err, client.ErrLocked):
+		return exitConflict
+	default:
+		return exitError
+	}
+}
+
+// client returns a client with the stored token.
+func (c *cli) client() (*client.Client, error) {
+	if c.settings.Token == "" {
+		return nil, errNotLoggedIn
+	}
+	return client.New(c.baseURL(), client.Token(c.settings.Token)), nil
+}
+
+func (c *cli) baseURL() string {
+	switch {
+	case c.url != "":
+		return c.url
+	case c.settings.URL != "":
+		return c.settings.URL
+	default:
+		return defaultURL
+	}
+}
+
+// newFlags returns the flags of a command, a mistake in them is a usage error.
+func (c *cli) newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(c.stderr, "usage: docsctl "+c.command)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil {
+		return &usageError{text: err.Error()}
+	}
+	return nil
+}
+
+// batch runs an operation of a command for every item and reports the
+// failed ones as they happen. The error of the last failure is returned when
+// all of them failed, errPartial when some did.
+func batch[T any](c *cli, items []T, name func(T) string, do func(T) error) error {
+	var last error
+	failed := 0
+	for _, item := range items {
+		err := do(item)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "docsctl: %s: %s\n", name(item), strings.TrimPrefix(err.Error(), "documents api: "))
+			last = err
+			failed++
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return nil
+	case failed == len(items):
+		return &reportedError{last}
+	default:
+		return &reportedError{errPartial}
+	}
+}
+
+// stringsFlag collects a repeated flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/Alina9496/documents/pkg/api/v1/client"
+	"github.com/google/uuid"
+)
+
+// login authenticates and stores the token with the address of the service.
+// The password is read from $DOCSCTL_PASSWORD or the first line of stdin when
+// -p is not given, so it does not end up in the shell history.
+func (c *cli) login(ctx context.Context, args []string) error {
+	flags := c.newFlags("login")
+	login := flags.String("u", "", "the login")
+	password := flags.String("p", "", "the password")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if *login == "" {
+		return usagef("login: -u is required")
+	}
+
+	if *password == "" {
+		*password = os.Getenv("DOCSCTL_PASSWORD")
+	}
+	if *password == "" {
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	token, err := client.New(c.baseURL()).Authenticate(ctx, *login, *password)
+	if err != nil {
+		return err
+	}
+
+	c.settings = settings{URL: c.baseURL(), Token: token}
+	err = c.save()
+	if err != nil {
+		return err
+	}
+
+	return c.print(&table{
+		header: []string{"LOGIN", "URL"},
+		rows:   [][]string{{*login, c.settings.URL}},
+		value:  map[string]string{"login": *login, "url": c.settings.URL},
+	})
+}
+
+// logout revokes the stored token and forgets it.
+func (c *cli) logout(ctx context.Context, args []string) error {
+	err := parseFlags(c.newFlags("logout"), args)
+	if err != nil {
+		return err
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	err = api.LogOut(ctx, c.settings.Token)
+	if err != nil {
+		return err
+	}
+
+	c.settings.Token = ""
+	return c.save()
+}
+
+func (c *cli) upload(ctx context.Context, args []string) error {
+	flags := c.newFlags("upload")
+	recursive := flags.Bool("r", false, "upload the files of directories and their subdirectories")
+	folderID := flags.String("folder", "", "the id of the folder to upload to")
+	mimeType := flags.String("mime", "", "the type of the files, guessed by default")
+	public := flags.Bool("public", false, "make the documents public")
+	description := flags.String("description", "", "the description of the documents")
+	var tags stringsFlag
+	flags.Var(&tags, "tag", "a tag of the documents, repeat for more")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("upload: no files given")
+	}
+
+	files, err := collectFiles(flags.Args(), *recursive)
+	if err != nil {
+		return err
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	uploaded := make([]v1.Data, 0, len(files))
+	batchErr := batch(c, files, func(f file) string { return f.path }, func(f file) error {
+		if f.err != nil {
+			return f.err
+		}
+
+		document, err := uploadFile(ctx, api, f.path, v1.Meta{
+			Name:        filepath.Base(f.path),
+			Mime:        *mimeType,
+			Description: *description,
+			Tags:        tags,
+			FolderID:    *folderID,
+			Public:      *public,
+		})
+		if err != nil {
+			return err
+		}
+
+		uploaded = append(uploaded, *document)
+		return nil
+	})
+
+	rows := make([][]string, 0, len(uploaded))
+	for _, document := range uploaded {
+		rows = append(rows, []string{document.ID, document.Name, document.Mime, strconv.FormatInt(document.Size, 10)})
+	}
+	err = c.print(&table{header: []string{"ID", "NAME", "MIME", "SIZE"}, rows: rows, value: uploaded})
+	if err != nil {
+		return err
+	}
+
+	return batchErr
+}
+
+// file is a file to upload or the reason a path given can not be uploaded.
+type file struct {
+	path string
+	err  error
+}
+
+// collectFiles expands the globs, a directory is walked with recursive.
+func collectFiles(patterns []string, recursive bool) ([]file, error) {
+	files := make([]file, 0, len(patterns))
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, usagef("upload: %s: %s", pattern, err)
+		}
+		if len(matches) == 0 {
+			files = append(files, file{path: pattern, err: fs.ErrNotExist})
+			continue
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			switch {
+			case err != nil:
+				files = append(files, file{path: match, err: err})
+			case !info.IsDir():
+				files = append(files, file{path: match})
+			case !recursive:
+				files = append(files, file{path: match, err: fmt.Errorf("is a directory, use -r")})
+			default:
+				err = filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+					if err != nil {
+						files = append(files, file{path: path, err: err})
+						return nil
+					}
+					if entry.Type().IsRegular() {
+						files = append(files, file{path: path})
+					}
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// uploadFile streams the file with an idempotency key, so a failed attempt
+// is retried without creating the document twice.
+func uploadFile(ctx context.Context, api *client.Client, path string, meta v1.Meta) (*v1.Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if meta.Mime == "" {
+		meta.Mime, err = detectMime(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return api.Upload(ctx, meta, f, uuid.NewString())
+}
+
+// detectMime guesses the type by the extension, then by the content.
+func detectMime(f *os.File) (string, error) {
+	detected := mime.TypeByExtension(filepath.Ext(f.Name()))
+	if detected == "" {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(f, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return "", err
+		}
+		detected = http.DetectContentType(head[:n])
+
+		_, err = f.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(detected)
+	if err != nil {
+		return "application/octet-stream", nil
+	}
+	return mediaType, nil
+}
+
+type downloaded struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func (c *cli) download(ctx context.Context, args []string) error {
+	flags := c.newFlags("download")
+	dir := flags.String("dir", ".", "the directory to save the documents in")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("download: no documents given")
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	saved := make([]downloaded, 0, flags.NArg())
+	batchErr := batch(c, flags.Args(), func(id string) string { return id }, func(id string) error {
+		document, err := downloadFile(ctx, api, id, *dir)
+		if err != nil {
+			return err
+		}
+
+		saved = append(saved, *document)
+		return nil
+	})
+
+	rows := make([][]string, 0, len(saved))
+	for _, document := range saved {
+		rows = append(rows, []string{document.ID, document.Path, strconv.FormatInt(document.Size, 10)})
+	}
+	err = c.print(&table{header: []string{"ID", "PATH", "SIZE"}, rows: rows, value: saved})
+	if err != nil {
+		return err
+	}
+
+	return batchErr
+}
+
+// downloadFile saves the document under its name, a failed download leaves
+// no partial file behind.
+func downloadFile(ctx context.Context, api *client.Client, id, dir string) (*downloaded, error) {
+	f, err := os.CreateTemp(dir, ".docsctl-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	info, err := api.Download(ctx, id, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(filepath.Clean("/" + info.Name))
+	if name == "/" || name == "." {
+		name = id
+	}
+	path := filepath.Join(dir, name)
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size
+	if stat, err := os.Stat(path); err == nil {
+		size = stat.Size()
+	}
+
+	return &downloaded{ID: id, Name: info.Name, Path: path, Size: size}, nil
+}
+
+type listing struct {
+	Docs       []v1.Document `json:"docs"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func (c *cli) ls(ctx context.Context, args []string) error {
+	flags := c.newFlags("ls")
+	filter := client.DocumentsFilter{}
+	flags.StringVar(&filter.Scope, "scope", "", "owned, shared, public or all")
+	flags.StringVar(&filter.Mime, "mime", "", "the type of the documents")
+	flags.StringVar(&filter.NamePrefix, "name", "", "the start of the names")
+	flags.StringVar(&filter.Owner, "owner", "", "the login of the owner")
+	flags.StringVar(&filter.Sort, "sort", "", "name, created or size")
+	flags.StringVar(&filter.Order, "order", "", "asc or desc")
+	flags.StringVar(&filter.Cursor, "cursor", "", "the next_cursor of the previous page")
+	flags.IntVar(&filter.Limit, "limit", 50, "the size of a page")
+	public := flags.String("public", "", "true or false")
+	all := flags.Bool("all", false, "list every page")
+	var tags stringsFlag
+	flags.Var(&tags, "tag", "a tag the documents have, repeat for more")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	filter.Tags = tags
+
+	if *public != "" {
+		value, err := strconv.ParseBool(*public)
+		if err != nil {
+			return usagef("ls: -public must be true or false")
+		}
+		filter.Public = &value
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	result := listing{Docs: make([]v1.Document, 0)}
+	for {
+		page, err := api.GetDocuments(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		result.Docs = append(result.Docs, page.DataDocuments.Docs...)
+		result.NextCursor = page.NextCursor
+		if !*all || page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	rows := make([][]string, 0, len(result.Docs))
+	for _, document := range result.Docs {
+		rows = append(rows, []string{
+			document.ID, document.Name, document.Mime,
+			strconv.FormatInt(document.Size, 10), document.Created, document.Permission,
+		})
+	}
+	err = c.print(&table{header: []string{"ID", "NAME", "MIME", "SIZE", "CREATED", "PERMISSION"}, rows: rows, value: result})
+	if err != nil {
+		return err
+	}
+
+	if result.NextCursor != "" && c.output == "table" {
+		fmt.Fprintf(c.stderr, "more documents: docsctl ls -cursor %s\n", result.NextCursor)
+	}
+
+	return nil
+}
+
+func (c *cli) rm(ctx context.Context, args []string) error {
+	flags := c.newFlags("rm")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("rm: no documents given")
+	}
+	ids := flags.Args()
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	deleted := make([]string, 0, len(ids))
+	batchErr := batch(c, ids, func(id string) string { return id }, func(id string) error {
+		err := api.DeleteDocument(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		deleted = append(deleted, id)
+		return nil
+	})
+
+	rows := make([][]string, 0, len(deleted))
+	for _, id := range deleted {
+		rows = append(rows, []string{id})
+	}
+	err = c.print(&table{header: []string{"DELETED"}, rows: rows, value: map[string][]string{"deleted": deleted}})
+	if err != nil {
+		return err
+	}
+
+	return batchErr
+}
+
+type grant struct {
+	ID         string `json:"id"`
+	Folder     bool   `json:"folder"`
+	Login      string `json:"login"`
+	Permission string `json:"permission,omitempty"`
+	Shared     bool   `json:"shared"`
+}
+
+func (c *cli) share(ctx context.Context, args []string) error {
+	return c.grant(ctx, "share", args, true)
+}
+
+func (c *cli) unshare(ctx context.Context, args []string) error {
+	return c.grant(ctx, "unshare", args, false)
+}
+
+func (c *cli) grant(ctx context.Context, name string, args []string, share bool) error {
+	flags := c.newFlags(name)
+	folder := flags.Bool("folder", false, "the id is of a folder")
+	permission := "read"
+	if share {
+		flags.StringVar(&permission, "permission", "read", "read, comment or edit")
+	}
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return usagef("%s: want an id and a login", name)
+	}
+	id, login := flags.Arg(0), flags.Arg(1)
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case share && *folder:
+		err = api.AddFolderGrant(ctx, id, login, permission)
+	case share:
+		err = api.AddGrant(ctx, id, login, permission)
+	case *folder:
+		err = api.RemoveFolderGrant(ctx, id, login)
+	default:
+		err = api.RemoveGrant(ctx, id, login)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := grant{ID: id, Folder: *folder, Login: login, Shared: share}
+	if share {
+		result.Permission = permission
+	}
+
+	return c.print(&table{
+		header: []string{"ID", "LOGIN", "PERMISSION", "SHARED"},
+		rows:   [][]string{{id, login, result.Permission, strconv.FormatBool(share)}},
+		value:  result,
+	})
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/api"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "CxBiwVruDAD8kp8jgeOY"
+
+// fakeService keeps the documents of one user in memory, the calls it does
+// not implement panic.
+type fakeService struct {
+	api.Service
+	mu        sync.Mutex
+	documents map[uuid.UUID]*domain.Document
+	grants    map[string]string
+}
+
+func (f *fakeService) Authentication(_ context.Context, user *domain.User) (string, error) {
+	if user.Password != "Passw_345" {
+		return "", service.ErrUserNotFound
+	}
+	return testToken, nil
+}
+
+func (f *fakeService) Upload(_ context.Context, document *dto.Document) (*domain.Document, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if document.Token != testToken {
+		return nil, service.ErrUserNotFound
+	}
+	id := uuid.New()
+	f.documents[id] = &domain.Document{
+		ID:      id,
+		Name:    document.Name,
+		Mime:    document.Mime,
+		Tags:    document.Tags,
+		Size:    int64(len(document.Content)),
+		Content: base64.StdEncoding.EncodeToString(document.Content),
+	}
+	return f.documents[id], nil
+}
+
+func (f *fakeService) GetDocument(_ context.Context, id uuid.UUID, _ string) (*domain.Document, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	document, ok := f.documents[id]
+	if !ok {
+		return nil, service.ErrDocumentNotFound
+	}
+	return document, nil
+}
+
+func (f *fakeService) GetDocuments(_ context.Context, filter *dto.GetDocumentsRequest) (*dto.DocumentsPage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if filter.Token != testToken {
+		return nil, service.ErrUserNotFound
+	}
+	page := &dto.DocumentsPage{}
+	for _, document := range f.documents {
+		if filter.Mime == "" || filter.Mime == document.Mime {
+			page.Documents = append(page.Documents, *document)
+		}
+	}
+	return page, nil
+}
+
+func (f *fakeService) DeleteDocument(_ context.Context, id uuid.UUID, _ string) (uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.documents[id]; !ok {
+		return uuid.Nil, service.ErrDocumentNotFound
+	}
+	delete(f.documents, id)
+	return id, nil
+}
+
+func (f *fakeService) AddGrant(_ context.Context, id uuid.UUID, _, login, permission string) error {
+	if permission != "read" && permission != "edit" {
+		return service.ErrInvalidPermission
+	}
+	f.grants[id.String()+"/"+login] = permission
+	return nil
+}
+
+func (f *fakeService) RemoveGrant(_ context.Context, id uuid.UUID, _, login string) error {
+	if _, ok := f.grants[id.String()+"/"+login]; !ok {
+		return service.ErrGrantNotFound
+	}
+	delete(f.grants, id.String()+"/"+login)
+	return nil
+}
+
+type env struct {
+	t       *testing.T
+	service *fakeService
+	url     string
+	config  string
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	router := gin.New()
+	s := &fakeService{documents: make(map[uuid.UUID]*domain.Document), grants: make(map[string]string)}
+	api.NewServer(router, logger.New("error"), s, &config.Config{AdminToken: "admin_token"})
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return &env{t: t, service: s, url: server.URL, config: filepath.Join(t.TempDir(), "config.json")}
+}
+
+// run runs docsctl and returns the exit code, stdout and stderr.
+func (e *env) run(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-config", e.config, "-url", e.url}, args...)
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func (e *env) login() {
+	code, _, stderr := e.run("Passw_345\n", "login", "-u", "login345")
+	require.Equal(e.t, exitOK, code, stderr)
+}
+
+func Test_Login(t *testing.T) {
+	e := newEnv(t)
+
+	code, _, stderr := e.run("", "ls")
+	assert.Equal(t, exitAuth, code)
+	assert.Contains(t, stderr, "not logged in")
+
+	code, _, _ = e.run("wrong\n", "login", "-u", "login345")
+	assert.Equal(t, exitNotFound, code)
+
+	code, _, _ = e.run("", "login")
+	assert.Equal(t, exitUsage, code)
+
+	e.login()
+	data, err := os.ReadFile(e.config)
+	require.NoError(t, err)
+	var stored settings
+	require.NoError(t, json.Unmarshal(data, &stored))
+	assert.Equal(t, settings{URL: e.url, Token: testToken}, stored)
+
+	info, err := os.Stat(e.config)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	code, _, _ = e.run("", "ls")
+	assert.Equal(t, exitOK, code)
+}
+
+func Test_UploadDownload(t *testing.T) {
+	e := newEnv(t)
+	e.login()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.csv"), []byte("b,c"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "c.txt"), []byte("c"), 0o600))
+
+	code, stdout, stderr := e.run("", "-o", "json", "upload", "-tag", "q1", filepath.Join(dir, "*.txt"), filepath.Join(dir, "sub"))
+	assert.Equal(t, exitPartial, code)
+	assert.Contains(t, stderr, "is a directory, use -r")
+	var uploaded []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Mime string `json:"mime"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &uploaded))
+	require.Len(t, uploaded, 1)
+	assert.Equal(t, "a.txt", uploaded[0].Name)
+	assert.Equal(t, "text/plain", uploaded[0].Mime)
+
+	code, _, stderr = e.run("", "upload", "-r", dir)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Len(t, e.service.documents, 4)
+
+	code, stdout, _ = e.run("", "-o", "json", "ls", "-mime", "text/csv")
+	assert.Equal(t, exitOK, code)
+	var listed listing
+	require.NoError(t, json.Unmarshal([]byte(stdout), &listed))
+	require.Len(t, listed.Docs, 1)
+	assert.Equal(t, "b.csv", listed.Docs[0].Name)
+
+	out := t.TempDir()
+	code, stdout, stderr = e.run("", "download", "-dir", out, listed.Docs[0].ID)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, filepath.Join(out, "b.csv"))
+	content, err := os.ReadFile(filepath.Join(out, "b.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "b,c", string(content))
+
+	code, _, _ = e.run("", "download", "-dir", out, uuid.NewString())
+	assert.Equal(t, exitNotFound, code)
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "a failed download leaves no file")
+}
+
+func Test_RmShare(t *testing.T) {
+	e := newEnv(t)
+	e.login()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o600))
+	code, stdout, _ := e.run("", "-o", "json", "upload", filepath.Join(dir, "a.txt"))
+	require.Equal(t, exitOK, code)
+	var uploaded []struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &uploaded))
+	id := uploaded[0].ID
+
+	code, _, _ = e.run("", "share", "-permission", "edit", id, "login2")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "edit", e.service.grants[id+"/login2"])
+
+	code, _, _ = e.run("", "share", "-permission", "owner", id, "login2")
+	assert.Equal(t, exitError, code, "a bad request of the api is not a usage error")
+
+	code, _, _ = e.run("", "unshare", id, "login2")
+	assert.Equal(t, exitOK, code)
+	code, _, _ = e.run("", "unshare", id, "login2")
+	assert.Equal(t, exitNotFound, code)
+
+	code, _, _ = e.run("", "share", id)
+	assert.Equal(t, exitUsage, code)
+
+	code, stdout, stderr := e.run("", "rm", id, uuid.NewString())
+	assert.Equal(t, exitPartial, code)
+	assert.Contains(t, stdout, id)
+	assert.Contains(t, stderr, "document not found")
+	assert.Empty(t, e.service.documents)
+}
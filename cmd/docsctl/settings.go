@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// settings is what login stores for the next commands.
+type settings struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// configPath is $DOCSCTL_CONFIG or docsctl/config.json in the config
+// directory of the user.
+func (c *cli) configPath() (string, error) {
+	if c.path != "" {
+		return c.path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "docsctl", "config.json"), nil
+}
+
+// load reads the settings, there are none before the first login.
+func (c *cli) load() error {
+	path, err := c.configPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &c.settings)
+}
+
+// save writes the settings readable by the user only, they hold the token.
+func (c *cli) save() error {
+	path, err := c.configPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c.settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -10,12 +10,25 @@ import (
 type (
 	// Config -.
 	Config struct {
-		App        `yaml:"app"`
-		HTTP       `yaml:"http"`
-		Log        `yaml:"logger"`
-		PG         `yaml:"postgres"`
-		Cache      `yaml:"cache"`
-		AdminToken string `env-required:"true" yaml:"admin_token"    env:"ADMIN_TOKEN"`
+		App             `yaml:"app"`
+		HTTP            `yaml:"http"`
+		GRPC            `yaml:"grpc"`
+		Log             `yaml:"logger"`
+		PG              `yaml:"postgres"`
+		Cache           `yaml:"cache"`
+		Trash           `yaml:"trash"`
+		RetentionPolicy `yaml:"retention_policy"`
+		Extraction      `yaml:"extraction"`
+		Thumbnail       `yaml:"thumbnail"`
+		Import          `yaml:"import"`
+		Idempotency     `yaml:"idempotency"`
+		Webhook         `yaml:"webhook"`
+		Events          `yaml:"events"`
+		Lock            `yaml:"lock"`
+		Token           `yaml:"token"`
+		Audit           `yaml:"audit"`
+		Migrations      `yaml:"migrations"`
+		AdminToken      string `env-required:"true" yaml:"admin_token"    env:"ADMIN_TOKEN"`
 	}
 
 	// App -.
@@ -29,6 +42,13 @@ type (
 		Port string `env-required:"true" yaml:"port" env:"HTTP_PORT"`
 	}
 
+	// GRPC -.
+	GRPC struct {
+		Port string `yaml:"port" env:"GRPC_PORT"`
+		// MaxUploadSize limits the content of a document uploaded over gRPC.
+		MaxUploadSize int64 `yaml:"max_upload_size" env:"GRPC_MAX_UPLOAD_SIZE"`
+	}
+
 	// Log -.
 	Log struct {
 		Level string `env-required:"true" yaml:"log_level"   env:"LOG_LEVEL"`
@@ -37,19 +57,113 @@ type (
 	// PG -.
 	PG struct {
 		URL string `env-required:"true" yaml:"PG_URL" env:"PG_URL"`
+		// PoolMax is the size of the connection pool, the change feed keeps one.
+		PoolMax int `yaml:"pool_max" env:"PG_POOL_MAX"`
 	}
 	// Cache -.
 	Cache struct {
 		DefaultExpiration time.Duration `yaml:"default_expiration" env:"DEFAULT_EXPIRATION"`
 		CleanupInterval   time.Duration `yaml:"cleanup_interval" env:"CLEANUP_INTERVAL"`
 	}
+
+	// Trash -.
+	Trash struct {
+		Retention     time.Duration `yaml:"retention"      env:"TRASH_RETENTION"`
+		PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL"`
+	}
+
+	// RetentionPolicy -.
+	RetentionPolicy struct {
+		ExpireInterval time.Duration `yaml:"expire_interval" env:"RETENTION_EXPIRE_INTERVAL"`
+	}
+
+	// Extraction -.
+	Extraction struct {
+		Interval     time.Duration `yaml:"interval"      env:"EXTRACTION_INTERVAL"`
+		BatchSize    int           `yaml:"batch_size"    env:"EXTRACTION_BATCH_SIZE"`
+		MaxAttempts  int           `yaml:"max_attempts"  env:"EXTRACTION_MAX_ATTEMPTS"`
+		RetryBackoff time.Duration `yaml:"retry_backoff" env:"EXTRACTION_RETRY_BACKOFF"`
+	}
+
+	// Thumbnail -.
+	Thumbnail struct {
+		Interval     time.Duration `yaml:"interval"      env:"THUMBNAIL_INTERVAL"`
+		BatchSize    int           `yaml:"batch_size"    env:"THUMBNAIL_BATCH_SIZE"`
+		MaxAttempts  int           `yaml:"max_attempts"  env:"THUMBNAIL_MAX_ATTEMPTS"`
+		RetryBackoff time.Duration `yaml:"retry_backoff" env:"THUMBNAIL_RETRY_BACKOFF"`
+	}
+
+	// Import -.
+	Import struct {
+		BatchSize    int   `yaml:"batch_size"     env:"IMPORT_BATCH_SIZE"`
+		MaxEntries   int   `yaml:"max_entries"    env:"IMPORT_MAX_ENTRIES"`
+		MaxEntrySize int64 `yaml:"max_entry_size" env:"IMPORT_MAX_ENTRY_SIZE"`
+		MaxTotalSize int64 `yaml:"max_total_size" env:"IMPORT_MAX_TOTAL_SIZE"`
+	}
+
+	// Idempotency -.
+	Idempotency struct {
+		TTL           time.Duration `yaml:"ttl"            env:"IDEMPOTENCY_TTL"`
+		LockTimeout   time.Duration `yaml:"lock_timeout"   env:"IDEMPOTENCY_LOCK_TIMEOUT"`
+		PurgeInterval time.Duration `yaml:"purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL"`
+	}
+
+	// Webhook -.
+	Webhook struct {
+		Interval     time.Duration `yaml:"interval"      env:"WEBHOOK_INTERVAL"`
+		BatchSize    int           `yaml:"batch_size"    env:"WEBHOOK_BATCH_SIZE"`
+		MaxAttempts  int           `yaml:"max_attempts"  env:"WEBHOOK_MAX_ATTEMPTS"`
+		RetryBackoff time.Duration `yaml:"retry_backoff" env:"WEBHOOK_RETRY_BACKOFF"`
+		Timeout      time.Duration `yaml:"timeout"       env:"WEBHOOK_TIMEOUT"`
+		// AllowedNetworks are the private networks, given as CIDR or IP, that
+		// webhooks may still be delivered to, e.g. a local test receiver.
+		AllowedNetworks []string `yaml:"allowed_networks" env:"WEBHOOK_ALLOWED_NETWORKS" env-separator:","`
+	}
+
+	// Events -.
+	Events struct {
+		Heartbeat         time.Duration `yaml:"heartbeat"          env:"EVENTS_HEARTBEAT"`
+		ReconnectInterval time.Duration `yaml:"reconnect_interval" env:"EVENTS_RECONNECT_INTERVAL"`
+	}
+
+	// Lock -.
+	Lock struct {
+		TTL           time.Duration `yaml:"ttl"            env:"LOCK_TTL"`
+		MaxTTL        time.Duration `yaml:"max_ttl"        env:"LOCK_MAX_TTL"`
+		PurgeInterval time.Duration `yaml:"purge_interval" env:"LOCK_PURGE_INTERVAL"`
+	}
+
+	// Audit -.
+	Audit struct {
+		ChainInterval time.Duration `yaml:"chain_interval" env:"AUDIT_CHAIN_INTERVAL"`
+	}
+
+	// Token -.
+	Token struct {
+		// TTL is how long a token works after the login, zero for ever.
+		TTL           time.Duration `yaml:"ttl"            env:"TOKEN_TTL"`
+		PurgeInterval time.Duration `yaml:"purge_interval" env:"TOKEN_PURGE_INTERVAL"`
+	}
+
+	// Migrations -.
+	Migrations struct {
+		Path string `yaml:"path" env:"MIGRATIONS_PATH" env-default:"../../migrations"`
+		// Skip leaves the migrations to docs-admin instead of running them up
+		// when the service starts.
+		Skip bool `yaml:"skip" env:"MIGRATIONS_SKIP"`
+	}
 )
 
 // NewConfig returns app config.
 func NewConfig() (*Config, error) {
+	return Load("../../config/config.yml")
+}
+
+// Load returns the config read from the file, the environment overrides it.
+func Load(path string) (*Config, error) {
 	cfg := &Config{}
 
-	err := cleanenv.ReadConfig("../../config/config.yml", cfg)
+	err := cleanenv.ReadConfig(path, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("config error: %w", err)
 	}
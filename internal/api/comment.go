@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (s *Server) GetComments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidDocumentID), errInvalidDocumentID)
+		return
+	}
+
+	comments, err := s.service.GetComments(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCommentsResp(comments))
+}
+
+func (s *Server) AddComment(c *gin.Context) {
+	req, err := toCommentRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	comment, err := s.service.AddComment(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.CommentResp{Data: toCommentResp(*comment)})
+}
+
+func (s *Server) UpdateComment(c *gin.Context) {
+	req, err := toCommentRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	comment, err := s.service.UpdateComment(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.CommentResp{Data: toCommentResp(*comment)})
+}
+
+func (s *Server) DeleteComment(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidDocumentID), errInvalidDocumentID)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("comment_id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidCommentID), errInvalidCommentID)
+		return
+	}
+
+	err = s.service.DeleteComment(c, documentID, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): true,
+		}})
+}
@@ -11,6 +11,17 @@ var (
 	errAdminUnauthorized = errors.New("admin unauthorized")
 	errInvalidMetaData   = errors.New("invalid meta")
 	errInvalidLimit      = errors.New("invalid limit")
+	errInvalidOffset     = errors.New("invalid offset")
+	errInvalidPublic     = errors.New("invalid public flag")
+	errInvalidDate       = errors.New("invalid date, use YYYY-MM-DD or RFC 3339")
+	errInvalidRetention  = errors.New("invalid retention policy")
+	errInvalidIfMatch    = errors.New("invalid If-Match header")
+	errInvalidBody       = errors.New("invalid body")
+	errInvalidFolderID   = errors.New("invalid folder id")
+	errInvalidDocumentID = errors.New("invalid document id")
+	errInvalidBefore     = errors.New("invalid before")
+	errInvalidWebhookID  = errors.New("invalid webhook id")
+	errInvalidCommentID  = errors.New("invalid comment id")
 )
 
 func (s *Server) errorResponse(c *gin.Context, code int, err error) {
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+// EventTicket issues a ticket that opens the change feed of the caller once.
+func (s *Server) EventTicket(c *gin.Context) {
+	ticket, err := s.service.IssueEventTicket(c, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.EventTicketResp{Data: toEventTicketResp(ticket)})
+}
+
+// Events streams the changes of the documents of the caller as Server-Sent
+// Events. EventSource can not set headers, so it passes a ticket from
+// EventTicket in the query instead of the token. A reconnecting client
+// resumes after its Last-Event-ID.
+func (s *Server) Events(c *gin.Context) {
+	stream, err := s.service.OpenEvents(c, getUserTokenFromContext(c), c.Query("ticket"), c.GetHeader("Last-Event-ID"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+	defer s.service.CloseEvents(stream)
+
+	// the stream outlives the write timeout of the server
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := s.heartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		events, err := s.service.NextEvents(c, stream)
+		if err != nil {
+			return
+		}
+		for i := range events {
+			if writeEvent(c, &events[i]) != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-stream.Done():
+			// the server is shutting down, the client reconnects elsewhere
+			return
+		case <-stream.Wake():
+		case <-ticker.C:
+			// a comment keeps proxies from closing an idle stream, the
+			// events missed while the feed was not listening are read too
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, event *domain.WebhookEvent) error {
+	_, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Event, event.Payload)
+	return err
+}
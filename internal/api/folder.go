@@ -0,0 +1,176 @@
+package api
+
+import (
+	"net/http"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (s *Server) AddGrant(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	var req v1.GrantReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidBody), errInvalidBody)
+		return
+	}
+
+	err = s.service.AddGrant(c, id, getUserTokenFromContext(c), req.Login, req.Permission)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"response": map[string]any{req.Login: true}})
+}
+
+func (s *Server) RemoveGrant(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	login := c.Param("login")
+	err = s.service.RemoveGrant(c, id, getUserTokenFromContext(c), login)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"response": map[string]any{login: false}})
+}
+
+func (s *Server) CreateFolder(c *gin.Context) {
+	var req v1.Folder
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidBody), errInvalidBody)
+		return
+	}
+
+	folder, err := toDomainFolder(req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	folder, err = s.service.CreateFolder(c, getUserTokenFromContext(c), folder)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.FolderResp{Data: toFolderResp(*folder)})
+}
+
+func (s *Server) GetFolderContents(c *gin.Context) {
+	id, err := parseFolderID(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	contents, err := s.service.GetFolderContents(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toFolderContentsResp(contents))
+}
+
+func (s *Server) UpdateFolder(c *gin.Context) {
+	req, err := toUpdateFolderRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	folder, err := s.service.UpdateFolder(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.FolderResp{Data: toFolderResp(*folder)})
+}
+
+func (s *Server) DeleteFolder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidFolderID), errInvalidFolderID)
+		return
+	}
+
+	err = s.service.DeleteFolder(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): true,
+		}})
+}
+
+func (s *Server) AddFolderGrant(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidFolderID), errInvalidFolderID)
+		return
+	}
+
+	var req v1.GrantReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidBody), errInvalidBody)
+		return
+	}
+
+	err = s.service.AddFolderGrant(c, id, getUserTokenFromContext(c), req.Login, req.Permission)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"response": map[string]any{req.Login: true}})
+}
+
+func (s *Server) RemoveFolderGrant(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidFolderID), errInvalidFolderID)
+		return
+	}
+
+	login := c.Param("login")
+	err = s.service.RemoveFolderGrant(c, id, getUserTokenFromContext(c), login)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"response": map[string]any{login: false}})
+}
+
+// ResolvePath returns the content of a document or the listing of a folder addressed by its path.
+func (s *Server) ResolvePath(c *gin.Context) {
+	contents, document, err := s.service.ResolvePath(c, c.Param("path"), getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	if document != nil {
+		s.writeDocument(c, document)
+		return
+	}
+
+	c.JSON(http.StatusOK, toFolderContentsResp(contents))
+}
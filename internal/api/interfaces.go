@@ -2,8 +2,11 @@ package api
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service"
 	"github.com/Alina9496/documents/internal/service/dto"
 	"github.com/google/uuid"
 )
@@ -12,8 +15,54 @@ type Service interface {
 	Registration(ctx context.Context, user *domain.User) (string, error)
 	Authentication(ctx context.Context, user *domain.User) (string, error)
 	LogOut(ctx context.Context, token string) error
-	Upload(ctx context.Context, document *dto.Document) (name string, err error)
+	Upload(ctx context.Context, document *dto.Document) (*domain.Document, error)
 	GetDocument(ctx context.Context, id uuid.UUID, token string) (*domain.Document, error)
-	GetDocuments(ctx context.Context, filter *dto.GetDocumentsRequest) ([]domain.Document, error)
+	GetDocumentMeta(ctx context.Context, id uuid.UUID, token string) (*dto.DocumentMeta, error)
+	UpdateDocument(ctx context.Context, req *dto.UpdateDocumentRequest) (*domain.Document, error)
+	UploadVersion(ctx context.Context, req *dto.DocumentContentRequest) (*domain.Document, error)
+	GetThumbnail(ctx context.Context, documentID uuid.UUID, token, size string) (*domain.Rendition, error)
+	GetDocuments(ctx context.Context, filter *dto.GetDocumentsRequest) (*dto.DocumentsPage, error)
+	Search(ctx context.Context, req *dto.SearchRequest) ([]domain.SearchResult, error)
+	ResolveArchive(ctx context.Context, req *dto.ArchiveRequest) ([]uuid.UUID, error)
+	WriteArchive(ctx context.Context, token string, ids []uuid.UUID, w io.Writer) (*dto.ArchiveManifest, error)
+	Import(ctx context.Context, req *dto.ImportRequest) (*dto.ImportReport, error)
+	GetDocumentText(ctx context.Context, documentID uuid.UUID, token string) (*domain.TextExtraction, error)
 	DeleteDocument(ctx context.Context, id uuid.UUID, token string) (uuid.UUID, error)
+	GetTrash(ctx context.Context, token string) ([]domain.Document, error)
+	RestoreDocument(ctx context.Context, id uuid.UUID, token string) (uuid.UUID, error)
+	SetLegalHold(ctx context.Context, id uuid.UUID, hold bool) error
+	AddRetentionPolicy(ctx context.Context, policy *domain.RetentionPolicy) (uuid.UUID, error)
+	GetRetentionPolicies(ctx context.Context) ([]domain.RetentionPolicy, error)
+	DeleteRetentionPolicy(ctx context.Context, id uuid.UUID) error
+	GetAuditEvents(ctx context.Context, filter *dto.GetAuditEvents) (*dto.AuditPage, error)
+	VerifyAudit(ctx context.Context) (*domain.AuditVerification, error)
+	AddGrant(ctx context.Context, documentID uuid.UUID, token, login, permission string) error
+	RemoveGrant(ctx context.Context, documentID uuid.UUID, token, login string) error
+	CreateFolder(ctx context.Context, token string, folder *domain.Folder) (*domain.Folder, error)
+	GetFolderContents(ctx context.Context, id uuid.UUID, token string) (*dto.FolderContents, error)
+	UpdateFolder(ctx context.Context, req *dto.UpdateFolderRequest) (*domain.Folder, error)
+	DeleteFolder(ctx context.Context, id uuid.UUID, token string) error
+	AddFolderGrant(ctx context.Context, id uuid.UUID, token, login, permission string) error
+	RemoveFolderGrant(ctx context.Context, id uuid.UUID, token, login string) error
+	ResolvePath(ctx context.Context, path, token string) (*dto.FolderContents, *domain.Document, error)
+	CreateWebhook(ctx context.Context, token string, webhook *domain.Webhook) (*domain.Webhook, error)
+	CreateAdminWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context, token string) ([]domain.Webhook, error)
+	GetAdminWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID, token string) error
+	DeleteAdminWebhook(ctx context.Context, id uuid.UUID) error
+	GetWebhookDeliveries(ctx context.Context, id uuid.UUID, token string) ([]domain.WebhookDelivery, error)
+	GetAdminWebhookDeliveries(ctx context.Context, id uuid.UUID) ([]domain.WebhookDelivery, error)
+	IssueEventTicket(ctx context.Context, token string) (*domain.EventTicket, error)
+	OpenEvents(ctx context.Context, token, ticket, lastEventID string) (*service.EventStream, error)
+	NextEvents(ctx context.Context, stream *service.EventStream) ([]domain.WebhookEvent, error)
+	CloseEvents(stream *service.EventStream)
+	GetComments(ctx context.Context, documentID uuid.UUID, token string) ([]domain.Comment, error)
+	AddComment(ctx context.Context, req *dto.CommentRequest) (*domain.Comment, error)
+	UpdateComment(ctx context.Context, req *dto.CommentRequest) (*domain.Comment, error)
+	DeleteComment(ctx context.Context, documentID, id uuid.UUID, token string) error
+	LockDocument(ctx context.Context, id uuid.UUID, token string, ttl time.Duration) (*domain.DocumentLock, error)
+	GetLock(ctx context.Context, id uuid.UUID, token string) (*domain.DocumentLock, error)
+	UnlockDocument(ctx context.Context, id uuid.UUID, token string) error
+	BreakLock(ctx context.Context, id uuid.UUID) error
 }
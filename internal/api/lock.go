@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LockDocument checks the document out, the body with the TTL is optional.
+func (s *Server) LockDocument(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidDocumentID), errInvalidDocumentID)
+		return
+	}
+
+	var req v1.LockReq
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.errorResponse(c, errToHttpStatus(errInvalidBody), errInvalidBody)
+		return
+	}
+
+	lock, err := s.service.LockDocument(c, id, getUserTokenFromContext(c), time.Duration(req.TTL)*time.Second)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.LockResp{Data: toLockResp(*lock)})
+}
+
+func (s *Server) GetLock(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidDocumentID), errInvalidDocumentID)
+		return
+	}
+
+	lock, err := s.service.GetLock(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.LockResp{Data: toLockResp(*lock)})
+}
+
+func (s *Server) UnlockDocument(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidDocumentID), errInvalidDocumentID)
+		return
+	}
+
+	err = s.service.UnlockDocument(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): false,
+		}})
+}
+
+// BreakLock releases the lock of a document whoever holds it.
+func (s *Server) BreakLock(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidDocumentID), errInvalidDocumentID)
+		return
+	}
+
+	err = s.service.BreakLock(c, id)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): false,
+		}})
+}
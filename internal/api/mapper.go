@@ -3,16 +3,22 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"html"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Alina9496/documents/internal/domain"
 	service "github.com/Alina9496/documents/internal/service"
 	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/internal/unpack"
 	v1 "github.com/Alina9496/documents/pkg/api/v1"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func toDomainUser(req v1.User) *domain.User {
@@ -42,6 +48,32 @@ func toFile(c *gin.Context) (*dto.Document, error) {
 		return nil, errInvalidMetaData
 	}
 
+	body, err := readFormFile(c)
+	if err != nil {
+		return nil, err
+	}
+
+	folderID, err := parseFolderID(req.FolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.Document{
+		Name:           req.Name,
+		Token:          req.Token,
+		IdempotencyKey: c.GetHeader("Idempotency-Key"),
+		Mime:           req.Mime,
+		Description:    req.Description,
+		Content:        body,
+		Grant:          req.Grant,
+		Tags:           req.Tags,
+		Metadata:       req.Metadata,
+		FolderID:       folderID,
+		Public:         req.Public,
+	}, nil
+}
+
+func readFormFile(c *gin.Context) ([]byte, error) {
 	file, err := c.FormFile("file")
 	if err != nil {
 		return nil, err
@@ -54,61 +86,847 @@ func toFile(c *gin.Context) (*dto.Document, error) {
 
 	defer f.Close()
 
-	body, err := io.ReadAll(f)
+	return io.ReadAll(f)
+}
+
+// toImportRequest reads the archive from the "file" form field and the
+// optional "meta" field shared by all the imported documents.
+func toImportRequest(c *gin.Context) (*dto.ImportRequest, error) {
+	var req v1.ImportMeta
+	if metaData := c.Request.FormValue("meta"); metaData != "" {
+		err := json.Unmarshal([]byte(metaData), &req)
+		if err != nil {
+			return nil, errInvalidMetaData
+		}
+	}
+	if req.Token == "" {
+		req.Token = getUserTokenFromContext(c)
+	}
+
+	archive, err := readFormFile(c)
+	if err != nil {
+		return nil, errInvalidBody
+	}
+
+	folderID, err := parseFolderID(req.FolderID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &dto.Document{
-		Name:    req.Name,
-		Token:   req.Token,
-		Mime:    req.Mime,
-		Content: body,
-		Grant:   req.Grant,
-		Public:  req.Public,
+	return &dto.ImportRequest{
+		Token:       req.Token,
+		Description: req.Description,
+		Grant:       req.Grant,
+		Tags:        req.Tags,
+		Metadata:    req.Metadata,
+		FolderID:    folderID,
+		Public:      req.Public,
+		Archive:     archive,
 	}, nil
 }
 
-func toUploadResponse(name string) v1.UploadResponse {
+func toImportResp(report *dto.ImportReport) v1.ImportResp {
+	entries := make([]v1.ImportEntry, 0, len(report.Entries))
+	for _, entry := range report.Entries {
+		item := v1.ImportEntry{
+			Path:  entry.Path,
+			Name:  entry.Name,
+			Mime:  entry.Mime,
+			Size:  entry.Size,
+			Error: entry.Error,
+		}
+		if entry.DocumentID != uuid.Nil {
+			item.ID = entry.DocumentID.String()
+		}
+		entries = append(entries, item)
+	}
+
+	return v1.ImportResp{Data: v1.ImportReport{
+		Created: report.Created,
+		Failed:  report.Failed,
+		Entries: entries,
+	}}
+}
+
+// toDocumentContentRequest reads a new version of a document from the "file"
+// form field, the optional "mime" field changes the MIME type.
+func toDocumentContentRequest(c *gin.Context) (*dto.DocumentContentRequest, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readFormFile(c)
+	if err != nil {
+		return nil, errInvalidBody
+	}
+
+	return &dto.DocumentContentRequest{
+		ID:       id,
+		Token:    getUserTokenFromContext(c),
+		Mime:     c.Request.FormValue("mime"),
+		Content:  body,
+		Revision: revision,
+	}, nil
+}
+
+func toUploadResponse(document *domain.Document) v1.UploadResponse {
+	grant := document.Grant
+	if grant == nil {
+		grant = []string{}
+	}
+
 	return v1.UploadResponse{
 		Data: v1.Data{
-			File: name,
+			File:     document.Name,
+			ID:       document.ID.String(),
+			Name:     document.Name,
+			Mime:     document.Mime,
+			Size:     document.Size,
+			Checksum: document.Checksum,
+			Created:  document.CreatedAt.Format(time.DateTime),
+			Public:   document.Public,
+			Grant:    grant,
+			URL:      documentURL(document.ID),
 		},
 	}
 }
+
+// documentURL is the path the content of a document is downloaded from.
+func documentURL(id uuid.UUID) string {
+	return "/api/docs/" + id.String()
+}
 func toGetDocumentsRequest(c *gin.Context) (*dto.GetDocumentsRequest, error) {
 	limit, err := strconv.Atoi(c.Query("limit"))
 	if err != nil {
 		return nil, errInvalidLimit
 	}
 	req := &dto.GetDocumentsRequest{
-		Token: getUserTokenFromContext(c),
-		Login: c.Query("login"),
-		Key:   c.Query("key"),
-		Value: c.Query("value"),
-		Limit: limit,
+		Token:        getUserTokenFromContext(c),
+		Scope:        c.Query("scope"),
+		Login:        c.Query("login"),
+		Key:          c.Query("key"),
+		Value:        c.Query("value"),
+		Tags:         c.QueryArray("tag"),
+		Metadata:     toMetadataFilter(c.Request.URL.Query()),
+		NamePrefix:   c.Query("name_prefix"),
+		NameContains: c.Query("name_contains"),
+		Mime:         c.Query("mime"),
+		Owner:        c.Query("owner"),
+		Sort:         c.Query("sort"),
+		Order:        c.Query("order"),
+		Cursor:       c.Query("cursor"),
+		Limit:        limit,
+	}
+
+	if value := c.Query("public"); value != "" {
+		public, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errInvalidPublic
+		}
+		req.Public = &public
+	}
+
+	req.CreatedFrom, err = parseDate(c.Query("created_from"), false)
+	if err != nil {
+		return nil, err
+	}
+
+	req.CreatedTo, err = parseDate(c.Query("created_to"), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, req.IsValid()
+}
+
+// parseDate accepts RFC 3339 or a plain date. A plain date ending a range
+// covers the whole day.
+func parseDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		if endOfDay {
+			date = date.Add(24*time.Hour - time.Microsecond)
+		}
+		return &date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errInvalidDate
+	}
+	date = date.UTC()
+
+	return &date, nil
+}
+
+const defaultSearchLimit = 20
+
+// toSearchRequest reads "q", "limit" and "offset"; limit defaults to 20 and offset to 0.
+func toSearchRequest(c *gin.Context) (*dto.SearchRequest, error) {
+	var err error
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, errInvalidLimit
+		}
+	}
+
+	offset := 0
+	if value := c.Query("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, errInvalidOffset
+		}
+	}
+
+	req := &dto.SearchRequest{
+		Token:  getUserTokenFromContext(c),
+		Query:  c.Query("q"),
+		Limit:  limit,
+		Offset: offset,
 	}
 
 	return req, req.IsValid()
 }
 
+func toSearchResp(req *dto.SearchRequest, results []domain.SearchResult) v1.SearchResp {
+	resp := v1.SearchResp{Data: v1.SearchData{
+		Results: make([]v1.SearchResult, 0, len(results)),
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+	}}
+	for _, result := range results {
+		resp.Data.Results = append(resp.Data.Results, v1.SearchResult{
+			Document: toDocumentResp(result.Document),
+			Rank:     result.Rank,
+			Snippet:  toSnippetHTML(result.Snippet),
+		})
+	}
+
+	return resp
+}
+
+// toSnippetHTML escapes the text of the snippet and wraps its hits in <mark>
+// tags, the text comes from the documents and may hold any markup.
+func toSnippetHTML(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
+
+var snippetMarks = strings.NewReplacer(domain.SnippetStart, "<mark>", domain.SnippetStop, "</mark>")
+
+func toDocumentTextResp(extraction *domain.TextExtraction) v1.DocumentTextResp {
+	return v1.DocumentTextResp{Data: v1.DocumentText{
+		DocumentID: extraction.DocumentID.String(),
+		Version:    extraction.Version,
+		Status:     extraction.Status,
+		Text:       extraction.Text,
+		Attempts:   extraction.Attempts,
+		Error:      extraction.Error,
+		Updated:    extraction.UpdatedAt.Format(time.DateTime),
+	}}
+}
+
+const metadataQueryPrefix = "meta."
+
+// toMetadataFilter collects "meta.<field>=<value>" query parameters. A value that
+// is a JSON number or boolean matches typed metadata, anything else matches a string.
+func toMetadataFilter(query url.Values) map[string]any {
+	var metadata map[string]any
+	for key, values := range query {
+		field, ok := strings.CutPrefix(key, metadataQueryPrefix)
+		if !ok || field == "" || len(values) == 0 {
+			continue
+		}
+
+		if metadata == nil {
+			metadata = make(map[string]any)
+		}
+
+		var value any
+		err := json.Unmarshal([]byte(values[0]), &value)
+		switch value.(type) {
+		case float64, bool:
+			if err == nil {
+				metadata[field] = value
+				continue
+			}
+		}
+		metadata[field] = values[0]
+	}
+
+	return metadata
+}
+
+func toDocumentsPageResp(page *dto.DocumentsPage) v1.GetDocumentsResp {
+	resp := toGetDocumentsResp(page.Documents)
+	resp.NextCursor = page.NextCursor
+
+	return resp
+}
+
 func toGetDocumentsResp(documents []domain.Document) v1.GetDocumentsResp {
 	var resp v1.GetDocumentsResp
 	resp.DataDocuments.Docs = make([]v1.Document, 0, len(documents))
 	for _, doc := range documents {
-		resp.DataDocuments.Docs = append(resp.DataDocuments.Docs, v1.Document{
-			ID:      doc.ID.String(),
-			Name:    doc.Name,
-			Mime:    doc.Mime,
-			File:    true,
-			Public:  doc.Public,
-			Created: doc.CreatedAt.Format(time.DateTime),
-			Grant:   doc.Grant,
-		})
+		resp.DataDocuments.Docs = append(resp.DataDocuments.Docs, toDocumentResp(doc))
+	}
+
+	return resp
+}
+
+func toDocumentResp(doc domain.Document) v1.Document {
+	document := v1.Document{
+		ID:          doc.ID.String(),
+		Name:        doc.Name,
+		Mime:        doc.Mime,
+		Description: doc.Description,
+		Size:        doc.Size,
+		Checksum:    doc.Checksum,
+		File:        true,
+		Public:      doc.Public,
+		Created:     doc.CreatedAt.Format(time.DateTime),
+		Grant:       doc.Grant,
+		Tags:        doc.Tags,
+		Metadata:    doc.Metadata,
+		Permission:  doc.Permission,
+		Thumbnail:   doc.Thumbnail,
+	}
+	if doc.FolderID != uuid.Nil {
+		document.FolderID = doc.FolderID.String()
+	}
+	if doc.DeletedAt != nil {
+		document.Deleted = doc.DeletedAt.Format(time.DateTime)
+	}
+
+	return document
+}
+
+func toUpdateDocumentRequest(c *gin.Context) (*dto.UpdateDocumentRequest, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		return nil, err
+	}
+
+	var req v1.UpdateDocumentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, errInvalidBody
+	}
+
+	var folderID *uuid.UUID
+	if req.FolderID != nil {
+		id, err := parseFolderID(*req.FolderID)
+		if err != nil {
+			return nil, err
+		}
+		folderID = &id
+	}
+
+	update := &dto.UpdateDocumentRequest{
+		ID:          id,
+		Token:       getUserTokenFromContext(c),
+		Name:        req.Name,
+		Mime:        req.Mime,
+		Description: req.Description,
+		Public:      req.Public,
+		Tags:        req.Tags,
+		Metadata:    req.Metadata,
+		FolderID:    folderID,
+		Revision:    revision,
+	}
+
+	return update, update.IsValid()
+}
+
+func toUpdateDocumentResp(document *domain.Document) v1.UpdateDocumentResp {
+	return v1.UpdateDocumentResp{
+		Data: toDocumentResp(*document),
+	}
+}
+
+// toETag builds the entity tag of a document from its metadata revision.
+// setDocumentHeaders describes a document in the headers of its download.
+func setDocumentHeaders(c *gin.Context, document *domain.Document) {
+	c.Header("ETag", toETag(document.Revision))
+	c.Header("Last-Modified", document.UpdatedAt.UTC().Format(http.TimeFormat))
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": document.Name}))
+	c.Header("X-Document-Id", document.ID.String())
+	c.Header("X-Document-Checksum", document.Checksum)
+	c.Header("X-Document-Version", strconv.Itoa(document.Version))
+	c.Header("X-Document-Public", strconv.FormatBool(document.Public))
+}
+
+func toDocumentMetaResp(meta *dto.DocumentMeta) v1.DocumentMetaResp {
+	document := meta.Document
+	grant := document.Grant
+	if grant == nil {
+		grant = []string{}
+	}
+
+	resp := v1.DocumentMeta{
+		ID:         document.ID.String(),
+		Name:       document.Name,
+		Mime:       document.Mime,
+		Size:       document.Size,
+		Checksum:   document.Checksum,
+		Owner:      meta.Owner,
+		Public:     document.Public,
+		Created:    document.CreatedAt.Format(time.DateTime),
+		Updated:    document.UpdatedAt.Format(time.DateTime),
+		Versions:   document.Version,
+		Revision:   document.Revision,
+		Grant:      grant,
+		Permission: document.Permission,
+		URL:        documentURL(document.ID),
+	}
+	if document.FolderID != uuid.Nil {
+		resp.FolderID = document.FolderID.String()
+	}
+
+	return v1.DocumentMetaResp{Data: resp}
+}
+
+func toETag(revision int) string {
+	return `"` + strconv.Itoa(revision) + `"`
+}
+
+// parseIfMatch returns the revision from an If-Match header, 0 when any revision matches.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	revision, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || revision < 1 {
+		return 0, errInvalidIfMatch
+	}
+
+	return revision, nil
+}
+
+// parseFolderID parses an optional folder id, an empty one means the top level.
+func toArchiveRequest(c *gin.Context) (*dto.ArchiveRequest, error) {
+	var body v1.ArchiveReq
+	if err := c.ShouldBindJSON(&body); err != nil {
+		return nil, errInvalidBody
+	}
+
+	req := &dto.ArchiveRequest{
+		Token: getUserTokenFromContext(c),
+		IDs:   make([]uuid.UUID, 0, len(body.IDs)),
+	}
+	for _, value := range body.IDs {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, errInvalidDocumentID
+		}
+		req.IDs = append(req.IDs, id)
+	}
+
+	var err error
+	req.FolderID, err = parseFolderID(body.FolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter := body.Filter; filter != nil {
+		req.Filter = &dto.GetDocumentsRequest{
+			Scope:        filter.Scope,
+			Login:        filter.Login,
+			Tags:         filter.Tags,
+			Metadata:     filter.Metadata,
+			NamePrefix:   filter.NamePrefix,
+			NameContains: filter.NameContains,
+			Mime:         filter.Mime,
+			Public:       filter.Public,
+			Owner:        filter.Owner,
+		}
+
+		req.Filter.CreatedFrom, err = parseDate(filter.CreatedFrom, false)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Filter.CreatedTo, err = parseDate(filter.CreatedTo, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return req, req.IsValid()
+}
+
+func parseFolderID(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, nil
+	}
+
+	folderID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, errInvalidFolderID
+	}
+
+	return folderID, nil
+}
+
+// toCommentRequest reads a new comment or, with comment_id in the path, the
+// new body of a comment.
+func toCommentRequest(c *gin.Context) (*dto.CommentRequest, error) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, errInvalidDocumentID
+	}
+
+	var id uuid.UUID
+	if commentID := c.Param("comment_id"); commentID != "" {
+		id, err = uuid.Parse(commentID)
+		if err != nil {
+			return nil, errInvalidCommentID
+		}
+	}
+
+	var req v1.CommentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, errInvalidBody
+	}
+
+	var parentID uuid.UUID
+	if req.ParentID != "" {
+		parentID, err = uuid.Parse(req.ParentID)
+		if err != nil {
+			return nil, errInvalidCommentID
+		}
+	}
+
+	return &dto.CommentRequest{
+		ID:         id,
+		DocumentID: documentID,
+		ParentID:   parentID,
+		Token:      getUserTokenFromContext(c),
+		Body:       req.Body,
+		Page:       req.Page,
+		RangeStart: req.RangeStart,
+		RangeEnd:   req.RangeEnd,
+	}, nil
+}
+
+func toCommentResp(comment domain.Comment) v1.Comment {
+	resp := v1.Comment{
+		ID:         comment.ID.String(),
+		Login:      comment.Login,
+		Body:       comment.Body,
+		Page:       comment.Page,
+		RangeStart: comment.RangeStart,
+		RangeEnd:   comment.RangeEnd,
+		Deleted:    comment.DeletedAt != nil,
+		Created:    comment.CreatedAt.Format(time.DateTime),
+		Updated:    comment.UpdatedAt.Format(time.DateTime),
+	}
+	if comment.ParentID != uuid.Nil {
+		resp.ParentID = comment.ParentID.String()
+	}
+
+	return resp
+}
+
+func toEventTicketResp(ticket *domain.EventTicket) v1.EventTicket {
+	return v1.EventTicket{
+		Ticket:  ticket.Ticket,
+		Expires: ticket.ExpiresAt.Format(time.DateTime),
+	}
+}
+
+func toLockResp(lock domain.DocumentLock) v1.Lock {
+	return v1.Lock{
+		DocumentID: lock.DocumentID.String(),
+		Login:      lock.Login,
+		Created:    lock.CreatedAt.Format(time.DateTime),
+		Expires:    lock.ExpiresAt.Format(time.DateTime),
+	}
+}
+
+func toCommentsResp(comments []domain.Comment) v1.CommentsResp {
+	resp := v1.CommentsResp{
+		Comments: make([]v1.Comment, 0, len(comments)),
+	}
+	for _, comment := range comments {
+		resp.Comments = append(resp.Comments, toCommentResp(comment))
+	}
+
+	return resp
+}
+
+func toDomainFolder(req v1.Folder) (*domain.Folder, error) {
+	parentID, err := parseFolderID(req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Folder{
+		Name:     req.Name,
+		ParentID: parentID,
+	}, nil
+}
+
+func toUpdateFolderRequest(c *gin.Context) (*dto.UpdateFolderRequest, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, errInvalidFolderID
+	}
+
+	var req v1.UpdateFolderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, errInvalidBody
+	}
+
+	update := &dto.UpdateFolderRequest{
+		ID:    id,
+		Token: getUserTokenFromContext(c),
+		Name:  req.Name,
+	}
+	if req.ParentID != nil {
+		parentID, err := parseFolderID(*req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		update.ParentID = &parentID
+	}
+
+	return update, nil
+}
+
+func toFolderResp(folder domain.Folder) v1.Folder {
+	resp := v1.Folder{
+		ID:      folder.ID.String(),
+		Name:    folder.Name,
+		Created: folder.CreatedAt.Format(time.DateTime),
+	}
+	if folder.ParentID != uuid.Nil {
+		resp.ParentID = folder.ParentID.String()
+	}
+
+	return resp
+}
+
+func toFolderContentsResp(contents *dto.FolderContents) v1.FolderContentsResp {
+	var resp v1.FolderContentsResp
+	if contents.Folder != nil {
+		folder := toFolderResp(*contents.Folder)
+		resp.Data.Folder = &folder
+	}
+
+	resp.Data.Folders = make([]v1.Folder, 0, len(contents.Folders))
+	for _, folder := range contents.Folders {
+		resp.Data.Folders = append(resp.Data.Folders, toFolderResp(folder))
+	}
+
+	resp.Data.Docs = make([]v1.Document, 0, len(contents.Documents))
+	for _, doc := range contents.Documents {
+		resp.Data.Docs = append(resp.Data.Docs, toDocumentResp(doc))
+	}
+
+	return resp
+}
+
+func toDomainRetentionPolicy(req v1.RetentionPolicy) (*domain.RetentionPolicy, error) {
+	policy := &domain.RetentionPolicy{
+		Kind: req.Kind,
+		Mime: req.Mime,
+		Tag:  req.Tag,
+		Days: req.Days,
+	}
+
+	if req.DocumentID != "" {
+		documentID, err := uuid.Parse(req.DocumentID)
+		if err != nil {
+			return nil, errInvalidRetention
+		}
+		policy.DocumentID = documentID
+	}
+
+	return policy, nil
+}
+
+func toRetentionPoliciesResp(policies []domain.RetentionPolicy) v1.RetentionPoliciesResp {
+	resp := v1.RetentionPoliciesResp{
+		Policies: make([]v1.RetentionPolicy, 0, len(policies)),
 	}
+	for _, policy := range policies {
+		item := v1.RetentionPolicy{
+			ID:      policy.ID.String(),
+			Kind:    policy.Kind,
+			Mime:    policy.Mime,
+			Tag:     policy.Tag,
+			Days:    policy.Days,
+			Created: policy.CreatedAt.Format(time.DateTime),
+		}
+		if policy.DocumentID != uuid.Nil {
+			item.DocumentID = policy.DocumentID.String()
+		}
+		resp.Policies = append(resp.Policies, item)
+	}
+
 	return resp
 }
 
+func toDomainWebhook(req v1.WebhookReq) *domain.Webhook {
+	return &domain.Webhook{
+		URL:    req.URL,
+		Events: req.Events,
+	}
+}
+
+func toWebhookResp(webhook domain.Webhook) v1.Webhook {
+	return v1.Webhook{
+		ID:      webhook.ID.String(),
+		URL:     webhook.URL,
+		Events:  webhook.Events,
+		Created: webhook.CreatedAt.Format(time.DateTime),
+	}
+}
+
+func toWebhooksResp(webhooks []domain.Webhook) v1.WebhooksResp {
+	resp := v1.WebhooksResp{
+		Webhooks: make([]v1.Webhook, 0, len(webhooks)),
+	}
+	for _, webhook := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, toWebhookResp(webhook))
+	}
+
+	return resp
+}
+
+// toWebhookDeliveriesResp shows the next attempt only while a delivery is pending.
+func toWebhookDeliveriesResp(deliveries []domain.WebhookDelivery) v1.WebhookDeliveriesResp {
+	resp := v1.WebhookDeliveriesResp{
+		Deliveries: make([]v1.WebhookDelivery, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		item := v1.WebhookDelivery{
+			ID:           delivery.ID,
+			Event:        delivery.Event,
+			Status:       delivery.Status,
+			Attempts:     delivery.Attempts,
+			ResponseCode: delivery.ResponseCode,
+			Error:        delivery.Error,
+			Created:      delivery.CreatedAt.Format(time.DateTime),
+		}
+		if delivery.Status == domain.DeliveryPending {
+			item.NextAttempt = delivery.NextAttemptAt.Format(time.DateTime)
+		}
+		if delivery.DeliveredAt != nil {
+			item.Delivered = delivery.DeliveredAt.Format(time.DateTime)
+		}
+		resp.Deliveries = append(resp.Deliveries, item)
+	}
+
+	return resp
+}
+
+const defaultAuditLimit = 100
+
+// toGetAuditEvents reads the filters of the audit log. Limit defaults to 100,
+// "from" and "to" take the formats of parseDate.
+func toGetAuditEvents(c *gin.Context) (*dto.GetAuditEvents, error) {
+	var err error
+	filter := &dto.GetAuditEvents{
+		Actor:   c.Query("actor"),
+		Action:  c.Query("action"),
+		Outcome: c.Query("outcome"),
+		Limit:   defaultAuditLimit,
+	}
+
+	if value := c.Query("limit"); value != "" {
+		filter.Limit, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, errInvalidLimit
+		}
+	}
+
+	if value := c.Query("before"); value != "" {
+		filter.Before, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || filter.Before < 1 {
+			return nil, errInvalidBefore
+		}
+	}
+
+	if value := c.Query("document_id"); value != "" {
+		filter.DocumentID, err = uuid.Parse(value)
+		if err != nil {
+			return nil, errInvalidDocumentID
+		}
+	}
+
+	filter.From, err = parseDate(c.Query("from"), false)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.To, err = parseDate(c.Query("to"), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, filter.IsValid()
+}
+
+func toAuditEventsResp(page *dto.AuditPage) v1.AuditEventsResp {
+	resp := v1.AuditEventsResp{
+		Events: make([]v1.AuditEvent, 0, len(page.Events)),
+		Next:   page.Next,
+	}
+	for _, event := range page.Events {
+		item := v1.AuditEvent{
+			ID:          event.ID,
+			Created:     event.CreatedAt.Format(time.RFC3339Nano),
+			ActorLogin:  event.ActorLogin,
+			Action:      event.Action,
+			TargetLogin: event.TargetLogin,
+			Outcome:     event.Outcome,
+			Reason:      event.Reason,
+			IP:          event.IP,
+			UserAgent:   event.UserAgent,
+			PrevHash:    event.PrevHash,
+			Hash:        event.Hash,
+		}
+		if event.ActorID != uuid.Nil {
+			item.ActorID = event.ActorID.String()
+		}
+		if event.DocumentID != uuid.Nil {
+			item.DocumentID = event.DocumentID.String()
+		}
+		if event.FolderID != uuid.Nil {
+			item.FolderID = event.FolderID.String()
+		}
+		resp.Events = append(resp.Events, item)
+	}
+
+	return resp
+}
+
+func toAuditVerificationResp(result *domain.AuditVerification) v1.AuditVerificationResp {
+	return v1.AuditVerificationResp{
+		Valid:    result.BrokenID == 0,
+		Checked:  result.Checked,
+		BrokenID: result.BrokenID,
+	}
+}
+
 func toLogOutTokenResp(token string) map[string]bool {
 	return map[string]bool{token: true}
 }
@@ -121,17 +939,84 @@ func errToHttpStatus(err error) int {
 	switch {
 	case errors.Is(err, errAdminUnauthorized):
 		return http.StatusUnauthorized
-	case errors.Is(err, service.ErrUserLoginIncorected),
+	case errors.Is(err, errInvalidRetention),
+		errors.Is(err, errInvalidBody),
+		errors.Is(err, errInvalidFolderID),
+		errors.Is(err, errInvalidDocumentID),
+		errors.Is(err, dto.ErrInvalidArchive),
+		errors.Is(err, dto.ErrTooManyDocuments),
+		errors.Is(err, errInvalidMetaData),
+		errors.Is(err, service.ErrInvalidArchive),
+		errors.Is(err, unpack.ErrUnsupported),
+		errors.Is(err, unpack.ErrTooManyEntries),
+		errors.Is(err, unpack.ErrTooLarge),
+		errors.Is(err, errInvalidLimit),
+		errors.Is(err, errInvalidOffset),
+		errors.Is(err, errInvalidPublic),
+		errors.Is(err, errInvalidDate),
+		errors.Is(err, dto.ErrInvalidKey),
+		errors.Is(err, dto.ErrEmptyValue),
+		errors.Is(err, dto.ErrInvalidSort),
+		errors.Is(err, dto.ErrInvalidOrder),
+		errors.Is(err, dto.ErrInvalidScope),
+		errors.Is(err, dto.ErrInvalidDateRange),
+		errors.Is(err, errInvalidBefore),
+		errors.Is(err, dto.ErrInvalidOutcome),
+		errors.Is(err, dto.ErrInvalidPeriod),
+		errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrInvalidThumbnailSize),
+		errors.Is(err, dto.ErrInvalidLimit),
+		errors.Is(err, dto.ErrEmptyQuery),
+		errors.Is(err, dto.ErrInvalidOffset),
+		errors.Is(err, service.ErrInvalidFolderName),
+		errors.Is(err, service.ErrFolderCycle),
+		errors.Is(err, dto.ErrEmptyName),
+		errors.Is(err, dto.ErrEmptyMime),
+		errors.Is(err, service.ErrInvalidRetentionPolicy),
+		errors.Is(err, service.ErrInvalidMetadata),
+		errors.Is(err, service.ErrInvalidIdempotencyKey),
+		errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrInvalidWebhookEvent),
+		errors.Is(err, errInvalidWebhookID),
+		errors.Is(err, errInvalidCommentID),
+		errors.Is(err, service.ErrInvalidPermission),
+		errors.Is(err, service.ErrInvalidLockTTL),
+		errors.Is(err, dto.ErrEmptyComment),
+		errors.Is(err, dto.ErrCommentTooLong),
+		errors.Is(err, dto.ErrInvalidPage),
+		errors.Is(err, dto.ErrInvalidRange),
+		errors.Is(err, service.ErrInvalidLastEventID),
+		errors.Is(err, service.ErrUserLoginIncorected),
 		errors.Is(err, service.ErrUserPasswordIncorected),
 		errors.Is(err, service.ErrUserIsNil):
 		return http.StatusBadRequest
 	case errors.Is(err, service.ErrUserNotFound),
 		errors.Is(err, service.ErrDocumentNotFound),
 		errors.Is(err, service.ErrDocumentsNotFound),
-		errors.Is(err, service.ErrTokenNotFound):
+		errors.Is(err, service.ErrTokenNotFound),
+		errors.Is(err, service.ErrRetentionPolicyNotFound),
+		errors.Is(err, service.ErrFolderNotFound),
+		errors.Is(err, service.ErrGrantNotFound),
+		errors.Is(err, service.ErrTextNotFound),
+		errors.Is(err, service.ErrThumbnailNotFound),
+		errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrCommentNotFound),
+		errors.Is(err, service.ErrLockNotFound):
 		return http.StatusNotFound
-	case errors.Is(err, service.ErrNoAccess):
+	case errors.Is(err, service.ErrNoAccess),
+		errors.Is(err, service.ErrUserDisabled):
 		return http.StatusForbidden
+	case errors.Is(err, service.ErrLegalHold),
+		errors.Is(err, service.ErrRetentionPolicy),
+		errors.Is(err, service.ErrFolderExists),
+		errors.Is(err, service.ErrFolderNotEmpty),
+		errors.Is(err, service.ErrIdempotencyKeyInUse):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrLocked):
+		return http.StatusLocked
+	case errors.Is(err, errInvalidIfMatch),
+		errors.Is(err, service.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
 	default:
 		return http.StatusInternalServerError
 	}
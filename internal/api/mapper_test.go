@@ -1,10 +1,17 @@
 package api
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
 	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,6 +63,46 @@ func Test_toLoginResp(t *testing.T) {
 	}
 }
 
+func Test_toUploadResponse(t *testing.T) {
+	id := uuid.MustParse("5b0e7a4e-2f61-4b7b-9c57-0e5e1c7f2a10")
+	tests := []struct {
+		name     string
+		document *domain.Document
+		want     v1.UploadResponse
+	}{
+		{
+			name: "convert to v1.UploadResponse",
+			document: &domain.Document{
+				ID:        id,
+				Name:      "photo.jpg",
+				Mime:      "image/jpeg",
+				Size:      3,
+				Checksum:  "abc",
+				CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Public:    true,
+			},
+			want: v1.UploadResponse{Data: v1.Data{
+				File:     "photo.jpg",
+				ID:       id.String(),
+				Name:     "photo.jpg",
+				Mime:     "image/jpeg",
+				Size:     3,
+				Checksum: "abc",
+				Created:  "2024-05-01 10:00:00",
+				Public:   true,
+				Grant:    []string{},
+				URL:      "/api/docs/5b0e7a4e-2f61-4b7b-9c57-0e5e1c7f2a10",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toUploadResponse(tt.document)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func Test_toTokenResp(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -97,3 +144,260 @@ func Test_toLogOutTokenResp(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr error
+	}{
+		{
+			name:   "no header",
+			header: "",
+			want:   0,
+		},
+		{
+			name:   "any revision",
+			header: "*",
+			want:   0,
+		},
+		{
+			name:   "strong etag",
+			header: toETag(3),
+			want:   3,
+		},
+		{
+			name:   "weak etag",
+			header: `W/"7"`,
+			want:   7,
+		},
+		{
+			name:    "invalid etag",
+			header:  `"abc"`,
+			wantErr: errInvalidIfMatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIfMatch(tt.header)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func Test_toMetadataFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  map[string]any
+	}{
+		{
+			name:  "no metadata filter",
+			query: url.Values{"key": {"mime"}, "value": {"image/jpg"}},
+			want:  nil,
+		},
+		{
+			name: "typed values",
+			query: url.Values{
+				"meta.department":     {"sales"},
+				"meta.amount":         {"100"},
+				"meta.paid":           {"true"},
+				"meta.invoice_number": {"007"},
+				"limit":               {"10"},
+			},
+			want: map[string]any{
+				"department":     "sales",
+				"amount":         float64(100),
+				"paid":           true,
+				"invoice_number": "007",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toMetadataFilter(tt.query)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_toSearchRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  *dto.SearchRequest
+		err   error
+	}{
+		{
+			name:  "default pagination",
+			query: "q=invoice",
+			want:  &dto.SearchRequest{Token: "token", Query: "invoice", Limit: defaultSearchLimit},
+			err:   nil,
+		},
+		{
+			name:  "limit and offset",
+			query: "q=invoice&limit=5&offset=10",
+			want:  &dto.SearchRequest{Token: "token", Query: "invoice", Limit: 5, Offset: 10},
+			err:   nil,
+		},
+		{
+			name:  "invalid offset",
+			query: "q=invoice&offset=next",
+			want:  nil,
+			err:   errInvalidOffset,
+		},
+		{
+			name:  "empty query",
+			query: "q=+",
+			want:  &dto.SearchRequest{Token: "token", Query: " ", Limit: defaultSearchLimit},
+			err:   dto.ErrEmptyQuery,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/search?"+tt.query, nil)
+			c.Request.Header.Set("token", "token")
+
+			got, err := toSearchRequest(c)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func Test_toSnippetHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{
+			name:    "hits",
+			snippet: "the \x01invoice\x02 of May",
+			want:    "the <mark>invoice</mark> of May",
+		},
+		{
+			name:    "markup in the document",
+			snippet: "<img src=x onerror=\"alert(1)\"> \x01invoice\x02 & <b>",
+			want:    "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>invoice</mark> &amp; &lt;b&gt;",
+		},
+		{
+			name:    "tags around a hit",
+			snippet: "<mark>\x01invoice\x02</mark>",
+			want:    "&lt;mark&gt;<mark>invoice</mark>&lt;/mark&gt;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, toSnippetHTML(tt.snippet))
+		})
+	}
+}
+
+func Test_parseDate(t *testing.T) {
+	day := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    string
+		endOfDay bool
+		want     *time.Time
+		err      error
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "date starts a range",
+			value: "2024-03-31",
+			want:  &day,
+		},
+		{
+			name:     "date ends a range",
+			value:    "2024-03-31",
+			endOfDay: true,
+			want:     func() *time.Time { t := day.Add(24*time.Hour - time.Microsecond); return &t }(),
+		},
+		{
+			name:  "rfc 3339 in utc",
+			value: "2024-03-31T03:00:00+03:00",
+			want:  &day,
+		},
+		{
+			name:  "invalid",
+			value: "31.03.2024",
+			err:   errInvalidDate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDate(tt.value, tt.endOfDay)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func Test_toGetAuditEvents(t *testing.T) {
+	documentID := uuid.New()
+	day := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query string
+		want  *dto.GetAuditEvents
+		err   error
+	}{
+		{
+			name:  "default limit",
+			query: "",
+			want:  &dto.GetAuditEvents{Limit: defaultAuditLimit},
+		},
+		{
+			name:  "all filters",
+			query: "actor=reader42&action=document.view&outcome=failure&document_id=" + documentID.String() + "&from=2024-03-31&before=42&limit=10",
+			want: &dto.GetAuditEvents{
+				Actor:      "reader42",
+				Action:     domain.AuditView,
+				Outcome:    domain.AuditFailure,
+				DocumentID: documentID,
+				From:       &day,
+				Before:     42,
+				Limit:      10,
+			},
+		},
+		{
+			name:  "invalid before",
+			query: "before=-1",
+			err:   errInvalidBefore,
+		},
+		{
+			name:  "invalid document id",
+			query: "document_id=42",
+			err:   errInvalidDocumentID,
+		},
+		{
+			name:  "invalid period",
+			query: "from=2024-04-01&to=2024-03-31",
+			want: &dto.GetAuditEvents{
+				From:  func() *time.Time { t := day.AddDate(0, 0, 1); return &t }(),
+				To:    func() *time.Time { t := day.Add(24*time.Hour - time.Microsecond); return &t }(),
+				Limit: defaultAuditLimit,
+			},
+			err: dto.ErrInvalidPeriod,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/audit?"+tt.query, nil)
+
+			got, err := toGetAuditEvents(c)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
@@ -7,19 +7,24 @@ import (
 	"github.com/google/uuid"
 
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-contrib/cors"
 
 	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service"
 	v1 "github.com/Alina9496/documents/pkg/api/v1"
 	"github.com/Alina9496/tool/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Server struct {
-	service Service
-	l       *logger.Logger
-	admin   string
+	service   Service
+	l         *logger.Logger
+	admin     string
+	heartbeat time.Duration
 }
 
 func NewServer(handler *gin.Engine, l *logger.Logger, t Service, cfg *config.Config) {
@@ -36,17 +41,67 @@ func NewServer(handler *gin.Engine, l *logger.Logger, t Service, cfg *config.Con
 
 	// Prometheus metrics
 	handler.GET("/metrics", gin.WrapH(promhttp.Handler()))
-	s := &Server{t, l, cfg.AdminToken}
+	s := &Server{t, l, cfg.AdminToken, cfg.Events.Heartbeat}
 
-	h := handler.Group("/api")
+	h := handler.Group("/api", s.client)
 	{
 		h.POST("/register", s.Registration)
 		h.POST("/auth", s.Authentication)
 		h.DELETE("/auth/:token", s.LogOut)
 		h.POST("/docs", s.Upload)
 		h.GET("/docs", s.GetDocuments)
+		h.POST("/docs/archive", s.Archive)
+		h.POST("/docs/import", s.Import)
 		h.GET("/docs/:id", s.GetDocument)
+		h.HEAD("/docs/:id", s.HeadDocument)
+		h.GET("/docs/:id/meta", s.GetDocumentMeta)
+		h.GET("/docs/:id/text", s.GetDocumentText)
+		h.GET("/docs/:id/thumbnail", s.GetThumbnail)
+		h.PUT("/docs/:id/content", s.UploadVersion)
+		h.PATCH("/docs/:id", s.UpdateDocument)
 		h.DELETE("/docs/:id", s.DeleteDocument)
+		h.GET("/search", s.Search)
+		h.POST("/docs/:id/grants", s.AddGrant)
+		h.DELETE("/docs/:id/grants/:login", s.RemoveGrant)
+		h.GET("/docs/:id/comments", s.GetComments)
+		h.POST("/docs/:id/comments", s.AddComment)
+		h.PATCH("/docs/:id/comments/:comment_id", s.UpdateComment)
+		h.DELETE("/docs/:id/comments/:comment_id", s.DeleteComment)
+		h.POST("/docs/:id/lock", s.LockDocument)
+		h.GET("/docs/:id/lock", s.GetLock)
+		h.DELETE("/docs/:id/lock", s.UnlockDocument)
+		h.POST("/folders", s.CreateFolder)
+		h.GET("/folders", s.GetFolderContents)
+		h.GET("/folders/:id", s.GetFolderContents)
+		h.PATCH("/folders/:id", s.UpdateFolder)
+		h.DELETE("/folders/:id", s.DeleteFolder)
+		h.POST("/folders/:id/grants", s.AddFolderGrant)
+		h.DELETE("/folders/:id/grants/:login", s.RemoveFolderGrant)
+		h.GET("/fs/*path", s.ResolvePath)
+		h.GET("/trash", s.GetTrash)
+		h.POST("/trash/:id/restore", s.RestoreDocument)
+		h.POST("/webhooks", s.CreateWebhook)
+		h.GET("/webhooks", s.GetWebhooks)
+		h.DELETE("/webhooks/:id", s.DeleteWebhook)
+		h.GET("/webhooks/:id/deliveries", s.GetWebhookDeliveries)
+		h.GET("/events", s.Events)
+		h.POST("/events/ticket", s.EventTicket)
+	}
+
+	admin := h.Group("/admin", s.adminRequired)
+	{
+		admin.PUT("/docs/:id/hold", s.SetLegalHold)
+		admin.DELETE("/docs/:id/hold", s.LiftLegalHold)
+		admin.DELETE("/docs/:id/lock", s.BreakLock)
+		admin.POST("/retention", s.AddRetentionPolicy)
+		admin.GET("/retention", s.GetRetentionPolicies)
+		admin.DELETE("/retention/:id", s.DeleteRetentionPolicy)
+		admin.GET("/audit", s.GetAuditEvents)
+		admin.GET("/audit/verify", s.VerifyAudit)
+		admin.POST("/webhooks", s.CreateAdminWebhook)
+		admin.GET("/webhooks", s.GetAdminWebhooks)
+		admin.DELETE("/webhooks/:id", s.DeleteAdminWebhook)
+		admin.GET("/webhooks/:id/deliveries", s.GetAdminWebhookDeliveries)
 	}
 }
 
@@ -58,13 +113,31 @@ func getUserTokenFromContext(c *gin.Context) string {
 	return c.Request.Header.Get("token")
 }
 
+// client keeps the address and the user agent of the caller for the audit log.
+func (s *Server) client(c *gin.Context) {
+	c.Set(service.ClientKey, service.Client{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	c.Next()
+}
+
+func (s *Server) adminRequired(c *gin.Context) {
+	if getAdminTokenFromContext(c) != s.admin {
+		s.errorResponse(c, errToHttpStatus(errAdminUnauthorized), errAdminUnauthorized)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
 func (s *Server) Registration(c *gin.Context) {
 	if getAdminTokenFromContext(c) != s.admin {
 		s.errorResponse(c, errToHttpStatus(errAdminUnauthorized), errAdminUnauthorized)
 		return
 	}
 
-	login, err := s.service.Registration(c.Request.Context(),
+	login, err := s.service.Registration(c,
 		toDomainUser(v1.User{
 			Login:    c.Request.FormValue("login"),
 			Password: c.Request.FormValue("pswd"),
@@ -78,7 +151,7 @@ func (s *Server) Registration(c *gin.Context) {
 }
 
 func (s *Server) Authentication(c *gin.Context) {
-	token, err := s.service.Authentication(c.Request.Context(),
+	token, err := s.service.Authentication(c,
 		toDomainUser(v1.User{
 			Login:    c.Request.FormValue("login"),
 			Password: c.Request.FormValue("pswd"),
@@ -109,13 +182,14 @@ func (s *Server) Upload(c *gin.Context) {
 		return
 	}
 
-	name, err := s.service.Upload(c, documet)
+	document, err := s.service.Upload(c, documet)
 	if err != nil {
 		s.errorResponse(c, errToHttpStatus(err), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, toUploadResponse(name))
+	c.Header("Location", documentURL(document.ID))
+	c.JSON(http.StatusOK, toUploadResponse(document))
 }
 
 func (s *Server) GetDocument(c *gin.Context) {
@@ -130,14 +204,123 @@ func (s *Server) GetDocument(c *gin.Context) {
 		return
 	}
 
+	s.writeDocument(c, document)
+}
+
+func (s *Server) GetDocumentMeta(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	meta, err := s.service.GetDocumentMeta(c, documentID, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.Header("ETag", toETag(meta.Document.Revision))
+	c.JSON(http.StatusOK, toDocumentMetaResp(meta))
+}
+
+// HeadDocument answers with the headers of GetDocument and the owner, without the content.
+func (s *Server) HeadDocument(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Status(errToHttpStatus(err))
+		return
+	}
+
+	meta, err := s.service.GetDocumentMeta(c, documentID, getUserTokenFromContext(c))
+	if err != nil {
+		c.Status(errToHttpStatus(err))
+		return
+	}
+
+	setDocumentHeaders(c, meta.Document)
+	c.Header("Content-Type", meta.Document.Mime)
+	c.Header("Content-Length", strconv.FormatInt(meta.Document.Size, 10))
+	c.Header("X-Document-Owner", meta.Owner)
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) GetDocumentText(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	extraction, err := s.service.GetDocumentText(c, documentID, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDocumentTextResp(extraction))
+}
+
+func (s *Server) GetThumbnail(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	rendition, err := s.service.GetThumbnail(c, documentID, getUserTokenFromContext(c), c.Query("size"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.Data(http.StatusOK, rendition.Mime, rendition.Data)
+}
+
+func (s *Server) UploadVersion(c *gin.Context) {
+	req, err := toDocumentContentRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	document, err := s.service.UploadVersion(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.Header("ETag", toETag(document.Revision))
+	c.JSON(http.StatusOK, toUpdateDocumentResp(document))
+}
+
+func (s *Server) writeDocument(c *gin.Context, document *domain.Document) {
 	decodedBytes, err := base64.StdEncoding.DecodeString(document.Content)
 	if err != nil {
 		s.errorResponse(c, errToHttpStatus(err), err)
 		return
 	}
+	setDocumentHeaders(c, document)
 	c.Data(http.StatusOK, document.Mime, decodedBytes)
 }
 
+func (s *Server) UpdateDocument(c *gin.Context) {
+	req, err := toUpdateDocumentRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	document, err := s.service.UpdateDocument(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.Header("ETag", toETag(document.Revision))
+	c.JSON(http.StatusOK, toUpdateDocumentResp(document))
+}
+
 func (s *Server) GetDocuments(c *gin.Context) {
 	filter, err := toGetDocumentsRequest(c)
 	if err != nil {
@@ -145,14 +328,74 @@ func (s *Server) GetDocuments(c *gin.Context) {
 		return
 	}
 
-	documents, err := s.service.GetDocuments(c, filter)
+	page, err := s.service.GetDocuments(c, filter)
 	if err != nil {
 		s.errorResponse(c, errToHttpStatus(err), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, toGetDocumentsResp(documents))
+	c.JSON(http.StatusOK, toDocumentsPageResp(page))
 }
+
+// Import creates a document for every file of an uploaded archive and reports
+// the result of each file.
+func (s *Server) Import(c *gin.Context) {
+	req, err := toImportRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	report, err := s.service.Import(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toImportResp(report))
+}
+
+// Archive streams the selected documents as a ZIP. Once the first byte is
+// sent the status can not change, later errors only cut the archive short.
+func (s *Server) Archive(c *gin.Context) {
+	req, err := toArchiveRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	ids, err := s.service.ResolveArchive(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="documents.zip"`)
+	c.Status(http.StatusOK)
+
+	_, err = s.service.WriteArchive(c, req.Token, ids, c.Writer)
+	if err != nil {
+		s.l.WithError(err).Error("api - Archive - error write archive")
+	}
+}
+
+func (s *Server) Search(c *gin.Context) {
+	req, err := toSearchRequest(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	results, err := s.service.Search(c, req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toSearchResp(req, results))
+}
+
 func (s *Server) DeleteDocument(c *gin.Context) {
 	token := getUserTokenFromContext(c)
 	id, err := uuid.Parse(c.Param("id"))
@@ -172,3 +415,138 @@ func (s *Server) DeleteDocument(c *gin.Context) {
 			id.String(): true,
 		}})
 }
+
+func (s *Server) GetTrash(c *gin.Context) {
+	documents, err := s.service.GetTrash(c, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toGetDocumentsResp(documents))
+}
+
+func (s *Server) RestoreDocument(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	id, err = s.service.RestoreDocument(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): true,
+		}})
+}
+
+func (s *Server) SetLegalHold(c *gin.Context) {
+	s.legalHold(c, true)
+}
+
+func (s *Server) LiftLegalHold(c *gin.Context) {
+	s.legalHold(c, false)
+}
+
+func (s *Server) legalHold(c *gin.Context, hold bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	err = s.service.SetLegalHold(c, id, hold)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): hold,
+		}})
+}
+
+func (s *Server) AddRetentionPolicy(c *gin.Context) {
+	var req v1.RetentionPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, errInvalidRetention)
+		return
+	}
+
+	policy, err := toDomainRetentionPolicy(req)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	id, err := s.service.AddRetentionPolicy(c, policy)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"response": map[string]any{"id": id.String()}})
+}
+
+func (s *Server) GetRetentionPolicies(c *gin.Context) {
+	policies, err := s.service.GetRetentionPolicies(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toRetentionPoliciesResp(policies))
+}
+
+func (s *Server) GetAuditEvents(c *gin.Context) {
+	filter, err := toGetAuditEvents(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	page, err := s.service.GetAuditEvents(c, filter)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAuditEventsResp(page))
+}
+
+// VerifyAudit checks the hash chain of the audit log. A broken chain is not
+// an error of the request, it is reported in the response.
+func (s *Server) VerifyAudit(c *gin.Context) {
+	result, err := s.service.VerifyAudit(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAuditVerificationResp(result))
+}
+
+func (s *Server) DeleteRetentionPolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	err = s.service.DeleteRetentionPolicy(c, id)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): true,
+		}})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (s *Server) CreateWebhook(c *gin.Context) {
+	var req v1.WebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidBody), errInvalidBody)
+		return
+	}
+
+	webhook, err := s.service.CreateWebhook(c, getUserTokenFromContext(c), toDomainWebhook(req))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	resp := toWebhookResp(*webhook)
+	resp.Secret = webhook.Secret
+	c.JSON(http.StatusOK, v1.WebhookResp{Data: resp})
+}
+
+func (s *Server) GetWebhooks(c *gin.Context) {
+	webhooks, err := s.service.GetWebhooks(c, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhooksResp(webhooks))
+}
+
+func (s *Server) DeleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidWebhookID), errInvalidWebhookID)
+		return
+	}
+
+	err = s.service.DeleteWebhook(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): true,
+		}})
+}
+
+func (s *Server) GetWebhookDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidWebhookID), errInvalidWebhookID)
+		return
+	}
+
+	deliveries, err := s.service.GetWebhookDeliveries(c, id, getUserTokenFromContext(c))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookDeliveriesResp(deliveries))
+}
+
+// CreateAdminWebhook registers a webhook that receives the events of every document.
+func (s *Server) CreateAdminWebhook(c *gin.Context) {
+	var req v1.WebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidBody), errInvalidBody)
+		return
+	}
+
+	webhook, err := s.service.CreateAdminWebhook(c, toDomainWebhook(req))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	resp := toWebhookResp(*webhook)
+	resp.Secret = webhook.Secret
+	c.JSON(http.StatusOK, v1.WebhookResp{Data: resp})
+}
+
+// GetAdminWebhooks lists the webhooks of all users and of the admin.
+func (s *Server) GetAdminWebhooks(c *gin.Context) {
+	webhooks, err := s.service.GetAdminWebhooks(c)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhooksResp(webhooks))
+}
+
+func (s *Server) DeleteAdminWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidWebhookID), errInvalidWebhookID)
+		return
+	}
+
+	err = s.service.DeleteAdminWebhook(c, id)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"response": map[string]any{
+			id.String(): true,
+		}})
+}
+
+func (s *Server) GetAdminWebhookDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(errInvalidWebhookID), errInvalidWebhookID)
+		return
+	}
+
+	deliveries, err := s.service.GetAdminWebhookDeliveries(c, id)
+	if err != nil {
+		s.errorResponse(c, errToHttpStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookDeliveriesResp(deliveries))
+}
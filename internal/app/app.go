@@ -2,7 +2,9 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,7 +14,9 @@ import (
 
 	"github.com/Alina9496/documents/config"
 	"github.com/Alina9496/documents/internal/api"
+	"github.com/Alina9496/documents/internal/dav"
 	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/rpc"
 	"github.com/Alina9496/documents/internal/service"
 	"github.com/Alina9496/tool/pkg/httpserver"
 	"github.com/Alina9496/tool/pkg/logger"
@@ -23,27 +27,89 @@ import (
 func Run(cfg *config.Config) {
 	l := logger.New(cfg.Log.Level)
 
-	runDatabaseMigration(cfg.PG.URL)
+	if !cfg.Migrations.Skip {
+		runDatabaseMigration(cfg.Migrations.Path, cfg.PG.URL)
+	}
 
 	// Repository
-	pg, err := postgres.New(cfg.PG.URL)
+	// the change feed keeps one connection of the pool
+	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(max(cfg.PG.PoolMax, 2)))
 	if err != nil {
 		l.Fatal(err)
 	}
 	defer pg.Close()
 
 	// Use case
-	service := service.New(
+	service, err := service.New(
 		repo.New(pg, l),
 		cache.New(cfg.DefaultExpiration, cfg.CleanupInterval),
 		l,
+		cfg,
 	)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - service.New: %w", err))
+	}
+
+	// Background workers
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runPeriodic(ctx, l, "purge trash", cfg.Trash.PurgeInterval, func(ctx context.Context) error {
+		_, err := service.PurgeTrash(ctx)
+		return err
+	})
+	runPeriodic(ctx, l, "expire documents", cfg.RetentionPolicy.ExpireInterval, func(ctx context.Context) error {
+		_, err := service.ExpireDocuments(ctx)
+		return err
+	})
+	runPeriodic(ctx, l, "purge idempotency keys", cfg.Idempotency.PurgeInterval, func(ctx context.Context) error {
+		_, err := service.PurgeIdempotencyKeys(ctx)
+		return err
+	})
+	runPeriodic(ctx, l, "purge locks", cfg.Lock.PurgeInterval, func(ctx context.Context) error {
+		_, err := service.PurgeLocks(ctx)
+		return err
+	})
+	runPeriodic(ctx, l, "purge tokens", cfg.Token.PurgeInterval, func(ctx context.Context) error {
+		_, err := service.PurgeTokens(ctx)
+		return err
+	})
+	runPeriodic(ctx, l, "chain audit log", cfg.Audit.ChainInterval, func(ctx context.Context) error {
+		_, err := service.ChainAudit(ctx)
+		return err
+	})
+	runTriggered(ctx, l, "extract text", cfg.Extraction.Interval, service.ExtractionWake(), func(ctx context.Context) error {
+		_, err := service.ExtractText(ctx)
+		return err
+	})
+	runTriggered(ctx, l, "generate thumbnails", cfg.Thumbnail.Interval, service.ThumbnailWake(), func(ctx context.Context) error {
+		_, err := service.GenerateThumbnails(ctx)
+		return err
+	})
+	runTriggered(ctx, l, "deliver webhooks", cfg.Webhook.Interval, service.WebhookWake(), func(ctx context.Context) error {
+		_, err := service.DeliverWebhooks(ctx)
+		return err
+	})
+	runPeriodic(ctx, l, "listen events", cfg.Events.ReconnectInterval, service.ListenEvents)
 
 	// HTTP Server
 	handler := gin.New()
 	api.NewServer(handler, l, service, cfg)
+	dav.New(service, l).Register(handler)
 	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTP.Port))
 
+	// gRPC Server
+	grpcServer := rpc.NewServer(l, service, cfg)
+	grpcNotify := make(chan error, 1)
+	listener, err := net.Listen("tcp", net.JoinHostPort("", cfg.GRPC.Port))
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - net.Listen: %w", err))
+	}
+	go func() {
+		grpcNotify <- grpcServer.Serve(listener)
+		close(grpcNotify)
+	}()
+
 	// Waiting signal
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
@@ -53,11 +119,16 @@ func Run(cfg *config.Config) {
 		l.Info("app - Run - signal: " + s.String())
 	case err = <-httpServer.Notify():
 		l.Error(fmt.Errorf("app - Run - httpServer.Notify: %w", err))
+	case err = <-grpcNotify:
+		l.Error(fmt.Errorf("app - Run - grpcServer.Serve: %w", err))
 	}
 
 	// Shutdown
+	// the event streams would hold the HTTP server until its timeout
+	service.StopEvents()
 	err = httpServer.Shutdown()
 	if err != nil {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
+	grpcServer.GracefulStop()
 }
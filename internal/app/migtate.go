@@ -16,7 +16,7 @@ const (
 	_defaultTimeout  = time.Second
 )
 
-func runDatabaseMigration(databaseURL string) {
+func runDatabaseMigration(path, databaseURL string) {
 
 	var (
 		attempts = _defaultAttempts
@@ -25,7 +25,7 @@ func runDatabaseMigration(databaseURL string) {
 	)
 
 	for attempts > 0 {
-		m, err = migrate.New("file://../../migrations", databaseURL)
+		m, err = migrate.New("file://"+path, databaseURL)
 		if err == nil {
 			break
 		}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alina9496/tool/pkg/logger"
+)
+
+// runPeriodic calls fn every interval until ctx is done.
+func runPeriodic(ctx context.Context, l *logger.Logger, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	runTriggered(ctx, l, name, interval, nil, fn)
+}
+
+// runTriggered calls fn every interval and whenever wake fires until ctx is done.
+func runTriggered(
+	ctx context.Context,
+	l *logger.Logger,
+	name string,
+	interval time.Duration,
+	wake <-chan struct{},
+	fn func(ctx context.Context) error,
+) {
+	if interval <= 0 {
+		l.Warn("app - runPeriodic - " + name + " disabled: interval is not set")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+
+			if err := fn(ctx); err != nil {
+				l.WithError(err).Error("app - runPeriodic - " + name)
+			}
+		}
+	}()
+}
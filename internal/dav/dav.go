@@ -0,0 +1,110 @@
+// Package dav serves the documents of a user over WebDAV so they can be
+// mounted as a network drive.
+package dav
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/webdav"
+)
+
+// Prefix is the path the WebDAV tree is served under.
+const Prefix = "/dav"
+
+const authenticateHeader = `Basic realm="documents", charset="UTF-8"`
+
+// methods are the HTTP methods of WebDAV, gin routes every method separately.
+var methods = []string{
+	http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+	"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+}
+
+type Handler struct {
+	service Service
+	l       *logger.Logger
+	locks   webdav.LockSystem
+}
+
+func New(s Service, l *logger.Logger) *Handler {
+	return &Handler{
+		service: s,
+		l:       l,
+		// WebDAV locks only guard the requests of the clients of this instance,
+		// check-out locks of documents are enforced by the service anyway
+		locks: webdav.NewMemLS(),
+	}
+}
+
+// Register routes the WebDAV methods under Prefix to the handler.
+func (h *Handler) Register(router gin.IRoutes) {
+	for _, method := range methods {
+		router.Handle(method, Prefix, h.serve)
+		router.Handle(method, Prefix+"/*path", h.serve)
+	}
+}
+
+func (h *Handler) serve(c *gin.Context) {
+	token, ok := h.authenticate(c)
+	if !ok {
+		c.Header("WWW-Authenticate", authenticateHeader)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), service.ClientKey, service.Client{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+
+	fs := &fileSystem{service: h.service, token: token}
+	handler := &webdav.Handler{
+		Prefix:     Prefix,
+		FileSystem: fs,
+		LockSystem: h.locks,
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				h.l.WithField("method", r.Method).WithField("path", r.URL.Path).WithError(err).Warn("webdav request failed")
+			}
+		},
+	}
+	handler.ServeHTTP(&authWriter{ResponseWriter: c.Writer, fs: fs}, c.Request.WithContext(ctx))
+}
+
+// authenticate accepts the token header of the API or Basic credentials, the
+// password being the password of the user or one of the user's tokens.
+func (h *Handler) authenticate(c *gin.Context) (string, bool) {
+	if token := c.GetHeader("token"); token != "" {
+		return token, h.service.CheckToken(c, token) == nil
+	}
+
+	login, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return "", false
+	}
+
+	token, err := h.service.BasicAuth(c, login, password)
+	if err != nil {
+		return "", false
+	}
+
+	return token, true
+}
+
+// authWriter answers 401 instead of the error status of the webdav package
+// when the token stopped working while the request was served.
+type authWriter struct {
+	http.ResponseWriter
+	fs *fileSystem
+}
+
+func (w *authWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest && w.fs.unauthorized.Load() {
+		w.Header().Set("WWW-Authenticate", authenticateHeader)
+		status = http.StatusUnauthorized
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
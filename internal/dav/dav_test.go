@@ -0,0 +1,275 @@
+package dav
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "CxBiwVruDAD8kp8jgeOY"
+
+// fakeService keeps the documents of the root folder of one user in memory.
+type fakeService struct {
+	Service
+	folders   []domain.Folder
+	documents map[uuid.UUID]*domain.Document
+	content   map[uuid.UUID]string
+	shared    []domain.Document
+	versions  int
+}
+
+func newFakeService() *fakeService {
+	id := uuid.New()
+	sharedID := uuid.New()
+	return &fakeService{
+		folders: []domain.Folder{{ID: uuid.New(), Name: "reports"}},
+		documents: map[uuid.UUID]*domain.Document{
+			id: {ID: id, Name: "notes.txt", Mime: "text/plain", Size: 5},
+		},
+		content: map[uuid.UUID]string{
+			id:       "notes",
+			sharedID: "shared",
+		},
+		shared: []domain.Document{
+			{ID: sharedID, Name: "plan/2024.txt", Mime: "text/plain", Size: 6},
+		},
+	}
+}
+
+func (f *fakeService) BasicAuth(_ context.Context, login, password string) (string, error) {
+	if login != "login345" || password != "Passw_345" {
+		return "", service.ErrUserNotFound
+	}
+	return testToken, nil
+}
+
+func (f *fakeService) CheckToken(_ context.Context, token string) error {
+	if token != testToken {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (f *fakeService) ResolvePath(_ context.Context, path, token string) (*dto.FolderContents, *domain.Document, error) {
+	if token != testToken {
+		return nil, nil, service.ErrTokenNotFound
+	}
+	switch path {
+	case "":
+		contents := &dto.FolderContents{Folders: f.folders}
+		for _, document := range f.documents {
+			contents.Documents = append(contents.Documents, *document)
+		}
+		return contents, nil, nil
+	case "reports":
+		return &dto.FolderContents{Folder: &f.folders[0]}, nil, nil
+	default:
+		return nil, nil, service.ErrFolderNotFound
+	}
+}
+
+func (f *fakeService) GetDocuments(_ context.Context, filter *dto.GetDocumentsRequest) (*dto.DocumentsPage, error) {
+	if filter.Scope != dto.ScopeShared {
+		return nil, service.ErrDocumentsNotFound
+	}
+	return &dto.DocumentsPage{Documents: f.shared}, nil
+}
+
+func (f *fakeService) GetDocument(_ context.Context, id uuid.UUID, _ string) (*domain.Document, error) {
+	content, ok := f.content[id]
+	if !ok {
+		return nil, service.ErrDocumentNotFound
+	}
+	return &domain.Document{ID: id, Content: base64.StdEncoding.EncodeToString([]byte(content))}, nil
+}
+
+func (f *fakeService) Upload(_ context.Context, document *dto.Document) (*domain.Document, error) {
+	id := uuid.New()
+	f.documents[id] = &domain.Document{ID: id, Name: document.Name, Mime: document.Mime, Size: int64(len(document.Content))}
+	f.content[id] = string(document.Content)
+	return f.documents[id], nil
+}
+
+func (f *fakeService) UploadVersion(_ context.Context, req *dto.DocumentContentRequest) (*domain.Document, error) {
+	f.versions++
+	f.content[req.ID] = string(req.Content)
+	return f.documents[req.ID], nil
+}
+
+func (f *fakeService) DeleteDocument(_ context.Context, id uuid.UUID, _ string) (uuid.UUID, error) {
+	if _, ok := f.documents[id]; !ok {
+		return uuid.Nil, service.ErrNoAccess
+	}
+	delete(f.documents, id)
+	return id, nil
+}
+
+func (f *fakeService) documentByName(name string) *domain.Document {
+	for _, document := range f.documents {
+		if document.Name == name {
+			return document
+		}
+	}
+	return nil
+}
+
+func newTestServer(s Service) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	New(s, logger.New("error")).Register(router)
+	return httptest.NewServer(router)
+}
+
+func do(t *testing.T, server *httptest.Server, method, path, body string, auth bool) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if auth {
+		req.SetBasicAuth("login345", "Passw_345")
+	}
+	if method == "PROPFIND" {
+		req.Header.Set("Depth", "1")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func Test_Authentication(t *testing.T) {
+	server := newTestServer(newFakeService())
+	defer server.Close()
+
+	resp, _ := do(t, server, "PROPFIND", "/dav/", "", false)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic")
+
+	resp, _ = do(t, server, "PROPFIND", "/dav/", "", true)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+
+	token := func(token string) int {
+		req, err := http.NewRequest("PROPFIND", server.URL+"/dav/", nil)
+		require.NoError(t, err)
+		req.Header.Set("token", token)
+		req.Header.Set("Depth", "1")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusMultiStatus, token(testToken))
+	assert.Equal(t, http.StatusUnauthorized, token("bogus"))
+}
+
+// expiringService hands out a token that expires before the request is served.
+type expiringService struct {
+	*fakeService
+}
+
+func (e expiringService) BasicAuth(_ context.Context, _, _ string) (string, error) {
+	return "expired", nil
+}
+
+func Test_Authentication_expired(t *testing.T) {
+	server := newTestServer(expiringService{newFakeService()})
+	defer server.Close()
+
+	resp, _ := do(t, server, "PROPFIND", "/dav/my/reports", "", true)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic")
+}
+
+func Test_Propfind(t *testing.T) {
+	server := newTestServer(newFakeService())
+	defer server.Close()
+
+	resp, body := do(t, server, "PROPFIND", "/dav/", "", true)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.Contains(t, body, "/dav/my/")
+	assert.Contains(t, body, "/dav/shared/")
+
+	resp, body = do(t, server, "PROPFIND", "/dav/my/", "", true)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.Contains(t, body, "/dav/my/reports/")
+	assert.Contains(t, body, "/dav/my/notes.txt")
+
+	resp, body = do(t, server, "PROPFIND", "/dav/shared/", "", true)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.Contains(t, body, "/dav/shared/plan_2024.txt")
+
+	resp, _ = do(t, server, "PROPFIND", "/dav/other/", "", true)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_Get(t *testing.T) {
+	server := newTestServer(newFakeService())
+	defer server.Close()
+
+	resp, body := do(t, server, http.MethodGet, "/dav/my/notes.txt", "", true)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "notes", body)
+
+	resp, body = do(t, server, http.MethodGet, "/dav/shared/plan_2024.txt", "", true)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "shared", body)
+
+	resp, _ = do(t, server, http.MethodGet, "/dav/my/missing.txt", "", true)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_Put(t *testing.T) {
+	s := newFakeService()
+	server := newTestServer(s)
+	defer server.Close()
+
+	resp, _ := do(t, server, http.MethodPut, "/dav/my/report.csv", "a,b", true)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	document := s.documentByName("report.csv")
+	require.NotNil(t, document)
+	assert.Equal(t, "text/csv", document.Mime)
+	assert.Equal(t, "a,b", s.content[document.ID])
+
+	resp, _ = do(t, server, http.MethodPut, "/dav/my/notes.txt", "new notes", true)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 1, s.versions)
+	assert.Equal(t, "new notes", s.content[s.documentByName("notes.txt").ID])
+
+	// documents are only created in the own tree
+	resp, _ = do(t, server, http.MethodPut, "/dav/report.csv", "a,b", true)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_Delete(t *testing.T) {
+	s := newFakeService()
+	server := newTestServer(s)
+	defer server.Close()
+
+	resp, _ := do(t, server, http.MethodDelete, "/dav/my/notes.txt", "", true)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Nil(t, s.documentByName("notes.txt"))
+
+	// webdav answers a failed removal with 405
+	resp, _ = do(t, server, http.MethodDelete, "/dav/shared/plan_2024.txt", "", true)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, _ = do(t, server, http.MethodDelete, "/dav/my", "", true)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,256 @@
+package dav
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/Alina9496/documents/internal/service/dto"
+	"golang.org/x/net/webdav"
+)
+
+// file reads the content of a document on first use and uploads what was
+// written on Close: a new document or a new version of an existing one.
+type file struct {
+	ctx      context.Context
+	fs       *fileSystem
+	entry    *entry
+	write    bool
+	truncate bool
+
+	reader  *bytes.Reader
+	written bytes.Buffer
+	dirty   bool
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	err := f.load()
+	if err != nil {
+		return 0, err
+	}
+
+	return f.reader.Read(p)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	err := f.load()
+	if err != nil {
+		return 0, err
+	}
+
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	if !f.write {
+		return 0, os.ErrPermission
+	}
+
+	f.dirty = true
+	return f.written.Write(p)
+}
+
+func (f *file) Readdir(int) ([]fs.FileInfo, error) {
+	return nil, os.ErrInvalid
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	info := f.entry.info()
+	if f.write && (f.dirty || f.truncate) {
+		info.size = int64(f.written.Len())
+		info.modTime = time.Now()
+	}
+
+	return info, nil
+}
+
+func (f *file) Close() error {
+	if !f.write || !(f.dirty || f.truncate) {
+		return nil
+	}
+
+	content := f.written.Bytes()
+	if f.entry.document != nil {
+		_, err := f.fs.service.UploadVersion(f.ctx, &dto.DocumentContentRequest{
+			ID:      f.entry.document.ID,
+			Token:   f.fs.token,
+			Content: content,
+		})
+		return f.fs.osError(err)
+	}
+
+	_, err := f.fs.service.Upload(f.ctx, &dto.Document{
+		Name:     f.entry.name,
+		Token:    f.fs.token,
+		Mime:     detectMime(f.entry.name, content),
+		Content:  content,
+		FolderID: f.entry.folderID,
+	})
+	return f.fs.osError(err)
+}
+
+// load reads the content of an existing document, writes start from empty.
+func (f *file) load() error {
+	if f.reader != nil {
+		return nil
+	}
+	if f.entry.document == nil || f.write {
+		f.reader = bytes.NewReader(f.written.Bytes())
+		return nil
+	}
+
+	document, err := f.fs.service.GetDocument(f.ctx, f.entry.document.ID, f.fs.token)
+	if err != nil {
+		return f.fs.osError(err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(document.Content)
+	if err != nil {
+		return err
+	}
+	f.reader = bytes.NewReader(content)
+
+	return nil
+}
+
+// dir lists a directory of the tree.
+type dir struct {
+	ctx   context.Context
+	fs    *fileSystem
+	entry *entry
+	name  string
+
+	infos  []fs.FileInfo
+	listed bool
+}
+
+func (d *dir) Readdir(count int) ([]fs.FileInfo, error) {
+	if !d.listed {
+		infos, err := d.list()
+		if err != nil {
+			return nil, err
+		}
+		d.infos, d.listed = infos, true
+	}
+
+	if count <= 0 {
+		infos := d.infos
+		d.infos = nil
+		return infos, nil
+	}
+
+	if len(d.infos) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(d.infos))
+	infos := d.infos[:count]
+	d.infos = d.infos[count:]
+
+	return infos, nil
+}
+
+func (d *dir) list() ([]fs.FileInfo, error) {
+	infos := make([]fs.FileInfo, 0)
+	switch {
+	case d.entry.name == "/":
+		infos = append(infos,
+			(&entry{name: ownRoot, dir: true}).info(),
+			(&entry{name: sharedRoot, dir: true}).info(),
+		)
+	case d.entry.shared:
+		documents, err := d.fs.sharedDocuments(d.ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range documents {
+			infos = append(infos, e.info())
+		}
+	default:
+		contents, err := d.fs.ownContents(d.ctx, splitPath(d.name)[1:])
+		if err != nil {
+			return nil, err
+		}
+		for i := range contents.Folders {
+			folder := &contents.Folders[i]
+			infos = append(infos, (&entry{name: folder.Name, dir: true, folderID: folder.ID, folder: folder}).info())
+		}
+		for i := range contents.Documents {
+			document := &contents.Documents[i]
+			infos = append(infos, (&entry{name: displayName(document.Name), document: document}).info())
+		}
+	}
+
+	return infos, nil
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.entry.info(), nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, os.ErrInvalid
+}
+
+func (d *dir) Seek(int64, int) (int64, error) {
+	return 0, os.ErrInvalid
+}
+
+func (d *dir) Write([]byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+// fileInfo describes an entry, the content type of documents is the stored
+// one so a PROPFIND does not read the content.
+type fileInfo struct {
+	name    string
+	size    int64
+	mime    string
+	modTime time.Time
+	dir     bool
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() any           { return nil }
+
+func (i *fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (i *fileInfo) ContentType(context.Context) (string, error) {
+	if i.dir || i.mime == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return i.mime, nil
+}
+
+// detectMime guesses the type of a new document by the extension, then by the content.
+func detectMime(name string, content []byte) string {
+	detected := mime.TypeByExtension(path.Ext(name))
+	if detected == "" {
+		detected = http.DetectContentType(content)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(detected)
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	return mediaType
+}
@@ -0,0 +1,349 @@
+package dav
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
+	"golang.org/x/net/webdav"
+)
+
+const (
+	// ownRoot holds the folders and documents of the user.
+	ownRoot = "my"
+	// sharedRoot holds the documents shared with the user.
+	sharedRoot = "shared"
+
+	// sharedPageSize is the page of the listing of the shared documents.
+	sharedPageSize = 100
+)
+
+// fileSystem maps the WebDAV tree of one user to the service: /my is the own
+// folder tree, /shared lists the documents of other users shared with them.
+type fileSystem struct {
+	service      Service
+	token        string
+	unauthorized atomic.Bool
+}
+
+var _ webdav.FileSystem = (*fileSystem)(nil)
+
+// entry is what a path resolves to. folderID is the own folder of a directory
+// and the folder a new document is created in.
+type entry struct {
+	name     string
+	dir      bool
+	shared   bool
+	folderID uuid.UUID
+	folder   *domain.Folder
+	document *domain.Document
+}
+
+func (fs *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	e, err := fs.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.info(), nil
+}
+
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, _ os.FileMode) (webdav.File, error) {
+	write := flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_TRUNC) != 0
+
+	e, err := fs.resolve(ctx, name)
+	if errors.Is(err, os.ErrNotExist) && flag&os.O_CREATE != 0 {
+		return fs.create(ctx, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+		return nil, os.ErrExist
+	}
+
+	if e.dir {
+		if write {
+			return nil, os.ErrPermission
+		}
+		return &dir{ctx: ctx, fs: fs, entry: e, name: name}, nil
+	}
+
+	return &file{ctx: ctx, fs: fs, entry: e, write: write, truncate: flag&os.O_TRUNC != 0}, nil
+}
+
+func (fs *fileSystem) Mkdir(ctx context.Context, name string, _ os.FileMode) error {
+	parent, base, err := fs.ownParent(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = fs.service.CreateFolder(ctx, fs.token, &domain.Folder{Name: base, ParentID: parent})
+	return fs.osError(err)
+}
+
+func (fs *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	e, err := fs.resolve(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.document != nil:
+		_, err = fs.service.DeleteDocument(ctx, e.document.ID, fs.token)
+	case e.folder != nil:
+		err = fs.service.DeleteFolder(ctx, e.folder.ID, fs.token)
+	default:
+		return os.ErrPermission
+	}
+
+	return fs.osError(err)
+}
+
+// Rename moves and renames folders and documents within the own tree.
+func (fs *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	e, err := fs.resolve(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	if e.shared || (e.document == nil && e.folder == nil) {
+		return os.ErrPermission
+	}
+
+	parent, base, err := fs.ownParent(ctx, newName)
+	if err != nil {
+		return err
+	}
+
+	if e.document != nil {
+		_, err = fs.service.UpdateDocument(ctx, &dto.UpdateDocumentRequest{
+			ID:       e.document.ID,
+			Token:    fs.token,
+			Name:     &base,
+			FolderID: &parent,
+		})
+		return fs.osError(err)
+	}
+
+	_, err = fs.service.UpdateFolder(ctx, &dto.UpdateFolderRequest{
+		ID:       e.folder.ID,
+		Token:    fs.token,
+		Name:     &base,
+		ParentID: &parent,
+	})
+	return fs.osError(err)
+}
+
+func (fs *fileSystem) resolve(ctx context.Context, name string) (*entry, error) {
+	segments := splitPath(name)
+	switch {
+	case len(segments) == 0:
+		return &entry{name: "/", dir: true}, nil
+	case segments[0] == ownRoot && len(segments) == 1:
+		return &entry{name: ownRoot, dir: true}, nil
+	case segments[0] == ownRoot:
+		return fs.resolveOwn(ctx, segments[1:])
+	case segments[0] == sharedRoot && len(segments) == 1:
+		return &entry{name: sharedRoot, dir: true, shared: true}, nil
+	case segments[0] == sharedRoot && len(segments) == 2:
+		return fs.resolveShared(ctx, segments[1])
+	default:
+		return nil, os.ErrNotExist
+	}
+}
+
+// resolveOwn looks the last segment up in the listing of its folder, so a
+// PROPFIND does not read the content of the documents.
+func (fs *fileSystem) resolveOwn(ctx context.Context, segments []string) (*entry, error) {
+	contents, err := fs.ownContents(ctx, segments[:len(segments)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	base := segments[len(segments)-1]
+	for i := range contents.Folders {
+		if contents.Folders[i].Name == base {
+			folder := &contents.Folders[i]
+			return &entry{name: base, dir: true, folderID: folder.ID, folder: folder}, nil
+		}
+	}
+	for i := range contents.Documents {
+		if displayName(contents.Documents[i].Name) == base {
+			return &entry{name: base, document: &contents.Documents[i]}, nil
+		}
+	}
+
+	return nil, os.ErrNotExist
+}
+
+func (fs *fileSystem) resolveShared(ctx context.Context, base string) (*entry, error) {
+	documents, err := fs.sharedDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range documents {
+		if e.name == base {
+			return e, nil
+		}
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// ownContents lists an own folder given by the segments after /my.
+func (fs *fileSystem) ownContents(ctx context.Context, segments []string) (*dto.FolderContents, error) {
+	contents, document, err := fs.service.ResolvePath(ctx, strings.Join(segments, "/"), fs.token)
+	if err != nil {
+		return nil, fs.osError(err)
+	}
+	if document != nil {
+		return nil, os.ErrNotExist
+	}
+
+	return contents, nil
+}
+
+// ownParent returns the own folder a new entry with the name goes into.
+func (fs *fileSystem) ownParent(ctx context.Context, name string) (uuid.UUID, string, error) {
+	segments := splitPath(name)
+	if len(segments) < 2 || segments[0] != ownRoot {
+		return uuid.Nil, "", os.ErrPermission
+	}
+
+	contents, err := fs.ownContents(ctx, segments[1:len(segments)-1])
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	parent := uuid.Nil
+	if contents.Folder != nil {
+		parent = contents.Folder.ID
+	}
+
+	return parent, segments[len(segments)-1], nil
+}
+
+// sharedDocuments lists the documents shared with the user under unique names.
+func (fs *fileSystem) sharedDocuments(ctx context.Context) ([]*entry, error) {
+	entries := make([]*entry, 0)
+	names := make(map[string]bool)
+
+	cursor := ""
+	for {
+		page, err := fs.service.GetDocuments(ctx, &dto.GetDocumentsRequest{
+			Token:  fs.token,
+			Scope:  dto.ScopeShared,
+			Cursor: cursor,
+			Limit:  sharedPageSize,
+		})
+		if err != nil {
+			return nil, fs.osError(err)
+		}
+
+		for i := range page.Documents {
+			document := &page.Documents[i]
+			name := uniqueName(names, displayName(document.Name), document.ID)
+			entries = append(entries, &entry{name: name, shared: true, document: document})
+		}
+
+		if page.NextCursor == "" {
+			return entries, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// create opens a new document of the own tree, it is uploaded on Close.
+func (fs *fileSystem) create(ctx context.Context, name string) (webdav.File, error) {
+	parent, base, err := fs.ownParent(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &entry{name: base, folderID: parent}
+	return &file{ctx: ctx, fs: fs, entry: e, write: true, truncate: true}, nil
+}
+
+func (e *entry) info() *fileInfo {
+	info := &fileInfo{name: e.name, dir: e.dir}
+	switch {
+	case e.document != nil:
+		info.size = e.document.Size
+		info.mime = e.document.Mime
+		info.modTime = e.document.CreatedAt
+		if !e.document.UpdatedAt.IsZero() {
+			info.modTime = e.document.UpdatedAt
+		}
+	case e.folder != nil:
+		info.modTime = e.folder.CreatedAt
+	default:
+		info.modTime = time.Now()
+	}
+
+	return info
+}
+
+func splitPath(name string) []string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path.Clean("/"+name), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
+
+// displayName keeps a document name a single path segment.
+func displayName(name string) string {
+	return strings.ReplaceAll(name, "/", "_")
+}
+
+// uniqueName tells documents with the same name apart by their id.
+func uniqueName(names map[string]bool, name string, id uuid.UUID) string {
+	if names[name] {
+		ext := path.Ext(name)
+		name = strings.TrimSuffix(name, ext) + " (" + id.String()[:8] + ")" + ext
+	}
+	names[name] = true
+
+	return name
+}
+
+// osError maps an error of the service to the errors the webdav package turns
+// into statuses. A token that stopped working is remembered, so the request is
+// answered with 401 instead.
+func (fs *fileSystem) osError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrTokenNotFound):
+		fs.unauthorized.Store(true)
+		return os.ErrPermission
+	case errors.Is(err, service.ErrDocumentNotFound),
+		errors.Is(err, service.ErrDocumentsNotFound),
+		errors.Is(err, service.ErrFolderNotFound):
+		return os.ErrNotExist
+	case errors.Is(err, service.ErrFolderExists):
+		return os.ErrExist
+	case errors.Is(err, service.ErrNoAccess),
+		errors.Is(err, service.ErrFolderNotEmpty),
+		errors.Is(err, service.ErrLocked),
+		errors.Is(err, service.ErrLegalHold),
+		errors.Is(err, service.ErrRetentionPolicy):
+		return os.ErrPermission
+	default:
+		return err
+	}
+}
@@ -0,0 +1,24 @@
+package dav
+
+import (
+	"context"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	BasicAuth(ctx context.Context, login, password string) (string, error)
+	CheckToken(ctx context.Context, token string) error
+	ResolvePath(ctx context.Context, path, token string) (*dto.FolderContents, *domain.Document, error)
+	GetDocuments(ctx context.Context, filter *dto.GetDocumentsRequest) (*dto.DocumentsPage, error)
+	GetDocument(ctx context.Context, id uuid.UUID, token string) (*domain.Document, error)
+	Upload(ctx context.Context, document *dto.Document) (*domain.Document, error)
+	UploadVersion(ctx context.Context, req *dto.DocumentContentRequest) (*domain.Document, error)
+	UpdateDocument(ctx context.Context, req *dto.UpdateDocumentRequest) (*domain.Document, error)
+	DeleteDocument(ctx context.Context, id uuid.UUID, token string) (uuid.UUID, error)
+	CreateFolder(ctx context.Context, token string, folder *domain.Folder) (*domain.Folder, error)
+	UpdateFolder(ctx context.Context, req *dto.UpdateFolderRequest) (*domain.Folder, error)
+	DeleteFolder(ctx context.Context, id uuid.UUID, token string) error
+}
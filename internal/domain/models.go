@@ -7,26 +7,380 @@ import (
 )
 
 type User struct {
-	ID       uuid.UUID
-	Login    string
+	ID    uuid.UUID
+	Login string
+	// Password is the bcrypt hash of the password once it is stored, users
+	// created before passwords were hashed keep the password itself until
+	// it is re-hashed.
 	Password string
 	Token    string
+	// TokenExpiresAt is when Token stops working, nil when it never does.
+	TokenExpiresAt *time.Time
+	// DisabledAt is set for a user who may no longer log in.
+	DisabledAt *time.Time
 }
 
 type Document struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	Name      string
-	Mime      string
-	Content   string
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	FolderID    uuid.UUID
+	Name        string
+	Mime        string
+	Description string
+	Content     string
+	Size        int64
+	// Checksum is the hex SHA-256 of the content.
+	Checksum  string
 	Grant     []string
+	Tags      []string
+	Metadata  map[string]any
+	Revision  int
+	Version   int
 	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
 	Public    bool
+	LegalHold bool
+	// Permission is what the requesting user may do with the document.
+	Permission string
+	// Thumbnail tells whether a thumbnail of the current version is ready.
+	Thumbnail bool
+}
+
+const (
+	PermissionOwner = "owner"
+	PermissionRead  = "read"
+	// PermissionComment is a grant that lets the user read and comment.
+	PermissionComment = "comment"
+	// PermissionEdit is a grant that also lets the user upload new content.
+	PermissionEdit = "edit"
+)
+
+const (
+	JobPending     = "pending"
+	JobDone        = "done"
+	JobFailed      = "failed"
+	JobUnsupported = "unsupported"
+)
+
+// Job is a background job on one version of a document. A pending job is
+// retried at NextAttemptAt until it is done or failed.
+type Job struct {
+	DocumentID    uuid.UUID
+	Version       int
+	Status        string
+	Attempts      int
+	Error         string
+	NextAttemptAt time.Time
+	UpdatedAt     time.Time
+}
+
+const (
+	ExtractionPending     = JobPending
+	ExtractionDone        = JobDone
+	ExtractionFailed      = JobFailed
+	ExtractionUnsupported = JobUnsupported
+)
+
+// TextExtraction is the text extracted from one version of a document.
+type TextExtraction struct {
+	Job
+	Text string
+}
+
+const (
+	ThumbnailPending     = JobPending
+	ThumbnailDone        = JobDone
+	ThumbnailFailed      = JobFailed
+	ThumbnailUnsupported = JobUnsupported
+)
+
+// ThumbnailJob renders the thumbnails of one version of a document.
+type ThumbnailJob struct {
+	Job
+}
+
+const (
+	IdempotencyPending = "pending"
+	IdempotencyDone    = "done"
+)
+
+// IdempotencyKey remembers the response to a request the client may retry.
+// A pending key belongs to a request still in flight.
+type IdempotencyKey struct {
+	UserID     uuid.UUID
+	Key        string
+	Status     string
+	DocumentID uuid.UUID
+	Response   []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// Rendition is a preview derived from one version of a document.
+type Rendition struct {
+	DocumentID uuid.UUID
+	Version    int
+	Size       string
+	Mime       string
+	Width      int
+	Height     int
+	Data       []byte
+	CreatedAt  time.Time
+}
+
+// The hits of a snippet are between SnippetStart and SnippetStop. The control
+// characters never occur in the snippet text, they are removed from it.
+const (
+	SnippetStart = "\x01"
+	SnippetStop  = "\x02"
+)
+
+// SearchResult is a document matched by a full-text query.
+// Snippet is a plain text fragment of the matched text with the hits marked by
+// SnippetStart and SnippetStop.
+type SearchResult struct {
+	Document Document
+	Rank     float32
+	Snippet  string
 }
 
 type Grant struct {
 	UserID         uuid.UUID
 	DocumentID     uuid.UUID
 	GrantUserLogin string
-	CreatedAt      time.Time
+	// Permission is PermissionRead, PermissionComment or PermissionEdit.
+	Permission string
+	CreatedAt  time.Time
+}
+
+// Folder groups documents of one user. ParentID is uuid.Nil for top level folders.
+type Folder struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ParentID  uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
+
+// FolderGrant gives a user access to every document in the folder and its subfolders.
+type FolderGrant struct {
+	UserID         uuid.UUID
+	FolderID       uuid.UUID
+	GrantUserLogin string
+	// Permission is PermissionRead, PermissionComment or PermissionEdit.
+	Permission string
+	CreatedAt  time.Time
+}
+
+const (
+	// RetentionMin forbids deleting a document until the period has passed.
+	RetentionMin = "min"
+	// RetentionExpire deletes a document automatically once the period has passed.
+	RetentionExpire = "expire"
+)
+
+type RetentionPolicy struct {
+	ID         uuid.UUID
+	Kind       string
+	Mime       string
+	Tag        string
+	DocumentID uuid.UUID
+	Days       int
+	CreatedAt  time.Time
+}
+
+const (
+	AuditRegister          = "user.register"
+	AuditLogin             = "user.login"
+	AuditLogout            = "user.logout"
+	AuditUserDisable       = "user.disable"
+	AuditUserEnable        = "user.enable"
+	AuditUpload            = "document.upload"
+	AuditView              = "document.view"
+	AuditList              = "document.list"
+	AuditUpdate            = "document.update"
+	AuditVersion           = "document.version"
+	AuditDelete            = "document.delete"
+	AuditRestore           = "document.restore"
+	AuditLock              = "document.lock"
+	AuditUnlock            = "document.unlock"
+	AuditGrantAdd          = "grant.add"
+	AuditGrantRemove       = "grant.remove"
+	AuditFolderGrantAdd    = "folder_grant.add"
+	AuditFolderGrantRemove = "folder_grant.remove"
+
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent records who did what to which document or user and how it ended.
+// Every event carries the hash of the previous one, so removing or changing
+// an event breaks the chain.
+type AuditEvent struct {
+	ID          int64
+	CreatedAt   time.Time
+	ActorID     uuid.UUID
+	ActorLogin  string
+	Action      string
+	DocumentID  uuid.UUID
+	FolderID    uuid.UUID
+	TargetLogin string
+	Outcome     string
+	Reason      string
+	IP          string
+	UserAgent   string
+	PrevHash    string
+	Hash        string
+}
+
+// AuditVerification is the result of checking the hash chain of the audit log.
+// BrokenID is the first event whose hash does not match, 0 when the chain is intact.
+type AuditVerification struct {
+	Checked  int64
+	BrokenID int64
+}
+
+const (
+	WebhookDocumentUploaded = "document.uploaded"
+	WebhookDocumentUpdated  = "document.updated"
+	WebhookDocumentShared   = "document.shared"
+	WebhookDocumentUnshared = "document.unshared"
+	WebhookDocumentDeleted  = "document.deleted"
+	WebhookDocumentRestored = "document.restored"
+
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to.
+var WebhookEvents = []string{
+	WebhookDocumentUploaded,
+	WebhookDocumentUpdated,
+	WebhookDocumentShared,
+	WebhookDocumentUnshared,
+	WebhookDocumentDeleted,
+	WebhookDocumentRestored,
+}
+
+// Webhook is an endpoint notified about document events. A webhook of a user
+// receives the events of the documents the user owns or is given access to,
+// a webhook of the admin has UserID uuid.Nil and receives every event.
+// Empty Events subscribes to all of them.
+type Webhook struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	URL       string
+	Secret    string
+	Events    []string
+	CreatedAt time.Time
+}
+
+// WebhookEvent is an entry of the outbox. It is written in the transaction
+// of the change it describes and later fanned out to the matching webhooks.
+// TargetLogin is the user a document was shared with or unshared from. Seq is
+// the place of the event in the change feed, given in the order the events
+// commit.
+type WebhookEvent struct {
+	ID          int64
+	Seq         int64
+	Event       string
+	DocumentID  uuid.UUID
+	UserID      uuid.UUID
+	TargetLogin string
+	Payload     []byte
+	CreatedAt   time.Time
+}
+
+// WebhookDelivery sends one event to one webhook. A pending delivery is
+// retried at NextAttemptAt until it is delivered or failed. URL, Secret,
+// Event and Payload are only set on claimed deliveries.
+type WebhookDelivery struct {
+	ID            int64
+	WebhookID     uuid.UUID
+	EventID       int64
+	Event         string
+	URL           string
+	Secret        string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	ResponseCode  int
+	Error         string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// EventTicket opens the change feed of the user once until ExpiresAt. It is
+// given to clients that can not send the token header, so that the token does
+// not end up in URLs.
+type EventTicket struct {
+	Ticket    string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+// EventNotification tells the listeners of the change feed that an event was
+// written to the outbox and who it concerns.
+type EventNotification struct {
+	ID          int64
+	UserID      uuid.UUID
+	TargetLogin string
+}
+
+// Comment is a remark on a document or a reply to one when ParentID is set.
+// Page, RangeStart and RangeEnd anchor it to a page and a range of the text,
+// zero values mean it is not anchored. A deleted comment keeps its place in
+// the thread without the body.
+type Comment struct {
+	ID         uuid.UUID
+	DocumentID uuid.UUID
+	ParentID   uuid.UUID
+	UserID     uuid.UUID
+	Login      string
+	Body       string
+	Page       int
+	RangeStart int
+	RangeEnd   int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+}
+
+// DocumentLock is a check-out of a document: until it expires only the
+// holder may upload new content.
+type DocumentLock struct {
+	DocumentID uuid.UUID
+	UserID     uuid.UUID
+	Login      string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// StorageUsage is the size of the documents of a user, the documents in the
+// trash are counted apart.
+type StorageUsage struct {
+	UserID         uuid.UUID
+	Login          string
+	Documents      int64
+	Bytes          int64
+	TrashDocuments int64
+	TrashBytes     int64
+}
+
+// ChecksumMismatch is a document whose content does not match its checksum.
+type ChecksumMismatch struct {
+	DocumentID uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Expected   string
+	Actual     string
+}
+
+// ChecksumReport is the result of verifying the checksums of all documents.
+type ChecksumReport struct {
+	Checked    int64
+	Mismatches []ChecksumMismatch
 }
@@ -0,0 +1,138 @@
+// Package extract pulls plain text out of uploaded documents for search and previews.
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxText caps the extracted text kept per document, Postgres rejects a tsvector over 1MB.
+const MaxText = 256 << 10
+
+var (
+	ErrUnsupported = errors.New("unsupported mime type")
+	ErrInvalidText = errors.New("content is not valid UTF-8 text")
+)
+
+// Extractor returns the plain text of a document in one format.
+type Extractor interface {
+	Extract(content []byte) (string, error)
+}
+
+// ExtractorFunc adapts an ordinary function to the Extractor interface.
+type ExtractorFunc func(content []byte) (string, error)
+
+func (f ExtractorFunc) Extract(content []byte) (string, error) {
+	return f(content)
+}
+
+// Registry selects an Extractor by MIME type. A "type/*" entry matches every
+// subtype that has no entry of its own.
+type Registry struct {
+	extractors map[string]Extractor
+}
+
+func NewRegistry() *Registry {
+	return &Registry{extractors: make(map[string]Extractor)}
+}
+
+// Default returns a registry with all built-in extractors.
+func Default() *Registry {
+	r := NewRegistry()
+
+	r.Register("text/*", ExtractorFunc(Plain))
+	for _, mime := range []string{
+		"application/json",
+		"application/xml",
+		"application/javascript",
+		"application/x-yaml",
+		"application/yaml",
+		"application/x-sh",
+		"application/sql",
+	} {
+		r.Register(mime, ExtractorFunc(Plain))
+	}
+
+	r.Register("text/markdown", ExtractorFunc(Markdown))
+	r.Register("text/x-markdown", ExtractorFunc(Markdown))
+	r.Register("text/csv", ExtractorFunc(CSV))
+	r.Register("text/html", ExtractorFunc(HTML))
+	r.Register("application/xhtml+xml", ExtractorFunc(HTML))
+	r.Register("application/pdf", ExtractorFunc(PDF))
+	r.Register("application/vnd.openxmlformats-officedocument.wordprocessingml.document", ExtractorFunc(DOCX))
+	r.Register("application/vnd.oasis.opendocument.text", ExtractorFunc(ODT))
+
+	return r
+}
+
+func (r *Registry) Register(mime string, extractor Extractor) {
+	r.extractors[normalizeMime(mime)] = extractor
+}
+
+func (r *Registry) Lookup(mime string) (Extractor, bool) {
+	mime = normalizeMime(mime)
+	if extractor, ok := r.extractors[mime]; ok {
+		return extractor, true
+	}
+
+	if kind, _, ok := strings.Cut(mime, "/"); ok {
+		extractor, ok := r.extractors[kind+"/*"]
+		return extractor, ok
+	}
+
+	return nil, false
+}
+
+// Extract runs the extractor registered for the MIME type and trims the result
+// to MaxText. ErrUnsupported is returned when no extractor is registered.
+func (r *Registry) Extract(mime string, content []byte) (string, error) {
+	extractor, ok := r.Lookup(mime)
+	if !ok {
+		return "", ErrUnsupported
+	}
+
+	text, err := safeExtract(extractor, content)
+	if err != nil {
+		return "", err
+	}
+
+	return truncate(strings.TrimSpace(text), MaxText), nil
+}
+
+// safeExtract turns a panic on a malformed document into an error.
+func safeExtract(extractor Extractor, content []byte) (text string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("extractor panic: %v", p)
+		}
+	}()
+
+	return extractor.Extract(content)
+}
+
+// normalizeMime drops parameters such as charset and lowercases the type.
+func normalizeMime(mime string) string {
+	mime, _, _ = strings.Cut(mime, ";")
+	return strings.ToLower(strings.TrimSpace(mime))
+}
+
+// truncate cuts the text to at most max bytes on a rune boundary.
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+
+	text = text[:max]
+	for len(text) > 0 && !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+
+	return text
+}
+
+// cleanText drops NUL bytes, Postgres does not store them in text columns.
+func cleanText(text string) string {
+	return strings.ReplaceAll(text, "\x00", "")
+}
@@ -0,0 +1,209 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Registry_Extract(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("text/*", ExtractorFunc(Plain))
+	registry.Register("text/csv", ExtractorFunc(CSV))
+	registry.Register("application/x-broken", ExtractorFunc(func([]byte) (string, error) {
+		panic("broken")
+	}))
+
+	tests := []struct {
+		name    string
+		mime    string
+		content []byte
+		want    string
+		err     string
+	}{
+		{
+			name:    "exact mime",
+			mime:    "text/csv",
+			content: []byte("a,b\nc,d\n"),
+			want:    "a b\nc d",
+		},
+		{
+			name:    "wildcard with parameters",
+			mime:    "Text/Plain; charset=utf-8",
+			content: []byte("  invoice  "),
+			want:    "invoice",
+		},
+		{
+			name:    "unsupported mime",
+			mime:    "image/png",
+			content: []byte("invoice"),
+			err:     ErrUnsupported.Error(),
+		},
+		{
+			name:    "extractor panic",
+			mime:    "application/x-broken",
+			content: []byte("invoice"),
+			err:     "extractor panic: broken",
+		},
+		{
+			name:    "long text is cut on a rune boundary",
+			mime:    "text/plain",
+			content: []byte("a" + strings.Repeat("я", MaxText)),
+			want:    "a" + strings.Repeat("я", (MaxText-1)/2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Extract(tt.mime, tt.content)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_formats(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor ExtractorFunc
+		content   []byte
+		want      []string
+		err       error
+	}{
+		{
+			name:      "plain text is not utf-8",
+			extractor: Plain,
+			content:   []byte{0xff, 0xfe},
+			err:       ErrInvalidText,
+		},
+		{
+			name:      "markdown",
+			extractor: Markdown,
+			content:   []byte("# Invoice\n\nSee **the [report](http://example.com/r)** and `code`.\n\n- first item\n"),
+			want:      []string{"Invoice", "See the report and code.", "first item"},
+		},
+		{
+			name:      "html",
+			extractor: HTML,
+			content: []byte(`<html><head><title>Invoice</title><style>p{}</style></head>` +
+				`<body><p>Total <b>42</b></p><script>var secret = 1;</script></body></html>`),
+			want: []string{"Invoice", "Total 42"},
+		},
+		{
+			name:      "docx",
+			extractor: DOCX,
+			content: zipArchive(t, "word/document.xml", `<?xml version="1.0"?>`+
+				`<w:document xmlns:w="`+wordNamespace+`"><w:body>`+
+				`<w:p><w:r><w:t>Invoice</w:t></w:r><w:r><w:t xml:space="preserve"> March</w:t></w:r></w:p>`+
+				`<w:p><w:r><w:t>Total</w:t></w:r></w:p>`+
+				`</w:body></w:document>`),
+			want: []string{"Invoice March", "Total"},
+		},
+		{
+			name:      "odt",
+			extractor: ODT,
+			content: zipArchive(t, "content.xml", `<?xml version="1.0"?>`+
+				`<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" `+
+				`xmlns:text="`+odtNamespace+`"><office:body><office:text>`+
+				`<text:h>Invoice</text:h><text:p>Total<text:s/><text:span>42</text:span></text:p>`+
+				`</office:text></office:body></office:document-content>`),
+			want: []string{"Invoice", "Total 42"},
+		},
+		{
+			name:      "docx without document part",
+			extractor: DOCX,
+			content:   zipArchive(t, "word/other.xml", "<x/>"),
+			want:      nil,
+		},
+		{
+			name:      "pdf",
+			extractor: PDF,
+			content: pdfDocument(t,
+				"BT /F1 12 Tf 72 712 Td (Invoice \\(March\\)) Tj 0 -14 Td [(To) -250 (tal)] TJ ET",
+				"BT <FEFF0418044204300433> Tj ET",
+			),
+			want: []string{"Invoice (March)", "To tal", "Итаг"},
+		},
+		{
+			name:      "not a pdf",
+			extractor: PDF,
+			content:   []byte("invoice"),
+			err:       ErrInvalidPDF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.extractor(tt.content)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err))
+				return
+			}
+			if tt.want == nil {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.want {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
+
+func Test_HTML_skipsScript(t *testing.T) {
+	got, err := HTML([]byte(`<p>visible</p><script>hidden()</script><noscript>hidden</noscript>`))
+	assert.NoError(t, err)
+	assert.Contains(t, got, "visible")
+	assert.NotContains(t, got, "hidden")
+}
+
+func zipArchive(t *testing.T, name, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create(name)
+	assert.NoError(t, err)
+	_, err = file.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+// pdfDocument builds a minimal PDF with the first content stream Flate
+// compressed and the others stored as is.
+func pdfDocument(t *testing.T, streams ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	for i, stream := range streams {
+		data := []byte(stream)
+		filter := ""
+		if i == 0 {
+			var compressed bytes.Buffer
+			writer := zlib.NewWriter(&compressed)
+			_, err := writer.Write(data)
+			assert.NoError(t, err)
+			assert.NoError(t, writer.Close())
+			data = compressed.Bytes()
+			filter = " /Filter /FlateDecode"
+		}
+
+		buf.WriteString("2 0 obj\n<< /Length 1" + filter + " >>\nstream\n")
+		buf.Write(data)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+	buf.WriteString("%%EOF\n")
+
+	return buf.Bytes()
+}
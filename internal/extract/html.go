@@ -0,0 +1,77 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlSkipped are elements whose content is never shown to a reader.
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+}
+
+// htmlBlocks end a line of the extracted text.
+var htmlBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Title: true, atom.Section: true, atom.Article: true, atom.Blockquote: true, atom.Pre: true,
+}
+
+// HTML returns the visible text of an HTML document.
+func HTML(content []byte) (string, error) {
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+
+	var sb strings.Builder
+	skipped := 0
+	for sb.Len() < MaxText {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return "", err
+			}
+			return cleanText(sb.String()), nil
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			tag := atom.Lookup(name)
+			if htmlSkipped[tag] {
+				skipped++
+			}
+			if htmlBlocks[tag] {
+				sb.WriteByte('\n')
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := atom.Lookup(name)
+			if htmlSkipped[tag] && skipped > 0 {
+				skipped--
+			}
+			if htmlBlocks[tag] {
+				sb.WriteByte('\n')
+			}
+		case html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			if htmlBlocks[atom.Lookup(name)] {
+				sb.WriteByte('\n')
+			}
+		case html.TextToken:
+			if skipped > 0 {
+				continue
+			}
+			text := strings.Join(strings.Fields(string(tokenizer.Text())), " ")
+			if text != "" {
+				sb.WriteString(text)
+				sb.WriteByte(' ')
+			}
+		}
+	}
+
+	return cleanText(sb.String()), nil
+}
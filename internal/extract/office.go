@@ -0,0 +1,127 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxXMLPart caps the uncompressed size of the XML part read from an office
+// archive, so a small zip bomb can not exhaust memory.
+const maxXMLPart = 32 << 20
+
+const (
+	wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	odtNamespace  = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// DOCX returns the paragraphs of an Office Open XML text document.
+func DOCX(content []byte) (string, error) {
+	return officeText(content, "word/document.xml", func(sb *strings.Builder, token xml.Token) {
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNamespace {
+				return
+			}
+			switch t.Name.Local {
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space == wordNamespace && t.Name.Local == "p" {
+				sb.WriteByte('\n')
+			}
+		}
+	}, func(stack []xml.Name) bool {
+		return len(stack) > 0 && stack[len(stack)-1] == xml.Name{Space: wordNamespace, Local: "t"}
+	})
+}
+
+// ODT returns the paragraphs and headings of an OpenDocument text document.
+func ODT(content []byte) (string, error) {
+	return officeText(content, "content.xml", func(sb *strings.Builder, token xml.Token) {
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != odtNamespace {
+				return
+			}
+			switch t.Name.Local {
+			case "s":
+				sb.WriteByte(' ')
+			case "tab":
+				sb.WriteByte('\t')
+			case "line-break":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space == odtNamespace && (t.Name.Local == "p" || t.Name.Local == "h") {
+				sb.WriteByte('\n')
+			}
+		}
+	}, func(stack []xml.Name) bool {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].Space == odtNamespace && (stack[i].Local == "p" || stack[i].Local == "h") {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// officeText walks the XML part of a zipped office document. onToken handles
+// layout elements, isText reports whether character data at the current
+// element path belongs to the document text.
+func officeText(
+	content []byte,
+	part string,
+	onToken func(sb *strings.Builder, token xml.Token),
+	isText func(stack []xml.Name) bool,
+) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("error open archive: %w", err)
+	}
+
+	file, err := archive.Open(part)
+	if err != nil {
+		return "", fmt.Errorf("error open %s: %w", part, err)
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(file, maxXMLPart))
+
+	var sb strings.Builder
+	var stack []xml.Name
+	for sb.Len() < MaxText {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("error read %s: %w", part, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if isText(stack) {
+				sb.Write(t)
+			}
+		}
+
+		onToken(&sb, token)
+	}
+
+	return cleanText(sb.String()), nil
+}
@@ -0,0 +1,352 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// maxPDFStream caps the inflated size of a single PDF stream.
+const maxPDFStream = 16 << 20
+
+var ErrInvalidPDF = errors.New("content is not a PDF document")
+
+// PDF returns the text shown by the content streams of a PDF document.
+//
+// Only the text operators of uncompressed and Flate compressed streams are
+// read, strings are decoded as PDFDocEncoding or UTF-16. Text drawn with
+// embedded CID fonts without a readable encoding comes out as noise and
+// scanned pages have no text at all.
+func PDF(content []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(content, "\r\n\t "), []byte("%PDF-")) {
+		return "", ErrInvalidPDF
+	}
+
+	var sb strings.Builder
+	rest := content
+	for sb.Len() < MaxText {
+		dict, data, next, ok := nextPDFStream(rest)
+		if !ok {
+			break
+		}
+		rest = next
+
+		stream, ok := decodePDFStream(dict, data)
+		if !ok {
+			continue
+		}
+		pdfContentText(&sb, stream)
+	}
+
+	return cleanText(sb.String()), nil
+}
+
+// nextPDFStream finds the next "stream ... endstream" block and returns the
+// dictionary in front of it, the raw stream data and the remaining input.
+func nextPDFStream(content []byte) (dict, data, rest []byte, ok bool) {
+	for {
+		start := bytes.Index(content, []byte("stream"))
+		if start < 0 {
+			return nil, nil, nil, false
+		}
+
+		// skip the "stream" inside "endstream"
+		if start >= 3 && string(content[start-3:start]) == "end" {
+			content = content[start+len("stream"):]
+			continue
+		}
+
+		dataStart := start + len("stream")
+		if bytes.HasPrefix(content[dataStart:], []byte("\r\n")) {
+			dataStart += 2
+		} else if bytes.HasPrefix(content[dataStart:], []byte("\n")) {
+			dataStart++
+		} else {
+			content = content[dataStart:]
+			continue
+		}
+
+		end := bytes.Index(content[dataStart:], []byte("endstream"))
+		if end < 0 {
+			return nil, nil, nil, false
+		}
+
+		dictStart := bytes.LastIndex(content[:start], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+
+		return content[dictStart:start], content[dataStart : dataStart+end], content[dataStart+end:], true
+	}
+}
+
+// decodePDFStream inflates the stream if needed and skips streams that can
+// not hold page content such as images and fonts.
+func decodePDFStream(dict, data []byte) ([]byte, bool) {
+	for _, skip := range []string{"/Image", "/FontFile", "/Length1", "/XRef", "/Metadata"} {
+		if bytes.Contains(dict, []byte(skip)) {
+			return nil, false
+		}
+	}
+
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return data, true
+	}
+
+	if !bytes.Contains(dict, []byte("/FlateDecode")) {
+		return nil, false
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close()
+
+	// a truncated stream still yields the text inflated so far
+	stream, _ := io.ReadAll(io.LimitReader(reader, maxPDFStream))
+
+	return stream, len(stream) > 0
+}
+
+// pdfContentText writes the strings shown by the Tj, TJ, ' and " operators.
+func pdfContentText(sb *strings.Builder, stream []byte) {
+	var operands []pdfOperand
+	inArray := false
+	var array []pdfOperand
+
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, next := readPDFLiteral(stream, i)
+			i = next
+			operand := pdfOperand{text: s, isText: true}
+			if inArray {
+				array = append(array, operand)
+			} else {
+				operands = append(operands, operand)
+			}
+		case c == '<' && i+1 < len(stream) && stream[i+1] == '<':
+			i += 2
+		case c == '>' && i+1 < len(stream) && stream[i+1] == '>':
+			i += 2
+		case c == '<':
+			s, next := readPDFHex(stream, i)
+			i = next
+			operand := pdfOperand{text: s, isText: true}
+			if inArray {
+				array = append(array, operand)
+			} else {
+				operands = append(operands, operand)
+			}
+		case c == '[':
+			inArray = true
+			array = array[:0]
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '/':
+			// names such as font resources are never shown
+			for i++; i < len(stream) && !isPDFSpace(stream[i]) && !isPDFDelimiter(stream[i]); i++ {
+			}
+		case isPDFSpace(c):
+			i++
+		default:
+			start := i
+			for i < len(stream) && !isPDFSpace(stream[i]) && !isPDFDelimiter(stream[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			word := string(stream[start:i])
+
+			if number, err := strconv.ParseFloat(word, 64); err == nil {
+				operand := pdfOperand{number: number}
+				if inArray {
+					array = append(array, operand)
+				} else {
+					operands = append(operands, operand)
+				}
+				continue
+			}
+			if inArray {
+				continue
+			}
+
+			pdfOperator(sb, word, operands, array)
+			operands = operands[:0]
+		}
+	}
+}
+
+type pdfOperand struct {
+	text   string
+	number float64
+	isText bool
+}
+
+func pdfOperator(sb *strings.Builder, operator string, operands, array []pdfOperand) {
+	switch operator {
+	case "Tj":
+		writePDFText(sb, lastPDFText(operands))
+	case "'", "\"":
+		sb.WriteByte('\n')
+		writePDFText(sb, lastPDFText(operands))
+	case "TJ":
+		for _, operand := range array {
+			if operand.isText {
+				writePDFText(sb, operand.text)
+			} else if operand.number < -200 {
+				// a wide negative kerning is how many producers draw a space
+				sb.WriteByte(' ')
+			}
+		}
+	case "T*", "ET":
+		sb.WriteByte('\n')
+	case "Td", "TD":
+		if len(operands) >= 2 && operands[len(operands)-1].number != 0 {
+			sb.WriteByte('\n')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+}
+
+func lastPDFText(operands []pdfOperand) string {
+	for i := len(operands) - 1; i >= 0; i-- {
+		if operands[i].isText {
+			return operands[i].text
+		}
+	}
+	return ""
+}
+
+func writePDFText(sb *strings.Builder, text string) {
+	for _, r := range text {
+		if unicode.IsPrint(r) || r == '\t' {
+			sb.WriteRune(r)
+		}
+	}
+}
+
+// readPDFLiteral reads a "(...)" string starting at i and returns it decoded.
+func readPDFLiteral(stream []byte, i int) (string, int) {
+	var raw []byte
+	depth := 0
+	for i++; i < len(stream); i++ {
+		c := stream[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(stream) {
+				break
+			}
+			switch e := stream[i]; e {
+			case 'n':
+				raw = append(raw, '\n')
+			case 'r':
+				raw = append(raw, '\r')
+			case 't':
+				raw = append(raw, '\t')
+			case 'b':
+				raw = append(raw, '\b')
+			case 'f':
+				raw = append(raw, '\f')
+			case '\r', '\n':
+				// line continuation
+				if e == '\r' && i+1 < len(stream) && stream[i+1] == '\n' {
+					i++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					value := 0
+					for n := 0; n < 3 && i < len(stream) && stream[i] >= '0' && stream[i] <= '7'; n++ {
+						value = value*8 + int(stream[i]-'0')
+						i++
+					}
+					i--
+					raw = append(raw, byte(value))
+				} else {
+					raw = append(raw, e)
+				}
+			}
+		case '(':
+			depth++
+			raw = append(raw, c)
+		case ')':
+			if depth == 0 {
+				return decodePDFString(raw), i + 1
+			}
+			depth--
+			raw = append(raw, c)
+		default:
+			raw = append(raw, c)
+		}
+	}
+
+	return decodePDFString(raw), i
+}
+
+// readPDFHex reads a "<...>" string starting at i and returns it decoded.
+func readPDFHex(stream []byte, i int) (string, int) {
+	var digits []byte
+	for i++; i < len(stream) && stream[i] != '>'; i++ {
+		if c := stream[i]; isHexDigit(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	raw := make([]byte, len(digits)/2)
+	for n := range raw {
+		value, _ := strconv.ParseUint(string(digits[2*n:2*n+2]), 16, 8)
+		raw[n] = byte(value)
+	}
+
+	return decodePDFString(raw), i + 1
+}
+
+// decodePDFString decodes UTF-16BE strings marked with a BOM, anything else
+// is read as PDFDocEncoding, which matches Latin-1 for printable characters.
+func decodePDFString(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xfe && raw[1] == 0xff {
+		units := make([]uint16, 0, len(raw)/2)
+		for n := 2; n+1 < len(raw); n += 2 {
+			units = append(units, uint16(raw[n])<<8|uint16(raw[n+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, len(raw))
+	for n, b := range raw {
+		runes[n] = rune(b)
+	}
+	return string(runes)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,74 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Plain returns UTF-8 text content as is.
+func Plain(content []byte) (string, error) {
+	if !utf8.Valid(content) {
+		return "", ErrInvalidText
+	}
+
+	return cleanText(string(content)), nil
+}
+
+var (
+	markdownImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownLinePrefix = regexp.MustCompile(`(?m)^\s{0,3}(?:#{1,6}\s+|>\s?|[-*+]\s+|\d+[.)]\s+)`)
+	markdownFence      = regexp.MustCompile("(?m)^\\s*(```|~~~).*$")
+	markdownRule       = regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$`)
+	markdownEmphasis   = regexp.MustCompile("(\\*\\*|__|\\*|_|~~|`)")
+)
+
+// Markdown returns the text of a Markdown document without the markup.
+// Link and image targets are dropped, their labels are kept.
+func Markdown(content []byte) (string, error) {
+	text, err := Plain(content)
+	if err != nil {
+		return "", err
+	}
+
+	text = markdownImage.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownFence.ReplaceAllString(text, "")
+	text = markdownRule.ReplaceAllString(text, "")
+	text = markdownLinePrefix.ReplaceAllString(text, "")
+	text = markdownEmphasis.ReplaceAllString(text, "")
+
+	return text, nil
+}
+
+// CSV returns the cells of a CSV document, one record per line.
+func CSV(content []byte) (string, error) {
+	if !utf8.Valid(content) {
+		return "", ErrInvalidText
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var sb strings.Builder
+	for sb.Len() < MaxText {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(strings.Join(record, " "))
+		sb.WriteByte('\n')
+	}
+
+	return cleanText(sb.String()), nil
+}
@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// passwordHashPattern matches the bcrypt hashes, every other stored password
+// is the password itself.
+const passwordHashPattern = `^\$2[aby]\$[0-9]{2}\$.{53}$`
+
+// SetUserDisabled disables the user or enables them again when disabledAt is nil.
+func (r *Repository) SetUserDisabled(ctx context.Context, login string, disabledAt *time.Time) (uuid.UUID, error) {
+	sql, args, err := r.pg.Builder.Update(tableUser).
+		Set("disabled_at", disabledAt).
+		Where(squirrel.Eq{"login": login}).
+		Suffix(suffixReturningID).
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var id uuid.UUID
+	err = r.conn(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrUserNotFound
+		}
+		return uuid.Nil, fmt.Errorf("error update user: %w", err)
+	}
+
+	return id, nil
+}
+
+// DeleteUserTokens logs the user out everywhere.
+func (r *Repository) DeleteUserTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	sql, args, err := r.pg.Builder.Delete(tableToken).
+		Where(squirrel.Eq{"user_id": userID.String()}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error delete tokens: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+func (r *Repository) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	sql, args, err := r.pg.Builder.Delete(tableToken).
+		Where(squirrel.Lt{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error delete tokens: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+// GetPlainPasswordUsers returns the users whose password is not hashed yet.
+func (r *Repository) GetPlainPasswordUsers(ctx context.Context) ([]domain.User, error) {
+	sql, args, err := r.pg.Builder.Select("id", "login", "password").
+		From(tableUser).
+		Where(squirrel.Expr("password !~ ?", passwordHashPattern)).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]domain.User, 0)
+	for rows.Next() {
+		var user domain.User
+		err = rows.Scan(&user.ID, &user.Login, &user.Password)
+		if err != nil {
+			return nil, fmt.Errorf("error scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// ReplacePassword stores the new password of the user and reports false when
+// the stored one is no longer the old one.
+func (r *Repository) ReplacePassword(ctx context.Context, userID uuid.UUID, old, password string) (bool, error) {
+	sql, args, err := r.pg.Builder.Update(tableUser).
+		Set("password", password).
+		Where(squirrel.Eq{"id": userID}).
+		Where(squirrel.Eq{"password": old}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("error update password: %w", err)
+	}
+
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// GetStorageUsage counts the documents of every user, the largest first. The
+// usage is counted on demand, the writes of documents keep no counters.
+func (r *Repository) GetStorageUsage(ctx context.Context) ([]domain.StorageUsage, error) {
+	sql, args, err := r.pg.Builder.Select(
+		"d.user_id",
+		"coalesce(u.login, '')",
+		"count(*) FILTER (WHERE d.deleted_at IS NULL)",
+		"coalesce(sum(d.size) FILTER (WHERE d.deleted_at IS NULL), 0)",
+		"count(*) FILTER (WHERE d.deleted_at IS NOT NULL)",
+		"coalesce(sum(d.size) FILTER (WHERE d.deleted_at IS NOT NULL), 0)",
+	).From(tableDocument+" AS d").
+		LeftJoin(tableUser+" AS u ON u.id = d.user_id").
+		GroupBy("d.user_id", "u.login").
+		OrderBy("coalesce(sum(d.size), 0) DESC", "d.user_id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get storage usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make([]domain.StorageUsage, 0)
+	for rows.Next() {
+		var u domain.StorageUsage
+		err = rows.Scan(&u.UserID, &u.Login, &u.Documents, &u.Bytes, &u.TrashDocuments, &u.TrashBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error scan storage usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
+
+// GetDocumentContents returns the documents after the id with their content
+// and checksum in the order of their ids, the documents in the trash too.
+func (r *Repository) GetDocumentContents(ctx context.Context, after uuid.UUID, limit int) ([]domain.Document, error) {
+	sql, args, err := r.pg.Builder.Select("id", "user_id", "name", "file", "checksum").
+		From(tableDocument).
+		Where(squirrel.Gt{"id": after}).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := make([]domain.Document, 0, limit)
+	for rows.Next() {
+		var document domain.Document
+		err = rows.Scan(&document.ID, &document.UserID, &document.Name, &document.Content, &document.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("error scan document: %w", err)
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, rows.Err()
+}
@@ -0,0 +1,332 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// auditLockKey is the advisory lock of the chainer of the audit log, so that
+// only one instance extends the chain at a time.
+const auditLockKey = 0x61756474
+
+var auditColumns = []string{
+	"e.id",
+	"e.created_at",
+	"e.actor_id",
+	"e.actor_login",
+	"e.action",
+	"e.document_id",
+	"e.folder_id",
+	"e.target_login",
+	"e.outcome",
+	"e.reason",
+	"e.ip",
+	"e.user_agent",
+	"COALESCE(e.prev_hash, '')",
+	"COALESCE(e.hash, '')",
+}
+
+// AddAuditEvent appends the event to the audit log. It sets the ID and the
+// time of the event, the hashes are set later by ChainAuditEvents.
+func (r *Repository) AddAuditEvent(ctx context.Context, event *domain.AuditEvent) error {
+	// the time is stored without a zone and with microseconds,
+	// it is hashed the way it is read back
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	query, args, err := r.pg.Builder.Insert(tableAuditEvent).
+		SetMap(map[string]any{
+			"created_at":   event.CreatedAt,
+			"actor_id":     nullUUID(event.ActorID),
+			"actor_login":  event.ActorLogin,
+			"action":       event.Action,
+			"document_id":  nullUUID(event.DocumentID),
+			"folder_id":    nullUUID(event.FolderID),
+			"target_login": event.TargetLogin,
+			"outcome":      event.Outcome,
+			"reason":       event.Reason,
+			"ip":           event.IP,
+			"user_agent":   event.UserAgent,
+		}).
+		Suffix(suffixReturningID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("error add audit event: %w", err)
+	}
+
+	return nil
+}
+
+// ChainAuditEvents chains up to limit of the events that are not chained yet,
+// in the order of their ids, to the last chained event and returns how many
+// it chained. An event committed after the ones with greater ids were chained
+// is chained after them, the chain follows chain_seq rather than the id.
+func (r *Repository) ChainAuditEvents(ctx context.Context, limit int) (int, error) {
+	var chained int
+	err := r.ExecTx(ctx, func(ctx context.Context) error {
+		_, err := r.conn(ctx).Exec(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey)
+		if err != nil {
+			return fmt.Errorf("error lock audit log: %w", err)
+		}
+
+		query, args, err := r.pg.Builder.Select("hash", "chain_seq").
+			From(tableAuditEvent).
+			Where(squirrel.NotEq{"chain_seq": nil}).
+			OrderBy("chain_seq DESC").
+			Limit(1).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("error build query: %w", err)
+		}
+
+		var prevHash string
+		var seq int64
+		err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&prevHash, &seq)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("error get last audit event: %w", err)
+		}
+
+		events, err := r.getUnchainedAuditEvents(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			seq++
+			event.PrevHash = prevHash
+			event.Hash = auditHash(event)
+
+			query, args, err := r.pg.Builder.Update(tableAuditEvent).
+				Set("prev_hash", event.PrevHash).
+				Set("hash", event.Hash).
+				Set("chain_seq", seq).
+				Where(squirrel.Eq{"id": event.ID}).
+				ToSql()
+			if err != nil {
+				return fmt.Errorf("error build query: %w", err)
+			}
+
+			_, err = r.conn(ctx).Exec(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("error chain audit event: %w", err)
+			}
+			prevHash = event.Hash
+		}
+
+		chained = len(events)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return chained, nil
+}
+
+func (r *Repository) getUnchainedAuditEvents(ctx context.Context, limit int) ([]*domain.AuditEvent, error) {
+	query, args, err := r.pg.Builder.Select(auditColumns...).
+		From(tableAuditEvent + " AS e").
+		Where(squirrel.Eq{"e.chain_seq": nil}).
+		OrderBy("e.id").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get audit events: %w", err)
+	}
+
+	defer rows.Close()
+
+	events := make([]*domain.AuditEvent, 0, limit)
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetAuditEvents returns the events matching the filter, the newest first.
+// An event without the login of its actor gets the current login of the user.
+func (r *Repository) GetAuditEvents(ctx context.Context, filter *dto.GetAuditEvents) ([]domain.AuditEvent, error) {
+	columns := append([]string(nil), auditColumns...)
+	columns[3] = "COALESCE(NULLIF(e.actor_login, ''), u.login, '')"
+
+	builder := r.pg.Builder.Select(columns...).
+		From(tableAuditEvent + " AS e").
+		LeftJoin(tableUser + " AS u ON u.id = e.actor_id").
+		OrderBy("e.id DESC").
+		Limit(uint64(filter.Limit))
+
+	if filter.Actor != "" {
+		if id, err := uuid.Parse(filter.Actor); err == nil {
+			builder = builder.Where(squirrel.Eq{"e.actor_id": id})
+		} else {
+			builder = builder.Where(squirrel.Or{
+				squirrel.Eq{"e.actor_login": filter.Actor},
+				squirrel.Eq{"u.login": filter.Actor},
+			})
+		}
+	}
+	if filter.Action != "" {
+		builder = builder.Where(squirrel.Eq{"e.action": filter.Action})
+	}
+	if filter.DocumentID != uuid.Nil {
+		builder = builder.Where(squirrel.Eq{"e.document_id": filter.DocumentID})
+	}
+	if filter.Outcome != "" {
+		builder = builder.Where(squirrel.Eq{"e.outcome": filter.Outcome})
+	}
+	if filter.From != nil {
+		builder = builder.Where(squirrel.GtOrEq{"e.created_at": *filter.From})
+	}
+	if filter.To != nil {
+		builder = builder.Where(squirrel.LtOrEq{"e.created_at": *filter.To})
+	}
+	if filter.Before != 0 {
+		builder = builder.Where(squirrel.Lt{"e.id": filter.Before})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get audit events: %w", err)
+	}
+
+	defer rows.Close()
+
+	events := make([]domain.AuditEvent, 0)
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// VerifyAuditChain walks the chain of the audit log from the first event and
+// stops at the first one that is not chained to its predecessor or whose hash
+// does not match its fields. The events that are not chained yet are skipped.
+func (r *Repository) VerifyAuditChain(ctx context.Context) (*domain.AuditVerification, error) {
+	query, args, err := r.pg.Builder.Select(auditColumns...).
+		From(tableAuditEvent + " AS e").
+		Where(squirrel.NotEq{"e.chain_seq": nil}).
+		OrderBy("e.chain_seq").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get audit events: %w", err)
+	}
+
+	defer rows.Close()
+
+	result := &domain.AuditVerification{}
+	prevHash := ""
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Checked++
+		if event.PrevHash != prevHash || event.Hash != auditHash(event) {
+			result.BrokenID = event.ID
+			return result, nil
+		}
+		prevHash = event.Hash
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func scanAuditEvent(rows pgx.Rows) (*domain.AuditEvent, error) {
+	var event domain.AuditEvent
+	err := rows.Scan(
+		&event.ID,
+		&event.CreatedAt,
+		&event.ActorID,
+		&event.ActorLogin,
+		&event.Action,
+		&event.DocumentID,
+		&event.FolderID,
+		&event.TargetLogin,
+		&event.Outcome,
+		&event.Reason,
+		&event.IP,
+		&event.UserAgent,
+		&event.PrevHash,
+		&event.Hash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error scan audit event: %w", err)
+	}
+
+	return &event, nil
+}
+
+// auditHash is the SHA-256 of the previous hash and the fields of the event.
+// They are encoded as a JSON array, so no two different events share the input.
+func auditHash(event *domain.AuditEvent) string {
+	data, _ := json.Marshal([]any{
+		event.PrevHash,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		event.ActorID,
+		event.ActorLogin,
+		event.Action,
+		event.DocumentID,
+		event.FolderID,
+		event.TargetLogin,
+		event.Outcome,
+		event.Reason,
+		event.IP,
+		event.UserAgent,
+	})
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,185 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *Repository) CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	now := time.Now()
+	query, args, err := r.pg.Builder.Insert(tableComment).
+		SetMap(map[string]any{
+			"document_id": comment.DocumentID,
+			"parent_id":   nullUUID(comment.ParentID),
+			"user_id":     comment.UserID,
+			"body":        comment.Body,
+			"page":        comment.Page,
+			"range_start": comment.RangeStart,
+			"range_end":   comment.RangeEnd,
+			"created_at":  now,
+			"updated_at":  now,
+		}).
+		Suffix(suffixReturningID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	created := *comment
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&created.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error create comment: %w", err)
+	}
+	created.CreatedAt, created.UpdatedAt = now, now
+
+	return &created, nil
+}
+
+func (r *Repository) GetComment(ctx context.Context, id uuid.UUID) (*domain.Comment, error) {
+	query, args, err := r.comments().
+		Where(squirrel.Eq{"c.id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	comment, err := scanComment(r.conn(ctx).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// GetComments returns the comments of the document with the deleted ones,
+// the oldest first.
+func (r *Repository) GetComments(ctx context.Context, documentID uuid.UUID) ([]domain.Comment, error) {
+	query, args, err := r.comments().
+		Where(squirrel.Eq{"c.document_id": documentID}).
+		OrderBy("c.created_at", "c.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get comments: %w", err)
+	}
+
+	defer rows.Close()
+
+	comments := make([]domain.Comment, 0)
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// UpdateComment replaces the body of a comment that is not deleted.
+func (r *Repository) UpdateComment(ctx context.Context, id uuid.UUID, body string) (time.Time, error) {
+	now := time.Now()
+	query, args, err := r.pg.Builder.Update(tableComment).
+		Set("body", body).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error update comment: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return time.Time{}, ErrCommentNotFound
+	}
+
+	return now, nil
+}
+
+// DeleteComment clears the body of the comment and marks it deleted, its
+// replies stay in the thread.
+func (r *Repository) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	query, args, err := r.pg.Builder.Update(tableComment).
+		Set("body", "").
+		Set("deleted_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete comment: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) comments() squirrel.SelectBuilder {
+	return r.pg.Builder.Select(
+		"c.id",
+		"c.document_id",
+		"c.parent_id",
+		"c.user_id",
+		"u.login",
+		"c.body",
+		"c.page",
+		"c.range_start",
+		"c.range_end",
+		"c.created_at",
+		"c.updated_at",
+		"c.deleted_at",
+	).From(tableComment + " AS c").
+		Join(tableUser + " AS u ON u.id = c.user_id")
+}
+
+func scanComment(row pgx.Row) (*domain.Comment, error) {
+	var comment domain.Comment
+	err := row.Scan(
+		&comment.ID,
+		&comment.DocumentID,
+		&comment.ParentID,
+		&comment.UserID,
+		&comment.Login,
+		&comment.Body,
+		&comment.Page,
+		&comment.RangeStart,
+		&comment.RangeEnd,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&comment.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
@@ -5,12 +5,49 @@ import "errors"
 type tansaction string
 
 const (
-	tableUser                    = "users"
-	tableToken                   = "token"
-	tableDocument                = "document"
-	tableGrant                   = "grants"
-	suffixReturningID            = "RETURNING id"
-	tansactionKey     tansaction = "tansactionSQL"
+	tableUser                       = "users"
+	tableToken                      = "token"
+	tableDocument                   = "document"
+	tableGrant                      = "grants"
+	tableRetentionPolicy            = "retention_policy"
+	tableFolder                     = "folder"
+	tableFolderGrant                = "folder_grants"
+	tableDocumentText               = "document_text"
+	tableThumbnailJob               = "document_thumbnail_job"
+	tableRendition                  = "document_rendition"
+	tableIdempotencyKey             = "idempotency_key"
+	tableAuditEvent                 = "audit_event"
+	tableWebhook                    = "webhook"
+	tableWebhookEvent               = "webhook_event"
+	tableEventTicket                = "event_ticket"
+	tableWebhookDelivery            = "webhook_delivery"
+	tableComment                    = "comment"
+	tableDocumentLock               = "document_lock"
+	suffixReturningID               = "RETURNING id"
+	tansactionKey        tansaction = "tansactionSQL"
 )
 
-var ErrTokenNotFound = errors.New("token not found")
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrTokenNotFound    = errors.New("token not found")
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrDocumentChanged  = errors.New("document was changed")
+
+	ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+
+	ErrFolderNotFound = errors.New("folder not found")
+	ErrGrantNotFound  = errors.New("grant not found")
+
+	ErrTextNotFound         = errors.New("document text not found")
+	ErrThumbnailJobNotFound = errors.New("thumbnail job not found")
+	ErrRenditionNotFound    = errors.New("rendition not found")
+
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrEventTicketNotFound     = errors.New("event ticket not found")
+
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrLockNotFound    = errors.New("lock not found")
+)
@@ -0,0 +1,253 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// eventsChannel is notified by a trigger on every event written to the outbox.
+const eventsChannel = "document_events"
+
+// eventsLockKey is the advisory lock of the sequencer of the change feed, so
+// that only one instance numbers the events at a time.
+const eventsLockKey = 0x65766e74
+
+// sequenceEventsQuery numbers the committed events that have no place in the
+// change feed yet after the last numbered one, in the order of their IDs.
+const sequenceEventsQuery = `WITH last AS (
+	SELECT COALESCE(max(feed_seq), 0) AS seq FROM webhook_event
+), next AS (
+	SELECT id, row_number() OVER (ORDER BY id) AS n FROM webhook_event
+	WHERE feed_seq IS NULL
+	ORDER BY id
+	LIMIT $1
+)
+UPDATE webhook_event AS e SET feed_seq = last.seq + next.n
+FROM last, next
+WHERE e.id = next.id`
+
+// SequenceEvents gives up to limit events their place in the change feed and
+// returns how many it numbered. The IDs are taken at insert but the events
+// become visible at commit, so a feed that followed them would skip the
+// events committed late; the numbers are given in the order the events are
+// seen committed instead.
+func (r *Repository) SequenceEvents(ctx context.Context, limit int) (int, error) {
+	var sequenced int
+	err := r.ExecTx(ctx, func(ctx context.Context) error {
+		_, err := r.conn(ctx).Exec(ctx, "SELECT pg_advisory_xact_lock($1)", eventsLockKey)
+		if err != nil {
+			return fmt.Errorf("error lock event feed: %w", err)
+		}
+
+		commandTag, err := r.conn(ctx).Exec(ctx, sequenceEventsQuery, limit)
+		if err != nil {
+			return fmt.Errorf("error sequence events: %w", err)
+		}
+
+		sequenced = int(commandTag.RowsAffected())
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return sequenced, nil
+}
+
+// GetEvents returns up to limit events after the given place in the change
+// feed that concern the user: the events of the documents the user owns and
+// the grants given to or taken from the login.
+func (r *Repository) GetEvents(ctx context.Context, userID uuid.UUID, login string, after int64, limit int) ([]domain.WebhookEvent, error) {
+	query, args, err := r.pg.Builder.Select(
+		"id",
+		"feed_seq",
+		"event",
+		"document_id",
+		"user_id",
+		"target_login",
+		"payload",
+		"created_at",
+	).From(tableWebhookEvent).
+		Where(squirrel.Gt{"feed_seq": after}).
+		Where(squirrel.Or{
+			squirrel.Eq{"user_id": userID},
+			squirrel.And{
+				squirrel.Eq{"target_login": login},
+				squirrel.NotEq{"target_login": ""},
+			},
+		}).
+		OrderBy("feed_seq").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get events: %w", err)
+	}
+
+	defer rows.Close()
+
+	events := make([]domain.WebhookEvent, 0)
+	for rows.Next() {
+		var event domain.WebhookEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.Seq,
+			&event.Event,
+			&event.DocumentID,
+			&event.UserID,
+			&event.TargetLogin,
+			&event.Payload,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetLastEventID returns the place of the newest event in the change feed, 0
+// when there is none.
+func (r *Repository) GetLastEventID(ctx context.Context) (int64, error) {
+	query, args, err := r.pg.Builder.Select("COALESCE(max(feed_seq), 0)").
+		From(tableWebhookEvent).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error build query: %w", err)
+	}
+
+	var id int64
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error get last event id: %w", err)
+	}
+
+	return id, nil
+}
+
+// AddEventTicket stores a ticket that opens the change feed of its user once.
+func (r *Repository) AddEventTicket(ctx context.Context, ticket *domain.EventTicket) error {
+	query, args, err := r.pg.Builder.Insert(tableEventTicket).
+		SetMap(map[string]any{
+			"ticket":     ticket.Ticket,
+			"user_id":    ticket.UserID,
+			"expires_at": ticket.ExpiresAt,
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error add event ticket: %w", err)
+	}
+
+	return nil
+}
+
+// TakeEventTicket deletes the ticket and returns its user, ErrEventTicketNotFound
+// when it is unknown, used or expired at now.
+func (r *Repository) TakeEventTicket(ctx context.Context, ticket string, now time.Time) (uuid.UUID, error) {
+	query, args, err := r.pg.Builder.Delete(tableEventTicket).
+		Where(squirrel.Eq{"ticket": ticket}).
+		Where(squirrel.Gt{"expires_at": now}).
+		Suffix("RETURNING user_id").
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var userID uuid.UUID
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrEventTicketNotFound
+		}
+		return uuid.Nil, fmt.Errorf("error take event ticket: %w", err)
+	}
+
+	return userID, nil
+}
+
+// DeleteExpiredEventTickets deletes the tickets that expired before now.
+func (r *Repository) DeleteExpiredEventTickets(ctx context.Context, now time.Time) (int64, error) {
+	query, args, err := r.pg.Builder.Delete(tableEventTicket).
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error delete event tickets: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+// ListenEvents holds a connection of the pool that listens to the events of
+// all instances and passes every notification to fn. It returns when the
+// context is canceled or the connection is lost.
+func (r *Repository) ListenEvents(ctx context.Context, fn func(notification *domain.EventNotification)) error {
+	conn, err := r.pg.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("error acquire connection: %w", err)
+	}
+	defer func() {
+		// the connection goes back to the pool, it must not keep listening
+		_, _ = conn.Exec(context.Background(), "UNLISTEN *")
+		conn.Release()
+	}()
+
+	_, err = conn.Exec(ctx, "LISTEN "+eventsChannel)
+	if err != nil {
+		return fmt.Errorf("error listen events: %w", err)
+	}
+
+	for {
+		received, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("error wait for notification: %w", err)
+		}
+
+		var payload struct {
+			ID          int64     `json:"id"`
+			UserID      uuid.UUID `json:"user_id"`
+			TargetLogin string    `json:"target_login"`
+		}
+		err = json.Unmarshal([]byte(received.Payload), &payload)
+		if err != nil {
+			r.l.WithError(err).Error("error decode event notification")
+			continue
+		}
+
+		fn(&domain.EventNotification{
+			ID:          payload.ID,
+			UserID:      payload.UserID,
+			TargetLogin: payload.TargetLogin,
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *Repository) AddTextExtraction(ctx context.Context, documentID uuid.UUID, version int) error {
+	return r.addJob(ctx, tableDocumentText, documentID, version)
+}
+
+// ClaimTextExtractions returns up to limit pending extractions due at now
+// and hides them from other callers until now+lease.
+func (r *Repository) ClaimTextExtractions(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.TextExtraction, error) {
+	jobs, err := r.claimJobs(ctx, tableDocumentText, now, lease, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	extractions := make([]domain.TextExtraction, 0, len(jobs))
+	for _, job := range jobs {
+		extractions = append(extractions, domain.TextExtraction{Job: job})
+	}
+
+	return extractions, nil
+}
+
+func (r *Repository) UpdateTextExtraction(ctx context.Context, extraction *domain.TextExtraction) error {
+	return r.updateJob(ctx, tableDocumentText, &extraction.Job, map[string]any{"text": extraction.Text}, ErrTextNotFound)
+}
+
+func (r *Repository) GetTextExtraction(ctx context.Context, documentID uuid.UUID, version int) (*domain.TextExtraction, error) {
+	query, args, err := r.pg.Builder.Select(
+		"document_id",
+		"version",
+		"status",
+		"text",
+		"attempts",
+		"last_error",
+		"next_attempt_at",
+		"updated_at",
+	).From(tableDocumentText).
+		Where(squirrel.Eq{"document_id": documentID}).
+		Where(squirrel.Eq{"version": version}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var extraction domain.TextExtraction
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(
+		&extraction.DocumentID,
+		&extraction.Version,
+		&extraction.Status,
+		&extraction.Text,
+		&extraction.Attempts,
+		&extraction.Error,
+		&extraction.NextAttemptAt,
+		&extraction.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTextNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get text extraction: %w", err)
+	}
+
+	return &extraction, nil
+}
+
+// SetDocumentText stores the text searched for the document, a text of an
+// outdated version is ignored.
+func (r *Repository) SetDocumentText(ctx context.Context, documentID uuid.UUID, version int, text string) error {
+	query, args, err := r.pg.Builder.Update(tableDocument).
+		Set("content_text", text).
+		Where(squirrel.Eq{"id": documentID}).
+		Where(squirrel.Eq{"version": version}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error set document text: %w", err)
+	}
+
+	return nil
+}
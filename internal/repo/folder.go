@@ -0,0 +1,437 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// folderAncestorsCTE selects the folder $1 and all of its parents.
+const folderAncestorsCTE = `WITH RECURSIVE ancestors AS (
+	SELECT id, parent_id FROM folder WHERE id = $1
+	UNION ALL
+	SELECT f.id, f.parent_id FROM folder AS f JOIN ancestors AS a ON f.id = a.parent_id
+)`
+
+// folderSubtreeCTE selects the folder $1 and all of its subfolders.
+const folderSubtreeCTE = `WITH RECURSIVE subtree AS (
+	SELECT id FROM folder WHERE id = $1
+	UNION ALL
+	SELECT f.id FROM folder AS f JOIN subtree AS s ON f.parent_id = s.id
+)`
+
+func (r *Repository) CreateFolder(ctx context.Context, folder *domain.Folder) (uuid.UUID, error) {
+	query, args, err := r.pg.Builder.Insert(tableFolder).
+		SetMap(map[string]any{
+			"user_id":    folder.UserID,
+			"parent_id":  nullUUID(folder.ParentID),
+			"name":       folder.Name,
+			"created_at": time.Now(),
+		}).
+		Suffix(suffixReturningID).
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var id uuid.UUID
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error create folder: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetFolder(ctx context.Context, id uuid.UUID) (*domain.Folder, error) {
+	return r.getFolder(ctx, squirrel.Eq{"id": id})
+}
+
+// GetFolderByName looks a folder up among the children of parentID, uuid.Nil means the top level.
+func (r *Repository) GetFolderByName(ctx context.Context, userID, parentID uuid.UUID, name string) (*domain.Folder, error) {
+	return r.getFolder(ctx, squirrel.And{
+		squirrel.Eq{"user_id": userID},
+		squirrel.Eq{"parent_id": nullUUID(parentID)},
+		squirrel.Eq{"name": name},
+	})
+}
+
+func (r *Repository) getFolder(ctx context.Context, where squirrel.Sqlizer) (*domain.Folder, error) {
+	query, args, err := r.pg.Builder.Select(
+		"id",
+		"user_id",
+		"parent_id",
+		"name",
+		"created_at",
+	).From(tableFolder).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var folder domain.Folder
+	err = r.conn(ctx).QueryRow(ctx, query, args...).
+		Scan(&folder.ID, &folder.UserID, &folder.ParentID, &folder.Name, &folder.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, fmt.Errorf("error get folder: %w", err)
+	}
+
+	return &folder, nil
+}
+
+// GetFolders returns the subfolders of parentID, uuid.Nil means the top level folders of the user.
+func (r *Repository) GetFolders(ctx context.Context, userID, parentID uuid.UUID) ([]domain.Folder, error) {
+	query, args, err := r.pg.Builder.Select(
+		"id",
+		"user_id",
+		"parent_id",
+		"name",
+		"created_at",
+	).From(tableFolder).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"parent_id": nullUUID(parentID)}).
+		OrderBy("name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	folders := make([]domain.Folder, 0)
+	for rows.Next() {
+		var folder domain.Folder
+
+		err := rows.Scan(&folder.ID, &folder.UserID, &folder.ParentID, &folder.Name, &folder.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		folders = append(folders, folder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// GetFolderDocuments returns the documents placed directly in folderID, uuid.Nil means the top level.
+func (r *Repository) GetFolderDocuments(ctx context.Context, userID, folderID uuid.UUID) ([]domain.Document, error) {
+	query, args, err := r.pg.Builder.Select(
+		"id",
+		"user_id",
+		"folder_id",
+		"name",
+		"mime",
+		"size",
+		"is_public",
+		"created_at",
+		"tags",
+		"metadata",
+	).From(tableDocument).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"folder_id": nullUUID(folderID)}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		OrderBy("name", "created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	documents := make([]domain.Document, 0)
+	for rows.Next() {
+		var doc domain.Document
+
+		err := rows.Scan(
+			&doc.ID,
+			&doc.UserID,
+			&doc.FolderID,
+			&doc.Name,
+			&doc.Mime,
+			&doc.Size,
+			&doc.Public,
+			&doc.CreatedAt,
+			&doc.Tags,
+			&doc.Metadata,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// GetDocumentIDByName returns the newest document with the given name placed directly in folderID.
+func (r *Repository) GetDocumentIDByName(ctx context.Context, userID, folderID uuid.UUID, name string) (uuid.UUID, error) {
+	query, args, err := r.pg.Builder.Select("id").
+		From(tableDocument).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"folder_id": nullUUID(folderID)}).
+		Where(squirrel.Eq{"name": name}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		OrderBy("created_at DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var id uuid.UUID
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrDocumentNotFound
+		}
+		return uuid.Nil, fmt.Errorf("error get document: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) UpdateFolder(ctx context.Context, folder *domain.Folder) error {
+	query, args, err := r.pg.Builder.Update(tableFolder).
+		Set("name", folder.Name).
+		Set("parent_id", nullUUID(folder.ParentID)).
+		Where(squirrel.Eq{"id": folder.ID}).
+		Where(squirrel.Eq{"user_id": folder.UserID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error update folder: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// DeleteFolder removes a folder that has neither subfolders nor documents, including trashed ones.
+func (r *Repository) DeleteFolder(ctx context.Context, id, userID uuid.UUID) error {
+	query, args, err := r.pg.Builder.Delete(tableFolder).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where("NOT EXISTS (SELECT 1 FROM " + tableFolder + " AS c WHERE c.parent_id = " + tableFolder + ".id)").
+		Where("NOT EXISTS (SELECT 1 FROM " + tableDocument + " AS d WHERE d.folder_id = " + tableFolder + ".id)").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete folder: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// IsFolderAncestor reports whether ancestorID is folderID itself or one of its parents.
+func (r *Repository) IsFolderAncestor(ctx context.Context, ancestorID, folderID uuid.UUID) (bool, error) {
+	query := folderAncestorsCTE + ` SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`
+
+	var exist bool
+	err := r.conn(ctx).QueryRow(ctx, query, folderID, ancestorID).Scan(&exist)
+	if err != nil {
+		return false, fmt.Errorf("error check folder ancestors: %w", err)
+	}
+
+	return exist, nil
+}
+
+func (r *Repository) AddFolderGrant(ctx context.Context, grant *domain.FolderGrant) error {
+	query, args, err := r.pg.Builder.Insert(tableFolderGrant).
+		SetMap(map[string]any{
+			"user_id":          grant.UserID,
+			"folder_id":        grant.FolderID,
+			"grant_user_login": grant.GrantUserLogin,
+			"permission":       grant.Permission,
+			"created_at":       time.Now(),
+		}).
+		Suffix("ON CONFLICT (folder_id, grant_user_login) DO UPDATE SET permission = EXCLUDED.permission").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error add folder grant: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteFolderGrant(ctx context.Context, folderID uuid.UUID, login string) error {
+	query, args, err := r.pg.Builder.Delete(tableFolderGrant).
+		Where(squirrel.Eq{"folder_id": folderID}).
+		Where(squirrel.Eq{"grant_user_login": login}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete folder grant: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrGrantNotFound
+	}
+
+	return nil
+}
+
+// CheckFolderGrant reports whether the login was granted the folder or one of its parents.
+func (r *Repository) CheckFolderGrant(ctx context.Context, folderID uuid.UUID, login string) (bool, error) {
+	query := folderAncestorsCTE + ` SELECT EXISTS (
+	SELECT 1 FROM ` + tableFolderGrant + ` AS g JOIN ancestors AS a ON g.folder_id = a.id
+	WHERE g.grant_user_login = $2
+)`
+
+	var exist bool
+	err := r.conn(ctx).QueryRow(ctx, query, folderID, login).Scan(&exist)
+	if err != nil {
+		return false, fmt.Errorf("error check folder grant: %w", err)
+	}
+
+	return exist, nil
+}
+
+// GetFolderGrantLogins returns the logins that were granted the folder or one of its parents.
+func (r *Repository) GetFolderGrantLogins(ctx context.Context, folderID uuid.UUID) ([]string, error) {
+	query := folderAncestorsCTE + ` SELECT DISTINCT g.grant_user_login
+FROM ` + tableFolderGrant + ` AS g JOIN ancestors AS a ON g.folder_id = a.id`
+
+	return r.queryStrings(ctx, query, folderID)
+}
+
+// GetFolderDocumentIDs returns the documents placed in the folder or any of its subfolders.
+func (r *Repository) GetFolderDocumentIDs(ctx context.Context, folderID uuid.UUID) ([]uuid.UUID, error) {
+	query := folderSubtreeCTE + ` SELECT d.id FROM ` + tableDocument + ` AS d JOIN subtree AS s ON d.folder_id = s.id`
+
+	rows, err := r.conn(ctx).Query(ctx, query, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetFolderTreeDocuments returns the documents outside the trash placed in the
+// folder or any of its subfolders, without their content.
+func (r *Repository) GetFolderTreeDocuments(ctx context.Context, folderID uuid.UUID) ([]domain.Document, error) {
+	query := folderSubtreeCTE + ` SELECT d.id, d.user_id, d.folder_id, d.name, d.mime, d.size, d.checksum, d.version, d.is_public
+	FROM ` + tableDocument + ` AS d JOIN subtree AS s ON d.folder_id = s.id
+	WHERE d.deleted_at IS NULL
+	ORDER BY d.id`
+
+	rows, err := r.conn(ctx).Query(ctx, query, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	documents := make([]domain.Document, 0)
+	for rows.Next() {
+		var doc domain.Document
+		err := rows.Scan(&doc.ID, &doc.UserID, &doc.FolderID, &doc.Name, &doc.Mime, &doc.Size,
+			&doc.Checksum, &doc.Version, &doc.Public)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+func (r *Repository) queryStrings(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// nullUUID stores uuid.Nil as NULL.
+func nullUUID(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// reserveIdempotencyKeyQuery inserts a pending key or takes over one that
+// expired or was left pending since before $6 by a request that never finished.
+const reserveIdempotencyKeyQuery = `INSERT INTO ` + tableIdempotencyKey + ` AS k (user_id, key, status, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, key) DO UPDATE SET
+	status = EXCLUDED.status, document_id = NULL, response = NULL,
+	created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+WHERE k.expires_at <= EXCLUDED.created_at OR (k.status = EXCLUDED.status AND k.created_at < $6)
+RETURNING k.user_id`
+
+// ReserveIdempotencyKey stores a pending key and reports false when the key
+// is already held by another request or by a finished one.
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	var userID uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, reserveIdempotencyKeyQuery,
+		key.UserID, key.Key, key.Status, key.CreatedAt, key.ExpiresAt, staleBefore,
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reserve idempotency key: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *Repository) GetIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotencyKey, error) {
+	query, args, err := r.pg.Builder.Select(
+		"user_id",
+		"key",
+		"status",
+		"document_id",
+		"response",
+		"created_at",
+		"expires_at",
+	).From(tableIdempotencyKey).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var stored domain.IdempotencyKey
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(
+		&stored.UserID,
+		&stored.Key,
+		&stored.Status,
+		&stored.DocumentID,
+		&stored.Response,
+		&stored.CreatedAt,
+		&stored.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get idempotency key: %w", err)
+	}
+
+	return &stored, nil
+}
+
+// CompleteIdempotencyKey stores the result of the request holding a pending key.
+func (r *Repository) CompleteIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) error {
+	query, args, err := r.pg.Builder.Update(tableIdempotencyKey).
+		Set("status", domain.IdempotencyDone).
+		Set("document_id", nullUUID(key.DocumentID)).
+		Set("response", key.Response).
+		Where(squirrel.Eq{"user_id": key.UserID}).
+		Where(squirrel.Eq{"key": key.Key}).
+		Where(squirrel.Eq{"status": domain.IdempotencyPending}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error complete idempotency key: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKey releases a pending key so that the request can be retried.
+func (r *Repository) DeleteIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error {
+	query, args, err := r.pg.Builder.Delete(tableIdempotencyKey).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"key": key}).
+		Where(squirrel.Eq{"status": domain.IdempotencyPending}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	query, args, err := r.pg.Builder.Delete(tableIdempotencyKey).
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error delete idempotency keys: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
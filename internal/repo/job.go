@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// claimJobsQuery leases due pending jobs of a job table by moving their next
+// attempt forward, so other instances skip them while they run.
+const claimJobsQuery = `UPDATE %[1]s SET next_attempt_at = $2
+WHERE (document_id, version) IN (
+	SELECT document_id, version FROM %[1]s
+	WHERE status = 'pending' AND next_attempt_at <= $1
+	ORDER BY next_attempt_at
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING document_id, version, status, attempts, last_error, next_attempt_at, updated_at`
+
+// addJob queues a pending job for the version of the document, a job queued
+// before is kept.
+func (r *Repository) addJob(ctx context.Context, table string, documentID uuid.UUID, version int) error {
+	query, args, err := r.pg.Builder.Insert(table).
+		SetMap(map[string]any{
+			"document_id":     documentID,
+			"version":         version,
+			"status":          domain.JobPending,
+			"next_attempt_at": time.Now(),
+			"updated_at":      time.Now(),
+		}).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error add job to %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// claimJobs returns up to limit pending jobs of the table due at now and hides
+// them from other callers until now+lease.
+func (r *Repository) claimJobs(ctx context.Context, table string, now time.Time, lease time.Duration, limit int) ([]domain.Job, error) {
+	rows, err := r.conn(ctx).Query(ctx, fmt.Sprintf(claimJobsQuery, table), now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claim jobs of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	jobs := make([]domain.Job, 0, limit)
+	for rows.Next() {
+		var job domain.Job
+		err := rows.Scan(
+			&job.DocumentID,
+			&job.Version,
+			&job.Status,
+			&job.Attempts,
+			&job.Error,
+			&job.NextAttemptAt,
+			&job.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// updateJob stores the outcome of an attempt of the job together with the
+// columns of its result, notFound is returned when the job is gone.
+func (r *Repository) updateJob(ctx context.Context, table string, job *domain.Job, result map[string]any, notFound error) error {
+	query, args, err := r.pg.Builder.Update(table).
+		SetMap(result).
+		Set("status", job.Status).
+		Set("attempts", job.Attempts).
+		Set("last_error", job.Error).
+		Set("next_attempt_at", job.NextAttemptAt).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"document_id": job.DocumentID}).
+		Where(squirrel.Eq{"version": job.Version}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error update job of %s: %w", table, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return notFound
+	}
+
+	return nil
+}
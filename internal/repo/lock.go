@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// acquireLockQuery takes the lock of a document, extends it for its holder or
+// takes over a lock that expired. A lock that is extended keeps its created_at.
+const acquireLockQuery = `INSERT INTO ` + tableDocumentLock + ` AS l (document_id, user_id, created_at, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (document_id) DO UPDATE SET
+	user_id = EXCLUDED.user_id, expires_at = EXCLUDED.expires_at,
+	created_at = CASE WHEN l.user_id = EXCLUDED.user_id AND l.expires_at > EXCLUDED.created_at
+		THEN l.created_at ELSE EXCLUDED.created_at END
+WHERE l.user_id = EXCLUDED.user_id OR l.expires_at <= EXCLUDED.created_at
+RETURNING l.created_at`
+
+// AcquireLock stores the lock and reports false when the document is locked
+// by another user. On success CreatedAt is set to when the holder took it.
+func (r *Repository) AcquireLock(ctx context.Context, lock *domain.DocumentLock) (bool, error) {
+	err := r.conn(ctx).QueryRow(ctx, acquireLockQuery,
+		lock.DocumentID, lock.UserID, lock.CreatedAt, lock.ExpiresAt,
+	).Scan(&lock.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error acquire lock: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetLock returns the lock of the document unless it expired before now.
+func (r *Repository) GetLock(ctx context.Context, documentID uuid.UUID, now time.Time) (*domain.DocumentLock, error) {
+	query, args, err := r.pg.Builder.Select(
+		"l.document_id",
+		"l.user_id",
+		"u.login",
+		"l.created_at",
+		"l.expires_at",
+	).From(tableDocumentLock + " AS l").
+		Join(tableUser + " AS u ON u.id = l.user_id").
+		Where(squirrel.Eq{"l.document_id": documentID}).
+		Where(squirrel.Gt{"l.expires_at": now}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var lock domain.DocumentLock
+	err = r.conn(ctx).QueryRow(ctx, query, args...).
+		Scan(&lock.DocumentID, &lock.UserID, &lock.Login, &lock.CreatedAt, &lock.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLockNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get lock: %w", err)
+	}
+
+	return &lock, nil
+}
+
+// DeleteLock releases the lock of the document held by the user, by anyone
+// when userID is uuid.Nil.
+func (r *Repository) DeleteLock(ctx context.Context, documentID, userID uuid.UUID) error {
+	builder := r.pg.Builder.Delete(tableDocumentLock).
+		Where(squirrel.Eq{"document_id": documentID})
+	if userID != uuid.Nil {
+		builder = builder.Where(squirrel.Eq{"user_id": userID})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete lock: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrLockNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteExpiredLocks(ctx context.Context, now time.Time) (int64, error) {
+	query, args, err := r.pg.Builder.Delete(tableDocumentLock).
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error delete locks: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
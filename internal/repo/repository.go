@@ -44,7 +44,10 @@ func (r *Repository) conn(ctx context.Context) db {
 	return r.pg.Pool
 }
 
-func (r *Repository) ExecTx(ctx context.Context, fn func(ctx context.Context) error) error {
+// ExecTx runs fn in a transaction that is committed when fn succeeds and rolled
+// back when it fails or panics, the panic is passed on to the caller. Nested
+// calls join the outer transaction.
+func (r *Repository) ExecTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
 	if _, ok := ctx.Value(tansactionKey).(pgx.Tx); ok {
 		return fn(ctx)
 	}
@@ -57,15 +60,19 @@ func (r *Repository) ExecTx(ctx context.Context, fn func(ctx context.Context) er
 	ctx = context.WithValue(ctx, tansactionKey, tx)
 
 	defer func() {
-		if p := recover(); p != nil {
+		p := recover()
+		if p != nil || err != nil {
 			if errRollback := tx.Rollback(ctx); errRollback != nil {
 				r.l.Error("rollback err %s", errRollback)
 			}
-			err = fmt.Errorf("panic :%s", p)
+			if p != nil {
+				panic(p)
+			}
 			return
 		}
 		if errCommit := tx.Commit(ctx); errCommit != nil {
 			r.l.Error("commit err %s", errCommit)
+			err = errCommit
 		}
 	}()
 	return fn(ctx)
@@ -98,24 +105,30 @@ func (r *Repository) Registration(ctx context.Context, user *domain.User) error
 	return nil
 }
 
-func (r *Repository) CheckUser(ctx context.Context, user *domain.User) (uuid.UUID, error) {
+// GetUserByLogin returns the user with the stored password, the first one
+// registered when the login was registered twice before logins were checked.
+func (r *Repository) GetUserByLogin(ctx context.Context, login string) (*domain.User, error) {
 	query, args, err := r.pg.Builder.
-		Select("id").
+		Select("id", "login", "password", "disabled_at").
 		From(tableUser).
-		Where(squirrel.Eq{"login": user.Login}).
-		Where(squirrel.Eq{"password": user.Password}).
+		Where(squirrel.Eq{"login": login}).
+		OrderBy("created_at").
+		Limit(1).
 		ToSql()
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error build query: %w", err)
+		return nil, fmt.Errorf("error build query: %w", err)
 	}
 
-	var id uuid.UUID
-	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&id)
+	var user domain.User
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&user.ID, &user.Login, &user.Password, &user.DisabledAt)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error check user: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error get user: %w", err)
 	}
 
-	return id, nil
+	return &user, nil
 }
 
 func (r *Repository) Authentication(ctx context.Context, user *domain.User) error {
@@ -125,11 +138,13 @@ func (r *Repository) Authentication(ctx context.Context, user *domain.User) erro
 			"user_id",
 			"token",
 			"created_at",
+			"expires_at",
 		).
 		Values(
 			user.ID,
 			user.Token,
 			time.Now(),
+			user.TokenExpiresAt,
 		).
 		Suffix(suffixReturningID).
 		ToSql()
@@ -170,6 +185,7 @@ func (r *Repository) GetUserID(ctx context.Context, token string) (uuid.UUID, er
 		Select("user_id").
 		From(tableToken).
 		Where(squirrel.Eq{"token": token}).
+		Where(squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Gt{"expires_at": time.Now()}}).
 		ToSql()
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("error build query: %w", err)
@@ -184,34 +200,94 @@ func (r *Repository) GetUserID(ctx context.Context, token string) (uuid.UUID, er
 	return userID, nil
 }
 
-func (r *Repository) Save(ctx context.Context, document *domain.Document) (uuid.UUID, error) {
+// GetUserToken returns the valid token of the user that expires last.
+func (r *Repository) GetUserToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	query, args, err := r.pg.Builder.
+		Select("token").
+		From(tableToken).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Gt{"expires_at": time.Now()}}).
+		OrderBy("expires_at DESC NULLS FIRST").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("error build query: %w", err)
+	}
+
+	var token string
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrTokenNotFound
+		}
+		return "", fmt.Errorf("error get token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Save stores a new document and returns it with the generated fields set.
+func (r *Repository) Save(ctx context.Context, document *domain.Document) (*domain.Document, error) {
 	sql, args, err := r.pg.Builder.Insert(tableDocument).SetMap(map[string]any{
-		"name":       document.Name,
-		"file":       document.Content,
-		"mime":       document.Mime,
-		"is_public":  document.Public,
-		"user_id":    document.UserID,
-		"created_at": time.Now(),
-	}).Suffix(suffixReturningID).ToSql()
+		"name":        document.Name,
+		"file":        document.Content,
+		"mime":        document.Mime,
+		"size":        document.Size,
+		"checksum":    document.Checksum,
+		"description": document.Description,
+		"tags":        tagsOrEmpty(document.Tags),
+		"metadata":    metadataOrEmpty(document.Metadata),
+		"is_public":   document.Public,
+		"user_id":     document.UserID,
+		"folder_id":   nullUUID(document.FolderID),
+		"created_at":  time.Now(),
+		"updated_at":  time.Now(),
+	}).Suffix("RETURNING id, revision, version, created_at, updated_at").ToSql()
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error build query: %w", err)
+		return nil, fmt.Errorf("error build query: %w", err)
 	}
 
-	var id uuid.UUID
-	err = r.conn(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	saved := *document
+	err = r.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&saved.ID,
+		&saved.Revision,
+		&saved.Version,
+		&saved.CreatedAt,
+		&saved.UpdatedAt,
+	)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error save document: %w", err)
+		return nil, fmt.Errorf("error save document: %w", err)
 	}
 
-	return id, nil
+	return &saved, nil
 }
 
+// AddGrant gives the login access to the document or changes the permission
+// of an existing grant.
 func (r *Repository) AddGrant(ctx context.Context, grant *domain.Grant) error {
-	sql, args, err := r.pg.Builder.Insert(tableGrant).
+	sql, args, err := r.pg.Builder.Update(tableGrant).
+		Set("permission", grant.Permission).
+		Where(squirrel.Eq{"document_id": grant.DocumentID}).
+		Where(squirrel.Eq{"grant_user_login": grant.GrantUserLogin}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("error update grant: %w", err)
+	}
+	if commandTag.RowsAffected() > 0 {
+		return nil
+	}
+
+	sql, args, err = r.pg.Builder.Insert(tableGrant).
 		SetMap(map[string]any{
 			"user_id":          grant.UserID,
 			"document_id":      grant.DocumentID,
 			"grant_user_login": grant.GrantUserLogin,
+			"permission":       grant.Permission,
 			"created_at":       time.Now(),
 		}).
 		ToSql()
@@ -229,13 +305,27 @@ func (r *Repository) AddGrant(ctx context.Context, grant *domain.Grant) error {
 
 func (r *Repository) GetDocument(ctx context.Context, id uuid.UUID) (*domain.Document, error) {
 	sql, args, err := r.pg.Builder.Select(
+		"id",
+		"name",
 		"file",
 		"mime",
+		"size",
+		"checksum",
+		"description",
+		"tags",
+		"metadata",
 		"is_public",
 		"user_id",
+		"folder_id",
+		"revision",
+		"version",
+		"created_at",
+		"updated_at",
+		"legal_hold",
 	).From(tableDocument).Where(
 		squirrel.Eq{"id": id},
 	).
+		Where(squirrel.Eq{"deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("error build query: %w", err)
@@ -243,7 +333,25 @@ func (r *Repository) GetDocument(ctx context.Context, id uuid.UUID) (*domain.Doc
 
 	var document domain.Document
 
-	err = r.conn(ctx).QueryRow(ctx, sql, args...).Scan(&document.Content, &document.Mime, &document.Public, &document.UserID)
+	err = r.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&document.ID,
+		&document.Name,
+		&document.Content,
+		&document.Mime,
+		&document.Size,
+		&document.Checksum,
+		&document.Description,
+		&document.Tags,
+		&document.Metadata,
+		&document.Public,
+		&document.UserID,
+		&document.FolderID,
+		&document.Revision,
+		&document.Version,
+		&document.CreatedAt,
+		&document.UpdatedAt,
+		&document.LegalHold,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("error add grant: %w", err)
 	}
@@ -251,27 +359,149 @@ func (r *Repository) GetDocument(ctx context.Context, id uuid.UUID) (*domain.Doc
 	return &document, nil
 }
 
-func (r *Repository) CheckGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
-	sql, args, err := r.pg.Builder.Select("1").From(tableGrant).
-		Where(squirrel.Eq{"grant_user_login": login}).
-		Where(squirrel.Eq{"document_id": documentID}).
+// UpdateDocument stores the editable metadata of the document if its revision
+// is still the one the caller has read, and bumps the revision.
+func (r *Repository) UpdateDocument(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+	query, args, err := r.pg.Builder.Update(tableDocument).
+		SetMap(map[string]any{
+			"name":        document.Name,
+			"mime":        document.Mime,
+			"description": document.Description,
+			"tags":        tagsOrEmpty(document.Tags),
+			"metadata":    metadataOrEmpty(document.Metadata),
+			"is_public":   document.Public,
+			"folder_id":   nullUUID(document.FolderID),
+			"revision":    squirrel.Expr("revision + 1"),
+			"updated_at":  time.Now(),
+		}).
+		Where(squirrel.Eq{"id": document.ID}).
+		Where(squirrel.Eq{"user_id": document.UserID}).
+		Where(squirrel.Eq{"revision": document.Revision}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Suffix("RETURNING revision, updated_at").
 		ToSql()
 	if err != nil {
-		return false, fmt.Errorf("error build query: %w", err)
+		return nil, fmt.Errorf("error build query: %w", err)
 	}
 
-	var exist bool
-	err = r.conn(ctx).QueryRow(ctx, sql, args...).Scan(&exist)
+	updated := *document
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&updated.Revision, &updated.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDocumentChanged
+		}
+		return nil, fmt.Errorf("error update document: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// ReplaceContent stores new content of the document as its next version if the
+// revision is still the one the caller has read. The searched text is cleared
+// until the new version is extracted.
+func (r *Repository) ReplaceContent(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+	query, args, err := r.pg.Builder.Update(tableDocument).
+		SetMap(map[string]any{
+			"file":         document.Content,
+			"mime":         document.Mime,
+			"size":         document.Size,
+			"checksum":     document.Checksum,
+			"content_text": "",
+			"version":      squirrel.Expr("version + 1"),
+			"revision":     squirrel.Expr("revision + 1"),
+			"updated_at":   time.Now(),
+		}).
+		Where(squirrel.Eq{"id": document.ID}).
+		Where(squirrel.Eq{"user_id": document.UserID}).
+		Where(squirrel.Eq{"revision": document.Revision}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Suffix("RETURNING version, revision, updated_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	updated := *document
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&updated.Version, &updated.Revision, &updated.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return false, nil
+			return nil, ErrDocumentChanged
 		}
-		return false, fmt.Errorf("error build query: %w", err)
+		return nil, fmt.Errorf("error replace content: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// CheckGrant reports whether the login was granted the document itself
+// or any folder on the path from the document up to the top level.
+func (r *Repository) CheckGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
+	return r.checkGrant(ctx, documentID, login)
+}
+
+// CheckCommentGrant reports whether the document or one of its folders is
+// granted to the login with the permission to comment.
+func (r *Repository) CheckCommentGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
+	return r.checkGrant(ctx, documentID, login, domain.PermissionComment, domain.PermissionEdit)
+}
+
+// CheckEditGrant reports whether the document or one of its folders is
+// granted to the login with the permission to upload new content.
+func (r *Repository) CheckEditGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
+	return r.checkGrant(ctx, documentID, login, domain.PermissionEdit)
+}
+
+// checkGrant looks for a grant with one of the permissions, with any when
+// none is given.
+func (r *Repository) checkGrant(ctx context.Context, documentID uuid.UUID, login string, permissions ...string) (bool, error) {
+	query := `WITH RECURSIVE ancestors AS (
+	SELECT f.id, f.parent_id FROM ` + tableFolder + ` AS f JOIN ` + tableDocument + ` AS d ON d.folder_id = f.id WHERE d.id = $1
+	UNION ALL
+	SELECT f.id, f.parent_id FROM ` + tableFolder + ` AS f JOIN ancestors AS a ON f.id = a.parent_id
+)
+SELECT EXISTS (
+	SELECT 1 FROM ` + tableGrant + ` WHERE document_id = $1 AND grant_user_login = $2 AND (cardinality($3::text[]) = 0 OR permission = ANY($3))
+) OR EXISTS (
+	SELECT 1 FROM ` + tableFolderGrant + ` AS g JOIN ancestors AS a ON g.folder_id = a.id
+	WHERE g.grant_user_login = $2 AND (cardinality($3::text[]) = 0 OR g.permission = ANY($3))
+)`
+
+	var exist bool
+	err := r.conn(ctx).QueryRow(ctx, query, documentID, login, append([]string{}, permissions...)).Scan(&exist)
+	if err != nil {
+		return false, fmt.Errorf("error check grant: %w", err)
 	}
 
 	return exist, nil
 }
 
+func (r *Repository) DeleteGrant(ctx context.Context, documentID uuid.UUID, login string) error {
+	query, args, err := r.pg.Builder.Delete(tableGrant).
+		Where(squirrel.Eq{"document_id": documentID}).
+		Where(squirrel.Eq{"grant_user_login": login}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete grant: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrGrantNotFound
+	}
+
+	return nil
+}
+
+// GetGrantLogins returns the logins the document itself is granted to.
+func (r *Repository) GetGrantLogins(ctx context.Context, documentID uuid.UUID) ([]string, error) {
+	query := `SELECT grant_user_login FROM ` + tableGrant + ` WHERE document_id = $1 ORDER BY grant_user_login`
+
+	return r.queryStrings(ctx, query, documentID)
+}
+
 func (r *Repository) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	sql, args, err := r.pg.Builder.Select(
 		"login",
@@ -291,27 +521,110 @@ func (r *Repository) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, e
 	return &user, nil
 }
 
-func (r *Repository) GetDocuments(ctx context.Context, filter *dto.GetDocuments) ([]domain.Document, error) {
-	where := make(squirrel.Or, 0, 3)
+// sharedFoldersCTE selects the folders shared with the login (?) and all of their subfolders.
+const sharedFoldersCTE = `WITH RECURSIVE shared AS (
+	SELECT folder_id AS id FROM ` + tableFolderGrant + ` WHERE grant_user_login = ?
+	UNION
+	SELECT f.id FROM ` + tableFolder + ` AS f JOIN shared AS s ON f.parent_id = s.id
+)`
 
-	where = append(where, squirrel.Eq{filter.Key: filter.Value})
-	where = append(where, squirrel.Eq{"d.user_id": filter.UserID})
-	where = append(where, squirrel.Eq{"g.grant_user_login": filter.Login})
+// sharedCondition holds for documents granted to the login (?) directly or through a parent folder.
+const sharedCondition = `(EXISTS (SELECT 1 FROM ` + tableGrant + ` AS g WHERE g.document_id = d.id AND g.grant_user_login = ?)
+	OR d.folder_id IN (SELECT id FROM shared))`
 
-	query, args, err := r.pg.Builder.Select(
+// GetDocuments lists the documents of the scope: owned by the user, shared
+// with the user by others, public or all of them together. The grants of a
+// document are only listed for its owner.
+func (r *Repository) GetDocuments(ctx context.Context, filter *dto.GetDocuments) ([]domain.Document, error) {
+	owned := squirrel.Eq{"d.user_id": filter.UserID}
+	shared := squirrel.And{
+		squirrel.NotEq{"d.user_id": filter.UserID},
+		squirrel.Expr(sharedCondition, filter.UserLogin),
+	}
+	public := squirrel.Eq{"d.is_public": true}
+
+	var scope squirrel.Sqlizer
+	switch filter.Scope {
+	case dto.ScopeOwned:
+		scope = owned
+	case dto.ScopeShared:
+		scope = shared
+	case dto.ScopePublic:
+		scope = public
+	default:
+		scope = squirrel.Or{owned, shared, public}
+	}
+
+	builder := r.pg.Builder.Select(
 		"d.id",
+		"d.user_id",
 		"d.name",
 		"d.mime",
+		"d.size",
+		"d.checksum",
 		"d.is_public",
 		"d.created_at",
-		"array_agg(DISTINCT g.grant_user_login) AS grant_user_logins",
-	).From("public.document AS d").
-		Join("public.grants AS g ON d.user_id = g.user_id").
-		Where(where).
-		GroupBy("d.id", "d.name", "d.mime", "d.is_public").
+		"d.tags",
+		"d.metadata",
+		"d.folder_id",
+	).
+		Column(squirrel.Expr("CASE WHEN d.user_id = ? THEN ARRAY(SELECT grant_user_login FROM "+tableGrant+
+			" WHERE document_id = d.id ORDER BY grant_user_login) END AS grant_user_logins", filter.UserID)).
+		Column("EXISTS (SELECT 1 FROM "+tableRendition+" AS r WHERE r.document_id = d.id AND r.version = d.version) AS thumbnail").
+		Prefix(sharedFoldersCTE, filter.UserLogin).
+		From(tableDocument + " AS d").
+		Where(scope).
+		Where(squirrel.Eq{"d.deleted_at": nil})
+
+	if filter.Key != "" {
+		builder = builder.Where(squirrel.Eq{"d." + filter.Key: filter.Value})
+	}
+	if filter.GrantedTo != "" {
+		builder = builder.Where("EXISTS (SELECT 1 FROM "+tableGrant+" AS g WHERE g.document_id = d.id AND g.grant_user_login = ?)", filter.GrantedTo)
+	}
+	if len(filter.Tags) > 0 {
+		builder = builder.Where("d.tags @> ?", filter.Tags)
+	}
+	if len(filter.Metadata) > 0 {
+		builder = builder.Where("d.metadata @> ?", filter.Metadata)
+	}
+	if filter.NamePrefix != "" {
+		builder = builder.Where("d.name LIKE ?", escapeLike(filter.NamePrefix)+"%")
+	}
+	if filter.NameContains != "" {
+		builder = builder.Where("d.name ILIKE ?", "%"+escapeLike(filter.NameContains)+"%")
+	}
+	if kind, ok := strings.CutSuffix(filter.Mime, "/*"); ok {
+		builder = builder.Where("d.mime LIKE ?", escapeLike(kind)+"/%")
+	} else if filter.Mime != "" {
+		builder = builder.Where(squirrel.Eq{"d.mime": filter.Mime})
+	}
+	if filter.Public != nil {
+		builder = builder.Where(squirrel.Eq{"d.is_public": *filter.Public})
+	}
+	if filter.Owner != "" {
+		builder = builder.Where("d.user_id IN (SELECT id FROM "+tableUser+" WHERE login = ?)", filter.Owner)
+	}
+	if filter.CreatedFrom != nil {
+		builder = builder.Where(squirrel.GtOrEq{"d.created_at": *filter.CreatedFrom})
+	}
+	if filter.CreatedTo != nil {
+		builder = builder.Where(squirrel.LtOrEq{"d.created_at": *filter.CreatedTo})
+	}
+
+	column, direction := sortColumn(filter.Sort), "ASC"
+	compare := ">"
+	if filter.Order == dto.OrderDesc {
+		direction, compare = "DESC", "<"
+	}
+	if filter.After != nil {
+		builder = builder.Where("("+column+", d.id) "+compare+" (?, ?)", cursorValue(filter.After), filter.After.ID)
+	}
+
+	query, args, err := builder.
+		OrderBy(column+" "+direction, "d.id "+direction).
 		Limit(uint64(filter.Limit)).
 		ToSql()
-
 	if err != nil {
 		return nil, fmt.Errorf("error building query: %w", err)
 	}
@@ -326,15 +639,91 @@ func (r *Repository) GetDocuments(ctx context.Context, filter *dto.GetDocuments)
 
 	for rows.Next() {
 		var doc domain.Document
-		var grantLogins sql.NullString
 
-		err := rows.Scan(&doc.ID, &doc.Name, &doc.Mime, &doc.Public, &doc.CreatedAt, &grantLogins)
+		err := rows.Scan(
+			&doc.ID,
+			&doc.UserID,
+			&doc.Name,
+			&doc.Mime,
+			&doc.Size,
+			&doc.Checksum,
+			&doc.Public,
+			&doc.CreatedAt,
+			&doc.Tags,
+			&doc.Metadata,
+			&doc.FolderID,
+			&doc.Grant,
+			&doc.Thumbnail,
+		)
 		if err != nil {
 			return nil, err
 		}
 
-		if grantLogins.Valid {
-			doc.Grant = strings.Split(strings.Trim(grantLogins.String, "{}"), ",")
+		documents = append(documents, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+func (r *Repository) DeleteDocument(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error) {
+	sql, args, err := r.pg.Builder.Update(tableDocument).
+		Set("deleted_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.Eq{"legal_hold": false}).
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return uuid.Nil, ErrDocumentNotFound
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetTrash(ctx context.Context, userID uuid.UUID) ([]domain.Document, error) {
+	query, args, err := r.pg.Builder.Select(
+		"id",
+		"name",
+		"mime",
+		"size",
+		"is_public",
+		"created_at",
+		"deleted_at",
+	).From(tableDocument).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		OrderBy("deleted_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	documents := make([]domain.Document, 0)
+	for rows.Next() {
+		doc := domain.Document{UserID: userID}
+
+		err := rows.Scan(&doc.ID, &doc.Name, &doc.Mime, &doc.Size, &doc.Public, &doc.CreatedAt, &doc.DeletedAt)
+		if err != nil {
+			return nil, err
 		}
 
 		documents = append(documents, doc)
@@ -347,18 +736,278 @@ func (r *Repository) GetDocuments(ctx context.Context, filter *dto.GetDocuments)
 	return documents, nil
 }
 
-func (r *Repository) DeleteDocument(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error) {
-	sql, args, err := r.pg.Builder.Delete(tableDocument).
+func (r *Repository) RestoreDocument(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error) {
+	sql, args, err := r.pg.Builder.Update(tableDocument).
+		Set("deleted_at", nil).
 		Where(squirrel.Eq{"id": id}).
 		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("error building query: %w", err)
 	}
-	_, err = r.conn(ctx).Exec(ctx, sql, args...)
+
+	commandTag, err := r.conn(ctx).Exec(ctx, sql, args...)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	if commandTag.RowsAffected() == 0 {
+		return uuid.Nil, ErrDocumentNotFound
+	}
 
 	return id, nil
 }
+
+func (r *Repository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	grantSQL, grantArgs, err := r.pg.Builder.Delete(tableGrant).
+		Where(squirrel.Expr(
+			"document_id IN (SELECT id FROM "+tableDocument+
+				" WHERE deleted_at IS NOT NULL AND deleted_at < ? AND legal_hold = false)",
+			before,
+		)).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, grantSQL, grantArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("error purge grants: %w", err)
+	}
+
+	sql, args, err := r.pg.Builder.Delete(tableDocument).
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		Where(squirrel.Lt{"deleted_at": before}).
+		Where(squirrel.Eq{"legal_hold": false}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error purge trash: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+func (r *Repository) SetLegalHold(ctx context.Context, id uuid.UUID, hold bool) error {
+	query, args, err := r.pg.Builder.Update(tableDocument).
+		Set("legal_hold", hold).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error building query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error set legal hold: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrDocumentNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) AddRetentionPolicy(ctx context.Context, policy *domain.RetentionPolicy) (uuid.UUID, error) {
+	values := map[string]any{
+		"kind":       policy.Kind,
+		"days":       policy.Days,
+		"created_at": time.Now(),
+	}
+	if policy.Mime != "" {
+		values["mime"] = policy.Mime
+	}
+	if policy.Tag != "" {
+		values["tag"] = policy.Tag
+	}
+	if policy.DocumentID != uuid.Nil {
+		values["document_id"] = policy.DocumentID
+	}
+
+	query, args, err := r.pg.Builder.Insert(tableRetentionPolicy).
+		SetMap(values).
+		Suffix(suffixReturningID).
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var id uuid.UUID
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error add retention policy: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) DeleteRetentionPolicy(ctx context.Context, id uuid.UUID) error {
+	query, args, err := r.pg.Builder.Delete(tableRetentionPolicy).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete retention policy: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrRetentionPolicyNotFound
+	}
+
+	return nil
+}
+
+// GetRetentionPolicies returns every policy when document is nil,
+// otherwise only the policies that apply to the given document.
+func (r *Repository) GetRetentionPolicies(ctx context.Context, document *domain.Document) ([]domain.RetentionPolicy, error) {
+	builder := r.pg.Builder.Select(
+		"id",
+		"kind",
+		"mime",
+		"tag",
+		"document_id",
+		"days",
+		"created_at",
+	).From(tableRetentionPolicy).
+		OrderBy("created_at")
+
+	if document != nil {
+		builder = builder.Where(squirrel.Or{
+			squirrel.Eq{"document_id": document.ID},
+			squirrel.Eq{"mime": document.Mime},
+			squirrel.Eq{"tag": tagsOrEmpty(document.Tags)},
+		})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	policies := make([]domain.RetentionPolicy, 0)
+	for rows.Next() {
+		var (
+			policy    domain.RetentionPolicy
+			mime, tag sql.NullString
+		)
+
+		err := rows.Scan(&policy.ID, &policy.Kind, &mime, &tag, &policy.DocumentID, &policy.Days, &policy.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		policy.Mime = mime.String
+		policy.Tag = tag.String
+
+		policies = append(policies, policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// GetExpiredDocuments returns documents whose expiry policy has passed and
+// that are neither on legal hold nor protected by a minimum retention policy.
+func (r *Repository) GetExpiredDocuments(ctx context.Context, now time.Time) ([]domain.Document, error) {
+	query, args, err := r.pg.Builder.Select(
+		"DISTINCT d.id",
+		"d.user_id",
+	).From(tableDocument+" AS d").
+		Join(tableRetentionPolicy+" AS p ON p.document_id = d.id OR p.mime = d.mime OR p.tag = ANY(d.tags)").
+		Where(squirrel.Eq{"p.kind": domain.RetentionExpire}).
+		Where(squirrel.Eq{"d.deleted_at": nil}).
+		Where(squirrel.Eq{"d.legal_hold": false}).
+		Where("d.created_at + p.days * interval '1 day' < ?", now).
+		Where(squirrel.Expr(
+			"NOT EXISTS (SELECT 1 FROM "+tableRetentionPolicy+" AS m"+
+				" WHERE m.kind = ? AND (m.document_id = d.id OR m.mime = d.mime OR m.tag = ANY(d.tags))"+
+				" AND d.created_at + m.days * interval '1 day' >= ?)",
+			domain.RetentionMin, now,
+		)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	documents := make([]domain.Document, 0)
+	for rows.Next() {
+		var doc domain.Document
+
+		err := rows.Scan(&doc.ID, &doc.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+func sortColumn(sort string) string {
+	switch sort {
+	case dto.SortCreated:
+		return "d.created_at"
+	case dto.SortSize:
+		return "d.size"
+	default:
+		return "d.name"
+	}
+}
+
+func cursorValue(cursor *dto.Cursor) any {
+	switch cursor.Sort {
+	case dto.SortCreated:
+		return cursor.Created
+	case dto.SortSize:
+		return cursor.Size
+	default:
+		return cursor.Name
+	}
+}
+
+// escapeLike makes the wildcards of a LIKE pattern match literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func metadataOrEmpty(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		return map[string]any{}
+	}
+	return metadata
+}
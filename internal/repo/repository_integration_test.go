@@ -0,0 +1,861 @@
+//go:build integration
+
+package repo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/Alina9496/tool/pkg/postgres"
+)
+
+// RepositorySuite runs the queries against a real schema. Set PG_URL to an
+// empty database and run `go test -tags integration ./internal/repo/...`.
+// Every test works inside a transaction that is rolled back at the end.
+type RepositorySuite struct {
+	suite.Suite
+	pg   *postgres.Postgres
+	repo *Repository
+	ctx  context.Context
+	tx   func()
+}
+
+func TestRepositorySuite(t *testing.T) {
+	url := os.Getenv("PG_URL")
+	if url == "" {
+		t.Skip("PG_URL is not set")
+	}
+
+	m, err := migrate.New("file://../../migrations", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	pg, err := postgres.New(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+
+	suite.Run(t, &RepositorySuite{pg: pg, repo: New(pg, logger.New("error"))})
+}
+
+func (s *RepositorySuite) SetupTest() {
+	tx, err := s.pg.Pool.Begin(context.Background())
+	s.Require().NoError(err)
+
+	s.ctx = context.WithValue(context.Background(), tansactionKey, tx)
+	s.tx = func() {
+		s.NoError(tx.Rollback(context.Background()))
+	}
+}
+
+func (s *RepositorySuite) TearDownTest() {
+	s.tx()
+}
+
+func (s *RepositorySuite) user(login string) *domain.User {
+	user := &domain.User{Login: login, Password: "password"}
+	s.Require().NoError(s.repo.Registration(s.ctx, user))
+
+	stored, err := s.repo.GetUserByLogin(s.ctx, login)
+	s.Require().NoError(err)
+	user.ID = stored.ID
+
+	return user
+}
+
+func (s *RepositorySuite) document(owner *domain.User, name string, public bool, folderID uuid.UUID) uuid.UUID {
+	saved, err := s.repo.Save(s.ctx, &domain.Document{
+		UserID:   owner.ID,
+		FolderID: folderID,
+		Name:     name,
+		Mime:     "text/plain",
+		Public:   public,
+	})
+	s.Require().NoError(err)
+
+	return saved.ID
+}
+
+func (s *RepositorySuite) grant(owner *domain.User, documentID uuid.UUID, login string) {
+	s.Require().NoError(s.repo.AddGrant(s.ctx, &domain.Grant{
+		UserID:         owner.ID,
+		DocumentID:     documentID,
+		GrantUserLogin: login,
+		Permission:     domain.PermissionRead,
+	}))
+}
+
+func (s *RepositorySuite) Test_GetDocuments_scopes() {
+	run := uuid.NewString()[:8]
+	alice, bob, carol := s.user(run+"alice"), s.user(run+"bob"), s.user(run+"carol")
+
+	folderID, err := s.repo.CreateFolder(s.ctx, &domain.Folder{UserID: alice.ID, Name: "reports"})
+	s.Require().NoError(err)
+	subfolderID, err := s.repo.CreateFolder(s.ctx, &domain.Folder{UserID: alice.ID, ParentID: folderID, Name: "2024"})
+	s.Require().NoError(err)
+	s.Require().NoError(s.repo.AddFolderGrant(s.ctx, &domain.FolderGrant{
+		UserID:         alice.ID,
+		FolderID:       folderID,
+		GrantUserLogin: bob.Login,
+	}))
+
+	s.grant(alice, s.document(alice, run+"a-direct", false, uuid.Nil), bob.Login)
+	s.document(alice, run+"a-folder", false, subfolderID)
+	s.document(alice, run+"a-public", true, uuid.Nil)
+	s.document(alice, run+"a-hidden", false, uuid.Nil)
+	s.grant(alice, s.document(alice, run+"a-carol", false, uuid.Nil), carol.Login)
+	s.grant(bob, s.document(bob, run+"b-own", false, uuid.Nil), carol.Login)
+	s.document(carol, run+"c-public", true, uuid.Nil)
+
+	tests := []struct {
+		name  string
+		scope string
+		want  []string
+	}{
+		{
+			name:  "owned",
+			scope: dto.ScopeOwned,
+			want:  []string{"b-own"},
+		},
+		{
+			name:  "shared directly and through a parent folder",
+			scope: dto.ScopeShared,
+			want:  []string{"a-direct", "a-folder"},
+		},
+		{
+			name:  "public",
+			scope: dto.ScopePublic,
+			want:  []string{"a-public", "c-public"},
+		},
+		{
+			name:  "all",
+			scope: dto.ScopeAll,
+			want:  []string{"a-direct", "a-folder", "a-public", "b-own", "c-public"},
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			documents, err := s.repo.GetDocuments(s.ctx, &dto.GetDocuments{
+				UserID:     bob.ID,
+				UserLogin:  bob.Login,
+				Scope:      tt.scope,
+				NamePrefix: run,
+				Sort:       dto.SortName,
+				Order:      dto.OrderAsc,
+				Limit:      10,
+			})
+			s.Require().NoError(err)
+
+			names := make([]string, 0, len(documents))
+			for _, doc := range documents {
+				names = append(names, doc.Name[len(run):])
+				if doc.UserID == bob.ID {
+					s.Equal([]string{carol.Login}, doc.Grant, doc.Name)
+				} else {
+					s.Empty(doc.Grant, "grants of %s are visible to a non-owner", doc.Name)
+				}
+			}
+			s.Equal(tt.want, names)
+		})
+	}
+}
+
+func (s *RepositorySuite) Test_GetDocuments_grantedTo() {
+	run := uuid.NewString()[:8]
+	alice, bob := s.user(run+"alice"), s.user(run+"bob")
+
+	s.grant(alice, s.document(alice, run+"granted", false, uuid.Nil), bob.Login)
+	s.document(alice, run+"private", false, uuid.Nil)
+
+	documents, err := s.repo.GetDocuments(s.ctx, &dto.GetDocuments{
+		UserID:     alice.ID,
+		UserLogin:  alice.Login,
+		Scope:      dto.ScopeOwned,
+		GrantedTo:  bob.Login,
+		NamePrefix: run,
+		Sort:       dto.SortName,
+		Order:      dto.OrderAsc,
+		Limit:      10,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(documents, 1)
+	s.Equal(run+"granted", documents[0].Name)
+}
+
+func (s *RepositorySuite) Test_ReplaceContent_thumbnail() {
+	run := uuid.NewString()[:8]
+	alice := s.user(run + "alice")
+	id := s.document(alice, run+"photo", false, uuid.Nil)
+
+	document, err := s.repo.GetDocument(s.ctx, id)
+	s.Require().NoError(err)
+	s.Require().NoError(s.repo.SaveRendition(s.ctx, &domain.Rendition{
+		DocumentID: id, Version: document.Version, Size: "small", Mime: "image/png", Width: 1, Height: 1, Data: []byte{1},
+	}))
+
+	list := func() domain.Document {
+		documents, err := s.repo.GetDocuments(s.ctx, &dto.GetDocuments{
+			UserID: alice.ID, UserLogin: alice.Login, Scope: dto.ScopeOwned, NamePrefix: run,
+			Sort: dto.SortName, Order: dto.OrderAsc, Limit: 10,
+		})
+		s.Require().NoError(err)
+		s.Require().Len(documents, 1)
+		return documents[0]
+	}
+	s.True(list().Thumbnail)
+
+	document.Content, document.Size = "bmV3", 3
+	updated, err := s.repo.ReplaceContent(s.ctx, document)
+	s.Require().NoError(err)
+	s.Equal(document.Version+1, updated.Version)
+	s.Equal(document.Revision+1, updated.Revision)
+	s.False(list().Thumbnail, "thumbnail of the previous version is reported")
+
+	_, err = s.repo.ReplaceContent(s.ctx, document)
+	s.ErrorIs(err, ErrDocumentChanged)
+}
+
+func (s *RepositorySuite) Test_IdempotencyKey() {
+	run := uuid.NewString()[:8]
+	alice := s.user(run + "alice")
+	now := time.Now()
+	key := &domain.IdempotencyKey{
+		UserID:    alice.ID,
+		Key:       run,
+		Status:    domain.IdempotencyPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	reserved, err := s.repo.ReserveIdempotencyKey(s.ctx, key, now.Add(-time.Minute))
+	s.Require().NoError(err)
+	s.True(reserved)
+
+	reserved, err = s.repo.ReserveIdempotencyKey(s.ctx, key, now.Add(-time.Minute))
+	s.Require().NoError(err)
+	s.False(reserved, "a key in flight is reserved twice")
+
+	documentID := s.document(alice, run+"doc", false, uuid.Nil)
+	s.Require().NoError(s.repo.CompleteIdempotencyKey(s.ctx, &domain.IdempotencyKey{
+		UserID: alice.ID, Key: run, DocumentID: documentID, Response: []byte(`"doc"`),
+	}))
+
+	stored, err := s.repo.GetIdempotencyKey(s.ctx, alice.ID, run)
+	s.Require().NoError(err)
+	s.Equal(domain.IdempotencyDone, stored.Status)
+	s.Equal(documentID, stored.DocumentID)
+	s.Equal([]byte(`"doc"`), stored.Response)
+
+	// a finished key is not taken over before it expires, even when old
+	reserved, err = s.repo.ReserveIdempotencyKey(s.ctx, key, now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.False(reserved)
+
+	later := *key
+	later.CreatedAt, later.ExpiresAt = now.Add(2*time.Hour), now.Add(3*time.Hour)
+	reserved, err = s.repo.ReserveIdempotencyKey(s.ctx, &later, now)
+	s.Require().NoError(err)
+	s.True(reserved, "an expired key is not taken over")
+
+	s.Require().NoError(s.repo.DeleteIdempotencyKey(s.ctx, alice.ID, run))
+	_, err = s.repo.GetIdempotencyKey(s.ctx, alice.ID, run)
+	s.ErrorIs(err, ErrIdempotencyKeyNotFound)
+}
+
+func (s *RepositorySuite) Test_AuditEvent_chain() {
+	run := uuid.NewString()[:8]
+	alice := s.user(run + "alice")
+	documentID := s.document(alice, run+"doc", false, uuid.Nil)
+
+	events := []*domain.AuditEvent{
+		{ActorID: alice.ID, Action: domain.AuditUpload, DocumentID: documentID, Outcome: domain.AuditSuccess},
+		{ActorID: alice.ID, Action: domain.AuditGrantAdd, DocumentID: documentID, TargetLogin: run + "bob", Outcome: domain.AuditSuccess},
+		{ActorLogin: run + "bob", Action: domain.AuditLogin, Outcome: domain.AuditFailure, Reason: "user not found", IP: "10.0.0.1"},
+	}
+	for _, event := range events {
+		s.Require().NoError(s.repo.AddAuditEvent(s.ctx, event))
+	}
+
+	listed, err := s.repo.GetAuditEvents(s.ctx, &dto.GetAuditEvents{Actor: alice.Login, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(listed, 2)
+	s.Empty(listed[0].Hash, "the event is chained when it is written")
+
+	_, err = s.repo.ChainAuditEvents(s.ctx, 1000)
+	s.Require().NoError(err)
+	chained, err := s.repo.ChainAuditEvents(s.ctx, 1000)
+	s.Require().NoError(err)
+	s.Zero(chained)
+
+	listed, err = s.repo.GetAuditEvents(s.ctx, &dto.GetAuditEvents{Actor: alice.Login, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(listed, 2)
+	s.Equal(events[1].ID, listed[0].ID)
+	s.Equal(alice.Login, listed[0].ActorLogin, "the login of the actor is not filled in")
+	s.Equal(listed[1].Hash, listed[0].PrevHash)
+	s.NotEmpty(listed[0].Hash)
+
+	result, err := s.repo.VerifyAuditChain(s.ctx)
+	s.Require().NoError(err)
+	s.Zero(result.BrokenID)
+
+	// the table refuses changes, a savepoint keeps the test transaction usable
+	tx := s.ctx.Value(tansactionKey).(pgx.Tx)
+	savepoint, err := tx.Begin(s.ctx)
+	s.Require().NoError(err)
+	_, err = savepoint.Exec(s.ctx, "UPDATE "+tableAuditEvent+" SET reason = 'edited' WHERE id = $1", events[1].ID)
+	s.Error(err)
+	s.Require().NoError(savepoint.Rollback(s.ctx))
+
+	// a chained event is not chained again
+	savepoint, err = tx.Begin(s.ctx)
+	s.Require().NoError(err)
+	_, err = savepoint.Exec(s.ctx, "UPDATE "+tableAuditEvent+" SET hash = 'forged', chain_seq = chain_seq + 1000000 WHERE id = $1", events[1].ID)
+	s.Error(err)
+	s.Require().NoError(savepoint.Rollback(s.ctx))
+
+	_, err = tx.Exec(s.ctx, "ALTER TABLE "+tableAuditEvent+" DISABLE TRIGGER audit_event_chain_only")
+	s.Require().NoError(err)
+	_, err = tx.Exec(s.ctx, "UPDATE "+tableAuditEvent+" SET reason = 'edited' WHERE id = $1", events[1].ID)
+	s.Require().NoError(err)
+
+	result, err = s.repo.VerifyAuditChain(s.ctx)
+	s.Require().NoError(err)
+	s.Equal(events[1].ID, result.BrokenID)
+}
+
+func (s *RepositorySuite) Test_Webhook_dispatch() {
+	run := uuid.NewString()[:8]
+	alice, bob, carol := s.user(run+"alice"), s.user(run+"bob"), s.user(run+"carol")
+	documentID := s.document(alice, run+"doc", false, uuid.Nil)
+
+	webhook := func(user *domain.User, events ...string) uuid.UUID {
+		created, err := s.repo.CreateWebhook(s.ctx, &domain.Webhook{
+			UserID: user.ID,
+			URL:    "http://localhost/" + user.Login,
+			Secret: "secret",
+			Events: events,
+		})
+		s.Require().NoError(err)
+		return created.ID
+	}
+	ownerHook := webhook(alice)
+	targetHook := webhook(bob, domain.WebhookDocumentShared)
+	otherHook := webhook(carol)
+	filteredHook := webhook(alice, domain.WebhookDocumentDeleted)
+
+	s.Require().NoError(s.repo.AddWebhookEvent(s.ctx, &domain.WebhookEvent{
+		Event:       domain.WebhookDocumentShared,
+		DocumentID:  documentID,
+		UserID:      alice.ID,
+		TargetLogin: bob.Login,
+		Payload:     []byte(`{"event":"document.shared"}`),
+	}))
+
+	now := time.Now()
+	dispatched, err := s.repo.DispatchWebhookEvents(s.ctx, now, 100)
+	s.Require().NoError(err)
+	s.Positive(dispatched)
+
+	dispatched, err = s.repo.DispatchWebhookEvents(s.ctx, now, 100)
+	s.Require().NoError(err)
+	s.Zero(dispatched, "an event is dispatched twice")
+
+	claimed, err := s.repo.ClaimWebhookDeliveries(s.ctx, now, time.Minute, 100)
+	s.Require().NoError(err)
+	byWebhook := make(map[uuid.UUID]domain.WebhookDelivery)
+	for _, delivery := range claimed {
+		byWebhook[delivery.WebhookID] = delivery
+	}
+	s.Contains(byWebhook, ownerHook)
+	s.Contains(byWebhook, targetHook)
+	s.NotContains(byWebhook, otherHook)
+	s.NotContains(byWebhook, filteredHook)
+	s.Equal([]byte(`{"event":"document.shared"}`), byWebhook[ownerHook].Payload)
+
+	again, err := s.repo.ClaimWebhookDeliveries(s.ctx, now, time.Minute, 100)
+	s.Require().NoError(err)
+	for _, delivery := range again {
+		s.NotEqual(ownerHook, delivery.WebhookID, "a claimed delivery is claimed again")
+	}
+
+	delivery := byWebhook[ownerHook]
+	deliveredAt := now
+	delivery.Status, delivery.Attempts, delivery.ResponseCode, delivery.DeliveredAt = domain.DeliveryDelivered, 1, 200, &deliveredAt
+	s.Require().NoError(s.repo.UpdateWebhookDelivery(s.ctx, &delivery))
+
+	history, err := s.repo.GetWebhookDeliveries(s.ctx, ownerHook, 10)
+	s.Require().NoError(err)
+	s.Require().Len(history, 1)
+	s.Equal(domain.DeliveryDelivered, history[0].Status)
+	s.Equal(domain.WebhookDocumentShared, history[0].Event)
+	s.NotNil(history[0].DeliveredAt)
+
+	s.Require().NoError(s.repo.DeleteWebhook(s.ctx, ownerHook))
+	s.ErrorIs(s.repo.DeleteWebhook(s.ctx, ownerHook), ErrWebhookNotFound)
+}
+
+func (s *RepositorySuite) Test_GetEvents() {
+	run := uuid.NewString()[:8]
+	alice, bob, carol := s.user(run+"alice"), s.user(run+"bob"), s.user(run+"carol")
+	documentID := s.document(alice, run+"doc", false, uuid.Nil)
+
+	after, err := s.repo.GetLastEventID(s.ctx)
+	s.Require().NoError(err)
+
+	for _, event := range []*domain.WebhookEvent{
+		{Event: domain.WebhookDocumentUploaded, UserID: alice.ID},
+		{Event: domain.WebhookDocumentShared, UserID: alice.ID, TargetLogin: bob.Login},
+		{Event: domain.WebhookDocumentUpdated, UserID: alice.ID},
+		{Event: domain.WebhookDocumentUnshared, UserID: alice.ID, TargetLogin: bob.Login},
+	} {
+		event.DocumentID, event.Payload = documentID, []byte(`{}`)
+		s.Require().NoError(s.repo.AddWebhookEvent(s.ctx, event))
+	}
+
+	events, err := s.repo.GetEvents(s.ctx, alice.ID, alice.Login, after, 10)
+	s.Require().NoError(err)
+	s.Empty(events, "the events are in the feed once they are numbered")
+
+	sequenced, err := s.repo.SequenceEvents(s.ctx, 10)
+	s.Require().NoError(err)
+	s.Equal(4, sequenced)
+
+	events, err = s.repo.GetEvents(s.ctx, alice.ID, alice.Login, after, 10)
+	s.Require().NoError(err)
+	s.Len(events, 4)
+
+	events, err = s.repo.GetEvents(s.ctx, bob.ID, bob.Login, after, 10)
+	s.Require().NoError(err)
+	s.Require().Len(events, 2)
+	s.Equal(domain.WebhookDocumentShared, events[0].Event)
+	s.Equal(domain.WebhookDocumentUnshared, events[1].Event)
+
+	resumed, err := s.repo.GetEvents(s.ctx, bob.ID, bob.Login, events[0].Seq, 10)
+	s.Require().NoError(err)
+	s.Require().Len(resumed, 1)
+	s.Equal(events[1].ID, resumed[0].ID)
+
+	events, err = s.repo.GetEvents(s.ctx, carol.ID, carol.Login, after, 10)
+	s.Require().NoError(err)
+	s.Empty(events)
+}
+
+func (s *RepositorySuite) Test_EventTicket() {
+	run := uuid.NewString()[:8]
+	alice := s.user(run + "alice")
+	now := time.Now()
+
+	for _, ticket := range []*domain.EventTicket{
+		{Ticket: run + "valid", UserID: alice.ID, ExpiresAt: now.Add(time.Minute)},
+		{Ticket: run + "expired", UserID: alice.ID, ExpiresAt: now.Add(-time.Second)},
+	} {
+		s.Require().NoError(s.repo.AddEventTicket(s.ctx, ticket))
+	}
+
+	userID, err := s.repo.TakeEventTicket(s.ctx, run+"valid", now)
+	s.Require().NoError(err)
+	s.Equal(alice.ID, userID)
+
+	// a ticket opens the feed once
+	_, err = s.repo.TakeEventTicket(s.ctx, run+"valid", now)
+	s.ErrorIs(err, ErrEventTicketNotFound)
+
+	_, err = s.repo.TakeEventTicket(s.ctx, run+"expired", now)
+	s.ErrorIs(err, ErrEventTicketNotFound)
+
+	deleted, err := s.repo.DeleteExpiredEventTickets(s.ctx, now)
+	s.Require().NoError(err)
+	s.GreaterOrEqual(deleted, int64(1))
+}
+
+// Test_SequenceEvents runs outside the transaction of the suite, the events
+// are numbered in the order they commit.
+func (s *RepositorySuite) Test_SequenceEvents() {
+	ctx := context.Background()
+	userID := uuid.New()
+	defer func() {
+		_, err := s.pg.Pool.Exec(ctx, "DELETE FROM "+tableWebhookEvent+" WHERE user_id = $1", userID)
+		s.NoError(err)
+	}()
+
+	after, err := s.repo.GetLastEventID(ctx)
+	s.Require().NoError(err)
+
+	add := func(ctx context.Context, event string) {
+		s.Require().NoError(s.repo.AddWebhookEvent(ctx, &domain.WebhookEvent{
+			Event:      event,
+			DocumentID: uuid.New(),
+			UserID:     userID,
+			Payload:    []byte(`{}`),
+		}))
+	}
+
+	// the first event takes its ID and commits after the second one
+	tx, err := s.pg.Pool.Begin(ctx)
+	s.Require().NoError(err)
+	add(context.WithValue(ctx, tansactionKey, tx), domain.WebhookDocumentUploaded)
+	add(ctx, domain.WebhookDocumentUpdated)
+
+	_, err = s.repo.SequenceEvents(ctx, 10)
+	s.Require().NoError(err)
+	events, err := s.repo.GetEvents(ctx, userID, "", after, 10)
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(domain.WebhookDocumentUpdated, events[0].Event)
+	after = events[0].Seq
+
+	s.Require().NoError(tx.Commit(ctx))
+	_, err = s.repo.SequenceEvents(ctx, 10)
+	s.Require().NoError(err)
+
+	// a stream that read the second event still gets the first one
+	events, err = s.repo.GetEvents(ctx, userID, "", after, 10)
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(domain.WebhookDocumentUploaded, events[0].Event)
+	s.Greater(events[0].Seq, after)
+}
+
+func (s *RepositorySuite) Test_Comments() {
+	run := uuid.NewString()[:8]
+	alice, bob := s.user(run+"alice"), s.user(run+"bob")
+	documentID := s.document(alice, run+"doc", false, uuid.Nil)
+
+	s.grant(alice, documentID, bob.Login)
+	canComment, err := s.repo.CheckCommentGrant(s.ctx, documentID, bob.Login)
+	s.Require().NoError(err)
+	s.False(canComment)
+
+	s.Require().NoError(s.repo.AddGrant(s.ctx, &domain.Grant{
+		UserID:         alice.ID,
+		DocumentID:     documentID,
+		GrantUserLogin: bob.Login,
+		Permission:     domain.PermissionComment,
+	}))
+	canComment, err = s.repo.CheckCommentGrant(s.ctx, documentID, bob.Login)
+	s.Require().NoError(err)
+	s.True(canComment)
+
+	logins, err := s.repo.GetGrantLogins(s.ctx, documentID)
+	s.Require().NoError(err)
+	s.Equal([]string{bob.Login}, logins)
+
+	root, err := s.repo.CreateComment(s.ctx, &domain.Comment{DocumentID: documentID, UserID: alice.ID, Body: "typo", Page: 1, RangeStart: 3, RangeEnd: 7})
+	s.Require().NoError(err)
+	reply, err := s.repo.CreateComment(s.ctx, &domain.Comment{DocumentID: documentID, ParentID: root.ID, UserID: bob.ID, Body: "fixed"})
+	s.Require().NoError(err)
+
+	_, err = s.repo.UpdateComment(s.ctx, reply.ID, "fixed now")
+	s.Require().NoError(err)
+	s.Require().NoError(s.repo.DeleteComment(s.ctx, root.ID))
+	s.ErrorIs(s.repo.DeleteComment(s.ctx, root.ID), ErrCommentNotFound)
+
+	comments, err := s.repo.GetComments(s.ctx, documentID)
+	s.Require().NoError(err)
+	s.Require().Len(comments, 2)
+	s.Equal(alice.Login, comments[0].Login)
+	s.Equal(1, comments[0].Page)
+	s.Empty(comments[0].Body)
+	s.NotNil(comments[0].DeletedAt)
+	s.Equal(root.ID, comments[1].ParentID)
+	s.Equal("fixed now", comments[1].Body)
+	s.Equal(bob.Login, comments[1].Login)
+}
+
+func (s *RepositorySuite) Test_DocumentLock() {
+	run := uuid.NewString()[:8]
+	alice, bob := s.user(run+"alice"), s.user(run+"bob")
+	documentID := s.document(alice, run+"doc", false, uuid.Nil)
+	now := time.Now()
+
+	lock := &domain.DocumentLock{DocumentID: documentID, UserID: alice.ID, CreatedAt: now, ExpiresAt: now.Add(time.Minute)}
+	acquired, err := s.repo.AcquireLock(s.ctx, lock)
+	s.Require().NoError(err)
+	s.True(acquired)
+
+	acquired, err = s.repo.AcquireLock(s.ctx, &domain.DocumentLock{DocumentID: documentID, UserID: bob.ID, CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	s.Require().NoError(err)
+	s.False(acquired)
+
+	// the holder extends the lock and keeps the time it was taken
+	extended := &domain.DocumentLock{DocumentID: documentID, UserID: alice.ID, CreatedAt: now.Add(time.Second), ExpiresAt: now.Add(time.Hour)}
+	acquired, err = s.repo.AcquireLock(s.ctx, extended)
+	s.Require().NoError(err)
+	s.True(acquired)
+	s.WithinDuration(now, extended.CreatedAt, time.Millisecond)
+
+	held, err := s.repo.GetLock(s.ctx, documentID, now)
+	s.Require().NoError(err)
+	s.Equal(alice.Login, held.Login)
+
+	// an expired lock is not returned and is taken over
+	_, err = s.repo.GetLock(s.ctx, documentID, now.Add(2*time.Hour))
+	s.ErrorIs(err, ErrLockNotFound)
+	later := now.Add(2 * time.Hour)
+	acquired, err = s.repo.AcquireLock(s.ctx, &domain.DocumentLock{DocumentID: documentID, UserID: bob.ID, CreatedAt: later, ExpiresAt: later.Add(time.Minute)})
+	s.Require().NoError(err)
+	s.True(acquired)
+
+	s.ErrorIs(s.repo.DeleteLock(s.ctx, documentID, alice.ID), ErrLockNotFound)
+	s.Require().NoError(s.repo.DeleteLock(s.ctx, documentID, uuid.Nil))
+
+	acquired, err = s.repo.AcquireLock(s.ctx, &domain.DocumentLock{DocumentID: documentID, UserID: alice.ID, CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	s.Require().NoError(err)
+	s.True(acquired)
+	purged, err := s.repo.DeleteExpiredLocks(s.ctx, now.Add(time.Hour))
+	s.Require().NoError(err)
+	s.GreaterOrEqual(purged, int64(1))
+	_, err = s.repo.GetLock(s.ctx, documentID, now)
+	s.ErrorIs(err, ErrLockNotFound)
+}
+
+func (s *RepositorySuite) Test_UserAdmin() {
+	alice := s.user("alice_admin1")
+
+	expired := time.Now().Add(-time.Minute)
+	s.Require().NoError(s.repo.Authentication(s.ctx, &domain.User{ID: alice.ID, Token: "expired_token_1", TokenExpiresAt: &expired}))
+	s.Require().NoError(s.repo.Authentication(s.ctx, &domain.User{ID: alice.ID, Token: "valid_token_1"}))
+
+	_, err := s.repo.GetUserID(s.ctx, "expired_token_1")
+	s.ErrorIs(err, pgx.ErrNoRows)
+	purged, err := s.repo.DeleteExpiredTokens(s.ctx, time.Now())
+	s.Require().NoError(err)
+	s.GreaterOrEqual(purged, int64(1))
+
+	users, err := s.repo.GetPlainPasswordUsers(s.ctx)
+	s.Require().NoError(err)
+	s.Contains(users, domain.User{ID: alice.ID, Login: alice.Login, Password: "password"})
+	hash := "$2a$04$" + strings.Repeat("a", 53)
+	replaced, err := s.repo.ReplacePassword(s.ctx, alice.ID, "other", hash)
+	s.Require().NoError(err)
+	s.False(replaced)
+	replaced, err = s.repo.ReplacePassword(s.ctx, alice.ID, "password", hash)
+	s.Require().NoError(err)
+	s.True(replaced)
+	users, err = s.repo.GetPlainPasswordUsers(s.ctx)
+	s.Require().NoError(err)
+	for _, user := range users {
+		s.NotEqual(alice.ID, user.ID)
+	}
+
+	now := time.Now()
+	id, err := s.repo.SetUserDisabled(s.ctx, alice.Login, &now)
+	s.Require().NoError(err)
+	s.Equal(alice.ID, id)
+	deleted, err := s.repo.DeleteUserTokens(s.ctx, alice.ID)
+	s.Require().NoError(err)
+	s.Equal(int64(1), deleted)
+	stored, err := s.repo.GetUserByLogin(s.ctx, alice.Login)
+	s.Require().NoError(err)
+	s.NotNil(stored.DisabledAt)
+	s.Equal(hash, stored.Password)
+
+	_, err = s.repo.SetUserDisabled(s.ctx, "nobody_admin1", nil)
+	s.ErrorIs(err, ErrUserNotFound)
+	_, err = s.repo.GetUserByLogin(s.ctx, "nobody_admin1")
+	s.ErrorIs(err, ErrUserNotFound)
+}
+
+func (s *RepositorySuite) Test_StorageUsage() {
+	alice := s.user("alice_usage1")
+	usageOf := func() domain.StorageUsage {
+		usage, err := s.repo.GetStorageUsage(s.ctx)
+		s.Require().NoError(err)
+		for _, u := range usage {
+			if u.UserID == alice.ID {
+				return u
+			}
+		}
+		return domain.StorageUsage{}
+	}
+
+	first, err := s.repo.Save(s.ctx, &domain.Document{UserID: alice.ID, Name: "a.txt", Mime: "text/plain", Size: 10})
+	s.Require().NoError(err)
+	_, err = s.repo.Save(s.ctx, &domain.Document{UserID: alice.ID, Name: "b.txt", Mime: "text/plain", Size: 5})
+	s.Require().NoError(err)
+	_, err = s.repo.DeleteDocument(s.ctx, first.ID, alice.ID)
+	s.Require().NoError(err)
+	usage := usageOf()
+	s.Equal(alice.Login, usage.Login)
+	s.Equal([]int64{1, 5, 1, 10}, []int64{usage.Documents, usage.Bytes, usage.TrashDocuments, usage.TrashBytes})
+
+	documents, err := s.repo.GetDocumentContents(s.ctx, uuid.Nil, 1000)
+	s.Require().NoError(err)
+	s.NotEmpty(documents)
+}
+
+func (s *RepositorySuite) Test_SearchDocuments_snippet() {
+	alice := s.user("alice_search1")
+	_, err := s.repo.Save(s.ctx, &domain.Document{
+		UserID:      alice.ID,
+		Name:        "notes.html",
+		Mime:        "text/html",
+		Description: "<script>alert(1)</script> zebracorn \x01 budget",
+	})
+	s.Require().NoError(err)
+
+	results, err := s.repo.SearchDocuments(s.ctx, &dto.Search{
+		UserID: alice.ID,
+		Login:  alice.Login,
+		Query:  "zebracorn",
+		Limit:  10,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+
+	// the snippet is the plain text, only the hit is marked
+	snippet := results[0].Snippet
+	s.Contains(snippet, "<script>")
+	s.Contains(snippet, domain.SnippetStart+"zebracorn"+domain.SnippetStop)
+	s.Equal(1, strings.Count(snippet, domain.SnippetStart))
+}
+
+func (s *RepositorySuite) Test_GetFolderTreeDocuments() {
+	alice := s.user("alice_tree1")
+	folderID, err := s.repo.CreateFolder(s.ctx, &domain.Folder{UserID: alice.ID, Name: "shared"})
+	s.Require().NoError(err)
+	subfolderID, err := s.repo.CreateFolder(s.ctx, &domain.Folder{UserID: alice.ID, ParentID: folderID, Name: "inner"})
+	s.Require().NoError(err)
+
+	top := s.document(alice, "top.txt", false, folderID)
+	inner := s.document(alice, "inner.txt", false, subfolderID)
+	trashed := s.document(alice, "trashed.txt", false, subfolderID)
+	s.document(alice, "outside.txt", false, uuid.Nil)
+	_, err = s.repo.DeleteDocument(s.ctx, trashed, alice.ID)
+	s.Require().NoError(err)
+
+	documents, err := s.repo.GetFolderTreeDocuments(s.ctx, folderID)
+	s.Require().NoError(err)
+
+	ids := make([]uuid.UUID, 0, len(documents))
+	for _, document := range documents {
+		ids = append(ids, document.ID)
+	}
+	s.ElementsMatch([]uuid.UUID{top, inner}, ids)
+}
+
+// Test_ExecTx runs outside the transaction of the suite, ExecTx would join it.
+func (s *RepositorySuite) Test_ExecTx() {
+	ctx := context.Background()
+	user := &domain.User{Login: "tx_" + uuid.NewString()[:8], Password: "password"}
+	register := func(ctx context.Context) error {
+		return s.repo.Registration(ctx, user)
+	}
+	registered := func() bool {
+		_, err := s.repo.GetUserByLogin(ctx, user.Login)
+		if errors.Is(err, ErrUserNotFound) {
+			return false
+		}
+		s.Require().NoError(err)
+		return true
+	}
+	defer func() {
+		_, err := s.pg.Pool.Exec(ctx, "DELETE FROM "+tableUser+" WHERE login = $1", user.Login)
+		s.NoError(err)
+	}()
+
+	// an error rolls the transaction back
+	errFailed := errors.New("failed")
+	err := s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		s.Require().NoError(register(ctx))
+		return errFailed
+	})
+	s.ErrorIs(err, errFailed)
+	s.False(registered())
+
+	// so does a panic, which reaches the caller
+	s.PanicsWithValue("failed", func() {
+		_ = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+			s.Require().NoError(register(ctx))
+			panic("failed")
+		})
+	})
+	s.False(registered())
+
+	// a nested call joins the outer transaction
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		s.Require().NoError(s.repo.ExecTx(ctx, register))
+		return errFailed
+	})
+	s.ErrorIs(err, errFailed)
+	s.False(registered())
+
+	s.Require().NoError(s.repo.ExecTx(ctx, register))
+	s.True(registered())
+}
+
+func (s *RepositorySuite) Test_GetUserToken() {
+	alice := s.user("alice_token1")
+
+	_, err := s.repo.GetUserToken(s.ctx, alice.ID)
+	s.ErrorIs(err, ErrTokenNotFound)
+
+	expired := time.Now().Add(-time.Minute)
+	soon := time.Now().Add(time.Hour)
+	later := time.Now().Add(24 * time.Hour)
+	s.Require().NoError(s.repo.Authentication(s.ctx, &domain.User{ID: alice.ID, Token: "expired_token_2", TokenExpiresAt: &expired}))
+	s.Require().NoError(s.repo.Authentication(s.ctx, &domain.User{ID: alice.ID, Token: "soon_token_2", TokenExpiresAt: &soon}))
+	s.Require().NoError(s.repo.Authentication(s.ctx, &domain.User{ID: alice.ID, Token: "later_token_2", TokenExpiresAt: &later}))
+
+	token, err := s.repo.GetUserToken(s.ctx, alice.ID)
+	s.Require().NoError(err)
+	s.Equal("later_token_2", token)
+}
+
+// Test_LegalHold_guard deletes past the checks of the service, the database
+// refuses while the hold is set.
+func (s *RepositorySuite) Test_LegalHold_guard() {
+	run := uuid.NewString()[:8]
+	alice := s.user(run + "alice")
+	documentID := s.document(alice, run+"doc", false, uuid.Nil)
+	s.Require().NoError(s.repo.SetLegalHold(s.ctx, documentID, true))
+
+	// a refused statement aborts the transaction of the suite, so every
+	// attempt runs in a savepoint
+	refused := func(sql string, args ...interface{}) {
+		_, err := s.repo.conn(s.ctx).Exec(s.ctx, "SAVEPOINT legal_hold")
+		s.Require().NoError(err)
+		_, err = s.repo.conn(s.ctx).Exec(s.ctx, sql, args...)
+		var pgErr *pgconn.PgError
+		s.Require().ErrorAs(err, &pgErr)
+		s.Equal("23001", pgErr.Code) // restrict_violation
+		_, err = s.repo.conn(s.ctx).Exec(s.ctx, "ROLLBACK TO SAVEPOINT legal_hold")
+		s.Require().NoError(err)
+	}
+	refused("DELETE FROM "+tableDocument+" WHERE id = $1", documentID)
+	refused("UPDATE "+tableDocument+" SET deleted_at = now() WHERE id = $1", documentID)
+	refused("DELETE FROM "+tableUser+" WHERE id = $1", alice.ID)
+
+	document, err := s.repo.GetDocument(s.ctx, documentID)
+	s.Require().NoError(err)
+	s.True(document.LegalHold)
+
+	// once the hold is lifted the document and the account can go
+	s.Require().NoError(s.repo.SetLegalHold(s.ctx, documentID, false))
+	_, err = s.repo.conn(s.ctx).Exec(s.ctx, "DELETE FROM "+tableDocument+" WHERE id = $1", documentID)
+	s.Require().NoError(err)
+	_, err = s.repo.conn(s.ctx).Exec(s.ctx, "DELETE FROM "+tableUser+" WHERE id = $1", alice.ID)
+	s.NoError(err)
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+)
+
+// searchQuery matches documents the user owns, public documents and documents
+// shared with the login directly or through any of their parent folders. The
+// snippet is plain text, the markers of its hits are removed from the text
+// beforehand.
+const searchQuery = `WITH RECURSIVE shared AS (
+	SELECT folder_id AS id FROM ` + tableFolderGrant + ` WHERE grant_user_login = $2
+	UNION
+	SELECT f.id FROM ` + tableFolder + ` AS f JOIN shared AS s ON f.parent_id = s.id
+)
+SELECT d.id, d.user_id, d.name, d.mime, d.size, d.description, d.is_public, d.created_at, d.tags, d.metadata,
+	ts_rank(d.search_vector, q) AS rank,
+	ts_headline('simple', translate(concat_ws(' ', d.name, d.description, d.content_text), $6, ''), q, $7) AS snippet
+FROM ` + tableDocument + ` AS d, websearch_to_tsquery('simple', $3) AS q
+WHERE d.deleted_at IS NULL
+	AND d.search_vector @@ q
+	AND (
+		d.user_id = $1
+		OR d.is_public
+		OR EXISTS (SELECT 1 FROM ` + tableGrant + ` AS g WHERE g.document_id = d.id AND g.grant_user_login = $2)
+		OR d.folder_id IN (SELECT id FROM shared)
+	)
+ORDER BY rank DESC, d.created_at DESC, d.id
+LIMIT $4 OFFSET $5`
+
+const snippetOptions = "StartSel=" + domain.SnippetStart + ", StopSel=" + domain.SnippetStop +
+	", MaxFragments=2, MaxWords=20, MinWords=5"
+
+func (r *Repository) SearchDocuments(ctx context.Context, search *dto.Search) ([]domain.SearchResult, error) {
+	rows, err := r.conn(ctx).Query(ctx, searchQuery, search.UserID, search.Login, search.Query, search.Limit, search.Offset,
+		domain.SnippetStart+domain.SnippetStop, snippetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error search documents: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]domain.SearchResult, 0, search.Limit)
+	for rows.Next() {
+		var result domain.SearchResult
+		doc := &result.Document
+		err := rows.Scan(&doc.ID, &doc.UserID, &doc.Name, &doc.Mime, &doc.Size, &doc.Description, &doc.Public,
+			&doc.CreatedAt, &doc.Tags, &doc.Metadata, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
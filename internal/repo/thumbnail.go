@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *Repository) AddThumbnailJob(ctx context.Context, documentID uuid.UUID, version int) error {
+	return r.addJob(ctx, tableThumbnailJob, documentID, version)
+}
+
+// ClaimThumbnailJobs returns up to limit pending jobs due at now
+// and hides them from other callers until now+lease.
+func (r *Repository) ClaimThumbnailJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.ThumbnailJob, error) {
+	jobs, err := r.claimJobs(ctx, tableThumbnailJob, now, lease, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnailJobs := make([]domain.ThumbnailJob, 0, len(jobs))
+	for _, job := range jobs {
+		thumbnailJobs = append(thumbnailJobs, domain.ThumbnailJob{Job: job})
+	}
+
+	return thumbnailJobs, nil
+}
+
+func (r *Repository) UpdateThumbnailJob(ctx context.Context, job *domain.ThumbnailJob) error {
+	return r.updateJob(ctx, tableThumbnailJob, &job.Job, nil, ErrThumbnailJobNotFound)
+}
+
+// SaveRendition stores a rendition replacing the one of the same version and size.
+func (r *Repository) SaveRendition(ctx context.Context, rendition *domain.Rendition) error {
+	query, args, err := r.pg.Builder.Insert(tableRendition).
+		SetMap(map[string]any{
+			"document_id": rendition.DocumentID,
+			"version":     rendition.Version,
+			"size":        rendition.Size,
+			"mime":        rendition.Mime,
+			"width":       rendition.Width,
+			"height":      rendition.Height,
+			"data":        rendition.Data,
+			"created_at":  time.Now(),
+		}).
+		Suffix(`ON CONFLICT (document_id, version, size) DO UPDATE SET
+			mime = EXCLUDED.mime, width = EXCLUDED.width, height = EXCLUDED.height,
+			data = EXCLUDED.data, created_at = EXCLUDED.created_at`).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error save rendition: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetRendition(ctx context.Context, documentID uuid.UUID, version int, size string) (*domain.Rendition, error) {
+	query, args, err := r.pg.Builder.Select(
+		"document_id",
+		"version",
+		"size",
+		"mime",
+		"width",
+		"height",
+		"data",
+		"created_at",
+	).From(tableRendition).
+		Where(squirrel.Eq{"document_id": documentID}).
+		Where(squirrel.Eq{"version": version}).
+		Where(squirrel.Eq{"size": size}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	var rendition domain.Rendition
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(
+		&rendition.DocumentID,
+		&rendition.Version,
+		&rendition.Size,
+		&rendition.Mime,
+		&rendition.Width,
+		&rendition.Height,
+		&rendition.Data,
+		&rendition.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRenditionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get rendition: %w", err)
+	}
+
+	return &rendition, nil
+}
+
+// DeleteOutdatedRenditions removes the renditions of the versions before the given one.
+func (r *Repository) DeleteOutdatedRenditions(ctx context.Context, documentID uuid.UUID, version int) error {
+	query, args, err := r.pg.Builder.Delete(tableRendition).
+		Where(squirrel.Eq{"document_id": documentID}).
+		Where(squirrel.Lt{"version": version}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete renditions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,340 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// dispatchWebhookEventsQuery marks up to $1 outbox events dispatched at $2 and
+// creates a pending delivery for every webhook that subscribes to them: the
+// webhooks of the admin, of the owner of the document and of the user it was
+// shared with or unshared from.
+const dispatchWebhookEventsQuery = `WITH dispatched AS (
+	UPDATE ` + tableWebhookEvent + ` SET dispatched_at = $2
+	WHERE id IN (
+		SELECT id FROM ` + tableWebhookEvent + `
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, event, user_id, target_login
+), deliveries AS (
+	INSERT INTO ` + tableWebhookDelivery + ` (webhook_id, event_id, status, next_attempt_at, created_at)
+	SELECT w.id, e.id, '` + domain.DeliveryPending + `', $2, $2
+	FROM dispatched AS e JOIN ` + tableWebhook + ` AS w ON
+		(w.user_id IS NULL OR w.user_id = e.user_id
+			OR w.user_id IN (SELECT id FROM ` + tableUser + ` WHERE login = e.target_login AND e.target_login <> ''))
+		AND (cardinality(w.events) = 0 OR e.event = ANY(w.events))
+	ON CONFLICT DO NOTHING
+)
+SELECT count(*) FROM dispatched`
+
+// claimWebhookDeliveriesQuery leases due pending deliveries by moving their
+// next attempt forward, so other instances skip them while they are sent.
+const claimWebhookDeliveriesQuery = `UPDATE ` + tableWebhookDelivery + ` AS d SET next_attempt_at = $2
+FROM ` + tableWebhook + ` AS w, ` + tableWebhookEvent + ` AS e
+WHERE w.id = d.webhook_id AND e.id = d.event_id AND d.id IN (
+	SELECT id FROM ` + tableWebhookDelivery + `
+	WHERE status = '` + domain.DeliveryPending + `' AND next_attempt_at <= $1
+	ORDER BY next_attempt_at
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.webhook_id, d.event_id, e.event, w.url, w.secret, e.payload,
+	d.status, d.attempts, d.response_code, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+func (r *Repository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	query, args, err := r.pg.Builder.Insert(tableWebhook).
+		SetMap(map[string]any{
+			"user_id": nullUUID(webhook.UserID),
+			"url":     webhook.URL,
+			"secret":  webhook.Secret,
+			"events":  tagsOrEmpty(webhook.Events),
+		}).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	created := *webhook
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&created.ID, &created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error create webhook: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	query, args, err := r.webhooks().
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	webhook, err := scanWebhook(r.conn(ctx).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// GetWebhooks returns the webhooks of the user, every webhook when userID is uuid.Nil.
+func (r *Repository) GetWebhooks(ctx context.Context, userID uuid.UUID) ([]domain.Webhook, error) {
+	builder := r.webhooks().OrderBy("created_at")
+	if userID != uuid.Nil {
+		builder = builder.Where(squirrel.Eq{"user_id": userID})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get webhooks: %w", err)
+	}
+
+	defer rows.Close()
+
+	webhooks := make([]domain.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes the webhook with its delivery history.
+func (r *Repository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	query, args, err := r.pg.Builder.Delete(tableWebhook).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error delete webhook: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// AddWebhookEvent writes the event to the outbox. It is called in the
+// transaction of the change the event describes.
+func (r *Repository) AddWebhookEvent(ctx context.Context, event *domain.WebhookEvent) error {
+	query, args, err := r.pg.Builder.Insert(tableWebhookEvent).
+		SetMap(map[string]any{
+			"event":        event.Event,
+			"document_id":  event.DocumentID,
+			"user_id":      event.UserID,
+			"target_login": event.TargetLogin,
+			"payload":      event.Payload,
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error add webhook event: %w", err)
+	}
+
+	return nil
+}
+
+// DispatchWebhookEvents fans up to limit outbox events out to the webhooks
+// subscribed to them and returns how many events were dispatched.
+func (r *Repository) DispatchWebhookEvents(ctx context.Context, now time.Time, limit int) (int, error) {
+	var dispatched int
+	err := r.conn(ctx).QueryRow(ctx, dispatchWebhookEventsQuery, limit, now).Scan(&dispatched)
+	if err != nil {
+		return 0, fmt.Errorf("error dispatch webhook events: %w", err)
+	}
+
+	return dispatched, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries due at now
+// and hides them from other callers until now+lease.
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.conn(ctx).Query(ctx, claimWebhookDeliveriesQuery, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0, limit)
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventID,
+			&delivery.Event,
+			&delivery.URL,
+			&delivery.Secret,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseCode,
+			&delivery.Error,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *Repository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query, args, err := r.pg.Builder.Update(tableWebhookDelivery).
+		Set("status", delivery.Status).
+		Set("attempts", delivery.Attempts).
+		Set("response_code", delivery.ResponseCode).
+		Set("last_error", delivery.Error).
+		Set("next_attempt_at", delivery.NextAttemptAt).
+		Set("delivered_at", delivery.DeliveredAt).
+		Where(squirrel.Eq{"id": delivery.ID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error build query: %w", err)
+	}
+
+	commandTag, err := r.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error update webhook delivery: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of the webhook, the newest first.
+func (r *Repository) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]domain.WebhookDelivery, error) {
+	query, args, err := r.pg.Builder.Select(
+		"d.id",
+		"d.webhook_id",
+		"d.event_id",
+		"e.event",
+		"d.status",
+		"d.attempts",
+		"d.response_code",
+		"d.last_error",
+		"d.next_attempt_at",
+		"d.created_at",
+		"d.delivered_at",
+	).From(tableWebhookDelivery + " AS d").
+		Join(tableWebhookEvent + " AS e ON e.id = d.event_id").
+		Where(squirrel.Eq{"d.webhook_id": webhookID}).
+		OrderBy("d.id DESC").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error build query: %w", err)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventID,
+			&delivery.Event,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseCode,
+			&delivery.Error,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *Repository) webhooks() squirrel.SelectBuilder {
+	return r.pg.Builder.Select(
+		"id",
+		"user_id",
+		"url",
+		"secret",
+		"events",
+		"created_at",
+	).From(tableWebhook)
+}
+
+func scanWebhook(row pgx.Row) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.Events,
+		&webhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/documents/pkg/api/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type tokenKey struct{}
+
+// unaryInterceptor authenticates the call, keeps the client for the audit log
+// and maps the error of the handler to a status.
+func (s *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp, err := handler(ctx, req)
+	return resp, s.toStatus(info.FullMethod, err)
+}
+
+func (s *Server) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	err = handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	return s.toStatus(info.FullMethod, err)
+}
+
+// authenticate requires the admin token for Register and the token of a user
+// for everything but Authenticate. The token itself is checked by the service.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = context.WithValue(ctx, service.ClientKey, toClient(ctx, md))
+
+	switch method {
+	case pb.Documents_Authenticate_FullMethodName:
+		return ctx, nil
+	case pb.Documents_Register_FullMethodName:
+		if first(md, "admin_token") != s.admin {
+			return nil, errAdminUnauthorized
+		}
+		return ctx, nil
+	}
+
+	token := first(md, "token")
+	if token == "" {
+		return nil, errUnauthenticated
+	}
+
+	return context.WithValue(ctx, tokenKey{}, token), nil
+}
+
+func (s *Server) toStatus(method string, err error) error {
+	err = toStatus(err)
+	if status.Code(err) == codes.Internal {
+		s.l.WithField("method", method).WithError(err).Error("rpc - call failed")
+	}
+
+	return err
+}
+
+func tokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+func toClient(ctx context.Context, md metadata.MD) service.Client {
+	client := service.Client{UserAgent: first(md, "user-agent")}
+	if p, ok := peer.FromContext(ctx); ok {
+		client.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client.IP); err == nil {
+			client.IP = host
+		}
+	}
+
+	return client
+}
+
+func first(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// serverStream hands the authenticated context to a stream handler.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/internal/unpack"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUnauthenticated   = errors.New("token required")
+	errAdminUnauthorized = errors.New("admin unauthorized")
+	errMissingMeta       = errors.New("the first message of an upload must be the meta")
+	errUnexpectedMeta    = errors.New("meta after the content of an upload")
+	errInvalidMetaData   = errors.New("invalid meta")
+	errUploadTooLarge    = errors.New("upload too large")
+	errInvalidFolderID   = errors.New("invalid folder id")
+	errInvalidDocumentID = errors.New("invalid document id")
+)
+
+// toStatus turns an error of a handler into a gRPC status, the codes follow
+// the HTTP statuses of the REST API.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Error(errToCode(err), err.Error())
+}
+
+func errToCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, errUnauthenticated),
+		errors.Is(err, errAdminUnauthorized):
+		return codes.Unauthenticated
+	case errors.Is(err, errMissingMeta),
+		errors.Is(err, errUnexpectedMeta),
+		errors.Is(err, errInvalidMetaData),
+		errors.Is(err, errInvalidFolderID),
+		errors.Is(err, errInvalidDocumentID),
+		errors.Is(err, dto.ErrInvalidArchive),
+		errors.Is(err, dto.ErrTooManyDocuments),
+		errors.Is(err, service.ErrInvalidArchive),
+		errors.Is(err, unpack.ErrUnsupported),
+		errors.Is(err, unpack.ErrTooManyEntries),
+		errors.Is(err, unpack.ErrTooLarge),
+		errors.Is(err, dto.ErrInvalidKey),
+		errors.Is(err, dto.ErrEmptyValue),
+		errors.Is(err, dto.ErrInvalidSort),
+		errors.Is(err, dto.ErrInvalidOrder),
+		errors.Is(err, dto.ErrInvalidScope),
+		errors.Is(err, dto.ErrInvalidDateRange),
+		errors.Is(err, dto.ErrInvalidOutcome),
+		errors.Is(err, dto.ErrInvalidPeriod),
+		errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrInvalidThumbnailSize),
+		errors.Is(err, dto.ErrInvalidLimit),
+		errors.Is(err, dto.ErrEmptyQuery),
+		errors.Is(err, dto.ErrInvalidOffset),
+		errors.Is(err, service.ErrInvalidFolderName),
+		errors.Is(err, service.ErrFolderCycle),
+		errors.Is(err, dto.ErrEmptyName),
+		errors.Is(err, dto.ErrEmptyMime),
+		errors.Is(err, service.ErrInvalidRetentionPolicy),
+		errors.Is(err, service.ErrInvalidMetadata),
+		errors.Is(err, service.ErrInvalidIdempotencyKey),
+		errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrInvalidWebhookEvent),
+		errors.Is(err, service.ErrInvalidPermission),
+		errors.Is(err, service.ErrInvalidLockTTL),
+		errors.Is(err, dto.ErrEmptyComment),
+		errors.Is(err, dto.ErrCommentTooLong),
+		errors.Is(err, dto.ErrInvalidPage),
+		errors.Is(err, dto.ErrInvalidRange),
+		errors.Is(err, service.ErrInvalidLastEventID),
+		errors.Is(err, service.ErrUserLoginIncorected),
+		errors.Is(err, service.ErrUserPasswordIncorected),
+		errors.Is(err, service.ErrUserIsNil):
+		return codes.InvalidArgument
+	case errors.Is(err, errUploadTooLarge):
+		return codes.ResourceExhausted
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrDocumentNotFound),
+		errors.Is(err, service.ErrDocumentsNotFound),
+		errors.Is(err, service.ErrTokenNotFound),
+		errors.Is(err, service.ErrRetentionPolicyNotFound),
+		errors.Is(err, service.ErrFolderNotFound),
+		errors.Is(err, service.ErrGrantNotFound),
+		errors.Is(err, service.ErrTextNotFound),
+		errors.Is(err, service.ErrThumbnailNotFound),
+		errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrCommentNotFound),
+		errors.Is(err, service.ErrLockNotFound):
+		return codes.NotFound
+	case errors.Is(err, service.ErrNoAccess),
+		errors.Is(err, service.ErrUserDisabled):
+		return codes.PermissionDenied
+	case errors.Is(err, service.ErrUserExists),
+		errors.Is(err, service.ErrFolderExists):
+		return codes.AlreadyExists
+	case errors.Is(err, service.ErrLegalHold),
+		errors.Is(err, service.ErrRetentionPolicy),
+		errors.Is(err, service.ErrFolderNotEmpty),
+		errors.Is(err, service.ErrLocked),
+		errors.Is(err, service.ErrPreconditionFailed):
+		return codes.FailedPrecondition
+	case errors.Is(err, service.ErrIdempotencyKeyInUse):
+		return codes.Aborted
+	default:
+		return codes.Internal
+	}
+}
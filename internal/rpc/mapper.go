@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/pkg/api/pb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toUploadDocument(token string, meta *pb.UploadMeta, content []byte) (*dto.Document, error) {
+	if meta.GetName() == "" || meta.GetMime() == "" {
+		return nil, errInvalidMetaData
+	}
+
+	folderID := uuid.Nil
+	if meta.GetFolderId() != "" {
+		id, err := uuid.Parse(meta.GetFolderId())
+		if err != nil {
+			return nil, errInvalidFolderID
+		}
+		folderID = id
+	}
+
+	return &dto.Document{
+		Name:           meta.GetName(),
+		Token:          token,
+		IdempotencyKey: meta.GetIdempotencyKey(),
+		Mime:           meta.GetMime(),
+		Description:    meta.GetDescription(),
+		Content:        content,
+		Grant:          meta.GetGrant(),
+		Tags:           meta.GetTags(),
+		Metadata:       toMetadata(meta.GetMetadata()),
+		FolderID:       folderID,
+		Public:         meta.GetPublic(),
+	}, nil
+}
+
+func toGetDocumentsRequest(token string, req *pb.ListDocumentsRequest) (*dto.GetDocumentsRequest, error) {
+	filter := &dto.GetDocumentsRequest{
+		Token:        token,
+		Scope:        req.GetScope(),
+		Login:        req.GetLogin(),
+		Key:          req.GetKey(),
+		Value:        req.GetValue(),
+		Tags:         req.GetTags(),
+		Metadata:     toMetadata(req.GetMetadata()),
+		NamePrefix:   req.GetNamePrefix(),
+		NameContains: req.GetNameContains(),
+		Mime:         req.GetMime(),
+		Public:       req.Public,
+		Owner:        req.GetOwner(),
+		Sort:         req.GetSort(),
+		Order:        req.GetOrder(),
+		Cursor:       req.GetCursor(),
+		Limit:        int(req.GetLimit()),
+	}
+
+	if req.CreatedFrom != nil {
+		createdFrom := req.GetCreatedFrom().AsTime()
+		filter.CreatedFrom = &createdFrom
+	}
+	if req.CreatedTo != nil {
+		createdTo := req.GetCreatedTo().AsTime()
+		filter.CreatedTo = &createdTo
+	}
+
+	return filter, filter.IsValid()
+}
+
+func toMetadata(metadata *structpb.Struct) map[string]any {
+	if metadata == nil {
+		return nil
+	}
+	return metadata.AsMap()
+}
+
+func toListDocumentsResponse(page *dto.DocumentsPage) *pb.ListDocumentsResponse {
+	resp := &pb.ListDocumentsResponse{
+		Documents:  make([]*pb.Document, 0, len(page.Documents)),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Documents {
+		resp.Documents = append(resp.Documents, toDocument(&page.Documents[i]))
+	}
+
+	return resp
+}
+
+func toDocument(document *domain.Document) *pb.Document {
+	resp := &pb.Document{
+		Id:          document.ID.String(),
+		Name:        document.Name,
+		Mime:        document.Mime,
+		Description: document.Description,
+		Size:        document.Size,
+		Checksum:    document.Checksum,
+		Grant:       document.Grant,
+		Tags:        document.Tags,
+		Revision:    int32(document.Revision),
+		Version:     int32(document.Version),
+		CreatedAt:   timestamppb.New(document.CreatedAt),
+		Public:      document.Public,
+		Permission:  document.Permission,
+	}
+
+	if document.FolderID != uuid.Nil {
+		resp.FolderId = document.FolderID.String()
+	}
+	if !document.UpdatedAt.IsZero() {
+		resp.UpdatedAt = timestamppb.New(document.UpdatedAt)
+	}
+	// metadata that does not fit a Struct is left out rather than failing the call
+	if metadata, err := structpb.NewStruct(document.Metadata); err == nil && len(document.Metadata) > 0 {
+		resp.Metadata = metadata
+	}
+
+	return resp
+}
@@ -0,0 +1,191 @@
+// Package rpc serves the document operations of the REST API over gRPC for
+// internal services.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/pkg/api/pb"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+// chunkSize is the size of the content in one message of a download.
+const chunkSize = 64 << 10
+
+type Server struct {
+	pb.UnimplementedDocumentsServer
+
+	service   Service
+	l         *logger.Logger
+	admin     string
+	maxUpload int64
+}
+
+// NewServer returns a gRPC server with the documents service registered.
+func NewServer(l *logger.Logger, t Service, cfg *config.Config) *grpc.Server {
+	s := &Server{
+		service:   t,
+		l:         l,
+		admin:     cfg.AdminToken,
+		maxUpload: cfg.GRPC.MaxUploadSize,
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+	pb.RegisterDocumentsServer(server, s)
+
+	return server
+}
+
+func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	login, err := s.service.Registration(ctx, &domain.User{
+		Login:    req.GetLogin(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RegisterResponse{Login: login}, nil
+}
+
+func (s *Server) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	token, err := s.service.Authentication(ctx, &domain.User{
+		Login:    req.GetLogin(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AuthenticateResponse{Token: token}, nil
+}
+
+func (s *Server) LogOut(ctx context.Context, _ *pb.LogOutRequest) (*pb.LogOutResponse, error) {
+	token := tokenFromContext(ctx)
+	err := s.service.LogOut(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LogOutResponse{Token: token}, nil
+}
+
+// Upload reads the whole content before storing the document, as the REST
+// API does with the multipart form.
+func (s *Server) Upload(stream pb.Documents_UploadServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	meta := req.GetMeta()
+	if meta == nil {
+		return errMissingMeta
+	}
+
+	var content bytes.Buffer
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetMeta() != nil {
+			return errUnexpectedMeta
+		}
+
+		if int64(content.Len()+len(req.GetChunk())) > s.maxUpload {
+			return errUploadTooLarge
+		}
+		content.Write(req.GetChunk())
+	}
+
+	ctx := stream.Context()
+	document, err := toUploadDocument(tokenFromContext(ctx), meta, content.Bytes())
+	if err != nil {
+		return err
+	}
+
+	uploaded, err := s.service.Upload(ctx, document)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(toDocument(uploaded))
+}
+
+// Download sends the document and then its content in chunks.
+func (s *Server) Download(req *pb.DownloadRequest, stream pb.Documents_DownloadServer) error {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return errInvalidDocumentID
+	}
+
+	ctx := stream.Context()
+	document, err := s.service.GetDocument(ctx, id, tokenFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	content, err := base64.StdEncoding.DecodeString(document.Content)
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&pb.DownloadResponse{Data: &pb.DownloadResponse_Document{Document: toDocument(document)}})
+	if err != nil {
+		return err
+	}
+
+	for len(content) > 0 {
+		chunk := content[:min(chunkSize, len(content))]
+		content = content[len(chunk):]
+
+		err = stream.Send(&pb.DownloadResponse{Data: &pb.DownloadResponse_Chunk{Chunk: chunk}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) ListDocuments(ctx context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+	filter, err := toGetDocumentsRequest(tokenFromContext(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.service.GetDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return toListDocumentsResponse(page), nil
+}
+
+func (s *Server) DeleteDocument(ctx context.Context, req *pb.DeleteDocumentRequest) (*pb.DeleteDocumentResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidDocumentID
+	}
+
+	id, err = s.service.DeleteDocument(ctx, id, tokenFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteDocumentResponse{Id: id.String()}, nil
+}
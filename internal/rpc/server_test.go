@@ -0,0 +1,270 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/pkg/api/pb"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testToken = "CxBiwVruDAD8kp8jgeOY"
+
+// fakeService keeps the documents of one user in memory.
+type fakeService struct {
+	documents map[uuid.UUID]*domain.Document
+	client    service.Client
+}
+
+func (f *fakeService) Registration(_ context.Context, user *domain.User) (string, error) {
+	return user.Login, nil
+}
+
+func (f *fakeService) Authentication(ctx context.Context, user *domain.User) (string, error) {
+	f.client, _ = ctx.Value(service.ClientKey).(service.Client)
+	if user.Password != "Passw_345" {
+		return "", service.ErrUserNotFound
+	}
+	return testToken, nil
+}
+
+func (f *fakeService) LogOut(_ context.Context, token string) error {
+	if token != testToken {
+		return service.ErrTokenNotFound
+	}
+	return nil
+}
+
+func (f *fakeService) Upload(_ context.Context, document *dto.Document) (*domain.Document, error) {
+	if document.Token != testToken {
+		return nil, service.ErrUserNotFound
+	}
+	id := uuid.New()
+	f.documents[id] = &domain.Document{
+		ID:       id,
+		Name:     document.Name,
+		Mime:     document.Mime,
+		Size:     int64(len(document.Content)),
+		Content:  base64.StdEncoding.EncodeToString(document.Content),
+		Metadata: document.Metadata,
+	}
+	return &domain.Document{ID: id, Name: document.Name, Mime: document.Mime, Metadata: document.Metadata}, nil
+}
+
+func (f *fakeService) GetDocument(_ context.Context, id uuid.UUID, _ string) (*domain.Document, error) {
+	document, ok := f.documents[id]
+	if !ok {
+		return nil, service.ErrDocumentNotFound
+	}
+	return document, nil
+}
+
+func (f *fakeService) GetDocuments(_ context.Context, filter *dto.GetDocumentsRequest) (*dto.DocumentsPage, error) {
+	page := &dto.DocumentsPage{}
+	for _, document := range f.documents {
+		if filter.Mime == "" || filter.Mime == document.Mime {
+			page.Documents = append(page.Documents, *document)
+		}
+	}
+	return page, nil
+}
+
+func (f *fakeService) DeleteDocument(_ context.Context, id uuid.UUID, _ string) (uuid.UUID, error) {
+	if _, ok := f.documents[id]; !ok {
+		return uuid.Nil, service.ErrNoAccess
+	}
+	delete(f.documents, id)
+	return id, nil
+}
+
+func newTestClient(t *testing.T, s Service) pb.DocumentsClient {
+	t.Helper()
+	cfg := &config.Config{
+		AdminToken: "admin_token",
+		GRPC:       config.GRPC{MaxUploadSize: 1 << 20},
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(logger.New("error"), s, cfg)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUserAgent("docs-test"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return pb.NewDocumentsClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "token", token)
+}
+
+func upload(t *testing.T, client pb.DocumentsClient, ctx context.Context, meta *pb.UploadMeta, content []byte) (*pb.Document, error) {
+	t.Helper()
+	stream, err := client.Upload(ctx)
+	require.NoError(t, err)
+
+	err = stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Meta{Meta: meta}})
+	require.NoError(t, err)
+	for len(content) > 0 {
+		chunk := content[:min(1000, len(content))]
+		content = content[len(chunk):]
+		if stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Chunk{Chunk: chunk}}) != nil {
+			break
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+func download(client pb.DocumentsClient, ctx context.Context, id string) (*pb.Document, []byte, error) {
+	stream, err := client.Download(ctx, &pb.DownloadRequest{Id: id})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var document *pb.Document
+	var content bytes.Buffer
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return document, content.Bytes(), nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp.GetDocument() != nil {
+			document = resp.GetDocument()
+		}
+		content.Write(resp.GetChunk())
+	}
+}
+
+func Test_Authentication(t *testing.T) {
+	s := &fakeService{documents: make(map[uuid.UUID]*domain.Document)}
+	client := newTestClient(t, s)
+	ctx := context.Background()
+
+	resp, err := client.Authenticate(ctx, &pb.AuthenticateRequest{Login: "login345", Password: "Passw_345"})
+	require.NoError(t, err)
+	assert.Equal(t, testToken, resp.GetToken())
+	assert.Contains(t, s.client.UserAgent, "docs-test")
+	assert.NotEmpty(t, s.client.IP)
+
+	_, err = client.Authenticate(ctx, &pb.AuthenticateRequest{Login: "login345", Password: "wrong"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Register(ctx, &pb.RegisterRequest{Login: "login346", Password: "Passw_346"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	adminCtx := metadata.AppendToOutgoingContext(ctx, "admin_token", "admin_token")
+	registered, err := client.Register(adminCtx, &pb.RegisterRequest{Login: "login346", Password: "Passw_346"})
+	require.NoError(t, err)
+	assert.Equal(t, "login346", registered.GetLogin())
+
+	_, err = client.LogOut(ctx, &pb.LogOutRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.LogOut(withToken("unknown"), &pb.LogOutRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	logout, err := client.LogOut(withToken(testToken), &pb.LogOutRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, testToken, logout.GetToken())
+
+	stream, err := client.Download(ctx, &pb.DownloadRequest{Id: uuid.NewString()})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func Test_UploadDownload(t *testing.T) {
+	s := &fakeService{documents: make(map[uuid.UUID]*domain.Document)}
+	client := newTestClient(t, s)
+	ctx := withToken(testToken)
+
+	content := []byte(strings.Repeat("0123456789", 10000))
+	meta := &pb.UploadMeta{Name: "report.txt", Mime: "text/plain"}
+	uploaded, err := upload(t, client, ctx, meta, content)
+	require.NoError(t, err)
+	assert.Equal(t, "report.txt", uploaded.GetName())
+
+	document, downloaded, err := download(client, ctx, uploaded.GetId())
+	require.NoError(t, err)
+	assert.Equal(t, uploaded.GetId(), document.GetId())
+	assert.Equal(t, int64(len(content)), document.GetSize())
+	assert.Equal(t, content, downloaded)
+
+	_, err = upload(t, client, ctx, &pb.UploadMeta{Name: "report.txt"}, content)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = upload(t, client, ctx, meta, make([]byte, 2<<20))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, _, err = download(client, ctx, uuid.NewString())
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, _, err = download(client, ctx, "not-an-id")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_ListDelete(t *testing.T) {
+	s := &fakeService{documents: make(map[uuid.UUID]*domain.Document)}
+	client := newTestClient(t, s)
+	ctx := withToken(testToken)
+
+	_, err := upload(t, client, ctx, &pb.UploadMeta{Name: "a.txt", Mime: "text/plain"}, []byte("a"))
+	require.NoError(t, err)
+	pdf, err := upload(t, client, ctx, &pb.UploadMeta{Name: "b.pdf", Mime: "application/pdf"}, []byte("b"))
+	require.NoError(t, err)
+
+	list, err := client.ListDocuments(ctx, &pb.ListDocumentsRequest{Mime: "application/pdf", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list.GetDocuments(), 1)
+	assert.Equal(t, pdf.GetId(), list.GetDocuments()[0].GetId())
+
+	_, err = client.ListDocuments(ctx, &pb.ListDocumentsRequest{Scope: "unknown", Limit: 10})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	deleted, err := client.DeleteDocument(ctx, &pb.DeleteDocumentRequest{Id: pdf.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, pdf.GetId(), deleted.GetId())
+
+	_, err = client.DeleteDocument(ctx, &pb.DeleteDocumentRequest{Id: pdf.GetId()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func Test_errToCode(t *testing.T) {
+	assert.Equal(t, codes.InvalidArgument, errToCode(dto.ErrInvalidLimit))
+	assert.Equal(t, codes.NotFound, errToCode(service.ErrDocumentNotFound))
+	assert.Equal(t, codes.PermissionDenied, errToCode(service.ErrNoAccess))
+	assert.Equal(t, codes.FailedPrecondition, errToCode(service.ErrLocked))
+	assert.Equal(t, codes.AlreadyExists, errToCode(service.ErrFolderExists))
+	assert.Equal(t, codes.Internal, errToCode(errors.New("unexpected")))
+}
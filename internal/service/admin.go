@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/google/uuid"
+)
+
+// defaultChecksumBatch is how many documents are read at once when their
+// checksums are verified.
+const defaultChecksumBatch = 100
+
+// DisableUser stops the user from logging in and revokes their tokens. The
+// tokens cached by a running service keep working until the cache expires.
+func (s *Service) DisableUser(ctx context.Context, login string) (err error) {
+	l := s.log.WithField("service_method", "DisableUser")
+
+	event := &domain.AuditEvent{Action: domain.AuditUserDisable, TargetLogin: login}
+	defer func() { s.audit(ctx, event, err) }()
+
+	now := time.Now()
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		userID, err := s.repo.SetUserDisabled(ctx, login, &now)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.repo.DeleteUserTokens(ctx, userID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		l.WithError(err).Error("error disable user")
+		return err
+	}
+
+	return nil
+}
+
+// EnableUser lets a disabled user log in again.
+func (s *Service) EnableUser(ctx context.Context, login string) (err error) {
+	l := s.log.WithField("service_method", "EnableUser")
+
+	event := &domain.AuditEvent{Action: domain.AuditUserEnable, TargetLogin: login}
+	defer func() { s.audit(ctx, event, err) }()
+
+	_, err = s.repo.SetUserDisabled(ctx, login, nil)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		l.WithError(err).Error("error enable user")
+		return err
+	}
+
+	return nil
+}
+
+// RehashPasswords replaces the passwords stored before the passwords were
+// hashed with their hashes and returns how many were replaced. A password
+// changed meanwhile is left alone.
+func (s *Service) RehashPasswords(ctx context.Context) (int, error) {
+	l := s.log.WithField("service_method", "RehashPasswords")
+
+	users, err := s.repo.GetPlainPasswordUsers(ctx)
+	if err != nil {
+		l.WithError(err).Error("error get users")
+		return 0, err
+	}
+
+	rehashed := 0
+	for _, user := range users {
+		hash, err := hashPassword(user.Password)
+		if err != nil {
+			l.WithError(err).Error("error hash password")
+			return rehashed, err
+		}
+
+		replaced, err := s.repo.ReplacePassword(ctx, user.ID, user.Password, hash)
+		if err != nil {
+			l.WithError(err).Error("error replace password")
+			return rehashed, err
+		}
+		if replaced {
+			rehashed++
+		}
+	}
+
+	return rehashed, nil
+}
+
+// PurgeTokens deletes the expired tokens and event tickets and returns how many
+// tokens were deleted.
+func (s *Service) PurgeTokens(ctx context.Context) (int64, error) {
+	l := s.log.WithField("service_method", "PurgeTokens")
+
+	now := time.Now()
+	purged, err := s.repo.DeleteExpiredTokens(ctx, now)
+	if err != nil {
+		l.WithError(err).Error("error delete tokens")
+		return 0, err
+	}
+
+	_, err = s.repo.DeleteExpiredEventTickets(ctx, now)
+	if err != nil {
+		l.WithError(err).Error("error delete event tickets")
+		return purged, err
+	}
+
+	return purged, nil
+}
+
+// GetStorageUsage counts the storage usage of the users, the largest first.
+func (s *Service) GetStorageUsage(ctx context.Context) ([]domain.StorageUsage, error) {
+	l := s.log.WithField("service_method", "GetStorageUsage")
+
+	usage, err := s.repo.GetStorageUsage(ctx)
+	if err != nil {
+		l.WithError(err).Error("error get storage usage")
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// VerifyChecksums compares the content of every document, those in the trash
+// too, with its checksum. The content is read batchSize documents at a time.
+// A content that can not be decoded is reported with an empty Actual.
+func (s *Service) VerifyChecksums(ctx context.Context, batchSize int) (*domain.ChecksumReport, error) {
+	l := s.log.WithField("service_method", "VerifyChecksums")
+
+	if batchSize <= 0 {
+		batchSize = defaultChecksumBatch
+	}
+
+	report := &domain.ChecksumReport{Mismatches: make([]domain.ChecksumMismatch, 0)}
+	after := uuid.Nil
+	for {
+		documents, err := s.repo.GetDocumentContents(ctx, after, batchSize)
+		if err != nil {
+			l.WithError(err).Error("error get documents")
+			return nil, err
+		}
+
+		for _, document := range documents {
+			actual := ""
+			content, err := base64.StdEncoding.DecodeString(document.Content)
+			if err == nil {
+				actual = checksum(content)
+			}
+
+			report.Checked++
+			if actual != document.Checksum {
+				report.Mismatches = append(report.Mismatches, domain.ChecksumMismatch{
+					DocumentID: document.ID,
+					UserID:     document.UserID,
+					Name:       document.Name,
+					Expected:   document.Checksum,
+					Actual:     actual,
+				})
+			}
+		}
+
+		if len(documents) < batchSize {
+			return report, nil
+		}
+		after = documents[len(documents)-1].ID
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (s *ServiceSuite) Test_DisableUser() {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name  string
+		err   error
+		calls func()
+	}{
+		{
+			name: "tokens are revoked",
+			calls: func() {
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().SetUserDisabled(ctx, "login345", gomock.Not(gomock.Nil())).Return(userID, nil)
+				s.repo.EXPECT().DeleteUserTokens(ctx, userID).Return(int64(2), nil)
+			},
+		},
+		{
+			name: "unknown login",
+			err:  ErrUserNotFound,
+			calls: func() {
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().SetUserDisabled(ctx, "login345", gomock.Any()).Return(uuid.Nil, repo.ErrUserNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			err := s.service.DisableUser(ctx, "login345")
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_EnableUser() {
+	ctx := context.Background()
+
+	s.repo.EXPECT().SetUserDisabled(ctx, "login345", (*time.Time)(nil)).Return(uuid.New(), nil)
+	s.NoError(s.service.EnableUser(ctx, "login345"))
+
+	s.repo.EXPECT().SetUserDisabled(ctx, "login346", (*time.Time)(nil)).Return(uuid.Nil, repo.ErrUserNotFound)
+	s.Equal(ErrUserNotFound, s.service.EnableUser(ctx, "login346"))
+}
+
+func (s *ServiceSuite) Test_AuthenticationTokenTTL() {
+	ctx := context.Background()
+	service, err := New(s.repo, s.cache, logger.New(""), &config.Config{Token: config.Token{TTL: time.Hour}})
+	s.Require().NoError(err)
+	hash, err := bcrypt.GenerateFromPassword([]byte("Passw_345"), bcrypt.MinCost)
+	s.Require().NoError(err)
+
+	s.repo.EXPECT().GetUserByLogin(ctx, "login345").Return(&domain.User{ID: uuid.New(), Password: string(hash)}, nil)
+	s.repo.EXPECT().Authentication(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, user *domain.User) error {
+			s.Require().NotNil(user.TokenExpiresAt)
+			s.WithinDuration(time.Now().Add(time.Hour), *user.TokenExpiresAt, time.Minute)
+			return nil
+		},
+	)
+
+	_, err = service.Authentication(ctx, &domain.User{Login: "login345", Password: "Passw_345"})
+	s.NoError(err)
+}
+
+func (s *ServiceSuite) Test_RehashPasswords() {
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	s.repo.EXPECT().GetPlainPasswordUsers(ctx).Return([]domain.User{
+		{ID: first, Login: "login345", Password: "Passw_345"},
+		{ID: second, Login: "login346", Password: "Passw_346"},
+	}, nil)
+	s.repo.EXPECT().ReplacePassword(ctx, first, "Passw_345", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID, old, hash string) (bool, error) {
+			s.True(verifyPassword(hash, old))
+			return true, nil
+		},
+	)
+	// the password was changed after it was read
+	s.repo.EXPECT().ReplacePassword(ctx, second, "Passw_346", gomock.Any()).Return(false, nil)
+
+	rehashed, err := s.service.RehashPasswords(ctx)
+	s.NoError(err)
+	s.Equal(1, rehashed)
+}
+
+func (s *ServiceSuite) Test_PurgeTokens() {
+	ctx := context.Background()
+
+	s.repo.EXPECT().DeleteExpiredTokens(ctx, gomock.Any()).Return(int64(3), nil)
+	s.repo.EXPECT().DeleteExpiredEventTickets(ctx, gomock.Any()).Return(int64(5), nil)
+	purged, err := s.service.PurgeTokens(ctx)
+	s.NoError(err)
+	s.Equal(int64(3), purged)
+
+	s.repo.EXPECT().DeleteExpiredTokens(ctx, gomock.Any()).Return(int64(0), errors.ErrUnsupported)
+	_, err = s.service.PurgeTokens(ctx)
+	s.Equal(errors.ErrUnsupported, err)
+}
+
+func (s *ServiceSuite) Test_GetStorageUsage() {
+	ctx := context.Background()
+	usage := []domain.StorageUsage{{UserID: uuid.New(), Login: "login345", Documents: 2, Bytes: 10}}
+
+	s.repo.EXPECT().GetStorageUsage(ctx).Return(usage, nil)
+
+	got, err := s.service.GetStorageUsage(ctx)
+	s.NoError(err)
+	s.Equal(usage, got)
+}
+
+func (s *ServiceSuite) Test_VerifyChecksums() {
+	ctx := context.Background()
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	content := base64.StdEncoding.EncodeToString([]byte("content"))
+
+	s.repo.EXPECT().GetDocumentContents(ctx, uuid.Nil, 2).Return([]domain.Document{
+		{ID: ids[0], Name: "a.txt", Content: content, Checksum: checksum([]byte("content"))},
+		{ID: ids[1], Name: "b.txt", Content: content, Checksum: checksum([]byte("changed"))},
+	}, nil)
+	s.repo.EXPECT().GetDocumentContents(ctx, ids[1], 2).Return([]domain.Document{
+		{ID: ids[2], Name: "c.txt", Content: "not base64", Checksum: checksum([]byte("content"))},
+	}, nil)
+
+	report, err := s.service.VerifyChecksums(ctx, 2)
+	s.NoError(err)
+	s.Equal(int64(3), report.Checked)
+	s.Equal([]domain.ChecksumMismatch{
+		{DocumentID: ids[1], Name: "b.txt", Expected: checksum([]byte("changed")), Actual: checksum([]byte("content"))},
+		{DocumentID: ids[2], Name: "c.txt", Expected: checksum([]byte("content"))},
+	}, report.Mismatches)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
+)
+
+const archiveManifest = "manifest.json"
+
+// ResolveArchive returns the IDs of the documents selected for an archive in
+// the order they are written. Access to every document is checked while the
+// archive is written.
+func (s *Service) ResolveArchive(ctx context.Context, req *dto.ArchiveRequest) ([]uuid.UUID, error) {
+	l := s.log.WithField("service_method", "ResolveArchive")
+
+	err := req.IsValid()
+	if err != nil {
+		l.Warn(err.Error())
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	switch {
+	case len(req.IDs) > 0:
+		ids = req.IDs
+	case req.FolderID != uuid.Nil:
+		ids, err = s.getFolderDocumentIDs(ctx, req.FolderID, req.Token)
+		if err != nil {
+			l.WithError(err).Warn("error get folder documents")
+			return nil, err
+		}
+	default:
+		ids, err = s.getFilteredDocumentIDs(ctx, req.Filter, req.Token)
+		if err != nil {
+			l.WithError(err).Warn("error get documents")
+			return nil, err
+		}
+	}
+
+	if len(ids) > dto.MaxArchiveDocuments {
+		return nil, dto.ErrTooManyDocuments
+	}
+
+	return uniqueIDs(ids), nil
+}
+
+func (s *Service) getFolderDocumentIDs(ctx context.Context, folderID uuid.UUID, token string) ([]uuid.UUID, error) {
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	folder, err := s.repo.GetFolder(ctx, folderID)
+	if err != nil {
+		return nil, ErrFolderNotFound
+	}
+
+	err = s.checkFolderAccess(ctx, userID, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetFolderDocumentIDs(ctx, folderID)
+}
+
+func (s *Service) getFilteredDocumentIDs(ctx context.Context, filter *dto.GetDocumentsRequest, token string) ([]uuid.UUID, error) {
+	query := *filter
+	query.Token = token
+	query.Cursor = ""
+	query.Limit = dto.MaxArchiveDocuments
+
+	err := query.IsValid()
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.GetDocuments(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	if page.NextCursor != "" {
+		return nil, dto.ErrTooManyDocuments
+	}
+
+	ids := make([]uuid.UUID, 0, len(page.Documents))
+	for _, document := range page.Documents {
+		ids = append(ids, document.ID)
+	}
+
+	return ids, nil
+}
+
+// WriteArchive streams a ZIP with the documents the caller can read followed
+// by manifest.json listing the included and the skipped IDs. Documents are
+// checked and loaded one by one, so the archive is never held in memory.
+func (s *Service) WriteArchive(ctx context.Context, token string, ids []uuid.UUID, w io.Writer) (*dto.ArchiveManifest, error) {
+	l := s.log.WithField("service_method", "WriteArchive")
+
+	archive := zip.NewWriter(w)
+	manifest := &dto.ArchiveManifest{
+		Included: make([]dto.ArchiveEntry, 0, len(ids)),
+		Skipped:  make([]dto.ArchiveSkipped, 0),
+	}
+	names := map[string]bool{archiveManifest: true}
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return manifest, err
+		}
+
+		document, err := s.GetDocument(ctx, id, token)
+		if err != nil {
+			manifest.Skipped = append(manifest.Skipped, dto.ArchiveSkipped{ID: id, Reason: err.Error()})
+			continue
+		}
+
+		content, err := base64.StdEncoding.DecodeString(document.Content)
+		if err != nil {
+			l.WithError(err).WithField("document_id", id).Error("error decode content")
+			manifest.Skipped = append(manifest.Skipped, dto.ArchiveSkipped{ID: id, Reason: "content is damaged"})
+			continue
+		}
+
+		name := uniqueArchiveName(names, archiveName(document.Name, id))
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: document.CreatedAt,
+		})
+		if err != nil {
+			return manifest, err
+		}
+
+		_, err = file.Write(content)
+		if err != nil {
+			return manifest, err
+		}
+
+		manifest.Included = append(manifest.Included, dto.ArchiveEntry{ID: id, Name: name, Size: int64(len(content))})
+	}
+
+	file, err := archive.Create(archiveManifest)
+	if err != nil {
+		return manifest, err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return manifest, err
+	}
+
+	return manifest, archive.Close()
+}
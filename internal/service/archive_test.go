@@ -0,0 +1,158 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_ResolveArchive() {
+	ctx := context.Background()
+	userID := uuid.New()
+	folderID := uuid.New()
+	id1, id2 := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name  string
+		req   *dto.ArchiveRequest
+		want  []uuid.UUID
+		err   error
+		calls func()
+	}{
+		{
+			name:  "duplicate ids",
+			req:   &dto.ArchiveRequest{Token: "token", IDs: []uuid.UUID{id1, id2, id1}},
+			want:  []uuid.UUID{id1, id2},
+			calls: func() {},
+		},
+		{
+			name:  "ids and folder",
+			req:   &dto.ArchiveRequest{Token: "token", IDs: []uuid.UUID{id1}, FolderID: folderID},
+			err:   dto.ErrInvalidArchive,
+			calls: func() {},
+		},
+		{
+			name: "folder",
+			req:  &dto.ArchiveRequest{Token: "token", FolderID: folderID},
+			want: []uuid.UUID{id1, id2},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: userID}, nil)
+				s.repo.EXPECT().GetFolderDocumentIDs(ctx, folderID).Return([]uuid.UUID{id1, id2}, nil)
+			},
+		},
+		{
+			name: "folder of another user",
+			req:  &dto.ArchiveRequest{Token: "token", FolderID: folderID},
+			err:  ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: uuid.New()}, nil)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "login"}, true)
+				s.repo.EXPECT().CheckFolderGrant(ctx, folderID, "login").Return(false, nil)
+			},
+		},
+		{
+			name: "filter",
+			req:  &dto.ArchiveRequest{Token: "token", Filter: &dto.GetDocumentsRequest{Scope: dto.ScopeOwned, Mime: "image/*"}},
+			want: []uuid.UUID{id1},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "login"}, true)
+				s.repo.EXPECT().GetDocuments(ctx, &dto.GetDocuments{
+					UserID:    userID,
+					UserLogin: "login",
+					Scope:     dto.ScopeOwned,
+					Mime:      "image/*",
+					Sort:      dto.SortName,
+					Order:     dto.OrderAsc,
+					Limit:     dto.MaxArchiveDocuments + 1,
+				}).Return([]domain.Document{{ID: id1, UserID: userID}}, nil)
+			},
+		},
+		{
+			name: "filter matches too many documents",
+			req:  &dto.ArchiveRequest{Token: "token", Filter: &dto.GetDocumentsRequest{}},
+			err:  dto.ErrTooManyDocuments,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "login"}, true)
+				s.repo.EXPECT().GetDocuments(ctx, gomock.Any()).Return(make([]domain.Document, dto.MaxArchiveDocuments+1), nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.ResolveArchive(ctx, tt.req)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_WriteArchive() {
+	ctx := context.Background()
+	userID := uuid.New()
+	own1 := &domain.Document{ID: uuid.New(), UserID: userID, Name: "report.pdf", CreatedAt: time.Now(),
+		Content: base64.StdEncoding.EncodeToString([]byte("first"))}
+	own2 := &domain.Document{ID: uuid.New(), UserID: userID, Name: "Report.pdf", CreatedAt: time.Now(),
+		Content: base64.StdEncoding.EncodeToString([]byte("second"))}
+	public := &domain.Document{ID: uuid.New(), UserID: uuid.New(), Name: "manifest.json", Public: true,
+		Content: base64.StdEncoding.EncodeToString([]byte("third"))}
+	private := &domain.Document{ID: uuid.New(), UserID: uuid.New(), Name: "secret.txt"}
+	missing := uuid.New()
+
+	for _, document := range []*domain.Document{own1, own2, public, private} {
+		s.cache.EXPECT().Get(prepareGetDocumentKey(document.ID)).Return(document, true)
+	}
+	s.cache.EXPECT().Get(prepareGetDocumentKey(missing)).Return(nil, false)
+	s.repo.EXPECT().GetDocument(ctx, missing).Return(nil, ErrDocumentNotFound)
+	s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true).AnyTimes()
+	// the grant of the private document is checked and the reader of the
+	// public one is recorded
+	s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "login"}, true).Times(2)
+	s.cache.EXPECT().Get(prepareCheckGrantKey(private.ID, "login")).Return(false, true)
+
+	var buf bytes.Buffer
+	manifest, err := s.service.WriteArchive(ctx, "token",
+		[]uuid.UUID{own1.ID, private.ID, own2.ID, missing, public.ID}, &buf)
+	s.Require().NoError(err)
+
+	s.Equal([]dto.ArchiveEntry{
+		{ID: own1.ID, Name: "report.pdf", Size: 5},
+		{ID: own2.ID, Name: "Report (2).pdf", Size: 6},
+		{ID: public.ID, Name: "manifest (2).json", Size: 5},
+	}, manifest.Included)
+	s.Equal([]dto.ArchiveSkipped{
+		{ID: private.ID, Reason: ErrNoAccess.Error()},
+		{ID: missing, Reason: ErrDocumentNotFound.Error()},
+	}, manifest.Skipped)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	s.Require().NoError(err)
+	s.Require().Len(archive.File, 4)
+
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		s.Require().NoError(err)
+		files[file.Name], err = io.ReadAll(r)
+		s.Require().NoError(err)
+	}
+	s.Equal("second", string(files["Report (2).pdf"]))
+
+	var stored dto.ArchiveManifest
+	s.Require().NoError(json.Unmarshal(files[archiveManifest], &stored))
+	s.Equal(*manifest, stored)
+}
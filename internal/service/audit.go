@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
+)
+
+// auditChainBatch is how many events are chained in one transaction.
+const auditChainBatch = 500
+
+// ClientKey is the context key of the Client that sent the request. It is a
+// string so that the api can keep the client in the keys of a gin.Context.
+const ClientKey = "audit_client"
+
+// Client is the caller of a request as it is recorded in the audit log.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// audit records the outcome of an action, a failure when err is not nil.
+// It is written even when the request was canceled. A failure to write it is
+// logged and does not change the result of the action.
+func (s *Service) audit(ctx context.Context, event *domain.AuditEvent, err error) {
+	client, _ := ctx.Value(ClientKey).(Client)
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+
+	event.Outcome = domain.AuditSuccess
+	if err != nil {
+		event.Outcome = domain.AuditFailure
+		event.Reason = err.Error()
+	}
+
+	errAudit := s.repo.AddAuditEvent(context.WithoutCancel(ctx), event)
+	if errAudit != nil {
+		s.log.WithField("service_method", "audit").
+			WithField("action", event.Action).
+			WithError(errAudit).
+			Error("error add audit event")
+	}
+}
+
+// auditActor resolves the user of the token for the audit log, an unknown
+// token leaves the actor empty.
+func (s *Service) auditActor(ctx context.Context, token string) (uuid.UUID, string) {
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		return uuid.Nil, ""
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return userID, ""
+	}
+
+	return userID, user.Login
+}
+
+// GetAuditEvents returns one page of the audit log, the newest events first.
+func (s *Service) GetAuditEvents(ctx context.Context, filter *dto.GetAuditEvents) (*dto.AuditPage, error) {
+	l := s.log.WithField("service_method", "GetAuditEvents")
+
+	err := filter.IsValid()
+	if err != nil {
+		l.Warn(err.Error())
+		return nil, err
+	}
+
+	// one extra event tells whether there is a next page
+	query := *filter
+	query.Limit = filter.Limit + 1
+	events, err := s.repo.GetAuditEvents(ctx, &query)
+	if err != nil {
+		l.WithError(err).Error("error get audit events")
+		return nil, err
+	}
+
+	page := &dto.AuditPage{Events: events}
+	if len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.Next = page.Events[filter.Limit-1].ID
+	}
+
+	return page, nil
+}
+
+// ChainAudit chains the events written to the audit log since the last run
+// and returns how many it chained. The events are written without their
+// hashes, so that the requests do not wait for each other to extend the chain.
+func (s *Service) ChainAudit(ctx context.Context) (int, error) {
+	l := s.log.WithField("service_method", "ChainAudit")
+
+	total := 0
+	for {
+		chained, err := s.repo.ChainAuditEvents(ctx, auditChainBatch)
+		if err != nil {
+			l.WithError(err).Error("error chain audit events")
+			return total, err
+		}
+
+		total += chained
+		if chained < auditChainBatch {
+			return total, nil
+		}
+	}
+}
+
+// VerifyAudit chains the pending events and checks the hash chain of the
+// whole audit log.
+func (s *Service) VerifyAudit(ctx context.Context) (*domain.AuditVerification, error) {
+	l := s.log.WithField("service_method", "VerifyAudit")
+
+	_, err := s.ChainAudit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.VerifyAuditChain(ctx)
+	if err != nil {
+		l.WithError(err).Error("error verify audit chain")
+		return nil, err
+	}
+
+	if result.BrokenID != 0 {
+		l.WithField("audit_event_id", result.BrokenID).Error("audit chain is broken")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_audit() {
+	// the suite accepts any audit event, this test needs its own repository
+	repository := NewMockRepository(gomock.NewController(s.T()))
+	service, err := New(repository, s.cache, logger.New(""), &config.Config{})
+	s.Require().NoError(err)
+
+	ctx := context.WithValue(context.Background(), ClientKey, Client{IP: "10.0.0.1", UserAgent: "curl/8.4.0"})
+	userID, ownerID, documentID := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name  string
+		run   func() error
+		err   error
+		calls func()
+	}{
+		{
+			name: "grant added",
+			run: func() error {
+				return service.AddGrant(ctx, documentID, "token", "reader42", "")
+			},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				repository.EXPECT().GetDocument(ctx, documentID).Return(&domain.Document{ID: documentID, UserID: userID}, nil)
+				repository.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				repository.EXPECT().AddGrant(ctx, toGrant("reader42", userID, documentID)).Return(nil)
+				repository.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil)
+				s.cache.EXPECT().Delete(prepareCheckGrantKey(documentID, "reader42"))
+				repository.EXPECT().AddAuditEvent(gomock.Any(), &domain.AuditEvent{
+					ActorID:     userID,
+					Action:      domain.AuditGrantAdd,
+					DocumentID:  documentID,
+					TargetLogin: "reader42",
+					Outcome:     domain.AuditSuccess,
+					IP:          "10.0.0.1",
+					UserAgent:   "curl/8.4.0",
+				}).Return(nil)
+			},
+		},
+		{
+			name: "view denied",
+			run: func() error {
+				_, err := service.GetDocument(ctx, documentID, "token")
+				return err
+			},
+			err: ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(&domain.Document{ID: documentID, UserID: ownerID}, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{Login: "reader42"}, true)
+				s.cache.EXPECT().Get(prepareCheckGrantKey(documentID, "reader42")).Return(false, true)
+				repository.EXPECT().AddAuditEvent(gomock.Any(), &domain.AuditEvent{
+					ActorID:    userID,
+					ActorLogin: "reader42",
+					Action:     domain.AuditView,
+					DocumentID: documentID,
+					Outcome:    domain.AuditFailure,
+					Reason:     ErrNoAccess.Error(),
+					IP:         "10.0.0.1",
+					UserAgent:  "curl/8.4.0",
+				}).Return(nil)
+			},
+		},
+		{
+			name: "public view with a token",
+			run: func() error {
+				_, err := service.GetDocument(ctx, documentID, "token")
+				return err
+			},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(&domain.Document{ID: documentID, UserID: ownerID, Public: true}, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{Login: "reader42"}, true)
+				repository.EXPECT().AddAuditEvent(gomock.Any(), &domain.AuditEvent{
+					ActorID:    userID,
+					ActorLogin: "reader42",
+					Action:     domain.AuditView,
+					DocumentID: documentID,
+					Outcome:    domain.AuditSuccess,
+					IP:         "10.0.0.1",
+					UserAgent:  "curl/8.4.0",
+				}).Return(nil)
+			},
+		},
+		{
+			name: "public view with an unknown token",
+			run: func() error {
+				_, err := service.GetDocument(ctx, documentID, "expired")
+				return err
+			},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(&domain.Document{ID: documentID, UserID: ownerID, Public: true}, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("expired")).Return(nil, false)
+				repository.EXPECT().GetUserID(ctx, "expired").Return(uuid.Nil, repo.ErrTokenNotFound)
+				repository.EXPECT().AddAuditEvent(gomock.Any(), &domain.AuditEvent{
+					Action:     domain.AuditView,
+					DocumentID: documentID,
+					Outcome:    domain.AuditSuccess,
+					IP:         "10.0.0.1",
+					UserAgent:  "curl/8.4.0",
+				}).Return(nil)
+			},
+		},
+		{
+			name: "unknown login",
+			run: func() error {
+				_, err := service.Authentication(ctx, &domain.User{Login: "stranger7", Password: "Passw_345"})
+				return err
+			},
+			err: ErrUserNotFound,
+			calls: func() {
+				repository.EXPECT().GetUserByLogin(ctx, "stranger7").Return(nil, repo.ErrUserNotFound)
+				repository.EXPECT().AddAuditEvent(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, event *domain.AuditEvent) error {
+						s.Equal(domain.AuditLogin, event.Action)
+						s.Equal("stranger7", event.ActorLogin)
+						s.Equal(uuid.Nil, event.ActorID)
+						s.Equal(domain.AuditFailure, event.Outcome)
+						return nil
+					},
+				)
+			},
+		},
+		{
+			name: "audit log unavailable",
+			run: func() error {
+				return service.LogOut(ctx, "token")
+			},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				repository.EXPECT().LogOut(ctx, "token").Return(nil)
+				s.cache.EXPECT().Delete(prepareGetUserIDKey("token"))
+				repository.EXPECT().AddAuditEvent(gomock.Any(), gomock.Any()).Return(errors.ErrUnsupported)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			s.ErrorIs(tt.run(), tt.err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_GetAuditEvents() {
+	ctx := context.Background()
+	events := []domain.AuditEvent{{ID: 9}, {ID: 8}, {ID: 7}}
+
+	tests := []struct {
+		name   string
+		filter *dto.GetAuditEvents
+		want   *dto.AuditPage
+		err    error
+		calls  func()
+	}{
+		{
+			name:   "next page",
+			filter: &dto.GetAuditEvents{Action: domain.AuditView, Limit: 2},
+			want:   &dto.AuditPage{Events: events[:2], Next: 8},
+			calls: func() {
+				s.repo.EXPECT().GetAuditEvents(ctx, &dto.GetAuditEvents{Action: domain.AuditView, Limit: 3}).Return(events, nil)
+			},
+		},
+		{
+			name:   "last page",
+			filter: &dto.GetAuditEvents{Before: 8, Limit: 5},
+			want:   &dto.AuditPage{Events: events[2:]},
+			calls: func() {
+				s.repo.EXPECT().GetAuditEvents(ctx, &dto.GetAuditEvents{Before: 8, Limit: 6}).Return(events[2:], nil)
+			},
+		},
+		{
+			name:   "invalid outcome",
+			filter: &dto.GetAuditEvents{Outcome: "maybe", Limit: 5},
+			err:    dto.ErrInvalidOutcome,
+			calls:  func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.GetAuditEvents(ctx, tt.filter)
+			s.Equal(tt.err, err)
+			s.Equal(tt.want, got)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_ChainAudit() {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		want  int
+		err   error
+		calls func()
+	}{
+		{
+			name: "batches until the log is chained",
+			want: auditChainBatch + 3,
+			calls: func() {
+				gomock.InOrder(
+					s.repo.EXPECT().ChainAuditEvents(ctx, auditChainBatch).Return(auditChainBatch, nil),
+					s.repo.EXPECT().ChainAuditEvents(ctx, auditChainBatch).Return(3, nil),
+				)
+			},
+		},
+		{
+			name: "error",
+			err:  errors.New("connection refused"),
+			calls: func() {
+				s.repo.EXPECT().ChainAuditEvents(ctx, auditChainBatch).Return(0, errors.New("connection refused"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.ChainAudit(ctx)
+			s.Equal(tt.err, err)
+			s.Equal(tt.want, got)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_VerifyAudit() {
+	ctx := context.Background()
+
+	// the pending events are chained before the chain is checked
+	gomock.InOrder(
+		s.repo.EXPECT().ChainAuditEvents(ctx, auditChainBatch).Return(2, nil),
+		s.repo.EXPECT().VerifyAuditChain(ctx).Return(&domain.AuditVerification{Checked: 10, BrokenID: 4}, nil),
+	)
+
+	result, err := s.service.VerifyAudit(ctx)
+	s.NoError(err)
+	s.Equal(&domain.AuditVerification{Checked: 10, BrokenID: 4}, result)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
+)
+
+// GetComments returns the comments of the document to anyone who may read it,
+// replies follow the order they were written in and point to their parent.
+func (s *Service) GetComments(ctx context.Context, documentID uuid.UUID, token string) ([]domain.Comment, error) {
+	l := s.log.WithField("service_method", "GetComments")
+
+	document, err := s.getDocument(ctx, documentID)
+	if err != nil {
+		l.WithError(err).Error("error get document")
+		return nil, ErrDocumentNotFound
+	}
+
+	_, _, err = s.checkAccess(ctx, document, token, domain.PermissionRead)
+	if err != nil {
+		l.WithError(err).Warn("error check access")
+		return nil, err
+	}
+
+	comments, err := s.repo.GetComments(ctx, documentID)
+	if err != nil {
+		l.WithError(err).Error("error get comments")
+		return nil, err
+	}
+
+	return pruneComments(comments), nil
+}
+
+// AddComment comments the document or, with ParentID set, replies to a
+// comment of the same document. Only the owner and the users granted
+// domain.PermissionComment may comment.
+func (s *Service) AddComment(ctx context.Context, req *dto.CommentRequest) (*domain.Comment, error) {
+	l := s.log.WithField("service_method", "AddComment")
+
+	err := req.IsValid()
+	if err != nil {
+		l.Warn(err.Error())
+		return nil, err
+	}
+
+	document, err := s.getDocument(ctx, req.DocumentID)
+	if err != nil {
+		l.WithError(err).Error("error get document")
+		return nil, ErrDocumentNotFound
+	}
+
+	userID, _, err := s.checkAccess(ctx, document, req.Token, domain.PermissionComment)
+	if err != nil {
+		l.WithError(err).Warn("error check access")
+		return nil, err
+	}
+
+	if req.ParentID != uuid.Nil {
+		parent, err := s.repo.GetComment(ctx, req.ParentID)
+		if err != nil || parent.DocumentID != req.DocumentID {
+			l.WithError(err).Warn("error get parent comment")
+			return nil, ErrCommentNotFound
+		}
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		l.WithError(err).Error("error get user")
+		return nil, ErrUserNotFound
+	}
+
+	comment, err := s.repo.CreateComment(ctx, toComment(req, userID))
+	if err != nil {
+		l.WithError(err).Error("error create comment")
+		return nil, err
+	}
+	comment.Login = user.Login
+
+	return comment, nil
+}
+
+// UpdateComment changes the body of a comment, only its author may do it
+// while they still may comment the document.
+func (s *Service) UpdateComment(ctx context.Context, req *dto.CommentRequest) (*domain.Comment, error) {
+	l := s.log.WithField("service_method", "UpdateComment")
+
+	err := req.IsValid()
+	if err != nil {
+		l.Warn(err.Error())
+		return nil, err
+	}
+
+	document, err := s.getDocument(ctx, req.DocumentID)
+	if err != nil {
+		l.WithError(err).Error("error get document")
+		return nil, ErrDocumentNotFound
+	}
+
+	userID, _, err := s.checkAccess(ctx, document, req.Token, domain.PermissionComment)
+	if err != nil {
+		l.WithError(err).Warn("error check access")
+		return nil, err
+	}
+
+	comment, err := s.getOwnComment(ctx, userID, req.DocumentID, req.ID)
+	if err != nil {
+		l.WithError(err).Warn("error get comment")
+		return nil, err
+	}
+
+	comment.UpdatedAt, err = s.repo.UpdateComment(ctx, comment.ID, req.Body)
+	if errors.Is(err, repo.ErrCommentNotFound) {
+		l.WithError(err).Warn("error update comment")
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		l.WithError(err).Error("error update comment")
+		return nil, err
+	}
+	comment.Body = req.Body
+
+	return comment, nil
+}
+
+// DeleteComment removes the body of a comment of the caller, the replies to
+// it stay in the thread.
+func (s *Service) DeleteComment(ctx context.Context, documentID, id uuid.UUID, token string) error {
+	l := s.log.WithField("service_method", "DeleteComment")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return ErrUserNotFound
+	}
+
+	_, err = s.getOwnComment(ctx, userID, documentID, id)
+	if err != nil {
+		l.WithError(err).Warn("error get comment")
+		return err
+	}
+
+	err = s.repo.DeleteComment(ctx, id)
+	if errors.Is(err, repo.ErrCommentNotFound) {
+		l.WithError(err).Warn("error delete comment")
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		l.WithError(err).Error("error delete comment")
+		return err
+	}
+
+	return nil
+}
+
+// getOwnComment hides comments of other users and deleted ones as not found.
+func (s *Service) getOwnComment(ctx context.Context, userID, documentID, id uuid.UUID) (*domain.Comment, error) {
+	comment, err := s.repo.GetComment(ctx, id)
+	if err != nil || comment.DocumentID != documentID || comment.UserID != userID || comment.DeletedAt != nil {
+		return nil, ErrCommentNotFound
+	}
+
+	return comment, nil
+}
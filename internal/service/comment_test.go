@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_GetComments() {
+	ctx := context.Background()
+	documentID, ownerID, userID := uuid.New(), uuid.New(), uuid.New()
+	document := &domain.Document{ID: documentID, UserID: ownerID}
+	deleted := time.Now()
+	comments := []domain.Comment{
+		{ID: uuid.New(), DocumentID: documentID, Body: "first"},
+		{ID: uuid.New(), DocumentID: documentID, DeletedAt: &deleted},
+	}
+
+	tests := []struct {
+		name  string
+		want  []domain.Comment
+		err   error
+		calls func()
+	}{
+		{
+			name: "granted reader",
+			want: comments[:1],
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "reader42"}, true)
+				s.cache.EXPECT().Get(prepareCheckGrantKey(documentID, "reader42")).Return(true, true)
+				s.repo.EXPECT().GetComments(ctx, documentID).Return(comments, nil)
+			},
+		},
+		{
+			name: "no grant",
+			err:  ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "reader42"}, true)
+				s.cache.EXPECT().Get(prepareCheckGrantKey(documentID, "reader42")).Return(false, true)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.GetComments(ctx, documentID, "token")
+			s.Equal(tt.err, err)
+			s.Equal(tt.want, got)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_AddComment() {
+	ctx := context.Background()
+	documentID, ownerID, userID, parentID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	document := &domain.Document{ID: documentID, UserID: ownerID}
+	user := &domain.User{ID: userID, Login: "reader42"}
+
+	tests := []struct {
+		name  string
+		req   *dto.CommentRequest
+		err   error
+		calls func()
+	}{
+		{
+			name: "reply with comment grant",
+			req:  &dto.CommentRequest{DocumentID: documentID, ParentID: parentID, Token: "token", Body: "agreed", Page: 2, RangeStart: 10, RangeEnd: 20},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(user, true).Times(2)
+				s.repo.EXPECT().CheckCommentGrant(ctx, documentID, "reader42").Return(true, nil)
+				s.repo.EXPECT().GetComment(ctx, parentID).Return(&domain.Comment{ID: parentID, DocumentID: documentID}, nil)
+				s.repo.EXPECT().CreateComment(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, comment *domain.Comment) (*domain.Comment, error) {
+						s.Equal(userID, comment.UserID)
+						s.Equal(parentID, comment.ParentID)
+						s.Equal(2, comment.Page)
+						return comment, nil
+					},
+				)
+			},
+		},
+		{
+			name: "read only grant",
+			req:  &dto.CommentRequest{DocumentID: documentID, Token: "token", Body: "agreed"},
+			err:  ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(user, true)
+				s.repo.EXPECT().CheckCommentGrant(ctx, documentID, "reader42").Return(false, nil)
+			},
+		},
+		{
+			name: "public document without grant",
+			req:  &dto.CommentRequest{DocumentID: documentID, Token: "token", Body: "agreed"},
+			err:  ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(&domain.Document{ID: documentID, UserID: ownerID, Public: true}, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(user, true)
+				s.repo.EXPECT().CheckCommentGrant(ctx, documentID, "reader42").Return(false, nil)
+			},
+		},
+		{
+			name: "parent of another document",
+			req:  &dto.CommentRequest{DocumentID: documentID, ParentID: parentID, Token: "token", Body: "agreed"},
+			err:  ErrCommentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(ownerID, true)
+				s.repo.EXPECT().GetComment(ctx, parentID).Return(&domain.Comment{ID: parentID, DocumentID: uuid.New()}, nil)
+			},
+		},
+		{
+			name:  "empty body",
+			req:   &dto.CommentRequest{DocumentID: documentID, Token: "token", Body: " "},
+			err:   dto.ErrEmptyComment,
+			calls: func() {},
+		},
+		{
+			name:  "range ends before it starts",
+			req:   &dto.CommentRequest{DocumentID: documentID, Token: "token", Body: "agreed", RangeStart: 5, RangeEnd: 3},
+			err:   dto.ErrInvalidRange,
+			calls: func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			_, err := s.service.AddComment(ctx, tt.req)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_UpdateComment() {
+	ctx := context.Background()
+	documentID, ownerID, userID, id := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	document := &domain.Document{ID: documentID, UserID: ownerID}
+	updated := time.Now()
+
+	tests := []struct {
+		name  string
+		err   error
+		calls func()
+	}{
+		{
+			name: "own comment",
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(ownerID, true)
+				s.repo.EXPECT().GetComment(ctx, id).Return(&domain.Comment{ID: id, DocumentID: documentID, UserID: ownerID}, nil)
+				s.repo.EXPECT().UpdateComment(ctx, id, "fixed").Return(updated, nil)
+			},
+		},
+		{
+			name: "comment of another user",
+			err:  ErrCommentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(ownerID, true)
+				s.repo.EXPECT().GetComment(ctx, id).Return(&domain.Comment{ID: id, DocumentID: documentID, UserID: userID}, nil)
+			},
+		},
+		{
+			name: "deleted meanwhile",
+			err:  ErrCommentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(ownerID, true)
+				s.repo.EXPECT().GetComment(ctx, id).Return(&domain.Comment{ID: id, DocumentID: documentID, UserID: ownerID}, nil)
+				s.repo.EXPECT().UpdateComment(ctx, id, "fixed").Return(time.Time{}, repo.ErrCommentNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.UpdateComment(ctx, &dto.CommentRequest{ID: id, DocumentID: documentID, Token: "token", Body: "fixed"})
+			s.Equal(tt.err, err)
+			if err == nil {
+				s.Equal("fixed", got.Body)
+				s.Equal(updated, got.UpdatedAt)
+			}
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_DeleteComment() {
+	ctx := context.Background()
+	documentID, userID, id := uuid.New(), uuid.New(), uuid.New()
+	deleted := time.Now()
+
+	tests := []struct {
+		name  string
+		err   error
+		calls func()
+	}{
+		{
+			name: "own comment",
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetComment(ctx, id).Return(&domain.Comment{ID: id, DocumentID: documentID, UserID: userID}, nil)
+				s.repo.EXPECT().DeleteComment(ctx, id).Return(nil)
+			},
+		},
+		{
+			name: "already deleted",
+			err:  ErrCommentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetComment(ctx, id).Return(&domain.Comment{ID: id, DocumentID: documentID, UserID: userID, DeletedAt: &deleted}, nil)
+			},
+		},
+		{
+			name: "not found",
+			err:  ErrCommentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetComment(ctx, id).Return(nil, repo.ErrCommentNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			err := s.service.DeleteComment(ctx, documentID, id, "token")
+			s.Equal(tt.err, err)
+		})
+	}
+}
@@ -3,7 +3,23 @@ package dto
 import "errors"
 
 var (
-	ErrInvalidKey   = errors.New("invalid key")
-	ErrInvalidLimit = errors.New("the limit must be greater than 0")
-	ErrEmptyValue   = errors.New("empty value")
+	ErrInvalidKey       = errors.New("invalid key")
+	ErrInvalidLimit     = errors.New("the limit must be greater than 0")
+	ErrEmptyValue       = errors.New("empty value")
+	ErrEmptyName        = errors.New("empty name")
+	ErrEmptyMime        = errors.New("empty mime")
+	ErrEmptyQuery       = errors.New("empty query")
+	ErrInvalidOffset    = errors.New("the offset must not be negative")
+	ErrInvalidSort      = errors.New("invalid sort")
+	ErrInvalidOrder     = errors.New("invalid order")
+	ErrInvalidScope     = errors.New("invalid scope")
+	ErrInvalidArchive   = errors.New("exactly one of ids, folder_id or filter must be set")
+	ErrTooManyDocuments = errors.New("too many documents for one archive")
+	ErrInvalidDateRange = errors.New("created_from is after created_to")
+	ErrInvalidOutcome   = errors.New("invalid outcome")
+	ErrInvalidPeriod    = errors.New("from is after to")
+	ErrEmptyComment     = errors.New("empty comment")
+	ErrCommentTooLong   = errors.New("comment is too long")
+	ErrInvalidPage      = errors.New("the page must not be negative")
+	ErrInvalidRange     = errors.New("the range must be empty or start before its end")
 )
@@ -1,30 +1,301 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Document is a new document. A retry of an upload with the same
+// IdempotencyKey replays the first response instead of saving it again.
 type Document struct {
-	Name    string
-	Token   string
-	Mime    string
-	Content []byte
-	Grant   []string
-	Public  bool
+	Name           string
+	Token          string
+	IdempotencyKey string
+	Mime           string
+	Description    string
+	Content        []byte
+	Grant          []string
+	Tags           []string
+	Metadata       map[string]any
+	FolderID       uuid.UUID
+	Public         bool
+}
+
+// DocumentContentRequest replaces the content of a document with a new version.
+// An empty Mime keeps the current one, Revision is the value from If-Match.
+type DocumentContentRequest struct {
+	ID       uuid.UUID
+	Token    string
+	Mime     string
+	Content  []byte
+	Revision int
+}
+
+// DocumentMeta describes a document without its content. Document.Grant is
+// filled for the owner only.
+type DocumentMeta struct {
+	Document *domain.Document
+	Owner    string
+}
+
+// UpdateDocumentRequest holds the fields to change; nil fields are left as is.
+// Revision is the value from If-Match, 0 skips the concurrency check.
+type UpdateDocumentRequest struct {
+	ID          uuid.UUID
+	Token       string
+	Name        *string
+	Mime        *string
+	Description *string
+	Public      *bool
+	Tags        []string
+	Metadata    map[string]any
+	FolderID    *uuid.UUID
+	Revision    int
 }
 
+// UpdateFolderRequest renames and/or moves a folder; a zero ParentID moves it to the top level.
+type UpdateFolderRequest struct {
+	ID       uuid.UUID
+	Token    string
+	Name     *string
+	ParentID *uuid.UUID
+}
+
+// FolderContents lists a folder; Folder is nil for the top level.
+type FolderContents struct {
+	Folder    *domain.Folder
+	Folders   []domain.Folder
+	Documents []domain.Document
+}
+
+const (
+	SortName    = "name"
+	SortCreated = "created"
+	SortSize    = "size"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	// ScopeOwned lists the documents of the user.
+	ScopeOwned = "owned"
+	// ScopeShared lists the documents of other users granted to the user
+	// directly or through a folder.
+	ScopeShared = "shared"
+	// ScopePublic lists the public documents of every user.
+	ScopePublic = "public"
+	// ScopeAll lists everything the user can read.
+	ScopeAll = "all"
+)
+
+// GetDocumentsRequest filters a documents listing. Mime may end with "/*" to
+// match a whole type, Cursor is the opaque next_cursor of the previous page.
+// Login keeps the documents granted to that login.
 type GetDocumentsRequest struct {
-	Token string
-	Login string
-	Key   string
-	Value string
-	Limit int
+	Token        string
+	Scope        string
+	Login        string
+	Key          string
+	Value        string
+	Tags         []string
+	Metadata     map[string]any
+	NamePrefix   string
+	NameContains string
+	Mime         string
+	Public       *bool
+	Owner        string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Sort         string
+	Order        string
+	Cursor       string
+	Limit        int
 }
 
 type GetDocuments struct {
+	UserID       uuid.UUID
+	UserLogin    string
+	Scope        string
+	GrantedTo    string
+	Key          string
+	Value        string
+	Tags         []string
+	Metadata     map[string]any
+	NamePrefix   string
+	NameContains string
+	Mime         string
+	Public       *bool
+	Owner        string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Sort         string
+	Order        string
+	After        *Cursor
+	Limit        int
+}
+
+// Cursor points at the last document of a page. Only the field of the sort
+// order is set besides ID.
+type Cursor struct {
+	Sort    string    `json:"s"`
+	Order   string    `json:"o"`
+	Name    string    `json:"n,omitempty"`
+	Created time.Time `json:"c,omitempty"`
+	Size    int64     `json:"z,omitempty"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// DocumentsPage is one page of a listing, NextCursor is empty on the last page.
+type DocumentsPage struct {
+	Documents  []domain.Document
+	NextCursor string
+}
+
+type SearchRequest struct {
+	Token  string
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type Search struct {
 	UserID uuid.UUID
 	Login  string
-	Key    string
-	Value  string
+	Query  string
 	Limit  int
+	Offset int
+}
+
+// MaxArchiveDocuments limits the number of documents in one archive.
+const MaxArchiveDocuments = 1000
+
+// ArchiveRequest selects the documents of an archive by exactly one of the
+// explicit IDs, a folder with its subfolders or a listing filter.
+type ArchiveRequest struct {
+	Token    string
+	IDs      []uuid.UUID
+	FolderID uuid.UUID
+	Filter   *GetDocumentsRequest
+}
+
+// ArchiveManifest is stored in the archive as manifest.json.
+type ArchiveManifest struct {
+	Included []ArchiveEntry   `json:"included"`
+	Skipped  []ArchiveSkipped `json:"skipped"`
+}
+
+type ArchiveEntry struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Size int64     `json:"size"`
+}
+
+type ArchiveSkipped struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// ImportRequest creates a document for every file of Archive, a ZIP or a
+// tar.gz. The other fields are shared by all the documents.
+type ImportRequest struct {
+	Token       string
+	Description string
+	Grant       []string
+	Tags        []string
+	Metadata    map[string]any
+	FolderID    uuid.UUID
+	Public      bool
+	Archive     []byte
+}
+
+// ImportReport lists the files of an archive in their order, Error is set
+// for a file that was not imported.
+type ImportReport struct {
+	Created int
+	Failed  int
+	Entries []ImportEntry
+}
+
+type ImportEntry struct {
+	Path       string
+	DocumentID uuid.UUID
+	Name       string
+	Mime       string
+	Size       int64
+	Error      string
+}
+
+// GetAuditEvents filters the audit log, the newest events first. Actor is a
+// user ID or login, Before is the ID of the last event of the previous page.
+type GetAuditEvents struct {
+	Actor      string
+	Action     string
+	DocumentID uuid.UUID
+	Outcome    string
+	From       *time.Time
+	To         *time.Time
+	Before     int64
+	Limit      int
+}
+
+// AuditPage is one page of the audit log, Next is 0 on the last page.
+type AuditPage struct {
+	Events []domain.AuditEvent
+	Next   int64
+}
+
+// WebhookPayload is the body of a webhook delivery. Login is the user the
+// document was shared with or unshared from.
+type WebhookPayload struct {
+	Event      string          `json:"event"`
+	Document   WebhookDocument `json:"document"`
+	Login      string          `json:"login,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// WebhookDocument is the document as it is after the change. The content is
+// never sent.
+type WebhookDocument struct {
+	ID       uuid.UUID `json:"id"`
+	OwnerID  uuid.UUID `json:"owner_id"`
+	FolderID string    `json:"folder_id,omitempty"`
+	Name     string    `json:"name"`
+	Mime     string    `json:"mime"`
+	Size     int64     `json:"size"`
+	Version  int       `json:"version"`
+	Checksum string    `json:"checksum"`
+	Public   bool      `json:"public"`
+}
+
+// MaxCommentLength limits the body of a comment in characters.
+const MaxCommentLength = 10000
+
+// CommentRequest adds a comment to the document or, with ID set, changes the
+// body of the comment. A zero Page or range leaves the comment unanchored.
+type CommentRequest struct {
+	ID         uuid.UUID
+	DocumentID uuid.UUID
+	ParentID   uuid.UUID
+	Token      string
+	Body       string
+	Page       int
+	RangeStart int
+	RangeEnd   int
+}
+
+func (u *UpdateDocumentRequest) IsValid() error {
+	if u.Name != nil && *u.Name == "" {
+		return ErrEmptyName
+	}
+
+	if u.Mime != nil && *u.Mime == "" {
+		return ErrEmptyMime
+	}
+
+	return nil
 }
 
 func (g *GetDocumentsRequest) IsValid() error {
@@ -32,6 +303,29 @@ func (g *GetDocumentsRequest) IsValid() error {
 		return ErrInvalidLimit
 	}
 
+	switch g.Scope {
+	case "", ScopeOwned, ScopeShared, ScopePublic, ScopeAll:
+	default:
+		return ErrInvalidScope
+	}
+
+	if g.Sort != "" && g.Sort != SortName && g.Sort != SortCreated && g.Sort != SortSize {
+		return ErrInvalidSort
+	}
+
+	if g.Order != "" && g.Order != OrderAsc && g.Order != OrderDesc {
+		return ErrInvalidOrder
+	}
+
+	if g.CreatedFrom != nil && g.CreatedTo != nil && g.CreatedFrom.After(*g.CreatedTo) {
+		return ErrInvalidDateRange
+	}
+
+	// key/value is optional, the other filters narrow the listing on their own
+	if g.Key == "" && g.Value == "" {
+		return nil
+	}
+
 	if g.Key != "name" && g.Key != "mime" {
 		return ErrInvalidKey
 	}
@@ -42,3 +336,77 @@ func (g *GetDocumentsRequest) IsValid() error {
 
 	return nil
 }
+
+func (s *SearchRequest) IsValid() error {
+	if strings.TrimSpace(s.Query) == "" {
+		return ErrEmptyQuery
+	}
+
+	if s.Limit < 1 {
+		return ErrInvalidLimit
+	}
+
+	if s.Offset < 0 {
+		return ErrInvalidOffset
+	}
+
+	return nil
+}
+
+func (g *GetAuditEvents) IsValid() error {
+	if g.Limit < 1 {
+		return ErrInvalidLimit
+	}
+
+	if g.Outcome != "" && g.Outcome != domain.AuditSuccess && g.Outcome != domain.AuditFailure {
+		return ErrInvalidOutcome
+	}
+
+	if g.From != nil && g.To != nil && g.From.After(*g.To) {
+		return ErrInvalidPeriod
+	}
+
+	return nil
+}
+
+func (a *ArchiveRequest) IsValid() error {
+	sources := 0
+	if len(a.IDs) > 0 {
+		sources++
+	}
+	if a.FolderID != uuid.Nil {
+		sources++
+	}
+	if a.Filter != nil {
+		sources++
+	}
+	if sources != 1 {
+		return ErrInvalidArchive
+	}
+
+	if len(a.IDs) > MaxArchiveDocuments {
+		return ErrTooManyDocuments
+	}
+
+	return nil
+}
+
+func (c *CommentRequest) IsValid() error {
+	if strings.TrimSpace(c.Body) == "" {
+		return ErrEmptyComment
+	}
+
+	if utf8.RuneCountInString(c.Body) > MaxCommentLength {
+		return ErrCommentTooLong
+	}
+
+	if c.Page < 0 {
+		return ErrInvalidPage
+	}
+
+	if c.RangeStart < 0 || c.RangeStart > c.RangeEnd || c.RangeStart == c.RangeEnd && c.RangeEnd != 0 {
+		return ErrInvalidRange
+	}
+
+	return nil
+}
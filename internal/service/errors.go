@@ -9,11 +9,51 @@ var (
 	ErrRegistrationUser       = errors.New("user not registration")
 	ErrUserNotFound           = errors.New("user not found")
 	ErrUserExists             = errors.New("user alresdy exists")
+	ErrUserDisabled           = errors.New("user is disabled")
 	ErrAuthenticationUser     = errors.New("user not authentication")
 	ErrNoAccess               = errors.New("there is no access to the file")
 
-	ErrTokenNotFound     = errors.New("token not found")
-	ErrDocumentNotFound  = errors.New("document not found")
-	ErrDocumentsNotFound = errors.New("documents not found")
-	ErrLogOutUser        = errors.New("user not finish the session")
+	ErrTokenNotFound      = errors.New("token not found")
+	ErrDocumentNotFound   = errors.New("document not found")
+	ErrDocumentsNotFound  = errors.New("documents not found")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrPreconditionFailed = errors.New("document was changed by another request")
+	ErrLogOutUser         = errors.New("user not finish the session")
+
+	ErrLegalHold               = errors.New("document is under legal hold")
+	ErrRetentionPolicy         = errors.New("document is protected by retention policy")
+	ErrInvalidRetentionPolicy  = errors.New("invalid retention policy")
+	ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+	ErrInvalidMetadata         = errors.New("metadata values must be strings, numbers or booleans")
+
+	ErrFolderNotFound    = errors.New("folder not found")
+	ErrFolderExists      = errors.New("folder already exists")
+	ErrFolderNotEmpty    = errors.New("folder is not empty")
+	ErrFolderCycle       = errors.New("folder can not be moved into itself")
+	ErrInvalidFolderName = errors.New("invalid folder name")
+	ErrGrantNotFound     = errors.New("grant not found")
+	ErrInvalidPermission = errors.New("permission must be read, comment or edit")
+
+	ErrTextNotFound         = errors.New("document text not found")
+	ErrThumbnailNotFound    = errors.New("thumbnail not found")
+	ErrInvalidThumbnailSize = errors.New("invalid thumbnail size")
+
+	ErrInvalidIdempotencyKey = errors.New("idempotency key must be 1 to 255 printable ASCII characters")
+	ErrIdempotencyKeyInUse   = errors.New("a request with this idempotency key is in progress")
+
+	ErrInvalidArchive = errors.New("archive is damaged")
+	ErrImportBatch    = errors.New("document was not saved, its batch was rolled back")
+
+	ErrInvalidWebhookURL   = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidWebhookEvent = errors.New("unknown webhook event")
+	ErrWebhookNotFound     = errors.New("webhook not found")
+
+	ErrInvalidLastEventID = errors.New("invalid Last-Event-ID")
+	ErrEventsStopped      = errors.New("the change feed is stopped")
+
+	ErrCommentNotFound = errors.New("comment not found")
+
+	ErrLocked         = errors.New("document is locked by another user")
+	ErrLockNotFound   = errors.New("document is not locked")
+	ErrInvalidLockTTL = errors.New("invalid lock ttl")
 )
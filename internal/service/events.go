@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxEventBatch limits the events read from the log or numbered at once.
+	maxEventBatch = 100
+
+	// eventTicketTTL is how long a ticket opens the change feed, it is used
+	// right after it is issued.
+	eventTicketTTL = time.Minute
+)
+
+// EventStream is the change feed of one client. It wakes up when an event
+// that concerns the user may have been written.
+type EventStream struct {
+	userID uuid.UUID
+	login  string
+	after  int64
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+// Wake signals that new events may be waiting.
+func (e *EventStream) Wake() <-chan struct{} {
+	return e.wake
+}
+
+// Done is closed when the service stops, the stream must end.
+func (e *EventStream) Done() <-chan struct{} {
+	return e.done
+}
+
+func (e *EventStream) notify() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// eventHub holds the open streams of this instance.
+type eventHub struct {
+	mu      sync.Mutex
+	streams map[*EventStream]struct{}
+	stopped bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{streams: make(map[*EventStream]struct{})}
+}
+
+// add registers the stream, it reports false once the hub is stopped.
+func (h *eventHub) add(stream *EventStream) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped {
+		return false
+	}
+
+	h.streams[stream] = struct{}{}
+	return true
+}
+
+func (h *eventHub) remove(stream *EventStream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.streams, stream)
+}
+
+// stop ends the open streams and refuses new ones.
+func (h *eventHub) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped {
+		return
+	}
+
+	h.stopped = true
+	for stream := range h.streams {
+		close(stream.done)
+	}
+}
+
+// publish wakes the streams of the users the event concerns, all of them
+// when notification is nil.
+func (h *eventHub) publish(notification *domain.EventNotification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for stream := range h.streams {
+		if notification == nil ||
+			stream.userID == notification.UserID ||
+			(notification.TargetLogin != "" && stream.login == notification.TargetLogin) {
+			stream.notify()
+		}
+	}
+}
+
+// IssueEventTicket returns a ticket that opens the change feed of the caller
+// once. A browser can not send the token header to an event stream, so it
+// asks for a ticket with the header and passes the ticket in the URL instead
+// of the token.
+func (s *Service) IssueEventTicket(ctx context.Context, token string) (*domain.EventTicket, error) {
+	l := s.log.WithField("service_method", "IssueEventTicket")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+
+	ticket := &domain.EventTicket{
+		Ticket:    generateToken(),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(eventTicketTTL),
+	}
+	err = s.repo.AddEventTicket(ctx, ticket)
+	if err != nil {
+		l.WithError(err).Error("error add event ticket")
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+// OpenEvents starts the change feed of the caller, who is identified by the
+// token or else by a ticket, which is used up. It resumes after lastEventID
+// when it is set and starts with the next event otherwise.
+func (s *Service) OpenEvents(ctx context.Context, token, ticket, lastEventID string) (*EventStream, error) {
+	l := s.log.WithField("service_method", "OpenEvents")
+
+	var after int64
+	var err error
+	if lastEventID != "" {
+		after, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || after < 0 {
+			l.Warn(ErrInvalidLastEventID.Error())
+			return nil, ErrInvalidLastEventID
+		}
+	}
+
+	var userID uuid.UUID
+	if token == "" && ticket != "" {
+		userID, err = s.repo.TakeEventTicket(ctx, ticket, time.Now())
+		if err != nil {
+			l.WithError(err).Error("error take event ticket")
+			return nil, ErrUserNotFound
+		}
+	} else {
+		userID, err = s.getUserID(ctx, token)
+		if err != nil {
+			l.WithError(err).Error("error get user id")
+			return nil, ErrUserNotFound
+		}
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		l.WithError(err).Error("error get user")
+		return nil, ErrUserNotFound
+	}
+
+	if lastEventID == "" {
+		after, err = s.repo.GetLastEventID(ctx)
+		if err != nil {
+			l.WithError(err).Error("error get last event id")
+			return nil, err
+		}
+	}
+
+	stream := &EventStream{
+		userID: userID,
+		login:  user.Login,
+		after:  after,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if !s.events.add(stream) {
+		return nil, ErrEventsStopped
+	}
+
+	return stream, nil
+}
+
+// CloseEvents stops waking the stream.
+func (s *Service) CloseEvents(stream *EventStream) {
+	s.events.remove(stream)
+}
+
+// StopEvents ends the open streams of this instance and refuses new ones, so
+// that a graceful shutdown does not wait for the clients to disconnect.
+func (s *Service) StopEvents() {
+	s.events.stop()
+}
+
+// NextEvents returns the events of the stream written since the last call,
+// the oldest first.
+func (s *Service) NextEvents(ctx context.Context, stream *EventStream) ([]domain.WebhookEvent, error) {
+	l := s.log.WithField("service_method", "NextEvents")
+
+	events := make([]domain.WebhookEvent, 0)
+	for {
+		batch, err := s.repo.GetEvents(ctx, stream.userID, stream.login, stream.after, maxEventBatch)
+		if err != nil {
+			l.WithError(err).Error("error get events")
+			return nil, err
+		}
+
+		events = append(events, batch...)
+		if len(batch) > 0 {
+			stream.after = batch[len(batch)-1].Seq
+		}
+		if len(batch) < maxEventBatch {
+			return events, nil
+		}
+	}
+}
+
+// ListenEvents wakes the streams of this instance on the events written by
+// any instance. It blocks until the connection is lost or ctx is done.
+func (s *Service) ListenEvents(ctx context.Context) error {
+	l := s.log.WithField("service_method", "ListenEvents")
+
+	// the events written while nobody listened are picked up now
+	err := s.sequenceEvents(ctx)
+	if err != nil {
+		l.WithError(err).Error("error sequence events")
+		return err
+	}
+	s.events.publish(nil)
+
+	return s.repo.ListenEvents(ctx, func(notification *domain.EventNotification) {
+		// the notification comes at commit, the event is read by the streams
+		// once it has its place in the feed
+		err := s.sequenceEvents(ctx)
+		if err != nil {
+			l.WithError(err).Error("error sequence events")
+		}
+		s.events.publish(notification)
+	})
+}
+
+// sequenceEvents gives the committed events their place in the change feed.
+func (s *Service) sequenceEvents(ctx context.Context) error {
+	for {
+		sequenced, err := s.repo.SequenceEvents(ctx, maxEventBatch)
+		if err != nil {
+			return err
+		}
+		if sequenced < maxEventBatch {
+			return nil
+		}
+	}
+}
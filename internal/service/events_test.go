@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_OpenEvents() {
+	ctx := context.Background()
+	userID := uuid.New()
+	byToken := func() {
+		s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+		s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "reader42"}, true)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		ticket      string
+		lastEventID string
+		after       int64
+		err         error
+		calls       func()
+	}{
+		{
+			name:  "from now",
+			token: "token",
+			after: 41,
+			calls: func() {
+				byToken()
+				s.repo.EXPECT().GetLastEventID(ctx).Return(int64(41), nil)
+			},
+		},
+		{
+			name:        "resumed",
+			token:       "token",
+			lastEventID: "17",
+			after:       17,
+			calls:       byToken,
+		},
+		{
+			name:        "ticket",
+			ticket:      "ticket",
+			lastEventID: "17",
+			after:       17,
+			calls: func() {
+				s.repo.EXPECT().TakeEventTicket(ctx, "ticket", gomock.Any()).Return(userID, nil)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "reader42"}, true)
+			},
+		},
+		{
+			name:   "used ticket",
+			ticket: "ticket",
+			err:    ErrUserNotFound,
+			calls: func() {
+				s.repo.EXPECT().TakeEventTicket(ctx, "ticket", gomock.Any()).Return(uuid.Nil, repo.ErrEventTicketNotFound)
+			},
+		},
+		{
+			name:        "invalid last event id",
+			ticket:      "ticket",
+			lastEventID: "seventeen",
+			err:         ErrInvalidLastEventID,
+			// the ticket is not used up
+			calls: func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+
+			stream, err := s.service.OpenEvents(ctx, tt.token, tt.ticket, tt.lastEventID)
+			s.Equal(tt.err, err)
+			if err == nil {
+				s.Equal(tt.after, stream.after)
+				s.service.CloseEvents(stream)
+			}
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_IssueEventTicket() {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+	s.repo.EXPECT().AddEventTicket(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, ticket *domain.EventTicket) error {
+			s.Equal(userID, ticket.UserID)
+			s.NotEmpty(ticket.Ticket)
+			s.WithinDuration(time.Now().Add(eventTicketTTL), ticket.ExpiresAt, time.Second)
+			return nil
+		},
+	)
+
+	ticket, err := s.service.IssueEventTicket(ctx, "token")
+	s.Require().NoError(err)
+	s.NotEqual("token", ticket.Ticket)
+
+	s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(nil, false)
+	s.repo.EXPECT().GetUserID(ctx, "token").Return(uuid.Nil, repo.ErrTokenNotFound)
+
+	_, err = s.service.IssueEventTicket(ctx, "token")
+	s.Equal(ErrUserNotFound, err)
+}
+
+func (s *ServiceSuite) Test_StopEvents() {
+	ctx := context.Background()
+	stream := &EventStream{userID: uuid.New(), wake: make(chan struct{}, 1), done: make(chan struct{})}
+	s.True(s.service.events.add(stream))
+	defer s.service.CloseEvents(stream)
+
+	s.service.StopEvents()
+	s.service.StopEvents()
+	select {
+	case <-stream.Done():
+	default:
+		s.Fail("the open stream is not ended")
+	}
+
+	// no stream is opened once the service stops
+	userID := uuid.New()
+	s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+	s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{ID: userID, Login: "reader42"}, true)
+
+	_, err := s.service.OpenEvents(ctx, "token", "", "17")
+	s.Equal(ErrEventsStopped, err)
+}
+
+func (s *ServiceSuite) Test_NextEvents() {
+	ctx := context.Background()
+	stream := &EventStream{userID: uuid.New(), login: "reader42", after: 5}
+
+	full := make([]domain.WebhookEvent, maxEventBatch)
+	for i := range full {
+		full[i].ID = int64(6 + i)
+		full[i].Seq = int64(6 + i)
+	}
+	// an event committed late has a lower ID than the ones before it
+	last := []domain.WebhookEvent{{ID: 42, Seq: 200, Event: domain.WebhookDocumentShared}}
+
+	s.repo.EXPECT().GetEvents(ctx, stream.userID, "reader42", int64(5), maxEventBatch).Return(full, nil)
+	s.repo.EXPECT().GetEvents(ctx, stream.userID, "reader42", int64(105), maxEventBatch).Return(last, nil)
+
+	events, err := s.service.NextEvents(ctx, stream)
+	s.Require().NoError(err)
+	s.Len(events, maxEventBatch+1)
+	s.Equal(int64(200), stream.after)
+
+	s.repo.EXPECT().GetEvents(ctx, stream.userID, "reader42", int64(200), maxEventBatch).Return([]domain.WebhookEvent{}, nil)
+	events, err = s.service.NextEvents(ctx, stream)
+	s.Require().NoError(err)
+	s.Empty(events)
+	s.Equal(int64(200), stream.after)
+}
+
+func (s *ServiceSuite) Test_ListenEvents() {
+	ctx := context.Background()
+	owner := &EventStream{userID: uuid.New(), login: "owner123", wake: make(chan struct{}, 1)}
+	reader := &EventStream{userID: uuid.New(), login: "reader42", wake: make(chan struct{}, 1)}
+	other := &EventStream{userID: uuid.New(), login: "other123", wake: make(chan struct{}, 1)}
+	for _, stream := range []*EventStream{owner, reader, other} {
+		s.service.events.add(stream)
+		defer s.service.CloseEvents(stream)
+	}
+
+	// the events written while nobody listened are numbered first
+	s.repo.EXPECT().SequenceEvents(ctx, maxEventBatch).Return(maxEventBatch, nil)
+	s.repo.EXPECT().SequenceEvents(ctx, maxEventBatch).Return(3, nil)
+	s.repo.EXPECT().ListenEvents(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, fn func(*domain.EventNotification)) error {
+			// listening wakes every stream to catch up
+			for _, stream := range []*EventStream{owner, reader, other} {
+				s.Len(stream.wake, 1)
+				<-stream.wake
+			}
+
+			s.repo.EXPECT().SequenceEvents(ctx, maxEventBatch).Return(1, nil)
+			fn(&domain.EventNotification{ID: 7, UserID: owner.userID, TargetLogin: "reader42"})
+			return nil
+		},
+	)
+
+	s.NoError(s.service.ListenEvents(ctx))
+	s.Len(owner.wake, 1)
+	s.Len(reader.wake, 1)
+	s.Empty(other.wake)
+}
+
+func (s *ServiceSuite) Test_ListenEvents_sequenceError() {
+	ctx := context.Background()
+	errSequence := errors.New("connection refused")
+
+	s.repo.EXPECT().SequenceEvents(ctx, maxEventBatch).Return(0, errSequence)
+
+	s.ErrorIs(s.service.ListenEvents(ctx), errSequence)
+}
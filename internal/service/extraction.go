@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/extract"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/google/uuid"
+)
+
+var errOutdatedVersion = errors.New("document version is outdated")
+
+// ExtractionWake signals that new documents are waiting for text extraction.
+func (s *Service) ExtractionWake() <-chan struct{} {
+	return s.extractWake
+}
+
+func (s *Service) notifyExtraction() {
+	select {
+	case s.extractWake <- struct{}{}:
+	default:
+	}
+}
+
+// ExtractText runs the due text extractions and returns how many of them are finished.
+// Failed extractions are retried with exponential backoff up to the configured number of attempts.
+func (s *Service) ExtractText(ctx context.Context) (int, error) {
+	l := s.log.WithField("service_method", "ExtractText")
+
+	extractions, err := s.repo.ClaimTextExtractions(ctx, time.Now(), jobLease, s.extraction.batch)
+	if err != nil {
+		l.WithError(err).Error("error claim text extractions")
+		return 0, err
+	}
+
+	finished := 0
+	for i := range extractions {
+		extraction := &extractions[i]
+		s.extractText(ctx, extraction)
+
+		err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+			err := s.repo.UpdateTextExtraction(ctx, extraction)
+			if err != nil {
+				return err
+			}
+
+			if extraction.Status != domain.ExtractionDone {
+				return nil
+			}
+
+			return s.repo.SetDocumentText(ctx, extraction.DocumentID, extraction.Version, extraction.Text)
+		})
+		if err != nil {
+			l.WithError(err).WithField("document_id", extraction.DocumentID).Error("error save text extraction")
+			continue
+		}
+
+		if extraction.Status != domain.ExtractionPending {
+			finished++
+		}
+	}
+
+	return finished, nil
+}
+
+// extractText fills the extraction with the text of the document or schedules a retry.
+func (s *Service) extractText(ctx context.Context, extraction *domain.TextExtraction) {
+	document, err := s.repo.GetDocument(ctx, extraction.DocumentID)
+	if err != nil {
+		s.extraction.retry(&extraction.Job, err)
+		return
+	}
+
+	if document.Version != extraction.Version {
+		extraction.Status = domain.ExtractionFailed
+		extraction.Error = errOutdatedVersion.Error()
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(document.Content)
+	if err != nil {
+		s.extraction.retry(&extraction.Job, err)
+		return
+	}
+
+	text, err := s.extractors.Extract(document.Mime, content)
+	switch {
+	case errors.Is(err, extract.ErrUnsupported):
+		extraction.Status = domain.ExtractionUnsupported
+		extraction.Error = ""
+	case err != nil:
+		s.extraction.retry(&extraction.Job, err)
+	default:
+		extraction.Status = domain.ExtractionDone
+		extraction.Text = text
+		extraction.Error = ""
+	}
+}
+
+// GetDocumentText returns the text extracted from the current version of a document the caller can read.
+func (s *Service) GetDocumentText(ctx context.Context, documentID uuid.UUID, token string) (*domain.TextExtraction, error) {
+	l := s.log.WithField("service_method", "GetDocumentText")
+
+	document, err := s.GetDocument(ctx, documentID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	extraction, err := s.repo.GetTextExtraction(ctx, document.ID, document.Version)
+	if err != nil {
+		l.WithError(err).Error("error get text extraction")
+		if errors.Is(err, repo.ErrTextNotFound) {
+			return nil, ErrTextNotFound
+		}
+		return nil, err
+	}
+
+	return extraction, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_ExtractText() {
+	ctx := context.Background()
+	documentID := uuid.New()
+	content := base64.StdEncoding.EncodeToString([]byte("invoice for march"))
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		document *domain.Document
+		attempts int
+		want     *domain.TextExtraction
+		finished int
+		calls    func()
+	}{
+		{
+			name:     "text document",
+			ctx:      ctx,
+			document: &domain.Document{ID: documentID, Mime: "text/plain", Content: content, Version: 1},
+			want: &domain.TextExtraction{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    1,
+					Status:     domain.ExtractionDone,
+				},
+				Text: "invoice for march",
+			},
+			finished: 1,
+			calls: func() {
+				s.repo.EXPECT().SetDocumentText(gomock.Any(), documentID, 1, "invoice for march").Return(nil)
+			},
+		},
+		{
+			name:     "unsupported mime",
+			ctx:      ctx,
+			document: &domain.Document{ID: documentID, Mime: "image/png", Content: content, Version: 1},
+			want: &domain.TextExtraction{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    1,
+					Status:     domain.ExtractionUnsupported,
+				},
+			},
+			finished: 1,
+			calls:    func() {},
+		},
+		{
+			name:     "outdated version",
+			ctx:      ctx,
+			document: &domain.Document{ID: documentID, Mime: "text/plain", Content: content, Version: 2},
+			want: &domain.TextExtraction{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    1,
+					Status:     domain.ExtractionFailed,
+					Error:      errOutdatedVersion.Error(),
+				},
+			},
+			finished: 1,
+			calls:    func() {},
+		},
+		{
+			name:     "broken document is retried",
+			ctx:      ctx,
+			document: &domain.Document{ID: documentID, Mime: "application/pdf", Content: content, Version: 1},
+			attempts: 1,
+			want: &domain.TextExtraction{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    1,
+					Status:     domain.ExtractionPending,
+					Attempts:   2,
+					Error:      "content is not a PDF document",
+				},
+			},
+			finished: 0,
+			calls:    func() {},
+		},
+		{
+			name:     "last attempt fails",
+			ctx:      ctx,
+			document: &domain.Document{ID: documentID, Mime: "application/pdf", Content: content, Version: 1},
+			attempts: defaultJobMaxAttempts - 1,
+			want: &domain.TextExtraction{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    1,
+					Status:     domain.ExtractionFailed,
+					Attempts:   defaultJobMaxAttempts,
+					Error:      "content is not a PDF document",
+				},
+			},
+			finished: 1,
+			calls:    func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			var saved *domain.TextExtraction
+			s.repo.EXPECT().ClaimTextExtractions(ctx, gomock.Any(), jobLease, defaultJobBatch).Return(
+				[]domain.TextExtraction{{Job: domain.Job{DocumentID: documentID, Version: 1, Status: domain.ExtractionPending, Attempts: tt.attempts}}}, nil)
+			s.repo.EXPECT().GetDocument(ctx, documentID).Return(tt.document, nil)
+			s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				},
+			)
+			s.repo.EXPECT().UpdateTextExtraction(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, extraction *domain.TextExtraction) error {
+					saved = extraction
+					return nil
+				},
+			)
+			tt.calls()
+
+			finished, err := s.service.ExtractText(tt.ctx)
+			s.NoError(err)
+			s.Equal(tt.finished, finished)
+
+			if tt.want.Status == domain.ExtractionPending {
+				s.True(saved.NextAttemptAt.After(time.Now()))
+			}
+			saved.NextAttemptAt = time.Time{}
+			s.Equal(tt.want, saved)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_ExtractText_claimError() {
+	ctx := context.Background()
+	claimErr := errors.New("connection refused")
+
+	s.repo.EXPECT().ClaimTextExtractions(ctx, gomock.Any(), jobLease, defaultJobBatch).Return(nil, claimErr)
+
+	finished, err := s.service.ExtractText(ctx)
+	s.Equal(0, finished)
+	s.Equal(claimErr, err)
+}
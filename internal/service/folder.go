@@ -0,0 +1,551 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
+)
+
+func (s *Service) CreateFolder(ctx context.Context, token string, folder *domain.Folder) (*domain.Folder, error) {
+	l := s.log.WithField("service_method", "CreateFolder")
+
+	if !checkFolderName(folder.Name) {
+		l.Warn(ErrInvalidFolderName.Error())
+		return nil, ErrInvalidFolderName
+	}
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+	folder.UserID = userID
+
+	err = s.checkFolderOwner(ctx, userID, folder.ParentID)
+	if err != nil {
+		l.WithError(err).Error("error check parent folder")
+		return nil, err
+	}
+
+	err = s.checkFolderNameFree(ctx, userID, folder.ParentID, folder.Name)
+	if err != nil {
+		l.WithError(err).Warn("error check folder name")
+		return nil, err
+	}
+
+	folder.ID, err = s.repo.CreateFolder(ctx, folder)
+	if err != nil {
+		l.WithError(err).Error("error create folder")
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// GetFolderContents lists a folder for its owner or for a user the folder or one of its parents is shared with.
+// uuid.Nil lists the top level of the caller.
+func (s *Service) GetFolderContents(ctx context.Context, id uuid.UUID, token string) (*dto.FolderContents, error) {
+	l := s.log.WithField("service_method", "GetFolderContents")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+
+	contents := &dto.FolderContents{}
+	ownerID := userID
+	if id != uuid.Nil {
+		contents.Folder, err = s.repo.GetFolder(ctx, id)
+		if err != nil {
+			l.WithError(err).Error("error get folder")
+			return nil, ErrFolderNotFound
+		}
+
+		err = s.checkFolderAccess(ctx, userID, contents.Folder)
+		if err != nil {
+			l.WithError(err).Warn("error check folder access")
+			return nil, err
+		}
+		ownerID = contents.Folder.UserID
+	}
+
+	contents.Folders, err = s.repo.GetFolders(ctx, ownerID, id)
+	if err != nil {
+		l.WithError(err).Error("error get folders")
+		return nil, err
+	}
+
+	contents.Documents, err = s.repo.GetFolderDocuments(ctx, ownerID, id)
+	if err != nil {
+		l.WithError(err).Error("error get folder documents")
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+func (s *Service) UpdateFolder(ctx context.Context, req *dto.UpdateFolderRequest) (*domain.Folder, error) {
+	l := s.log.WithField("service_method", "UpdateFolder")
+
+	if req.Name != nil && !checkFolderName(*req.Name) {
+		l.Warn(ErrInvalidFolderName.Error())
+		return nil, ErrInvalidFolderName
+	}
+
+	userID, err := s.getUserID(ctx, req.Token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+
+	folder, err := s.getOwnFolder(ctx, userID, req.ID)
+	if err != nil {
+		l.WithError(err).Error("error get folder")
+		return nil, err
+	}
+
+	updated := *folder
+	if req.Name != nil {
+		updated.Name = *req.Name
+	}
+	if req.ParentID != nil {
+		updated.ParentID = *req.ParentID
+	}
+
+	if updated.ParentID != folder.ParentID {
+		err = s.checkFolderMove(ctx, userID, folder.ID, updated.ParentID)
+		if err != nil {
+			l.WithError(err).Warn("error check folder move")
+			return nil, err
+		}
+	}
+
+	if updated.ParentID != folder.ParentID || updated.Name != folder.Name {
+		err = s.checkFolderNameFree(ctx, userID, updated.ParentID, updated.Name)
+		if err != nil {
+			l.WithError(err).Warn("error check folder name")
+			return nil, err
+		}
+	}
+
+	var logins []string
+	if updated.ParentID != folder.ParentID && folder.ParentID != uuid.Nil {
+		logins, err = s.repo.GetFolderGrantLogins(ctx, folder.ParentID)
+		if err != nil {
+			l.WithError(err).Error("error get folder grants")
+			return nil, err
+		}
+	}
+
+	err = s.repo.UpdateFolder(ctx, &updated)
+	if err != nil {
+		l.WithError(err).Error("error update folder")
+		return nil, err
+	}
+
+	err = s.forgetFolderGrants(ctx, folder.ID, logins)
+	if err != nil {
+		l.WithError(err).Error("error forget folder grants")
+	}
+
+	return &updated, nil
+}
+
+// DeleteFolder removes an empty folder of the caller.
+func (s *Service) DeleteFolder(ctx context.Context, id uuid.UUID, token string) error {
+	l := s.log.WithField("service_method", "DeleteFolder")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return ErrUserNotFound
+	}
+
+	_, err = s.getOwnFolder(ctx, userID, id)
+	if err != nil {
+		l.WithError(err).Error("error get folder")
+		return err
+	}
+
+	err = s.repo.DeleteFolder(ctx, id, userID)
+	if err != nil {
+		l.WithError(err).Warn("error delete folder")
+		if errors.Is(err, repo.ErrFolderNotFound) {
+			return ErrFolderNotEmpty
+		}
+		return err
+	}
+
+	return nil
+}
+
+// AddFolderGrant shares the folder with the login, an empty permission
+// means domain.PermissionRead. Granting it again changes the permission.
+func (s *Service) AddFolderGrant(ctx context.Context, id uuid.UUID, token, login, permission string) (err error) {
+	l := s.log.WithField("service_method", "AddFolderGrant")
+
+	event := &domain.AuditEvent{Action: domain.AuditFolderGrantAdd, FolderID: id, TargetLogin: login}
+	defer func() { s.audit(ctx, event, err) }()
+
+	if !checkLogin(login) {
+		l.Warn(ErrUserLoginIncorected.Error())
+		return ErrUserLoginIncorected
+	}
+
+	grant := toFolderGrant(login, uuid.Nil, id)
+	grant.Permission, err = grantPermission(permission)
+	if err != nil {
+		l.Warn(err.Error())
+		return err
+	}
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return ErrUserNotFound
+	}
+	event.ActorID = userID
+
+	_, err = s.getOwnFolder(ctx, userID, id)
+	if err != nil {
+		l.WithError(err).Error("error get folder")
+		return err
+	}
+
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		grant.UserID = userID
+		err := s.repo.AddFolderGrant(ctx, grant)
+		if err != nil {
+			l.WithError(err).Error("error add folder grant")
+			return err
+		}
+
+		err = s.emitFolderWebhookEvents(ctx, domain.WebhookDocumentShared, id, login)
+		if err != nil {
+			l.WithError(err).Error("error emit webhook events")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyWebhooks()
+
+	return nil
+}
+
+func (s *Service) RemoveFolderGrant(ctx context.Context, id uuid.UUID, token, login string) (err error) {
+	l := s.log.WithField("service_method", "RemoveFolderGrant")
+
+	event := &domain.AuditEvent{Action: domain.AuditFolderGrantRemove, FolderID: id, TargetLogin: login}
+	defer func() { s.audit(ctx, event, err) }()
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return ErrUserNotFound
+	}
+	event.ActorID = userID
+
+	_, err = s.getOwnFolder(ctx, userID, id)
+	if err != nil {
+		l.WithError(err).Error("error get folder")
+		return err
+	}
+
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		err := s.repo.DeleteFolderGrant(ctx, id, login)
+		if err != nil {
+			l.WithError(err).Warn("error delete folder grant")
+			return err
+		}
+
+		err = s.emitFolderWebhookEvents(ctx, domain.WebhookDocumentUnshared, id, login)
+		if err != nil {
+			l.WithError(err).Error("error emit webhook events")
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrGrantNotFound) {
+			return ErrGrantNotFound
+		}
+		return err
+	}
+
+	err = s.forgetFolderGrants(ctx, id, []string{login})
+	if err != nil {
+		l.WithError(err).Error("error forget folder grants")
+	}
+	s.notifyWebhooks()
+
+	return nil
+}
+
+// emitFolderWebhookEvents records the event for every document in the folder
+// and its subfolders, sharing a folder shares all of them.
+func (s *Service) emitFolderWebhookEvents(ctx context.Context, event string, folderID uuid.UUID, login string) error {
+	documents, err := s.repo.GetFolderTreeDocuments(ctx, folderID)
+	if err != nil {
+		return err
+	}
+
+	for i := range documents {
+		err = s.emitWebhookEvent(ctx, event, &documents[i], login)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResolvePath looks up a folder or a document of the caller by a slash separated path.
+// Exactly one of the returned folder contents and document is set.
+func (s *Service) ResolvePath(ctx context.Context, path, token string) (*dto.FolderContents, *domain.Document, error) {
+	l := s.log.WithField("service_method", "ResolvePath")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, nil, ErrUserNotFound
+	}
+
+	segments := splitPath(path)
+	if len(segments) == 0 {
+		contents, err := s.GetFolderContents(ctx, uuid.Nil, token)
+		return contents, nil, err
+	}
+
+	parentID := uuid.Nil
+	for _, name := range segments[:len(segments)-1] {
+		folder, err := s.repo.GetFolderByName(ctx, userID, parentID, name)
+		if err != nil {
+			l.WithError(err).Warn("error get folder by name")
+			return nil, nil, ErrFolderNotFound
+		}
+		parentID = folder.ID
+	}
+
+	name := segments[len(segments)-1]
+	folder, err := s.repo.GetFolderByName(ctx, userID, parentID, name)
+	if err == nil {
+		contents, err := s.GetFolderContents(ctx, folder.ID, token)
+		return contents, nil, err
+	}
+
+	documentID, err := s.repo.GetDocumentIDByName(ctx, userID, parentID, name)
+	if err != nil {
+		l.WithError(err).Warn("error get document by name")
+		return nil, nil, ErrDocumentNotFound
+	}
+
+	document, err := s.GetDocument(ctx, documentID, token)
+	return nil, document, err
+}
+
+// AddGrant shares the document with the login, an empty permission means
+// domain.PermissionRead. Granting it again changes the permission.
+func (s *Service) AddGrant(ctx context.Context, documentID uuid.UUID, token, login, permission string) (err error) {
+	l := s.log.WithField("service_method", "AddGrant")
+
+	event := &domain.AuditEvent{Action: domain.AuditGrantAdd, DocumentID: documentID, TargetLogin: login}
+	defer func() { s.audit(ctx, event, err) }()
+
+	if !checkLogin(login) {
+		l.Warn(ErrUserLoginIncorected.Error())
+		return ErrUserLoginIncorected
+	}
+
+	grant := toGrant(login, uuid.Nil, documentID)
+	grant.Permission, err = grantPermission(permission)
+	if err != nil {
+		l.Warn(err.Error())
+		return err
+	}
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return ErrUserNotFound
+	}
+	event.ActorID = userID
+
+	document, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil || document.UserID != userID {
+		l.WithError(err).Error("error get document")
+		return ErrDocumentNotFound
+	}
+
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		grant.UserID = userID
+		err := s.repo.AddGrant(ctx, grant)
+		if err != nil {
+			l.WithError(err).Error("error add grant")
+			return err
+		}
+
+		err = s.emitWebhookEvent(ctx, domain.WebhookDocumentShared, document, login)
+		if err != nil {
+			l.WithError(err).Error("error emit webhook event")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.cache.Delete(prepareCheckGrantKey(documentID, login))
+	s.notifyWebhooks()
+
+	return nil
+}
+
+func (s *Service) RemoveGrant(ctx context.Context, documentID uuid.UUID, token, login string) (err error) {
+	l := s.log.WithField("service_method", "RemoveGrant")
+
+	event := &domain.AuditEvent{Action: domain.AuditGrantRemove, DocumentID: documentID, TargetLogin: login}
+	defer func() { s.audit(ctx, event, err) }()
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return ErrUserNotFound
+	}
+	event.ActorID = userID
+
+	document, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil || document.UserID != userID {
+		l.WithError(err).Error("error get document")
+		return ErrDocumentNotFound
+	}
+
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		err := s.repo.DeleteGrant(ctx, documentID, login)
+		if err != nil {
+			l.WithError(err).Warn("error delete grant")
+			return err
+		}
+
+		err = s.emitWebhookEvent(ctx, domain.WebhookDocumentUnshared, document, login)
+		if err != nil {
+			l.WithError(err).Error("error emit webhook event")
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrGrantNotFound) {
+			return ErrGrantNotFound
+		}
+		return err
+	}
+
+	s.cache.Delete(prepareCheckGrantKey(documentID, login))
+	s.notifyWebhooks()
+
+	return nil
+}
+
+func (s *Service) getOwnFolder(ctx context.Context, userID, id uuid.UUID) (*domain.Folder, error) {
+	folder, err := s.repo.GetFolder(ctx, id)
+	if err != nil || folder.UserID != userID {
+		return nil, ErrFolderNotFound
+	}
+
+	return folder, nil
+}
+
+// checkFolderOwner accepts the top level (uuid.Nil) or a folder of the user.
+func (s *Service) checkFolderOwner(ctx context.Context, userID, folderID uuid.UUID) error {
+	if folderID == uuid.Nil {
+		return nil
+	}
+
+	_, err := s.getOwnFolder(ctx, userID, folderID)
+	return err
+}
+
+func (s *Service) checkFolderAccess(ctx context.Context, userID uuid.UUID, folder *domain.Folder) error {
+	if folder.UserID == userID {
+		return nil
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	isAccess, err := s.repo.CheckFolderGrant(ctx, folder.ID, user.Login)
+	if err != nil {
+		return err
+	}
+	if !isAccess {
+		return ErrNoAccess
+	}
+
+	return nil
+}
+
+func (s *Service) checkFolderNameFree(ctx context.Context, userID, parentID uuid.UUID, name string) error {
+	_, err := s.repo.GetFolderByName(ctx, userID, parentID, name)
+	if err == nil {
+		return ErrFolderExists
+	}
+	if !errors.Is(err, repo.ErrFolderNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// checkFolderMove rejects moving a folder under a folder of another user or into its own subtree.
+func (s *Service) checkFolderMove(ctx context.Context, userID, folderID, parentID uuid.UUID) error {
+	if parentID == uuid.Nil {
+		return nil
+	}
+
+	err := s.checkFolderOwner(ctx, userID, parentID)
+	if err != nil {
+		return err
+	}
+
+	isCycle, err := s.repo.IsFolderAncestor(ctx, folderID, parentID)
+	if err != nil {
+		return err
+	}
+	if isCycle {
+		return ErrFolderCycle
+	}
+
+	return nil
+}
+
+// forgetFolderGrants drops cached grant checks of every document under the folder for the given logins.
+func (s *Service) forgetFolderGrants(ctx context.Context, folderID uuid.UUID, logins []string) error {
+	if len(logins) == 0 {
+		return nil
+	}
+
+	documentIDs, err := s.repo.GetFolderDocumentIDs(ctx, folderID)
+	if err != nil {
+		return err
+	}
+
+	s.forgetGrants(documentIDs, logins)
+
+	return nil
+}
+
+func (s *Service) forgetGrants(documentIDs []uuid.UUID, logins []string) {
+	for _, documentID := range documentIDs {
+		for _, login := range logins {
+			s.cache.Delete(prepareCheckGrantKey(documentID, login))
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_CreateFolder() {
+	ctx := context.Background()
+	userID := uuid.New()
+	parentID := uuid.New()
+	folderID := uuid.New()
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		folder *domain.Folder
+		want   *domain.Folder
+		err    error
+		calls  func()
+	}{
+		{
+			name:   "success",
+			ctx:    ctx,
+			folder: &domain.Folder{Name: "2024", ParentID: parentID},
+			want:   &domain.Folder{ID: folderID, UserID: userID, Name: "2024", ParentID: parentID},
+			err:    nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, parentID).Return(&domain.Folder{ID: parentID, UserID: userID}, nil)
+				s.repo.EXPECT().GetFolderByName(ctx, userID, parentID, "2024").Return(nil, repo.ErrFolderNotFound)
+				s.repo.EXPECT().CreateFolder(ctx, gomock.Any()).Return(folderID, nil)
+			},
+		},
+		{
+			name:   "invalid name",
+			ctx:    ctx,
+			folder: &domain.Folder{Name: "reports/2024"},
+			want:   nil,
+			err:    ErrInvalidFolderName,
+			calls:  func() {},
+		},
+		{
+			name:   "parent folder of another user",
+			ctx:    ctx,
+			folder: &domain.Folder{Name: "2024", ParentID: parentID},
+			want:   nil,
+			err:    ErrFolderNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, parentID).Return(&domain.Folder{ID: parentID, UserID: uuid.New()}, nil)
+			},
+		},
+		{
+			name:   "folder already exists",
+			ctx:    ctx,
+			folder: &domain.Folder{Name: "reports"},
+			want:   nil,
+			err:    ErrFolderExists,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolderByName(ctx, userID, uuid.Nil, "reports").Return(&domain.Folder{}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.CreateFolder(tt.ctx, "token", tt.folder)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_GetFolderContents() {
+	ctx := context.Background()
+	userID := uuid.New()
+	ownerID := uuid.New()
+	folderID := uuid.New()
+	folder := &domain.Folder{ID: folderID, UserID: ownerID, Name: "reports"}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		id    uuid.UUID
+		want  *dto.FolderContents
+		err   error
+		calls func()
+	}{
+		{
+			name: "top level",
+			ctx:  ctx,
+			id:   uuid.Nil,
+			want: &dto.FolderContents{
+				Folders:   []domain.Folder{{Name: "reports"}},
+				Documents: []domain.Document{{Name: "q1.pdf"}},
+			},
+			err: nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolders(ctx, userID, uuid.Nil).Return([]domain.Folder{{Name: "reports"}}, nil)
+				s.repo.EXPECT().GetFolderDocuments(ctx, userID, uuid.Nil).Return([]domain.Document{{Name: "q1.pdf"}}, nil)
+			},
+		},
+		{
+			name: "shared folder",
+			ctx:  ctx,
+			id:   folderID,
+			want: &dto.FolderContents{
+				Folder:    folder,
+				Folders:   []domain.Folder{},
+				Documents: []domain.Document{},
+			},
+			err: nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(folder, nil)
+				s.cache.EXPECT().Get(gomock.Any()).Return(&domain.User{Login: "login345"}, true)
+				s.repo.EXPECT().CheckFolderGrant(ctx, folderID, "login345").Return(true, nil)
+				s.repo.EXPECT().GetFolders(ctx, ownerID, folderID).Return([]domain.Folder{}, nil)
+				s.repo.EXPECT().GetFolderDocuments(ctx, ownerID, folderID).Return([]domain.Document{}, nil)
+			},
+		},
+		{
+			name: "no access",
+			ctx:  ctx,
+			id:   folderID,
+			want: nil,
+			err:  ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(folder, nil)
+				s.cache.EXPECT().Get(gomock.Any()).Return(&domain.User{Login: "login345"}, true)
+				s.repo.EXPECT().CheckFolderGrant(ctx, folderID, "login345").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.GetFolderContents(tt.ctx, tt.id, "token")
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_UpdateFolder() {
+	ctx := context.Background()
+	userID := uuid.New()
+	folderID := uuid.New()
+	oldParentID := uuid.New()
+	childID := uuid.New()
+	documentID := uuid.New()
+	folder := &domain.Folder{ID: folderID, UserID: userID, ParentID: oldParentID, Name: "q1"}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		req   *dto.UpdateFolderRequest
+		want  *domain.Folder
+		err   error
+		calls func()
+	}{
+		{
+			name: "move to top level",
+			ctx:  ctx,
+			req:  &dto.UpdateFolderRequest{ID: folderID, Token: "token", ParentID: &uuid.Nil},
+			want: &domain.Folder{ID: folderID, UserID: userID, Name: "q1"},
+			err:  nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(folder, nil)
+				s.repo.EXPECT().GetFolderByName(ctx, userID, uuid.Nil, "q1").Return(nil, repo.ErrFolderNotFound)
+				s.repo.EXPECT().GetFolderGrantLogins(ctx, oldParentID).Return([]string{"login345"}, nil)
+				s.repo.EXPECT().UpdateFolder(ctx, &domain.Folder{ID: folderID, UserID: userID, Name: "q1"}).Return(nil)
+				s.repo.EXPECT().GetFolderDocumentIDs(ctx, folderID).Return([]uuid.UUID{documentID}, nil)
+				s.cache.EXPECT().Delete(prepareCheckGrantKey(documentID, "login345"))
+			},
+		},
+		{
+			name: "move into own subfolder",
+			ctx:  ctx,
+			req:  &dto.UpdateFolderRequest{ID: folderID, Token: "token", ParentID: &childID},
+			want: nil,
+			err:  ErrFolderCycle,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(folder, nil)
+				s.repo.EXPECT().GetFolder(ctx, childID).Return(&domain.Folder{ID: childID, UserID: userID}, nil)
+				s.repo.EXPECT().IsFolderAncestor(ctx, folderID, childID).Return(true, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.UpdateFolder(tt.ctx, tt.req)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_DeleteFolder() {
+	ctx := context.Background()
+	userID := uuid.New()
+	folderID := uuid.New()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		err   error
+		calls func()
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			err:  nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: userID}, nil)
+				s.repo.EXPECT().DeleteFolder(ctx, folderID, userID).Return(nil)
+			},
+		},
+		{
+			name: "folder is not empty",
+			ctx:  ctx,
+			err:  ErrFolderNotEmpty,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: userID}, nil)
+				s.repo.EXPECT().DeleteFolder(ctx, folderID, userID).Return(repo.ErrFolderNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			err := s.service.DeleteFolder(tt.ctx, folderID, "token")
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_AddFolderGrant() {
+	ctx := context.Background()
+	userID := uuid.New()
+	folderID := uuid.New()
+	execTx := func() {
+		s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			},
+		)
+	}
+	tests := []struct {
+		name       string
+		permission string
+		err        error
+		calls      func()
+	}{
+		{
+			name: "every document of the folder is shared",
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: userID}, nil)
+				execTx()
+				s.repo.EXPECT().AddFolderGrant(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, grant *domain.FolderGrant) error {
+						s.Equal(userID, grant.UserID)
+						s.Equal(domain.PermissionRead, grant.Permission)
+						return nil
+					},
+				)
+				s.repo.EXPECT().GetFolderTreeDocuments(ctx, folderID).Return([]domain.Document{
+					{ID: uuid.New(), UserID: userID, FolderID: folderID},
+					{ID: uuid.New(), UserID: userID, FolderID: uuid.New()},
+				}, nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, event *domain.WebhookEvent) error {
+						s.Equal(domain.WebhookDocumentShared, event.Event)
+						s.Equal("login345", event.TargetLogin)
+						return nil
+					},
+				).Times(2)
+			},
+		},
+		{
+			name:       "unknown permission",
+			permission: "admin",
+			err:        ErrInvalidPermission,
+			calls:      func() {},
+		},
+		{
+			name: "event is not recorded",
+			err:  errors.New("outbox is unavailable"),
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: userID}, nil)
+				execTx()
+				s.repo.EXPECT().AddFolderGrant(ctx, gomock.Any()).Return(nil)
+				s.repo.EXPECT().GetFolderTreeDocuments(ctx, folderID).Return([]domain.Document{{ID: uuid.New()}}, nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(errors.New("outbox is unavailable"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			err := s.service.AddFolderGrant(ctx, folderID, "token", "login345", tt.permission)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_RemoveFolderGrant() {
+	ctx := context.Background()
+	userID := uuid.New()
+	folderID := uuid.New()
+	documentID := uuid.New()
+	execTx := func() {
+		s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			},
+		)
+	}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		err   error
+		calls func()
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			err:  nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: userID}, nil)
+				execTx()
+				s.repo.EXPECT().DeleteFolderGrant(ctx, folderID, "login345").Return(nil)
+				s.repo.EXPECT().GetFolderTreeDocuments(ctx, folderID).Return([]domain.Document{{ID: documentID, UserID: userID}}, nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, event *domain.WebhookEvent) error {
+						s.Equal(domain.WebhookDocumentUnshared, event.Event)
+						s.Equal(documentID, event.DocumentID)
+						return nil
+					},
+				)
+				s.repo.EXPECT().GetFolderDocumentIDs(ctx, folderID).Return([]uuid.UUID{documentID}, nil)
+				s.cache.EXPECT().Delete(prepareCheckGrantKey(documentID, "login345"))
+			},
+		},
+		{
+			name: "grant not found",
+			ctx:  ctx,
+			err:  ErrGrantNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolder(ctx, folderID).Return(&domain.Folder{ID: folderID, UserID: userID}, nil)
+				execTx()
+				s.repo.EXPECT().DeleteFolderGrant(ctx, folderID, "login345").Return(repo.ErrGrantNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			err := s.service.RemoveFolderGrant(tt.ctx, folderID, "token", "login345")
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_ResolvePath() {
+	ctx := context.Background()
+	userID := uuid.New()
+	reportsID := uuid.New()
+	yearID := uuid.New()
+	documentID := uuid.New()
+	document := &domain.Document{ID: documentID, UserID: userID, Name: "q1.pdf"}
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		path         string
+		wantDocument *domain.Document
+		err          error
+		calls        func()
+	}{
+		{
+			name:         "document",
+			ctx:          ctx,
+			path:         "/reports/2024/q1.pdf",
+			wantDocument: document,
+			err:          nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolderByName(ctx, userID, uuid.Nil, "reports").Return(&domain.Folder{ID: reportsID}, nil)
+				s.repo.EXPECT().GetFolderByName(ctx, userID, reportsID, "2024").Return(&domain.Folder{ID: yearID}, nil)
+				s.repo.EXPECT().GetFolderByName(ctx, userID, yearID, "q1.pdf").Return(nil, repo.ErrFolderNotFound)
+				s.repo.EXPECT().GetDocumentIDByName(ctx, userID, yearID, "q1.pdf").Return(documentID, nil)
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document, true)
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+			},
+		},
+		{
+			name:         "missing folder",
+			ctx:          ctx,
+			path:         "/archive/q1.pdf",
+			wantDocument: nil,
+			err:          ErrFolderNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetFolderByName(ctx, userID, uuid.Nil, "archive").Return(nil, repo.ErrFolderNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			_, document, err := s.service.ResolvePath(tt.ctx, tt.path, "token")
+			s.Equal(tt.wantDocument, document)
+			s.Equal(tt.err, err)
+		})
+	}
+}
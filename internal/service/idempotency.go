@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// reserveIdempotencyKey claims key for a request of the user. It returns nil
+// when the key is claimed and the request should run, the stored key of a
+// finished request to replay, or ErrIdempotencyKeyInUse while the first
+// request with the key is in flight.
+func (s *Service) reserveIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotencyKey, error) {
+	now := time.Now()
+	reserved, err := s.repo.ReserveIdempotencyKey(ctx, &domain.IdempotencyKey{
+		UserID:    userID,
+		Key:       key,
+		Status:    domain.IdempotencyPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.idempotency.TTL),
+	}, now.Add(-s.idempotency.LockTimeout))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	stored, err := s.repo.GetIdempotencyKey(ctx, userID, key)
+	// the first request failed and released the key in the meantime
+	if errors.Is(err, repo.ErrIdempotencyKeyNotFound) {
+		return nil, ErrIdempotencyKeyInUse
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.Status != domain.IdempotencyDone {
+		return nil, ErrIdempotencyKeyInUse
+	}
+
+	return stored, nil
+}
+
+// releaseIdempotencyKey frees the key of a failed request so the client can
+// retry it. It runs even when the request was canceled.
+func (s *Service) releaseIdempotencyKey(ctx context.Context, l *logger.Logger, userID uuid.UUID, key string) {
+	err := s.repo.DeleteIdempotencyKey(context.WithoutCancel(ctx), userID, key)
+	if err != nil {
+		l.WithError(err).Error("error release idempotency key")
+	}
+}
+
+// PurgeIdempotencyKeys removes the keys kept longer than their TTL.
+func (s *Service) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	l := s.log.WithField("service_method", "PurgeIdempotencyKeys")
+
+	purged, err := s.repo.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+	if err != nil {
+		l.WithError(err).Error("error delete idempotency keys")
+		return 0, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/internal/unpack"
+	"github.com/google/uuid"
+)
+
+// Import creates a document for every file of an archive. The archive is
+// unpacked and checked before anything is saved, then the documents are saved
+// in transactions of the configured batch size, all in one when it is 0.
+// A file that can not be unpacked or a batch that fails is reported in its
+// entries, the rest of the archive is still imported.
+func (s *Service) Import(ctx context.Context, req *dto.ImportRequest) (*dto.ImportReport, error) {
+	l := s.log.WithField("service_method", "Import")
+
+	if !isValidMetadata(req.Metadata) {
+		l.Warn(ErrInvalidMetadata.Error())
+		return nil, ErrInvalidMetadata
+	}
+
+	userID, err := s.getUserID(ctx, req.Token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrTokenNotFound
+	}
+
+	err = s.checkFolderOwner(ctx, userID, req.FolderID)
+	if err != nil {
+		l.WithError(err).Error("error check folder")
+		return nil, err
+	}
+
+	report := &dto.ImportReport{Entries: make([]dto.ImportEntry, 0)}
+	documents := make(map[int]*dto.Document)
+	err = unpack.Walk(req.Archive, s.importLimits(), func(entry unpack.Entry) error {
+		item := dto.ImportEntry{Path: entry.Path}
+		if entry.Err != nil {
+			item.Error = entry.Err.Error()
+			report.Entries = append(report.Entries, item)
+			return nil
+		}
+
+		document := toImportDocument(req, entry)
+		item.Name, item.Mime, item.Size = document.Name, document.Mime, int64(len(document.Content))
+		documents[len(report.Entries)] = document
+		report.Entries = append(report.Entries, item)
+		return nil
+	})
+	if err != nil {
+		l.WithError(err).Warn("error unpack archive")
+		return nil, toImportError(err)
+	}
+
+	batch := make([]int, 0, len(documents))
+	for i := range report.Entries {
+		if _, ok := documents[i]; !ok {
+			continue
+		}
+
+		batch = append(batch, i)
+		if len(batch) == s.imports.BatchSize {
+			s.importBatch(ctx, userID, report, documents, batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		s.importBatch(ctx, userID, report, documents, batch)
+	}
+
+	for _, entry := range report.Entries {
+		if entry.Error != "" {
+			report.Failed++
+		} else {
+			report.Created++
+		}
+	}
+
+	if report.Created > 0 {
+		s.notifyExtraction()
+		s.notifyThumbnails()
+		s.notifyWebhooks()
+	}
+
+	return report, nil
+}
+
+// importBatch saves the documents of the given entries in one transaction
+// and marks every entry of the batch failed when it is rolled back.
+func (s *Service) importBatch(ctx context.Context, userID uuid.UUID, report *dto.ImportReport, documents map[int]*dto.Document, batch []int) {
+	l := s.log.WithField("service_method", "Import")
+
+	ids := make([]uuid.UUID, len(batch))
+	err := s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		for i, entry := range batch {
+			saved, err := s.saveDocument(ctx, l, userID, documents[entry])
+			if err != nil {
+				return err
+			}
+			ids[i] = saved.ID
+		}
+
+		return nil
+	})
+
+	for i, entry := range batch {
+		if err != nil {
+			report.Entries[entry].Error = ErrImportBatch.Error()
+			continue
+		}
+		report.Entries[entry].DocumentID = ids[i]
+	}
+}
+
+func (s *Service) importLimits() unpack.Limits {
+	return unpack.Limits{
+		MaxEntries:   s.imports.MaxEntries,
+		MaxEntrySize: s.imports.MaxEntrySize,
+		MaxTotalSize: s.imports.MaxTotalSize,
+	}
+}
+
+func toImportDocument(req *dto.ImportRequest, entry unpack.Entry) *dto.Document {
+	return &dto.Document{
+		Name:        path.Base(entry.Path),
+		Token:       req.Token,
+		Mime:        detectMime(entry.Path, entry.Content),
+		Description: req.Description,
+		Content:     entry.Content,
+		Grant:       req.Grant,
+		Tags:        req.Tags,
+		Metadata:    req.Metadata,
+		FolderID:    req.FolderID,
+		Public:      req.Public,
+	}
+}
+
+// detectMime prefers the type of the file extension and sniffs the content
+// of files without a known one.
+func detectMime(name string, content []byte) string {
+	if mimeType := mime.TypeByExtension(strings.ToLower(path.Ext(name))); mimeType != "" {
+		return mimeType
+	}
+
+	return http.DetectContentType(content)
+}
+
+// toImportError keeps the errors about the archive itself and hides the
+// details of a broken one.
+func toImportError(err error) error {
+	for _, known := range []error{unpack.ErrUnsupported, unpack.ErrTooManyEntries, unpack.ErrTooLarge} {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+
+	return ErrInvalidArchive
+}
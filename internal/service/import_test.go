@@ -0,0 +1,165 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/internal/unpack"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_Import() {
+	ctx := context.Background()
+	userID := uuid.New()
+	id1, id2 := uuid.New(), uuid.New()
+	png := []byte("\x89PNG\r\n\x1a\n")
+
+	save := func(id uuid.UUID) func(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+		return func(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+			saved := *document
+			saved.ID, saved.Version = id, 1
+			return &saved, nil
+		}
+	}
+	tx := func() {
+		s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			},
+		)
+	}
+
+	tests := []struct {
+		name      string
+		batchSize int
+		req       *dto.ImportRequest
+		want      *dto.ImportReport
+		err       error
+		calls     func()
+	}{
+		{
+			name: "one transaction",
+			req: &dto.ImportRequest{
+				Token:   "token",
+				Tags:    []string{"scan"},
+				Public:  true,
+				Archive: zipArchive(s.T(), "notes.txt", "hello", "../escape.txt", "x", "photos/", "", "photos/cat", string(png)),
+			},
+			want: &dto.ImportReport{
+				Created: 2,
+				Failed:  1,
+				Entries: []dto.ImportEntry{
+					{Path: "notes.txt", DocumentID: id1, Name: "notes.txt", Mime: "text/plain; charset=utf-8", Size: 5},
+					{Path: "../escape.txt", Error: unpack.ErrUnsafePath.Error()},
+					{Path: "photos/cat", DocumentID: id2, Name: "cat", Mime: "image/png", Size: int64(len(png))},
+				},
+			},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				tx()
+				s.repo.EXPECT().Save(ctx, &domain.Document{
+					Name:     "notes.txt",
+					UserID:   userID,
+					Mime:     "text/plain; charset=utf-8",
+					Content:  "aGVsbG8=",
+					Size:     5,
+					Tags:     []string{"scan"},
+					Checksum: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+					Public:   true,
+				}).DoAndReturn(save(id1))
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil).Times(2)
+				s.repo.EXPECT().AddTextExtraction(ctx, id1, 1).Return(nil)
+				s.repo.EXPECT().Save(ctx, gomock.Any()).DoAndReturn(save(id2))
+				s.repo.EXPECT().AddTextExtraction(ctx, id2, 1).Return(nil)
+				s.repo.EXPECT().AddThumbnailJob(ctx, id2, 1).Return(nil)
+			},
+		},
+		{
+			name:      "failed batch",
+			batchSize: 1,
+			req: &dto.ImportRequest{
+				Token:   "token",
+				Grant:   []string{"login"},
+				Archive: zipArchive(s.T(), "a.txt", "a", "b.txt", "b"),
+			},
+			want: &dto.ImportReport{
+				Created: 1,
+				Failed:  1,
+				Entries: []dto.ImportEntry{
+					{Path: "a.txt", Name: "a.txt", Mime: "text/plain; charset=utf-8", Size: 1, Error: ErrImportBatch.Error()},
+					{Path: "b.txt", DocumentID: id2, Name: "b.txt", Mime: "text/plain; charset=utf-8", Size: 1},
+				},
+			},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				tx()
+				s.repo.EXPECT().Save(ctx, gomock.Any()).DoAndReturn(save(id1))
+				s.repo.EXPECT().AddGrant(ctx, toGrant("login", userID, id1)).Return(errors.ErrUnsupported)
+				tx()
+				s.repo.EXPECT().Save(ctx, gomock.Any()).DoAndReturn(save(id2))
+				s.repo.EXPECT().AddGrant(ctx, toGrant("login", userID, id2)).Return(nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil).Times(2)
+				s.repo.EXPECT().AddTextExtraction(ctx, id2, 1).Return(nil)
+			},
+		},
+		{
+			name: "not an archive",
+			req:  &dto.ImportRequest{Token: "token", Archive: []byte("plain text")},
+			err:  unpack.ErrUnsupported,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+			},
+		},
+		{
+			name: "damaged archive",
+			req:  &dto.ImportRequest{Token: "token", Archive: []byte("PK\x03\x04 damaged")},
+			err:  ErrInvalidArchive,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+			},
+		},
+		{
+			name:  "invalid metadata",
+			req:   &dto.ImportRequest{Token: "token", Metadata: map[string]any{"list": []any{1}}},
+			err:   ErrInvalidMetadata,
+			calls: func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			s.service.imports.BatchSize = tt.batchSize
+			tt.calls()
+			got, err := s.service.Import(ctx, tt.req)
+			s.Equal(tt.err, err)
+			s.Equal(tt.want, got)
+		})
+	}
+}
+
+// zipArchive builds a ZIP from name/content pairs, a name ending with "/" is a directory.
+func zipArchive(t *testing.T, files ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		file, err := archive.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
@@ -13,20 +13,104 @@ import (
 type Repository interface {
 	ExecTx(ctx context.Context, fn func(ctx context.Context) error) error
 	Registration(ctx context.Context, user *domain.User) error
-	CheckUser(ctx context.Context, user *domain.User) (uuid.UUID, error)
+	GetUserByLogin(ctx context.Context, login string) (*domain.User, error)
 	Authentication(ctx context.Context, user *domain.User) error
 	GetUserID(ctx context.Context, token string) (uuid.UUID, error)
+	GetUserToken(ctx context.Context, userID uuid.UUID) (string, error)
 	LogOut(ctx context.Context, token string) error
-	Save(ctx context.Context, document *domain.Document) (uuid.UUID, error)
+	SetUserDisabled(ctx context.Context, login string, disabledAt *time.Time) (uuid.UUID, error)
+	DeleteUserTokens(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error)
+	GetPlainPasswordUsers(ctx context.Context) ([]domain.User, error)
+	ReplacePassword(ctx context.Context, userID uuid.UUID, old, password string) (bool, error)
+	GetStorageUsage(ctx context.Context) ([]domain.StorageUsage, error)
+	GetDocumentContents(ctx context.Context, after uuid.UUID, limit int) ([]domain.Document, error)
+	Save(ctx context.Context, document *domain.Document) (*domain.Document, error)
 	AddGrant(ctx context.Context, grant *domain.Grant) error
 	GetDocument(ctx context.Context, id uuid.UUID) (*domain.Document, error)
+	UpdateDocument(ctx context.Context, document *domain.Document) (*domain.Document, error)
+	ReplaceContent(ctx context.Context, document *domain.Document) (*domain.Document, error)
 	CheckGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error)
+	CheckCommentGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error)
+	CheckEditGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error)
+	DeleteGrant(ctx context.Context, documentID uuid.UUID, login string) error
+	GetGrantLogins(ctx context.Context, documentID uuid.UUID) ([]string, error)
 	GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	GetDocuments(ctx context.Context, filter *dto.GetDocuments) ([]domain.Document, error)
+	SearchDocuments(ctx context.Context, search *dto.Search) ([]domain.SearchResult, error)
+	AddTextExtraction(ctx context.Context, documentID uuid.UUID, version int) error
+	ClaimTextExtractions(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.TextExtraction, error)
+	UpdateTextExtraction(ctx context.Context, extraction *domain.TextExtraction) error
+	GetTextExtraction(ctx context.Context, documentID uuid.UUID, version int) (*domain.TextExtraction, error)
+	SetDocumentText(ctx context.Context, documentID uuid.UUID, version int, text string) error
+	AddThumbnailJob(ctx context.Context, documentID uuid.UUID, version int) error
+	ClaimThumbnailJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.ThumbnailJob, error)
+	UpdateThumbnailJob(ctx context.Context, job *domain.ThumbnailJob) error
+	SaveRendition(ctx context.Context, rendition *domain.Rendition) error
+	GetRendition(ctx context.Context, documentID uuid.UUID, version int, size string) (*domain.Rendition, error)
+	DeleteOutdatedRenditions(ctx context.Context, documentID uuid.UUID, version int) error
 	DeleteDocument(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error)
+	GetTrash(ctx context.Context, userID uuid.UUID) ([]domain.Document, error)
+	RestoreDocument(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	SetLegalHold(ctx context.Context, id uuid.UUID, hold bool) error
+	AddRetentionPolicy(ctx context.Context, policy *domain.RetentionPolicy) (uuid.UUID, error)
+	DeleteRetentionPolicy(ctx context.Context, id uuid.UUID) error
+	GetRetentionPolicies(ctx context.Context, document *domain.Document) ([]domain.RetentionPolicy, error)
+	GetExpiredDocuments(ctx context.Context, now time.Time) ([]domain.Document, error)
+	CreateFolder(ctx context.Context, folder *domain.Folder) (uuid.UUID, error)
+	GetFolder(ctx context.Context, id uuid.UUID) (*domain.Folder, error)
+	GetFolderByName(ctx context.Context, userID, parentID uuid.UUID, name string) (*domain.Folder, error)
+	GetFolders(ctx context.Context, userID, parentID uuid.UUID) ([]domain.Folder, error)
+	GetFolderDocuments(ctx context.Context, userID, folderID uuid.UUID) ([]domain.Document, error)
+	GetDocumentIDByName(ctx context.Context, userID, folderID uuid.UUID, name string) (uuid.UUID, error)
+	UpdateFolder(ctx context.Context, folder *domain.Folder) error
+	DeleteFolder(ctx context.Context, id, userID uuid.UUID) error
+	IsFolderAncestor(ctx context.Context, ancestorID, folderID uuid.UUID) (bool, error)
+	AddFolderGrant(ctx context.Context, grant *domain.FolderGrant) error
+	DeleteFolderGrant(ctx context.Context, folderID uuid.UUID, login string) error
+	CheckFolderGrant(ctx context.Context, folderID uuid.UUID, login string) (bool, error)
+	GetFolderGrantLogins(ctx context.Context, folderID uuid.UUID) ([]string, error)
+	GetFolderDocumentIDs(ctx context.Context, folderID uuid.UUID) ([]uuid.UUID, error)
+	GetFolderTreeDocuments(ctx context.Context, folderID uuid.UUID) ([]domain.Document, error)
+	ReserveIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey, staleBefore time.Time) (bool, error)
+	GetIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+	AddAuditEvent(ctx context.Context, event *domain.AuditEvent) error
+	GetAuditEvents(ctx context.Context, filter *dto.GetAuditEvents) ([]domain.AuditEvent, error)
+	ChainAuditEvents(ctx context.Context, limit int) (int, error)
+	VerifyAuditChain(ctx context.Context) (*domain.AuditVerification, error)
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error)
+	GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context, userID uuid.UUID) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	AddWebhookEvent(ctx context.Context, event *domain.WebhookEvent) error
+	DispatchWebhookEvents(ctx context.Context, now time.Time, limit int) (int, error)
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]domain.WebhookDelivery, error)
+	SequenceEvents(ctx context.Context, limit int) (int, error)
+	GetEvents(ctx context.Context, userID uuid.UUID, login string, after int64, limit int) ([]domain.WebhookEvent, error)
+	GetLastEventID(ctx context.Context) (int64, error)
+	AddEventTicket(ctx context.Context, ticket *domain.EventTicket) error
+	TakeEventTicket(ctx context.Context, ticket string, now time.Time) (uuid.UUID, error)
+	DeleteExpiredEventTickets(ctx context.Context, now time.Time) (int64, error)
+	ListenEvents(ctx context.Context, fn func(notification *domain.EventNotification)) error
+	CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error)
+	GetComment(ctx context.Context, id uuid.UUID) (*domain.Comment, error)
+	GetComments(ctx context.Context, documentID uuid.UUID) ([]domain.Comment, error)
+	UpdateComment(ctx context.Context, id uuid.UUID, body string) (time.Time, error)
+	DeleteComment(ctx context.Context, id uuid.UUID) error
+	AcquireLock(ctx context.Context, lock *domain.DocumentLock) (bool, error)
+	GetLock(ctx context.Context, documentID uuid.UUID, now time.Time) (*domain.DocumentLock, error)
+	DeleteLock(ctx context.Context, documentID, userID uuid.UUID) error
+	DeleteExpiredLocks(ctx context.Context, now time.Time) (int64, error)
 }
 
 type Cache interface {
 	Set(k string, x any, d time.Duration)
 	Get(k string) (any, bool)
+	Delete(k string)
 }
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+)
+
+const (
+	defaultJobBatch       = 10
+	defaultJobMaxAttempts = 5
+
+	// jobLease hides a claimed job from other instances while it runs.
+	jobLease = 5 * time.Minute
+)
+
+// jobPolicy is how a background worker claims and retries its jobs.
+type jobPolicy struct {
+	batch        int
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+// newJobPolicy fills in the defaults of the settings that are not configured.
+func newJobPolicy(batch, maxAttempts int, retryBackoff time.Duration) jobPolicy {
+	if batch <= 0 {
+		batch = defaultJobBatch
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultJobMaxAttempts
+	}
+
+	return jobPolicy{batch: batch, maxAttempts: maxAttempts, retryBackoff: retryBackoff}
+}
+
+// retry counts the failed attempt and schedules the next one with exponential
+// backoff, the job fails once it is out of attempts.
+func (p jobPolicy) retry(job *domain.Job, err error) {
+	job.Attempts++
+	job.Error = err.Error()
+	if job.Attempts >= p.maxAttempts {
+		job.Status = domain.JobFailed
+		return
+	}
+
+	job.Status = domain.JobPending
+	job.NextAttemptAt = time.Now().Add(retryBackoff(p.retryBackoff, job.Attempts))
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_jobPolicy(t *testing.T) {
+	assert.Equal(t, jobPolicy{batch: defaultJobBatch, maxAttempts: defaultJobMaxAttempts}, newJobPolicy(0, 0, 0))
+
+	policy := newJobPolicy(20, 3, time.Minute)
+	job := &domain.Job{Status: domain.JobPending, Attempts: 1}
+
+	policy.retry(job, errors.New("connection refused"))
+	assert.Equal(t, domain.JobPending, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "connection refused", job.Error)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), job.NextAttemptAt, time.Second)
+
+	policy.retry(job, errors.New("connection reset"))
+	assert.Equal(t, domain.JobFailed, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Equal(t, "connection reset", job.Error)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/google/uuid"
+)
+
+// LockDocument checks the document out for the caller for ttl, the configured
+// TTL when it is zero. Locking it again extends the lock of the holder. Only
+// users who may upload new content of the document may lock it.
+func (s *Service) LockDocument(ctx context.Context, id uuid.UUID, token string, ttl time.Duration) (_ *domain.DocumentLock, err error) {
+	l := s.log.WithField("service_method", "LockDocument")
+
+	event := &domain.AuditEvent{Action: domain.AuditLock, DocumentID: id}
+	defer func() { s.audit(ctx, event, err) }()
+
+	if ttl == 0 {
+		ttl = s.locks.TTL
+	}
+	if ttl <= 0 || ttl > s.locks.MaxTTL {
+		l.Warn(ErrInvalidLockTTL.Error())
+		return nil, ErrInvalidLockTTL
+	}
+
+	document, err := s.repo.GetDocument(ctx, id)
+	if err != nil {
+		l.WithError(err).Error("error get document")
+		return nil, ErrDocumentNotFound
+	}
+
+	event.ActorID, event.ActorLogin, err = s.checkAccess(ctx, document, token, domain.PermissionEdit)
+	if err != nil {
+		l.WithError(err).Warn("error check access")
+		return nil, err
+	}
+
+	user, err := s.getUserByID(ctx, event.ActorID)
+	if err != nil {
+		l.WithError(err).Error("error get user")
+		return nil, ErrUserNotFound
+	}
+
+	now := time.Now()
+	lock := &domain.DocumentLock{
+		DocumentID: id,
+		UserID:     user.ID,
+		Login:      user.Login,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	acquired, err := s.repo.AcquireLock(ctx, lock)
+	if err != nil {
+		l.WithError(err).Error("error acquire lock")
+		return nil, err
+	}
+	if !acquired {
+		l.Warn(ErrLocked.Error())
+		return nil, ErrLocked
+	}
+
+	return lock, nil
+}
+
+// GetLock returns the lock of the document to anyone who may read it.
+func (s *Service) GetLock(ctx context.Context, id uuid.UUID, token string) (*domain.DocumentLock, error) {
+	l := s.log.WithField("service_method", "GetLock")
+
+	document, err := s.getDocument(ctx, id)
+	if err != nil {
+		l.WithError(err).Error("error get document")
+		return nil, ErrDocumentNotFound
+	}
+
+	_, _, err = s.checkAccess(ctx, document, token, domain.PermissionRead)
+	if err != nil {
+		l.WithError(err).Warn("error check access")
+		return nil, err
+	}
+
+	lock, err := s.repo.GetLock(ctx, id, time.Now())
+	if errors.Is(err, repo.ErrLockNotFound) {
+		return nil, ErrLockNotFound
+	}
+	if err != nil {
+		l.WithError(err).Error("error get lock")
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// UnlockDocument checks the document in, only the holder of the lock may do it.
+func (s *Service) UnlockDocument(ctx context.Context, id uuid.UUID, token string) (err error) {
+	l := s.log.WithField("service_method", "UnlockDocument")
+
+	event := &domain.AuditEvent{Action: domain.AuditUnlock, DocumentID: id}
+	defer func() { s.audit(ctx, event, err) }()
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return ErrUserNotFound
+	}
+	event.ActorID = userID
+
+	err = s.checkLock(ctx, id, userID)
+	if err != nil {
+		l.WithError(err).Warn("error check lock")
+		return err
+	}
+
+	err = s.repo.DeleteLock(ctx, id, userID)
+	if errors.Is(err, repo.ErrLockNotFound) {
+		return ErrLockNotFound
+	}
+	if err != nil {
+		l.WithError(err).Error("error delete lock")
+		return err
+	}
+
+	return nil
+}
+
+// BreakLock releases the lock of the document whoever holds it.
+func (s *Service) BreakLock(ctx context.Context, id uuid.UUID) error {
+	l := s.log.WithField("service_method", "BreakLock")
+
+	err := s.repo.DeleteLock(ctx, id, uuid.Nil)
+	if errors.Is(err, repo.ErrLockNotFound) {
+		return ErrLockNotFound
+	}
+	if err != nil {
+		l.WithError(err).Error("error delete lock")
+		return err
+	}
+
+	return nil
+}
+
+// PurgeLocks removes the locks that expired, they stopped blocking uploads
+// when they expired.
+func (s *Service) PurgeLocks(ctx context.Context) (int64, error) {
+	l := s.log.WithField("service_method", "PurgeLocks")
+
+	purged, err := s.repo.DeleteExpiredLocks(ctx, time.Now())
+	if err != nil {
+		l.WithError(err).Error("error delete locks")
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// checkLock returns ErrLocked when another user holds the lock of the document.
+func (s *Service) checkLock(ctx context.Context, documentID, userID uuid.UUID) error {
+	lock, err := s.repo.GetLock(ctx, documentID, time.Now())
+	if errors.Is(err, repo.ErrLockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if lock.UserID != userID {
+		return ErrLocked
+	}
+
+	return nil
+}
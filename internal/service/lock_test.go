@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_LockDocument() {
+	ctx := context.Background()
+	id, ownerID, editorID := uuid.New(), uuid.New(), uuid.New()
+	document := &domain.Document{ID: id, UserID: ownerID}
+	editor := &domain.User{ID: editorID, Login: "editor42"}
+
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		err   error
+		calls func()
+	}{
+		{
+			name: "editor with default ttl",
+			calls: func() {
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(editorID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(editorID)).Return(editor, true).Times(2)
+				s.repo.EXPECT().CheckEditGrant(ctx, id, "editor42").Return(true, nil)
+				s.repo.EXPECT().AcquireLock(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, lock *domain.DocumentLock) (bool, error) {
+						s.Equal(editorID, lock.UserID)
+						s.Equal(15*time.Minute, lock.ExpiresAt.Sub(lock.CreatedAt))
+						return true, nil
+					},
+				)
+			},
+		},
+		{
+			name: "held by another user",
+			ttl:  time.Minute,
+			err:  ErrLocked,
+			calls: func() {
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(ownerID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(ownerID)).Return(&domain.User{ID: ownerID, Login: "owner42"}, true)
+				s.repo.EXPECT().AcquireLock(ctx, gomock.Any()).Return(false, nil)
+			},
+		},
+		{
+			name: "reader",
+			ttl:  time.Minute,
+			err:  ErrNoAccess,
+			calls: func() {
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(editorID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(editorID)).Return(editor, true)
+				s.repo.EXPECT().CheckEditGrant(ctx, id, "editor42").Return(false, nil)
+			},
+		},
+		{
+			name:  "ttl above the limit",
+			ttl:   2 * time.Hour,
+			err:   ErrInvalidLockTTL,
+			calls: func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			_, err := s.service.LockDocument(ctx, id, "token", tt.ttl)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_UnlockDocument() {
+	ctx := context.Background()
+	id, userID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name  string
+		err   error
+		calls func()
+	}{
+		{
+			name: "holder",
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetLock(ctx, id, gomock.Any()).Return(&domain.DocumentLock{DocumentID: id, UserID: userID}, nil)
+				s.repo.EXPECT().DeleteLock(ctx, id, userID).Return(nil)
+			},
+		},
+		{
+			name: "held by another user",
+			err:  ErrLocked,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetLock(ctx, id, gomock.Any()).Return(&domain.DocumentLock{DocumentID: id, UserID: uuid.New()}, nil)
+			},
+		},
+		{
+			name: "not locked",
+			err:  ErrLockNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().GetLock(ctx, id, gomock.Any()).Return(nil, repo.ErrLockNotFound)
+				s.repo.EXPECT().DeleteLock(ctx, id, userID).Return(repo.ErrLockNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			err := s.service.UnlockDocument(ctx, id, "token")
+			s.Equal(tt.err, err)
+		})
+	}
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"encoding/base64"
+	"strings"
 
 	"github.com/Alina9496/documents/internal/domain"
 	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/internal/thumbnail"
 	"github.com/google/uuid"
 )
 
@@ -14,12 +16,18 @@ func toDocument(userID uuid.UUID, document *dto.Document) *domain.Document {
 	}
 
 	return &domain.Document{
-		Name:    document.Name,
-		UserID:  userID,
-		Mime:    document.Mime,
-		Content: base64.StdEncoding.EncodeToString(document.Content),
-		Grant:   document.Grant,
-		Public:  document.Public,
+		Name:        document.Name,
+		UserID:      userID,
+		Mime:        document.Mime,
+		Description: document.Description,
+		Content:     base64.StdEncoding.EncodeToString(document.Content),
+		Size:        int64(len(document.Content)),
+		Checksum:    checksum(document.Content),
+		Grant:       document.Grant,
+		Tags:        normalizeTags(document.Tags),
+		Metadata:    document.Metadata,
+		FolderID:    document.FolderID,
+		Public:      document.Public,
 	}
 }
 
@@ -28,15 +36,143 @@ func toGrant(login string, userID, documentID uuid.UUID) *domain.Grant {
 		UserID:         userID,
 		DocumentID:     documentID,
 		GrantUserLogin: login,
+		Permission:     domain.PermissionRead,
 	}
 }
 
-func toGetDocuments(userID uuid.UUID, filter *dto.GetDocumentsRequest) *dto.GetDocuments {
-	return &dto.GetDocuments{
-		UserID: userID,
-		Login:  filter.Login,
-		Key:    filter.Key,
-		Value:  filter.Value,
-		Limit:  filter.Limit,
+func toGetDocuments(user *domain.User, filter *dto.GetDocumentsRequest, after *dto.Cursor) *dto.GetDocuments {
+	query := &dto.GetDocuments{
+		UserID:       user.ID,
+		UserLogin:    user.Login,
+		Scope:        filter.Scope,
+		GrantedTo:    filter.Login,
+		Key:          filter.Key,
+		Value:        filter.Value,
+		Tags:         normalizeTags(filter.Tags),
+		Metadata:     filter.Metadata,
+		NamePrefix:   filter.NamePrefix,
+		NameContains: filter.NameContains,
+		Mime:         filter.Mime,
+		Public:       filter.Public,
+		Owner:        filter.Owner,
+		CreatedFrom:  filter.CreatedFrom,
+		CreatedTo:    filter.CreatedTo,
+		Sort:         filter.Sort,
+		Order:        filter.Order,
+		After:        after,
+		Limit:        filter.Limit,
+	}
+	if query.Scope == "" {
+		query.Scope = dto.ScopeAll
+	}
+	if query.Sort == "" {
+		query.Sort = dto.SortName
+	}
+	if query.Order == "" {
+		query.Order = dto.OrderAsc
+	}
+
+	return query
+}
+
+func toCursor(query *dto.GetDocuments, last *domain.Document) *dto.Cursor {
+	cursor := &dto.Cursor{
+		Sort:  query.Sort,
+		Order: query.Order,
+		ID:    last.ID,
+	}
+	switch query.Sort {
+	case dto.SortCreated:
+		cursor.Created = last.CreatedAt
+	case dto.SortSize:
+		cursor.Size = last.Size
+	default:
+		cursor.Name = last.Name
+	}
+
+	return cursor
+}
+
+func applyUpdate(document *domain.Document, req *dto.UpdateDocumentRequest) *domain.Document {
+	updated := *document
+	if req.Name != nil {
+		updated.Name = *req.Name
+	}
+	if req.Mime != nil {
+		updated.Mime = *req.Mime
+	}
+	if req.Description != nil {
+		updated.Description = *req.Description
+	}
+	if req.Public != nil {
+		updated.Public = *req.Public
+	}
+	if req.Tags != nil {
+		updated.Tags = normalizeTags(req.Tags)
+	}
+	if req.Metadata != nil {
+		updated.Metadata = req.Metadata
+	}
+	if req.FolderID != nil {
+		updated.FolderID = *req.FolderID
+	}
+
+	return &updated
+}
+
+// applyContent returns the document with the content of req, the MIME type is
+// kept when req does not change it.
+func applyContent(document *domain.Document, req *dto.DocumentContentRequest) *domain.Document {
+	updated := *document
+	updated.Content = base64.StdEncoding.EncodeToString(req.Content)
+	updated.Size = int64(len(req.Content))
+	updated.Checksum = checksum(req.Content)
+	if req.Mime != "" {
+		updated.Mime = req.Mime
+	}
+
+	return &updated
+}
+
+func toRendition(job *domain.ThumbnailJob, image thumbnail.Thumbnail) *domain.Rendition {
+	return &domain.Rendition{
+		DocumentID: job.DocumentID,
+		Version:    job.Version,
+		Size:       image.Size,
+		Mime:       image.Mime,
+		Width:      image.Width,
+		Height:     image.Height,
+		Data:       image.Data,
+	}
+}
+
+func toComment(req *dto.CommentRequest, userID uuid.UUID) *domain.Comment {
+	return &domain.Comment{
+		DocumentID: req.DocumentID,
+		ParentID:   req.ParentID,
+		UserID:     userID,
+		Body:       req.Body,
+		Page:       req.Page,
+		RangeStart: req.RangeStart,
+		RangeEnd:   req.RangeEnd,
+	}
+}
+
+func toFolderGrant(login string, userID, folderID uuid.UUID) *domain.FolderGrant {
+	return &domain.FolderGrant{
+		UserID:         userID,
+		FolderID:       folderID,
+		GrantUserLogin: login,
+		Permission:     domain.PermissionRead,
+	}
+}
+
+func toSearch(user *domain.User, req *dto.SearchRequest) *dto.Search {
+	return &dto.Search{
+		UserID: user.ID,
+		Login:  user.Login,
+		Query:  strings.TrimSpace(req.Query),
+		Limit:  req.Limit,
+		Offset: req.Offset,
 	}
 }
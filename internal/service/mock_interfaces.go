@@ -9,10 +9,10 @@ import (
 	reflect "reflect"
 	time "time"
 
-	gomock "github.com/golang/mock/gomock"
-	uuid "github.com/google/uuid"
 	domain "github.com/Alina9496/documents/internal/domain"
 	dto "github.com/Alina9496/documents/internal/service/dto"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // MockRepository is a mock of Repository interface.
@@ -38,6 +38,63 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AcquireLock mocks base method.
+func (m *MockRepository) AcquireLock(ctx context.Context, lock *domain.DocumentLock) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLock", ctx, lock)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireLock indicates an expected call of AcquireLock.
+func (mr *MockRepositoryMockRecorder) AcquireLock(ctx, lock interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLock", reflect.TypeOf((*MockRepository)(nil).AcquireLock), ctx, lock)
+}
+
+// AddAuditEvent mocks base method.
+func (m *MockRepository) AddAuditEvent(ctx context.Context, event *domain.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuditEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAuditEvent indicates an expected call of AddAuditEvent.
+func (mr *MockRepositoryMockRecorder) AddAuditEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuditEvent", reflect.TypeOf((*MockRepository)(nil).AddAuditEvent), ctx, event)
+}
+
+// AddEventTicket mocks base method.
+func (m *MockRepository) AddEventTicket(ctx context.Context, ticket *domain.EventTicket) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEventTicket", ctx, ticket)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddEventTicket indicates an expected call of AddEventTicket.
+func (mr *MockRepositoryMockRecorder) AddEventTicket(ctx, ticket interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEventTicket", reflect.TypeOf((*MockRepository)(nil).AddEventTicket), ctx, ticket)
+}
+
+// AddFolderGrant mocks base method.
+func (m *MockRepository) AddFolderGrant(ctx context.Context, grant *domain.FolderGrant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFolderGrant", ctx, grant)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddFolderGrant indicates an expected call of AddFolderGrant.
+func (mr *MockRepositoryMockRecorder) AddFolderGrant(ctx, grant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFolderGrant", reflect.TypeOf((*MockRepository)(nil).AddFolderGrant), ctx, grant)
+}
+
 // AddGrant mocks base method.
 func (m *MockRepository) AddGrant(ctx context.Context, grant *domain.Grant) error {
 	m.ctrl.T.Helper()
@@ -52,6 +109,63 @@ func (mr *MockRepositoryMockRecorder) AddGrant(ctx, grant interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGrant", reflect.TypeOf((*MockRepository)(nil).AddGrant), ctx, grant)
 }
 
+// AddRetentionPolicy mocks base method.
+func (m *MockRepository) AddRetentionPolicy(ctx context.Context, policy *domain.RetentionPolicy) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRetentionPolicy", ctx, policy)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRetentionPolicy indicates an expected call of AddRetentionPolicy.
+func (mr *MockRepositoryMockRecorder) AddRetentionPolicy(ctx, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRetentionPolicy", reflect.TypeOf((*MockRepository)(nil).AddRetentionPolicy), ctx, policy)
+}
+
+// AddTextExtraction mocks base method.
+func (m *MockRepository) AddTextExtraction(ctx context.Context, documentID uuid.UUID, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTextExtraction", ctx, documentID, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTextExtraction indicates an expected call of AddTextExtraction.
+func (mr *MockRepositoryMockRecorder) AddTextExtraction(ctx, documentID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTextExtraction", reflect.TypeOf((*MockRepository)(nil).AddTextExtraction), ctx, documentID, version)
+}
+
+// AddThumbnailJob mocks base method.
+func (m *MockRepository) AddThumbnailJob(ctx context.Context, documentID uuid.UUID, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddThumbnailJob", ctx, documentID, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddThumbnailJob indicates an expected call of AddThumbnailJob.
+func (mr *MockRepositoryMockRecorder) AddThumbnailJob(ctx, documentID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddThumbnailJob", reflect.TypeOf((*MockRepository)(nil).AddThumbnailJob), ctx, documentID, version)
+}
+
+// AddWebhookEvent mocks base method.
+func (m *MockRepository) AddWebhookEvent(ctx context.Context, event *domain.WebhookEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhookEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebhookEvent indicates an expected call of AddWebhookEvent.
+func (mr *MockRepositoryMockRecorder) AddWebhookEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhookEvent", reflect.TypeOf((*MockRepository)(nil).AddWebhookEvent), ctx, event)
+}
+
 // Authentication mocks base method.
 func (m *MockRepository) Authentication(ctx context.Context, user *domain.User) error {
 	m.ctrl.T.Helper()
@@ -66,6 +180,66 @@ func (mr *MockRepositoryMockRecorder) Authentication(ctx, user interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authentication", reflect.TypeOf((*MockRepository)(nil).Authentication), ctx, user)
 }
 
+// ChainAuditEvents mocks base method.
+func (m *MockRepository) ChainAuditEvents(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainAuditEvents", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainAuditEvents indicates an expected call of ChainAuditEvents.
+func (mr *MockRepositoryMockRecorder) ChainAuditEvents(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainAuditEvents", reflect.TypeOf((*MockRepository)(nil).ChainAuditEvents), ctx, limit)
+}
+
+// CheckCommentGrant mocks base method.
+func (m *MockRepository) CheckCommentGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckCommentGrant", ctx, documentID, login)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckCommentGrant indicates an expected call of CheckCommentGrant.
+func (mr *MockRepositoryMockRecorder) CheckCommentGrant(ctx, documentID, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckCommentGrant", reflect.TypeOf((*MockRepository)(nil).CheckCommentGrant), ctx, documentID, login)
+}
+
+// CheckEditGrant mocks base method.
+func (m *MockRepository) CheckEditGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckEditGrant", ctx, documentID, login)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckEditGrant indicates an expected call of CheckEditGrant.
+func (mr *MockRepositoryMockRecorder) CheckEditGrant(ctx, documentID, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckEditGrant", reflect.TypeOf((*MockRepository)(nil).CheckEditGrant), ctx, documentID, login)
+}
+
+// CheckFolderGrant mocks base method.
+func (m *MockRepository) CheckFolderGrant(ctx context.Context, folderID uuid.UUID, login string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckFolderGrant", ctx, folderID, login)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckFolderGrant indicates an expected call of CheckFolderGrant.
+func (mr *MockRepositoryMockRecorder) CheckFolderGrant(ctx, folderID, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckFolderGrant", reflect.TypeOf((*MockRepository)(nil).CheckFolderGrant), ctx, folderID, login)
+}
+
 // CheckGrant mocks base method.
 func (m *MockRepository) CheckGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -81,19 +255,122 @@ func (mr *MockRepositoryMockRecorder) CheckGrant(ctx, documentID, login interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckGrant", reflect.TypeOf((*MockRepository)(nil).CheckGrant), ctx, documentID, login)
 }
 
-// CheckUser mocks base method.
-func (m *MockRepository) CheckUser(ctx context.Context, user *domain.User) (uuid.UUID, error) {
+// ClaimTextExtractions mocks base method.
+func (m *MockRepository) ClaimTextExtractions(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.TextExtraction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimTextExtractions", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]domain.TextExtraction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimTextExtractions indicates an expected call of ClaimTextExtractions.
+func (mr *MockRepositoryMockRecorder) ClaimTextExtractions(ctx, now, lease, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimTextExtractions", reflect.TypeOf((*MockRepository)(nil).ClaimTextExtractions), ctx, now, lease, limit)
+}
+
+// ClaimThumbnailJobs mocks base method.
+func (m *MockRepository) ClaimThumbnailJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.ThumbnailJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimThumbnailJobs", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]domain.ThumbnailJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimThumbnailJobs indicates an expected call of ClaimThumbnailJobs.
+func (mr *MockRepositoryMockRecorder) ClaimThumbnailJobs(ctx, now, lease, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimThumbnailJobs", reflect.TypeOf((*MockRepository)(nil).ClaimThumbnailJobs), ctx, now, lease, limit)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) ClaimWebhookDeliveries(ctx, now, lease, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).ClaimWebhookDeliveries), ctx, now, lease, limit)
+}
+
+// CompleteIdempotencyKey mocks base method.
+func (m *MockRepository) CompleteIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) CompleteIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).CompleteIdempotencyKey), ctx, key)
+}
+
+// CreateComment mocks base method.
+func (m *MockRepository) CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", ctx, comment)
+	ret0, _ := ret[0].(*domain.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockRepositoryMockRecorder) CreateComment(ctx, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockRepository)(nil).CreateComment), ctx, comment)
+}
+
+// CreateFolder mocks base method.
+func (m *MockRepository) CreateFolder(ctx context.Context, folder *domain.Folder) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckUser", ctx, user)
+	ret := m.ctrl.Call(m, "CreateFolder", ctx, folder)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CheckUser indicates an expected call of CheckUser.
-func (mr *MockRepositoryMockRecorder) CheckUser(ctx, user interface{}) *gomock.Call {
+// CreateFolder indicates an expected call of CreateFolder.
+func (mr *MockRepositoryMockRecorder) CreateFolder(ctx, folder interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFolder", reflect.TypeOf((*MockRepository)(nil).CreateFolder), ctx, folder)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, webhook)
+	ret0, _ := ret[0].(*domain.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockRepositoryMockRecorder) CreateWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockRepository)(nil).CreateWebhook), ctx, webhook)
+}
+
+// DeleteComment mocks base method.
+func (m *MockRepository) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockRepositoryMockRecorder) DeleteComment(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUser", reflect.TypeOf((*MockRepository)(nil).CheckUser), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockRepository)(nil).DeleteComment), ctx, id)
 }
 
 // DeleteDocument mocks base method.
@@ -111,144 +388,1100 @@ func (mr *MockRepositoryMockRecorder) DeleteDocument(ctx, id, userID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDocument", reflect.TypeOf((*MockRepository)(nil).DeleteDocument), ctx, id, userID)
 }
 
-// ExecTx mocks base method.
-func (m *MockRepository) ExecTx(ctx context.Context, fn func(context.Context) error) error {
+// DeleteExpiredEventTickets mocks base method.
+func (m *MockRepository) DeleteExpiredEventTickets(ctx context.Context, now time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecTx", ctx, fn)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteExpiredEventTickets", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// ExecTx indicates an expected call of ExecTx.
-func (mr *MockRepositoryMockRecorder) ExecTx(ctx, fn interface{}) *gomock.Call {
+// DeleteExpiredEventTickets indicates an expected call of DeleteExpiredEventTickets.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredEventTickets(ctx, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecTx", reflect.TypeOf((*MockRepository)(nil).ExecTx), ctx, fn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredEventTickets", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredEventTickets), ctx, now)
 }
 
-// GetDocument mocks base method.
-func (m *MockRepository) GetDocument(ctx context.Context, id uuid.UUID) (*domain.Document, error) {
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDocument", ctx, id)
-	ret0, _ := ret[0].(*domain.Document)
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx, now)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetDocument indicates an expected call of GetDocument.
-func (mr *MockRepositoryMockRecorder) GetDocument(ctx, id interface{}) *gomock.Call {
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredIdempotencyKeys(ctx, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*MockRepository)(nil).GetDocument), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredIdempotencyKeys), ctx, now)
 }
 
-// GetDocuments mocks base method.
-func (m *MockRepository) GetDocuments(ctx context.Context, filter *dto.GetDocuments) ([]domain.Document, error) {
+// DeleteExpiredLocks mocks base method.
+func (m *MockRepository) DeleteExpiredLocks(ctx context.Context, now time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDocuments", ctx, filter)
-	ret0, _ := ret[0].([]domain.Document)
+	ret := m.ctrl.Call(m, "DeleteExpiredLocks", ctx, now)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetDocuments indicates an expected call of GetDocuments.
-func (mr *MockRepositoryMockRecorder) GetDocuments(ctx, filter interface{}) *gomock.Call {
+// DeleteExpiredLocks indicates an expected call of DeleteExpiredLocks.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredLocks(ctx, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocuments", reflect.TypeOf((*MockRepository)(nil).GetDocuments), ctx, filter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredLocks", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredLocks), ctx, now)
 }
 
-// GetUser mocks base method.
-func (m *MockRepository) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+// DeleteExpiredTokens mocks base method.
+func (m *MockRepository) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", ctx, id)
-	ret0, _ := ret[0].(*domain.User)
+	ret := m.ctrl.Call(m, "DeleteExpiredTokens", ctx, now)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockRepositoryMockRecorder) GetUser(ctx, id interface{}) *gomock.Call {
+// DeleteExpiredTokens indicates an expected call of DeleteExpiredTokens.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredTokens(ctx, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepository)(nil).GetUser), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredTokens", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredTokens), ctx, now)
 }
 
-// GetUserID mocks base method.
-func (m *MockRepository) GetUserID(ctx context.Context, token string) (uuid.UUID, error) {
+// DeleteFolder mocks base method.
+func (m *MockRepository) DeleteFolder(ctx context.Context, id, userID uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserID", ctx, token)
-	ret0, _ := ret[0].(uuid.UUID)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "DeleteFolder", ctx, id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetUserID indicates an expected call of GetUserID.
-func (mr *MockRepositoryMockRecorder) GetUserID(ctx, token interface{}) *gomock.Call {
+// DeleteFolder indicates an expected call of DeleteFolder.
+func (mr *MockRepositoryMockRecorder) DeleteFolder(ctx, id, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*MockRepository)(nil).GetUserID), ctx, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFolder", reflect.TypeOf((*MockRepository)(nil).DeleteFolder), ctx, id, userID)
 }
 
-// LogOut mocks base method.
-func (m *MockRepository) LogOut(ctx context.Context, token string) error {
+// DeleteFolderGrant mocks base method.
+func (m *MockRepository) DeleteFolderGrant(ctx context.Context, folderID uuid.UUID, login string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LogOut", ctx, token)
+	ret := m.ctrl.Call(m, "DeleteFolderGrant", ctx, folderID, login)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// LogOut indicates an expected call of LogOut.
-func (mr *MockRepositoryMockRecorder) LogOut(ctx, token interface{}) *gomock.Call {
+// DeleteFolderGrant indicates an expected call of DeleteFolderGrant.
+func (mr *MockRepositoryMockRecorder) DeleteFolderGrant(ctx, folderID, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogOut", reflect.TypeOf((*MockRepository)(nil).LogOut), ctx, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFolderGrant", reflect.TypeOf((*MockRepository)(nil).DeleteFolderGrant), ctx, folderID, login)
 }
 
-// Registration mocks base method.
-func (m *MockRepository) Registration(ctx context.Context, user *domain.User) error {
+// DeleteGrant mocks base method.
+func (m *MockRepository) DeleteGrant(ctx context.Context, documentID uuid.UUID, login string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Registration", ctx, user)
+	ret := m.ctrl.Call(m, "DeleteGrant", ctx, documentID, login)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Registration indicates an expected call of Registration.
-func (mr *MockRepositoryMockRecorder) Registration(ctx, user interface{}) *gomock.Call {
+// DeleteGrant indicates an expected call of DeleteGrant.
+func (mr *MockRepositoryMockRecorder) DeleteGrant(ctx, documentID, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Registration", reflect.TypeOf((*MockRepository)(nil).Registration), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrant", reflect.TypeOf((*MockRepository)(nil).DeleteGrant), ctx, documentID, login)
 }
 
-// Save mocks base method.
-func (m *MockRepository) Save(ctx context.Context, document *domain.Document) (uuid.UUID, error) {
+// DeleteIdempotencyKey mocks base method.
+func (m *MockRepository) DeleteIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Save", ctx, document)
-	ret0, _ := ret[0].(uuid.UUID)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// Save indicates an expected call of Save.
-func (mr *MockRepositoryMockRecorder) Save(ctx, document interface{}) *gomock.Call {
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) DeleteIdempotencyKey(ctx, userID, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, document)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).DeleteIdempotencyKey), ctx, userID, key)
 }
 
-// MockCache is a mock of Cache interface.
-type MockCache struct {
-	ctrl     *gomock.Controller
-	recorder *MockCacheMockRecorder
+// DeleteLock mocks base method.
+func (m *MockRepository) DeleteLock(ctx context.Context, documentID, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLock", ctx, documentID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// MockCacheMockRecorder is the mock recorder for MockCache.
-type MockCacheMockRecorder struct {
-	mock *MockCache
+// DeleteLock indicates an expected call of DeleteLock.
+func (mr *MockRepositoryMockRecorder) DeleteLock(ctx, documentID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLock", reflect.TypeOf((*MockRepository)(nil).DeleteLock), ctx, documentID, userID)
 }
 
-// NewMockCache creates a new mock instance.
-func NewMockCache(ctrl *gomock.Controller) *MockCache {
-	mock := &MockCache{ctrl: ctrl}
-	mock.recorder = &MockCacheMockRecorder{mock}
-	return mock
+// DeleteOutdatedRenditions mocks base method.
+func (m *MockRepository) DeleteOutdatedRenditions(ctx context.Context, documentID uuid.UUID, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOutdatedRenditions", ctx, documentID, version)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockCache) EXPECT() *MockCacheMockRecorder {
-	return m.recorder
+// DeleteOutdatedRenditions indicates an expected call of DeleteOutdatedRenditions.
+func (mr *MockRepositoryMockRecorder) DeleteOutdatedRenditions(ctx, documentID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOutdatedRenditions", reflect.TypeOf((*MockRepository)(nil).DeleteOutdatedRenditions), ctx, documentID, version)
+}
+
+// DeleteRetentionPolicy mocks base method.
+func (m *MockRepository) DeleteRetentionPolicy(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRetentionPolicy", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRetentionPolicy indicates an expected call of DeleteRetentionPolicy.
+func (mr *MockRepositoryMockRecorder) DeleteRetentionPolicy(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRetentionPolicy", reflect.TypeOf((*MockRepository)(nil).DeleteRetentionPolicy), ctx, id)
+}
+
+// DeleteUserTokens mocks base method.
+func (m *MockRepository) DeleteUserTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserTokens", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserTokens indicates an expected call of DeleteUserTokens.
+func (mr *MockRepositoryMockRecorder) DeleteUserTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserTokens", reflect.TypeOf((*MockRepository)(nil).DeleteUserTokens), ctx, userID)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockRepositoryMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockRepository)(nil).DeleteWebhook), ctx, id)
+}
+
+// DispatchWebhookEvents mocks base method.
+func (m *MockRepository) DispatchWebhookEvents(ctx context.Context, now time.Time, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchWebhookEvents", ctx, now, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DispatchWebhookEvents indicates an expected call of DispatchWebhookEvents.
+func (mr *MockRepositoryMockRecorder) DispatchWebhookEvents(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchWebhookEvents", reflect.TypeOf((*MockRepository)(nil).DispatchWebhookEvents), ctx, now, limit)
+}
+
+// ExecTx mocks base method.
+func (m *MockRepository) ExecTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecTx indicates an expected call of ExecTx.
+func (mr *MockRepositoryMockRecorder) ExecTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecTx", reflect.TypeOf((*MockRepository)(nil).ExecTx), ctx, fn)
+}
+
+// GetAuditEvents mocks base method.
+func (m *MockRepository) GetAuditEvents(ctx context.Context, filter *dto.GetAuditEvents) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", ctx, filter)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents.
+func (mr *MockRepositoryMockRecorder) GetAuditEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockRepository)(nil).GetAuditEvents), ctx, filter)
+}
+
+// GetComment mocks base method.
+func (m *MockRepository) GetComment(ctx context.Context, id uuid.UUID) (*domain.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComment", ctx, id)
+	ret0, _ := ret[0].(*domain.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComment indicates an expected call of GetComment.
+func (mr *MockRepositoryMockRecorder) GetComment(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockRepository)(nil).GetComment), ctx, id)
+}
+
+// GetComments mocks base method.
+func (m *MockRepository) GetComments(ctx context.Context, documentID uuid.UUID) ([]domain.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComments", ctx, documentID)
+	ret0, _ := ret[0].([]domain.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComments indicates an expected call of GetComments.
+func (mr *MockRepositoryMockRecorder) GetComments(ctx, documentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockRepository)(nil).GetComments), ctx, documentID)
+}
+
+// GetDocument mocks base method.
+func (m *MockRepository) GetDocument(ctx context.Context, id uuid.UUID) (*domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocument", ctx, id)
+	ret0, _ := ret[0].(*domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocument indicates an expected call of GetDocument.
+func (mr *MockRepositoryMockRecorder) GetDocument(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*MockRepository)(nil).GetDocument), ctx, id)
+}
+
+// GetDocumentContents mocks base method.
+func (m *MockRepository) GetDocumentContents(ctx context.Context, after uuid.UUID, limit int) ([]domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocumentContents", ctx, after, limit)
+	ret0, _ := ret[0].([]domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocumentContents indicates an expected call of GetDocumentContents.
+func (mr *MockRepositoryMockRecorder) GetDocumentContents(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocumentContents", reflect.TypeOf((*MockRepository)(nil).GetDocumentContents), ctx, after, limit)
+}
+
+// GetDocumentIDByName mocks base method.
+func (m *MockRepository) GetDocumentIDByName(ctx context.Context, userID, folderID uuid.UUID, name string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocumentIDByName", ctx, userID, folderID, name)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocumentIDByName indicates an expected call of GetDocumentIDByName.
+func (mr *MockRepositoryMockRecorder) GetDocumentIDByName(ctx, userID, folderID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocumentIDByName", reflect.TypeOf((*MockRepository)(nil).GetDocumentIDByName), ctx, userID, folderID, name)
+}
+
+// GetDocuments mocks base method.
+func (m *MockRepository) GetDocuments(ctx context.Context, filter *dto.GetDocuments) ([]domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocuments", ctx, filter)
+	ret0, _ := ret[0].([]domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocuments indicates an expected call of GetDocuments.
+func (mr *MockRepositoryMockRecorder) GetDocuments(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocuments", reflect.TypeOf((*MockRepository)(nil).GetDocuments), ctx, filter)
+}
+
+// GetEvents mocks base method.
+func (m *MockRepository) GetEvents(ctx context.Context, userID uuid.UUID, login string, after int64, limit int) ([]domain.WebhookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", ctx, userID, login, after, limit)
+	ret0, _ := ret[0].([]domain.WebhookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockRepositoryMockRecorder) GetEvents(ctx, userID, login, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockRepository)(nil).GetEvents), ctx, userID, login, after, limit)
+}
+
+// GetExpiredDocuments mocks base method.
+func (m *MockRepository) GetExpiredDocuments(ctx context.Context, now time.Time) ([]domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredDocuments", ctx, now)
+	ret0, _ := ret[0].([]domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredDocuments indicates an expected call of GetExpiredDocuments.
+func (mr *MockRepositoryMockRecorder) GetExpiredDocuments(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredDocuments", reflect.TypeOf((*MockRepository)(nil).GetExpiredDocuments), ctx, now)
+}
+
+// GetFolder mocks base method.
+func (m *MockRepository) GetFolder(ctx context.Context, id uuid.UUID) (*domain.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolder", ctx, id)
+	ret0, _ := ret[0].(*domain.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolder indicates an expected call of GetFolder.
+func (mr *MockRepositoryMockRecorder) GetFolder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolder", reflect.TypeOf((*MockRepository)(nil).GetFolder), ctx, id)
+}
+
+// GetFolderByName mocks base method.
+func (m *MockRepository) GetFolderByName(ctx context.Context, userID, parentID uuid.UUID, name string) (*domain.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolderByName", ctx, userID, parentID, name)
+	ret0, _ := ret[0].(*domain.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolderByName indicates an expected call of GetFolderByName.
+func (mr *MockRepositoryMockRecorder) GetFolderByName(ctx, userID, parentID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolderByName", reflect.TypeOf((*MockRepository)(nil).GetFolderByName), ctx, userID, parentID, name)
+}
+
+// GetFolderDocumentIDs mocks base method.
+func (m *MockRepository) GetFolderDocumentIDs(ctx context.Context, folderID uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolderDocumentIDs", ctx, folderID)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolderDocumentIDs indicates an expected call of GetFolderDocumentIDs.
+func (mr *MockRepositoryMockRecorder) GetFolderDocumentIDs(ctx, folderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolderDocumentIDs", reflect.TypeOf((*MockRepository)(nil).GetFolderDocumentIDs), ctx, folderID)
+}
+
+// GetFolderDocuments mocks base method.
+func (m *MockRepository) GetFolderDocuments(ctx context.Context, userID, folderID uuid.UUID) ([]domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolderDocuments", ctx, userID, folderID)
+	ret0, _ := ret[0].([]domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolderDocuments indicates an expected call of GetFolderDocuments.
+func (mr *MockRepositoryMockRecorder) GetFolderDocuments(ctx, userID, folderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolderDocuments", reflect.TypeOf((*MockRepository)(nil).GetFolderDocuments), ctx, userID, folderID)
+}
+
+// GetFolderGrantLogins mocks base method.
+func (m *MockRepository) GetFolderGrantLogins(ctx context.Context, folderID uuid.UUID) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolderGrantLogins", ctx, folderID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolderGrantLogins indicates an expected call of GetFolderGrantLogins.
+func (mr *MockRepositoryMockRecorder) GetFolderGrantLogins(ctx, folderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolderGrantLogins", reflect.TypeOf((*MockRepository)(nil).GetFolderGrantLogins), ctx, folderID)
+}
+
+// GetFolderTreeDocuments mocks base method.
+func (m *MockRepository) GetFolderTreeDocuments(ctx context.Context, folderID uuid.UUID) ([]domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolderTreeDocuments", ctx, folderID)
+	ret0, _ := ret[0].([]domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolderTreeDocuments indicates an expected call of GetFolderTreeDocuments.
+func (mr *MockRepositoryMockRecorder) GetFolderTreeDocuments(ctx, folderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolderTreeDocuments", reflect.TypeOf((*MockRepository)(nil).GetFolderTreeDocuments), ctx, folderID)
+}
+
+// GetFolders mocks base method.
+func (m *MockRepository) GetFolders(ctx context.Context, userID, parentID uuid.UUID) ([]domain.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolders", ctx, userID, parentID)
+	ret0, _ := ret[0].([]domain.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolders indicates an expected call of GetFolders.
+func (mr *MockRepositoryMockRecorder) GetFolders(ctx, userID, parentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolders", reflect.TypeOf((*MockRepository)(nil).GetFolders), ctx, userID, parentID)
+}
+
+// GetGrantLogins mocks base method.
+func (m *MockRepository) GetGrantLogins(ctx context.Context, documentID uuid.UUID) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrantLogins", ctx, documentID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrantLogins indicates an expected call of GetGrantLogins.
+func (mr *MockRepositoryMockRecorder) GetGrantLogins(ctx, documentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrantLogins", reflect.TypeOf((*MockRepository)(nil).GetGrantLogins), ctx, documentID)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockRepository) GetIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(*domain.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) GetIdempotencyKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).GetIdempotencyKey), ctx, userID, key)
+}
+
+// GetLastEventID mocks base method.
+func (m *MockRepository) GetLastEventID(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastEventID", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastEventID indicates an expected call of GetLastEventID.
+func (mr *MockRepositoryMockRecorder) GetLastEventID(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEventID", reflect.TypeOf((*MockRepository)(nil).GetLastEventID), ctx)
+}
+
+// GetLock mocks base method.
+func (m *MockRepository) GetLock(ctx context.Context, documentID uuid.UUID, now time.Time) (*domain.DocumentLock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLock", ctx, documentID, now)
+	ret0, _ := ret[0].(*domain.DocumentLock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLock indicates an expected call of GetLock.
+func (mr *MockRepositoryMockRecorder) GetLock(ctx, documentID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLock", reflect.TypeOf((*MockRepository)(nil).GetLock), ctx, documentID, now)
+}
+
+// GetPlainPasswordUsers mocks base method.
+func (m *MockRepository) GetPlainPasswordUsers(ctx context.Context) ([]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlainPasswordUsers", ctx)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlainPasswordUsers indicates an expected call of GetPlainPasswordUsers.
+func (mr *MockRepositoryMockRecorder) GetPlainPasswordUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlainPasswordUsers", reflect.TypeOf((*MockRepository)(nil).GetPlainPasswordUsers), ctx)
+}
+
+// GetRendition mocks base method.
+func (m *MockRepository) GetRendition(ctx context.Context, documentID uuid.UUID, version int, size string) (*domain.Rendition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRendition", ctx, documentID, version, size)
+	ret0, _ := ret[0].(*domain.Rendition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRendition indicates an expected call of GetRendition.
+func (mr *MockRepositoryMockRecorder) GetRendition(ctx, documentID, version, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRendition", reflect.TypeOf((*MockRepository)(nil).GetRendition), ctx, documentID, version, size)
+}
+
+// GetRetentionPolicies mocks base method.
+func (m *MockRepository) GetRetentionPolicies(ctx context.Context, document *domain.Document) ([]domain.RetentionPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRetentionPolicies", ctx, document)
+	ret0, _ := ret[0].([]domain.RetentionPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRetentionPolicies indicates an expected call of GetRetentionPolicies.
+func (mr *MockRepositoryMockRecorder) GetRetentionPolicies(ctx, document interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRetentionPolicies", reflect.TypeOf((*MockRepository)(nil).GetRetentionPolicies), ctx, document)
+}
+
+// GetStorageUsage mocks base method.
+func (m *MockRepository) GetStorageUsage(ctx context.Context) ([]domain.StorageUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStorageUsage", ctx)
+	ret0, _ := ret[0].([]domain.StorageUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStorageUsage indicates an expected call of GetStorageUsage.
+func (mr *MockRepositoryMockRecorder) GetStorageUsage(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStorageUsage", reflect.TypeOf((*MockRepository)(nil).GetStorageUsage), ctx)
+}
+
+// GetTextExtraction mocks base method.
+func (m *MockRepository) GetTextExtraction(ctx context.Context, documentID uuid.UUID, version int) (*domain.TextExtraction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTextExtraction", ctx, documentID, version)
+	ret0, _ := ret[0].(*domain.TextExtraction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTextExtraction indicates an expected call of GetTextExtraction.
+func (mr *MockRepositoryMockRecorder) GetTextExtraction(ctx, documentID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTextExtraction", reflect.TypeOf((*MockRepository)(nil).GetTextExtraction), ctx, documentID, version)
+}
+
+// GetTrash mocks base method.
+func (m *MockRepository) GetTrash(ctx context.Context, userID uuid.UUID) ([]domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrash", ctx, userID)
+	ret0, _ := ret[0].([]domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrash indicates an expected call of GetTrash.
+func (mr *MockRepositoryMockRecorder) GetTrash(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockRepository)(nil).GetTrash), ctx, userID)
+}
+
+// GetUser mocks base method.
+func (m *MockRepository) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, id)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockRepositoryMockRecorder) GetUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepository)(nil).GetUser), ctx, id)
+}
+
+// GetUserByLogin mocks base method.
+func (m *MockRepository) GetUserByLogin(ctx context.Context, login string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByLogin", ctx, login)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByLogin indicates an expected call of GetUserByLogin.
+func (mr *MockRepositoryMockRecorder) GetUserByLogin(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*MockRepository)(nil).GetUserByLogin), ctx, login)
+}
+
+// GetUserID mocks base method.
+func (m *MockRepository) GetUserID(ctx context.Context, token string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserID", ctx, token)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserID indicates an expected call of GetUserID.
+func (mr *MockRepositoryMockRecorder) GetUserID(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*MockRepository)(nil).GetUserID), ctx, token)
+}
+
+// GetUserToken mocks base method.
+func (m *MockRepository) GetUserToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserToken", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserToken indicates an expected call of GetUserToken.
+func (mr *MockRepositoryMockRecorder) GetUserToken(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserToken", reflect.TypeOf((*MockRepository)(nil).GetUserToken), ctx, userID)
+}
+
+// GetWebhook mocks base method.
+func (m *MockRepository) GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", ctx, id)
+	ret0, _ := ret[0].(*domain.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockRepositoryMockRecorder) GetWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockRepository)(nil).GetWebhook), ctx, id)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockRepository) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, webhookID, limit)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) GetWebhookDeliveries(ctx, webhookID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).GetWebhookDeliveries), ctx, webhookID, limit)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockRepository) GetWebhooks(ctx context.Context, userID uuid.UUID) ([]domain.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", ctx, userID)
+	ret0, _ := ret[0].([]domain.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockRepositoryMockRecorder) GetWebhooks(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockRepository)(nil).GetWebhooks), ctx, userID)
+}
+
+// IsFolderAncestor mocks base method.
+func (m *MockRepository) IsFolderAncestor(ctx context.Context, ancestorID, folderID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFolderAncestor", ctx, ancestorID, folderID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsFolderAncestor indicates an expected call of IsFolderAncestor.
+func (mr *MockRepositoryMockRecorder) IsFolderAncestor(ctx, ancestorID, folderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFolderAncestor", reflect.TypeOf((*MockRepository)(nil).IsFolderAncestor), ctx, ancestorID, folderID)
+}
+
+// ListenEvents mocks base method.
+func (m *MockRepository) ListenEvents(ctx context.Context, fn func(*domain.EventNotification)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListenEvents", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListenEvents indicates an expected call of ListenEvents.
+func (mr *MockRepositoryMockRecorder) ListenEvents(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenEvents", reflect.TypeOf((*MockRepository)(nil).ListenEvents), ctx, fn)
+}
+
+// LogOut mocks base method.
+func (m *MockRepository) LogOut(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogOut", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogOut indicates an expected call of LogOut.
+func (mr *MockRepositoryMockRecorder) LogOut(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogOut", reflect.TypeOf((*MockRepository)(nil).LogOut), ctx, token)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockRepositoryMockRecorder) PurgeTrash(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockRepository)(nil).PurgeTrash), ctx, before)
+}
+
+// Registration mocks base method.
+func (m *MockRepository) Registration(ctx context.Context, user *domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Registration", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Registration indicates an expected call of Registration.
+func (mr *MockRepositoryMockRecorder) Registration(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Registration", reflect.TypeOf((*MockRepository)(nil).Registration), ctx, user)
+}
+
+// ReplaceContent mocks base method.
+func (m *MockRepository) ReplaceContent(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceContent", ctx, document)
+	ret0, _ := ret[0].(*domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceContent indicates an expected call of ReplaceContent.
+func (mr *MockRepositoryMockRecorder) ReplaceContent(ctx, document interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceContent", reflect.TypeOf((*MockRepository)(nil).ReplaceContent), ctx, document)
+}
+
+// ReplacePassword mocks base method.
+func (m *MockRepository) ReplacePassword(ctx context.Context, userID uuid.UUID, old, password string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplacePassword", ctx, userID, old, password)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplacePassword indicates an expected call of ReplacePassword.
+func (mr *MockRepositoryMockRecorder) ReplacePassword(ctx, userID, old, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplacePassword", reflect.TypeOf((*MockRepository)(nil).ReplacePassword), ctx, userID, old, password)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockRepository) ReserveIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", ctx, key, staleBefore)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) ReserveIdempotencyKey(ctx, key, staleBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).ReserveIdempotencyKey), ctx, key, staleBefore)
+}
+
+// RestoreDocument mocks base method.
+func (m *MockRepository) RestoreDocument(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreDocument", ctx, id, userID)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreDocument indicates an expected call of RestoreDocument.
+func (mr *MockRepositoryMockRecorder) RestoreDocument(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreDocument", reflect.TypeOf((*MockRepository)(nil).RestoreDocument), ctx, id, userID)
+}
+
+// Save mocks base method.
+func (m *MockRepository) Save(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, document)
+	ret0, _ := ret[0].(*domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRepositoryMockRecorder) Save(ctx, document interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, document)
+}
+
+// SaveRendition mocks base method.
+func (m *MockRepository) SaveRendition(ctx context.Context, rendition *domain.Rendition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRendition", ctx, rendition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRendition indicates an expected call of SaveRendition.
+func (mr *MockRepositoryMockRecorder) SaveRendition(ctx, rendition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRendition", reflect.TypeOf((*MockRepository)(nil).SaveRendition), ctx, rendition)
+}
+
+// SearchDocuments mocks base method.
+func (m *MockRepository) SearchDocuments(ctx context.Context, search *dto.Search) ([]domain.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchDocuments", ctx, search)
+	ret0, _ := ret[0].([]domain.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchDocuments indicates an expected call of SearchDocuments.
+func (mr *MockRepositoryMockRecorder) SearchDocuments(ctx, search interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchDocuments", reflect.TypeOf((*MockRepository)(nil).SearchDocuments), ctx, search)
+}
+
+// SequenceEvents mocks base method.
+func (m *MockRepository) SequenceEvents(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SequenceEvents", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SequenceEvents indicates an expected call of SequenceEvents.
+func (mr *MockRepositoryMockRecorder) SequenceEvents(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SequenceEvents", reflect.TypeOf((*MockRepository)(nil).SequenceEvents), ctx, limit)
+}
+
+// SetDocumentText mocks base method.
+func (m *MockRepository) SetDocumentText(ctx context.Context, documentID uuid.UUID, version int, text string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDocumentText", ctx, documentID, version, text)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDocumentText indicates an expected call of SetDocumentText.
+func (mr *MockRepositoryMockRecorder) SetDocumentText(ctx, documentID, version, text interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDocumentText", reflect.TypeOf((*MockRepository)(nil).SetDocumentText), ctx, documentID, version, text)
+}
+
+// SetLegalHold mocks base method.
+func (m *MockRepository) SetLegalHold(ctx context.Context, id uuid.UUID, hold bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLegalHold", ctx, id, hold)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLegalHold indicates an expected call of SetLegalHold.
+func (mr *MockRepositoryMockRecorder) SetLegalHold(ctx, id, hold interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLegalHold", reflect.TypeOf((*MockRepository)(nil).SetLegalHold), ctx, id, hold)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockRepository) SetUserDisabled(ctx context.Context, login string, disabledAt *time.Time) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, login, disabledAt)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockRepositoryMockRecorder) SetUserDisabled(ctx, login, disabledAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockRepository)(nil).SetUserDisabled), ctx, login, disabledAt)
+}
+
+// TakeEventTicket mocks base method.
+func (m *MockRepository) TakeEventTicket(ctx context.Context, ticket string, now time.Time) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeEventTicket", ctx, ticket, now)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeEventTicket indicates an expected call of TakeEventTicket.
+func (mr *MockRepositoryMockRecorder) TakeEventTicket(ctx, ticket, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeEventTicket", reflect.TypeOf((*MockRepository)(nil).TakeEventTicket), ctx, ticket, now)
+}
+
+// UpdateComment mocks base method.
+func (m *MockRepository) UpdateComment(ctx context.Context, id uuid.UUID, body string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", ctx, id, body)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockRepositoryMockRecorder) UpdateComment(ctx, id, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockRepository)(nil).UpdateComment), ctx, id, body)
+}
+
+// UpdateDocument mocks base method.
+func (m *MockRepository) UpdateDocument(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDocument", ctx, document)
+	ret0, _ := ret[0].(*domain.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDocument indicates an expected call of UpdateDocument.
+func (mr *MockRepositoryMockRecorder) UpdateDocument(ctx, document interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDocument", reflect.TypeOf((*MockRepository)(nil).UpdateDocument), ctx, document)
+}
+
+// UpdateFolder mocks base method.
+func (m *MockRepository) UpdateFolder(ctx context.Context, folder *domain.Folder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFolder", ctx, folder)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFolder indicates an expected call of UpdateFolder.
+func (mr *MockRepositoryMockRecorder) UpdateFolder(ctx, folder interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFolder", reflect.TypeOf((*MockRepository)(nil).UpdateFolder), ctx, folder)
+}
+
+// UpdateTextExtraction mocks base method.
+func (m *MockRepository) UpdateTextExtraction(ctx context.Context, extraction *domain.TextExtraction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTextExtraction", ctx, extraction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTextExtraction indicates an expected call of UpdateTextExtraction.
+func (mr *MockRepositoryMockRecorder) UpdateTextExtraction(ctx, extraction interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTextExtraction", reflect.TypeOf((*MockRepository)(nil).UpdateTextExtraction), ctx, extraction)
+}
+
+// UpdateThumbnailJob mocks base method.
+func (m *MockRepository) UpdateThumbnailJob(ctx context.Context, job *domain.ThumbnailJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateThumbnailJob", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateThumbnailJob indicates an expected call of UpdateThumbnailJob.
+func (mr *MockRepositoryMockRecorder) UpdateThumbnailJob(ctx, job interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateThumbnailJob", reflect.TypeOf((*MockRepository)(nil).UpdateThumbnailJob), ctx, job)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockRepository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockRepositoryMockRecorder) UpdateWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockRepository)(nil).UpdateWebhookDelivery), ctx, delivery)
+}
+
+// VerifyAuditChain mocks base method.
+func (m *MockRepository) VerifyAuditChain(ctx context.Context) (*domain.AuditVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAuditChain", ctx)
+	ret0, _ := ret[0].(*domain.AuditVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAuditChain indicates an expected call of VerifyAuditChain.
+func (mr *MockRepositoryMockRecorder) VerifyAuditChain(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAuditChain", reflect.TypeOf((*MockRepository)(nil).VerifyAuditChain), ctx)
+}
+
+// MockCache is a mock of Cache interface.
+type MockCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheMockRecorder
+}
+
+// MockCacheMockRecorder is the mock recorder for MockCache.
+type MockCacheMockRecorder struct {
+	mock *MockCache
+}
+
+// NewMockCache creates a new mock instance.
+func NewMockCache(ctrl *gomock.Controller) *MockCache {
+	mock := &MockCache{ctrl: ctrl}
+	mock.recorder = &MockCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCache) EXPECT() *MockCacheMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockCache) Delete(k string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", k)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCacheMockRecorder) Delete(k interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCache)(nil).Delete), k)
 }
 
 // Get mocks base method.
@@ -2,52 +2,99 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"sort"
+	"net/http"
+	"time"
 
+	"github.com/Alina9496/documents/config"
 	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/extract"
 	"github.com/Alina9496/documents/internal/repo"
 	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/Alina9496/documents/internal/thumbnail"
 	"github.com/Alina9496/tool/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/patrickmn/go-cache"
 )
 
 type Service struct {
-	repo  Repository
-	cache Cache
-	log   *logger.Logger
+	repo           Repository
+	cache          Cache
+	log            *logger.Logger
+	trashRetention time.Duration
+	extractors     *extract.Registry
+	extraction     jobPolicy
+	extractWake    chan struct{}
+	thumbnails     jobPolicy
+	thumbnailWake  chan struct{}
+	imports        config.Import
+	idempotency    config.Idempotency
+	webhooks       config.Webhook
+	webhookWake    chan struct{}
+	webhookClient  *http.Client
+	events         *eventHub
+	locks          config.Lock
+	tokenTTL       time.Duration
 }
 
 func New(
 	r Repository,
 	cache Cache,
 	log *logger.Logger,
-) *Service {
-	return &Service{
-		repo:  r,
-		cache: cache,
-		log:   log,
+	cfg *config.Config,
+) (*Service, error) {
+	allowed, err := parseNetworks(cfg.Webhook.AllowedNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("webhook.allowed_networks: %w", err)
 	}
+
+	return &Service{
+		repo:           r,
+		cache:          cache,
+		log:            log,
+		trashRetention: cfg.Trash.Retention,
+		extractors:     extract.Default(),
+		extraction:     newJobPolicy(cfg.Extraction.BatchSize, cfg.Extraction.MaxAttempts, cfg.Extraction.RetryBackoff),
+		extractWake:    make(chan struct{}, 1),
+		thumbnails:     newJobPolicy(cfg.Thumbnail.BatchSize, cfg.Thumbnail.MaxAttempts, cfg.Thumbnail.RetryBackoff),
+		thumbnailWake:  make(chan struct{}, 1),
+		imports:        cfg.Import,
+		idempotency:    cfg.Idempotency,
+		webhooks:       cfg.Webhook,
+		webhookWake:    make(chan struct{}, 1),
+		webhookClient:  newWebhookClient(cfg.Webhook.Timeout, allowed),
+		events:         newEventHub(),
+		locks:          cfg.Lock,
+		tokenTTL:       cfg.Token.TTL,
+	}, nil
 }
 
-func (s *Service) isUserExists(ctx context.Context, user *domain.User) bool {
-	id, err := s.repo.CheckUser(ctx, user)
-	if err != nil || id == uuid.Nil {
-		return false
+// checkCredentials looks the user up by the login and sets their id when the
+// password is theirs.
+func (s *Service) checkCredentials(ctx context.Context, user *domain.User) error {
+	stored, err := s.repo.GetUserByLogin(ctx, user.Login)
+	if err != nil || !verifyPassword(stored.Password, user.Password) {
+		return ErrUserNotFound
+	}
+	user.ID = stored.ID
+	if stored.DisabledAt != nil {
+		return ErrUserDisabled
 	}
-	user.ID = id
-	return true
+	return nil
 }
 
-func (s *Service) Registration(ctx context.Context, user *domain.User) (string, error) {
+func (s *Service) Registration(ctx context.Context, user *domain.User) (_ string, err error) {
 	l := s.log.WithField("service_method", "Registration")
 	if user == nil {
 		l.Warn(ErrUserIsNil.Error())
 		return "", ErrUserIsNil
 	}
 
+	event := &domain.AuditEvent{Action: domain.AuditRegister, TargetLogin: user.Login}
+	defer func() { s.audit(ctx, event, err) }()
+
 	if !checkLogin(user.Login) {
 		l.Warn(ErrUserLoginIncorected.Error())
 		return "", ErrUserLoginIncorected
@@ -58,12 +105,23 @@ func (s *Service) Registration(ctx context.Context, user *domain.User) (string,
 		return "", ErrUserPasswordIncorected
 	}
 
-	if s.isUserExists(ctx, user) {
+	_, err = s.repo.GetUserByLogin(ctx, user.Login)
+	if err == nil {
 		l.WithError(ErrUserExists).Error("error when check user")
 		return "", fmt.Errorf("error when check user: %w", ErrUserExists)
 	}
+	if !errors.Is(err, repo.ErrUserNotFound) {
+		l.WithError(err).Error("error when check user")
+		return "", fmt.Errorf("error when registration user: %w", ErrRegistrationUser)
+	}
 
-	err := s.repo.Registration(ctx, user)
+	hash, err := hashPassword(user.Password)
+	if err != nil {
+		l.WithError(err).Error("error when hash password")
+		return "", fmt.Errorf("error when registration user: %w", ErrRegistrationUser)
+	}
+
+	err = s.repo.Registration(ctx, &domain.User{Login: user.Login, Password: hash})
 	if err != nil {
 		l.WithError(err).Error("error when registration user")
 		return "", fmt.Errorf("error when registration user: %w", ErrRegistrationUser)
@@ -72,13 +130,19 @@ func (s *Service) Registration(ctx context.Context, user *domain.User) (string,
 	return user.Login, nil
 }
 
-func (s *Service) Authentication(ctx context.Context, user *domain.User) (string, error) {
+func (s *Service) Authentication(ctx context.Context, user *domain.User) (_ string, err error) {
 	l := s.log.WithField("service_method", "Authentication")
 	if user == nil {
 		l.Warn(ErrUserIsNil.Error())
 		return "", ErrUserIsNil
 	}
 
+	event := &domain.AuditEvent{Action: domain.AuditLogin, ActorLogin: user.Login}
+	defer func() {
+		event.ActorID = user.ID
+		s.audit(ctx, event, err)
+	}()
+
 	if !checkLogin(user.Login) {
 		l.Warn(ErrUserLoginIncorected.Error())
 		return "", ErrUserLoginIncorected
@@ -89,13 +153,13 @@ func (s *Service) Authentication(ctx context.Context, user *domain.User) (string
 		return "", ErrUserPasswordIncorected
 	}
 
-	if !s.isUserExists(ctx, user) {
-		l.WithError(ErrUserNotFound).Error("error when check user")
-		return "", fmt.Errorf("error when check user: %w", ErrUserNotFound)
+	err = s.checkCredentials(ctx, user)
+	if err != nil {
+		l.WithError(err).Error("error when check user")
+		return "", fmt.Errorf("error when check user: %w", err)
 	}
 
-	user.Token = generateToken()
-	err := s.repo.Authentication(ctx, user)
+	err = s.issueToken(ctx, user)
 	if err != nil {
 		l.WithError(err).Error("error when authentication user")
 		return "", fmt.Errorf("error when authentication user: %w", ErrAuthenticationUser)
@@ -104,10 +168,27 @@ func (s *Service) Authentication(ctx context.Context, user *domain.User) (string
 	return user.Token, nil
 }
 
-func (s *Service) LogOut(ctx context.Context, token string) error {
+// issueToken stores a new token of the user, it expires after the token TTL.
+func (s *Service) issueToken(ctx context.Context, user *domain.User) error {
+	user.Token = generateToken()
+	if s.tokenTTL > 0 {
+		expiresAt := time.Now().Add(s.tokenTTL)
+		user.TokenExpiresAt = &expiresAt
+	}
+
+	return s.repo.Authentication(ctx, user)
+}
+
+func (s *Service) LogOut(ctx context.Context, token string) (err error) {
 	l := s.log.WithField("service_method", "LogOut")
 
-	err := s.repo.LogOut(ctx, token)
+	event := &domain.AuditEvent{Action: domain.AuditLogout}
+	defer func() { s.audit(ctx, event, err) }()
+
+	// the token is only known before the logout
+	event.ActorID, _ = s.getUserID(ctx, token)
+
+	err = s.repo.LogOut(ctx, token)
 	if err != nil {
 		if errors.Is(err, repo.ErrTokenNotFound) {
 			return ErrTokenNotFound
@@ -115,129 +196,755 @@ func (s *Service) LogOut(ctx context.Context, token string) error {
 		l.WithError(err).Error("error when logout user")
 		return fmt.Errorf("error when logout user: %w", ErrLogOutUser)
 	}
+
+	// resolving the actor may have cached the token
+	s.cache.Delete(prepareGetUserIDKey(token))
 	return nil
 }
 
-func (s *Service) Upload(ctx context.Context, document *dto.Document) (name string, err error) {
+// CheckToken returns ErrUserNotFound when the token is unknown or expired. It
+// lets the clients that keep the token for a session reject it up front.
+func (s *Service) CheckToken(ctx context.Context, token string) error {
+	l := s.log.WithField("service_method", "CheckToken")
+
+	_, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Warn("error get user id")
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// BasicAuth returns a token for Basic credentials of clients that cannot send
+// the token header. The password is the password of the user or one of their
+// tokens. A session is kept for the credentials so every request of a WebDAV
+// client does not log the user in again, and a valid token of the user is
+// reused so the sessions of the instances do not pile up tokens.
+func (s *Service) BasicAuth(ctx context.Context, login, password string) (_ string, err error) {
+	l := s.log.WithField("service_method", "BasicAuth")
+
+	key := prepareBasicAuthKey(login, password)
+	if token, ok := s.cache.Get(key); ok {
+		if _, err := s.getUserID(ctx, token.(string)); err == nil {
+			return token.(string), nil
+		}
+		s.cache.Delete(key)
+	}
+
+	if userID, err := s.getUserID(ctx, password); err == nil {
+		user, err := s.getUserByID(ctx, userID)
+		if err == nil && user.Login == login {
+			return password, nil
+		}
+	}
+
+	user := &domain.User{Login: login, Password: password}
+	event := &domain.AuditEvent{Action: domain.AuditLogin, ActorLogin: login}
+	defer func() {
+		event.ActorID = user.ID
+		s.audit(ctx, event, err)
+	}()
+
+	if !checkLogin(login) || !checkPassword(password) {
+		l.Warn(ErrUserNotFound.Error())
+		return "", ErrUserNotFound
+	}
+
+	err = s.checkCredentials(ctx, user)
+	if err != nil {
+		l.WithError(err).Warn("error when check user")
+		return "", ErrUserNotFound
+	}
+
+	token, err := s.repo.GetUserToken(ctx, user.ID)
+	if errors.Is(err, repo.ErrTokenNotFound) {
+		err = s.issueToken(ctx, user)
+		token = user.Token
+	}
+	if err != nil {
+		l.WithError(err).Error("error when authentication user")
+		return "", ErrAuthenticationUser
+	}
+	s.cache.Set(key, token, cache.DefaultExpiration)
+
+	return token, nil
+}
+
+// Upload stores a new document and returns it without the content.
+func (s *Service) Upload(ctx context.Context, document *dto.Document) (_ *domain.Document, err error) {
 	l := s.log.WithField("service_method", "Upload")
 
+	event := &domain.AuditEvent{Action: domain.AuditUpload}
+	defer func() { s.audit(ctx, event, err) }()
+
+	if !isValidMetadata(document.Metadata) {
+		l.Warn(ErrInvalidMetadata.Error())
+		return nil, ErrInvalidMetadata
+	}
+
+	if document.IdempotencyKey != "" && !isValidIdempotencyKey(document.IdempotencyKey) {
+		l.Warn(ErrInvalidIdempotencyKey.Error())
+		return nil, ErrInvalidIdempotencyKey
+	}
+
 	userID, err := s.getUserID(ctx, document.Token)
 	if err != nil {
 		l.WithError(err).Error("error get user id")
-		return "", ErrTokenNotFound
+		return nil, ErrTokenNotFound
+	}
+	event.ActorID = userID
+
+	err = s.checkFolderOwner(ctx, userID, document.FolderID)
+	if err != nil {
+		l.WithError(err).Error("error check folder")
+		return nil, err
 	}
 
+	if document.IdempotencyKey != "" {
+		stored, err := s.reserveIdempotencyKey(ctx, userID, document.IdempotencyKey)
+		if err != nil {
+			l.WithError(err).Warn("error reserve idempotency key")
+			return nil, err
+		}
+		if stored != nil {
+			var replayed domain.Document
+			err = json.Unmarshal(stored.Response, &replayed)
+			if err != nil {
+				l.WithError(err).Error("error decode stored response")
+				return nil, err
+			}
+			event.DocumentID = replayed.ID
+			return &replayed, nil
+		}
+	}
+
+	var saved *domain.Document
 	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
-		documentID, err := s.repo.Save(ctx, toDocument(userID, document))
+		saved, err = s.saveDocument(ctx, l, userID, document)
 		if err != nil {
-			l.WithError(err).Error("error save document")
 			return err
 		}
 
-		for _, login := range document.Grant {
-			err = s.repo.AddGrant(ctx, toGrant(login, userID, documentID))
-			if err != nil {
-				l.WithError(err).Error("error add grant")
-				return err
-			}
+		saved.Content = ""
+		saved.Permission = domain.PermissionOwner
+		if document.IdempotencyKey == "" {
+			return nil
+		}
+
+		response, err := json.Marshal(saved)
+		if err != nil {
+			return err
 		}
 
-		return nil
+		err = s.repo.CompleteIdempotencyKey(ctx, &domain.IdempotencyKey{
+			UserID:     userID,
+			Key:        document.IdempotencyKey,
+			DocumentID: saved.ID,
+			Response:   response,
+		})
+		if err != nil {
+			l.WithError(err).Error("error complete idempotency key")
+		}
+		return err
 	})
 	if err != nil {
-		return "", err
+		if document.IdempotencyKey != "" {
+			s.releaseIdempotencyKey(ctx, l, userID, document.IdempotencyKey)
+		}
+		return nil, err
+	}
+
+	event.DocumentID = saved.ID
+	s.notifyExtraction()
+	s.notifyThumbnails()
+	s.notifyWebhooks()
+	return saved, nil
+}
+
+// saveDocument stores a new document with its grants and queues its
+// processing. It is called inside a transaction.
+func (s *Service) saveDocument(ctx context.Context, l *logger.Logger, userID uuid.UUID, document *dto.Document) (*domain.Document, error) {
+	saved, err := s.repo.Save(ctx, toDocument(userID, document))
+	if err != nil {
+		l.WithError(err).Error("error save document")
+		return nil, err
+	}
+
+	for _, login := range document.Grant {
+		err = s.repo.AddGrant(ctx, toGrant(login, userID, saved.ID))
+		if err != nil {
+			l.WithError(err).Error("error add grant")
+			return nil, err
+		}
+	}
+
+	err = s.emitWebhookEvent(ctx, domain.WebhookDocumentUploaded, saved, "")
+	if err != nil {
+		l.WithError(err).Error("error emit webhook event")
+		return nil, err
+	}
+
+	for _, login := range document.Grant {
+		err = s.emitWebhookEvent(ctx, domain.WebhookDocumentShared, saved, login)
+		if err != nil {
+			l.WithError(err).Error("error emit webhook event")
+			return nil, err
+		}
+	}
+
+	err = s.repo.AddTextExtraction(ctx, saved.ID, saved.Version)
+	if err != nil {
+		l.WithError(err).Error("error add text extraction")
+		return nil, err
 	}
-	return document.Name, nil
+
+	if thumbnail.Supported(saved.Mime) {
+		err = s.repo.AddThumbnailJob(ctx, saved.ID, saved.Version)
+		if err != nil {
+			l.WithError(err).Error("error add thumbnail job")
+			return nil, err
+		}
+	}
+
+	return saved, nil
 }
 
-func (s *Service) GetDocument(ctx context.Context, documentID uuid.UUID, token string) (*domain.Document, error) {
+func (s *Service) GetDocument(ctx context.Context, documentID uuid.UUID, token string) (_ *domain.Document, err error) {
 	l := s.log.WithField("service_method", "GetDocument")
 
+	event := &domain.AuditEvent{Action: domain.AuditView, DocumentID: documentID}
+	defer func() { s.audit(ctx, event, err) }()
+
 	document, err := s.getDocument(ctx, documentID)
 	if err != nil {
 		l.WithError(err).Error("error get document")
 		return nil, ErrDocumentNotFound
 	}
 
-	if document.Public {
-		return document, nil
+	event.ActorID, event.ActorLogin, err = s.checkAccess(ctx, document, token, domain.PermissionRead)
+	if err != nil {
+		l.WithError(err).Warn("error check access")
+		return nil, err
 	}
 
-	userID, err := s.getUserID(ctx, token)
+	// a public document is read without resolving the token, the reader is
+	// still recorded when one is sent
+	if event.ActorID == uuid.Nil && token != "" {
+		event.ActorID, event.ActorLogin = s.auditActor(ctx, token)
+	}
+
+	return document, nil
+}
+
+// GetDocumentMeta returns what GetDocument returns without the content, with
+// the owner login and, for the owner, the grants of the document.
+func (s *Service) GetDocumentMeta(ctx context.Context, documentID uuid.UUID, token string) (*dto.DocumentMeta, error) {
+	l := s.log.WithField("service_method", "GetDocumentMeta")
+
+	document, err := s.GetDocument(ctx, documentID, token)
 	if err != nil {
-		l.WithError(err).Error("error get user id")
+		return nil, err
+	}
+
+	owner, err := s.getUserByID(ctx, document.UserID)
+	if err != nil {
+		l.WithError(err).Error("error get owner")
 		return nil, ErrUserNotFound
 	}
 
-	if userID == document.UserID {
-		return document, nil
+	meta := *document
+	meta.Content = ""
+	meta.Grant = nil
+	meta.Permission = domain.PermissionRead
+
+	if s.isOwner(ctx, token, document) {
+		meta.Permission = domain.PermissionOwner
+		meta.Grant, err = s.repo.GetGrantLogins(ctx, documentID)
+		if err != nil {
+			l.WithError(err).Error("error get grants")
+			return nil, err
+		}
 	}
 
-	user, err := s.getUserByID(ctx, userID)
+	return &dto.DocumentMeta{Document: &meta, Owner: owner.Login}, nil
+}
+
+func (s *Service) UpdateDocument(ctx context.Context, req *dto.UpdateDocumentRequest) (_ *domain.Document, err error) {
+	l := s.log.WithField("service_method", "UpdateDocument")
+
+	event := &domain.AuditEvent{Action: domain.AuditUpdate, DocumentID: req.ID}
+	defer func() { s.audit(ctx, event, err) }()
+
+	err = req.IsValid()
 	if err != nil {
-		l.WithError(err).Error("error get user")
+		l.Warn(err.Error())
+		return nil, err
+	}
+
+	if !isValidMetadata(req.Metadata) {
+		l.Warn(ErrInvalidMetadata.Error())
+		return nil, ErrInvalidMetadata
+	}
+
+	userID, err := s.getUserID(ctx, req.Token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
 		return nil, ErrUserNotFound
 	}
+	event.ActorID = userID
+
+	document, err := s.repo.GetDocument(ctx, req.ID)
+	if err != nil || document.UserID != userID {
+		l.WithError(err).Error("error get document")
+		return nil, ErrDocumentNotFound
+	}
+
+	if req.Revision != 0 && req.Revision != document.Revision {
+		l.Warn(ErrPreconditionFailed.Error())
+		return nil, ErrPreconditionFailed
+	}
 
-	isAccess, err := s.checkGrant(ctx, documentID, user.Login)
+	var logins []string
+	if req.FolderID != nil && *req.FolderID != document.FolderID {
+		err = s.checkFolderOwner(ctx, userID, *req.FolderID)
+		if err != nil {
+			l.WithError(err).Error("error check folder")
+			return nil, err
+		}
+
+		if document.FolderID != uuid.Nil {
+			logins, err = s.repo.GetFolderGrantLogins(ctx, document.FolderID)
+			if err != nil {
+				l.WithError(err).Error("error get folder grants")
+				return nil, err
+			}
+		}
+	}
+
+	var updated *domain.Document
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		updated, err = s.repo.UpdateDocument(ctx, applyUpdate(document, req))
+		if err != nil {
+			l.WithError(err).Error("error update document")
+			return err
+		}
+
+		err = s.emitWebhookEvent(ctx, domain.WebhookDocumentUpdated, updated, "")
+		if err != nil {
+			l.WithError(err).Error("error emit webhook event")
+		}
+		return err
+	})
 	if err != nil {
-		l.WithError(err).Error("error check grant")
+		if errors.Is(err, repo.ErrDocumentChanged) {
+			return nil, ErrPreconditionFailed
+		}
 		return nil, err
 	}
 
-	if isAccess {
-		return document, nil
+	s.cache.Delete(prepareGetDocumentKey(req.ID))
+	s.forgetGrants([]uuid.UUID{req.ID}, logins)
+	s.notifyWebhooks()
+
+	return updated, nil
+}
+
+// UploadVersion replaces the content of a document the caller owns with its next
+// version. Text and thumbnails of the new version are produced in the background.
+func (s *Service) UploadVersion(ctx context.Context, req *dto.DocumentContentRequest) (_ *domain.Document, err error) {
+	l := s.log.WithField("service_method", "UploadVersion")
+
+	event := &domain.AuditEvent{Action: domain.AuditVersion, DocumentID: req.ID}
+	defer func() { s.audit(ctx, event, err) }()
+
+	document, err := s.repo.GetDocument(ctx, req.ID)
+	if err != nil {
+		l.WithError(err).Error("error get document")
+		return nil, ErrDocumentNotFound
+	}
+
+	// the owner and the users granted domain.PermissionEdit upload new content
+	event.ActorID, event.ActorLogin, err = s.checkAccess(ctx, document, req.Token, domain.PermissionEdit)
+	if err != nil {
+		l.WithError(err).Warn("error check access")
+		return nil, err
+	}
+
+	if req.Revision != 0 && req.Revision != document.Revision {
+		l.Warn(ErrPreconditionFailed.Error())
+		return nil, ErrPreconditionFailed
+	}
+
+	err = s.checkLock(ctx, req.ID, event.ActorID)
+	if err != nil {
+		l.WithError(err).Warn("error check lock")
+		return nil, err
 	}
 
-	return nil, ErrNoAccess
+	var updated *domain.Document
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		updated, err = s.repo.ReplaceContent(ctx, applyContent(document, req))
+		if err != nil {
+			l.WithError(err).Error("error replace content")
+			return err
+		}
+
+		err = s.repo.AddTextExtraction(ctx, updated.ID, updated.Version)
+		if err != nil {
+			l.WithError(err).Error("error add text extraction")
+			return err
+		}
+
+		if thumbnail.Supported(updated.Mime) {
+			err = s.repo.AddThumbnailJob(ctx, updated.ID, updated.Version)
+			if err != nil {
+				l.WithError(err).Error("error add thumbnail job")
+				return err
+			}
+		}
+
+		err = s.emitWebhookEvent(ctx, domain.WebhookDocumentUpdated, updated, "")
+		if err != nil {
+			l.WithError(err).Error("error emit webhook event")
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrDocumentChanged) {
+			return nil, ErrPreconditionFailed
+		}
+		return nil, err
+	}
+
+	s.cache.Delete(prepareGetDocumentKey(req.ID))
+	s.notifyExtraction()
+	s.notifyThumbnails()
+	s.notifyWebhooks()
+
+	return updated, nil
 }
 
-func (s *Service) GetDocuments(ctx context.Context, filter *dto.GetDocumentsRequest) ([]domain.Document, error) {
+// GetDocuments returns one page of the listing sorted by name unless another order is requested.
+func (s *Service) GetDocuments(ctx context.Context, filter *dto.GetDocumentsRequest) (_ *dto.DocumentsPage, err error) {
 	l := s.log.WithField("service_method", "GetDocuments")
 
+	event := &domain.AuditEvent{Action: domain.AuditList}
+	defer func() { s.audit(ctx, event, err) }()
+
 	userID, err := s.getUserID(ctx, filter.Token)
 	if err != nil {
 		l.WithError(err).Error("error get user id")
 		return nil, ErrUserNotFound
 	}
+	event.ActorID = userID
 
-	documents, err := s.repo.GetDocuments(ctx, toGetDocuments(userID, filter))
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		l.WithError(err).Error("error get user")
+		return nil, ErrUserNotFound
+	}
+	event.ActorLogin = user.Login
+
+	after, err := decodeCursor(filter.Cursor)
+	if err != nil {
+		l.WithError(err).Warn("error decode cursor")
+		return nil, ErrInvalidCursor
+	}
+
+	query := toGetDocuments(user, filter, after)
+	if after != nil && (after.Sort != query.Sort || after.Order != query.Order) {
+		l.Warn("cursor was issued for another sort order")
+		return nil, ErrInvalidCursor
+	}
+
+	// one extra document tells whether there is a next page
+	query.Limit = filter.Limit + 1
+	documents, err := s.repo.GetDocuments(ctx, query)
 	if err != nil {
 		l.WithError(err).Error("error get documents")
 		return nil, ErrDocumentsNotFound
 	}
 
-	sort.SliceStable(documents, func(i, j int) bool {
-		if documents[i].Name < documents[j].Name {
-			return true
-		} else if documents[i].Name > documents[j].Name {
-			return false
+	for i := range documents {
+		documents[i].Permission = permission(userID, &documents[i])
+	}
+
+	page := &dto.DocumentsPage{Documents: documents}
+	if len(documents) > filter.Limit {
+		page.Documents = documents[:filter.Limit]
+		page.NextCursor = encodeCursor(toCursor(query, &page.Documents[filter.Limit-1]))
+	}
+
+	return page, nil
+}
+
+// Search runs a full-text query over names, descriptions and extracted text of
+// the documents the caller can read, the best matches first.
+func (s *Service) Search(ctx context.Context, req *dto.SearchRequest) ([]domain.SearchResult, error) {
+	l := s.log.WithField("service_method", "Search")
+
+	userID, err := s.getUserID(ctx, req.Token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		l.WithError(err).Error("error get user")
+		return nil, ErrUserNotFound
+	}
+
+	results, err := s.repo.SearchDocuments(ctx, toSearch(user, req))
+	if err != nil {
+		l.WithError(err).Error("error search documents")
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *Service) DeleteDocument(ctx context.Context, id uuid.UUID, token string) (_ uuid.UUID, err error) {
+	l := s.log.WithField("service_method", "DeleteDocument")
+
+	event := &domain.AuditEvent{Action: domain.AuditDelete, DocumentID: id}
+	defer func() { s.audit(ctx, event, err) }()
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return uuid.Nil, ErrUserNotFound
+	}
+	event.ActorID = userID
+
+	document, err := s.repo.GetDocument(ctx, id)
+	if err != nil || document.UserID != userID {
+		l.WithError(err).Error("error get document")
+		return uuid.Nil, ErrDocumentNotFound
+	}
+
+	err = s.checkDeletable(ctx, document)
+	if err != nil {
+		l.WithError(err).Warn("document can not be deleted")
+		return uuid.Nil, err
+	}
+
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		id, err = s.repo.DeleteDocument(ctx, id, userID)
+		if err != nil {
+			l.WithError(err).Error("error delete document")
+			return ErrDocumentNotFound
 		}
 
-		return documents[i].CreatedAt.Before(documents[j].CreatedAt)
+		err = s.emitWebhookEvent(ctx, domain.WebhookDocumentDeleted, document, "")
+		if err != nil {
+			l.WithError(err).Error("error emit webhook event")
+		}
+		return err
 	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	s.cache.Delete(prepareGetDocumentKey(id))
+	s.notifyWebhooks()
+
+	return id, nil
+}
+
+func (s *Service) GetTrash(ctx context.Context, token string) ([]domain.Document, error) {
+	l := s.log.WithField("service_method", "GetTrash")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+
+	documents, err := s.repo.GetTrash(ctx, userID)
+	if err != nil {
+		l.WithError(err).Error("error get trash")
+		return nil, ErrDocumentsNotFound
+	}
 
 	return documents, nil
 }
 
-func (s *Service) DeleteDocument(ctx context.Context, id uuid.UUID, token string) (uuid.UUID, error) {
-	l := s.log.WithField("service_method", "DeleteDocument")
+func (s *Service) RestoreDocument(ctx context.Context, id uuid.UUID, token string) (_ uuid.UUID, err error) {
+	l := s.log.WithField("service_method", "RestoreDocument")
+
+	event := &domain.AuditEvent{Action: domain.AuditRestore, DocumentID: id}
+	defer func() { s.audit(ctx, event, err) }()
 
 	userID, err := s.getUserID(ctx, token)
 	if err != nil {
 		l.WithError(err).Error("error get user id")
 		return uuid.Nil, ErrUserNotFound
 	}
+	event.ActorID = userID
+
+	err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		id, err = s.repo.RestoreDocument(ctx, id, userID)
+		if err != nil {
+			l.WithError(err).Error("error restore document")
+			return ErrDocumentNotFound
+		}
+
+		document, err := s.repo.GetDocument(ctx, id)
+		if err != nil {
+			l.WithError(err).Error("error get document")
+			return err
+		}
 
-	id, err = s.repo.DeleteDocument(ctx, id, userID)
+		err = s.emitWebhookEvent(ctx, domain.WebhookDocumentRestored, document, "")
+		if err != nil {
+			l.WithError(err).Error("error emit webhook event")
+		}
+		return err
+	})
 	if err != nil {
-		l.WithError(err).Error("error get document")
-		return uuid.Nil, ErrDocumentNotFound
+		return uuid.Nil, err
+	}
+
+	s.notifyWebhooks()
+
+	return id, nil
+}
+
+// PurgeTrash permanently removes documents that stayed in the trash longer than the retention period.
+func (s *Service) PurgeTrash(ctx context.Context) (int64, error) {
+	l := s.log.WithField("service_method", "PurgeTrash")
+
+	var purged int64
+	err := s.repo.ExecTx(ctx, func(ctx context.Context) error {
+		var err error
+		purged, err = s.repo.PurgeTrash(ctx, time.Now().Add(-s.trashRetention))
+		return err
+	})
+	if err != nil {
+		l.WithError(err).Error("error purge trash")
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+func (s *Service) SetLegalHold(ctx context.Context, id uuid.UUID, hold bool) error {
+	l := s.log.WithField("service_method", "SetLegalHold")
+
+	err := s.repo.SetLegalHold(ctx, id, hold)
+	if err != nil {
+		l.WithError(err).Error("error set legal hold")
+		return ErrDocumentNotFound
+	}
+
+	s.cache.Delete(prepareGetDocumentKey(id))
+
+	return nil
+}
+
+func (s *Service) AddRetentionPolicy(ctx context.Context, policy *domain.RetentionPolicy) (uuid.UUID, error) {
+	l := s.log.WithField("service_method", "AddRetentionPolicy")
+
+	if !isValidRetentionPolicy(policy) {
+		l.Warn(ErrInvalidRetentionPolicy.Error())
+		return uuid.Nil, ErrInvalidRetentionPolicy
+	}
+
+	id, err := s.repo.AddRetentionPolicy(ctx, policy)
+	if err != nil {
+		l.WithError(err).Error("error add retention policy")
+		return uuid.Nil, err
 	}
 
 	return id, nil
 }
 
+func (s *Service) GetRetentionPolicies(ctx context.Context) ([]domain.RetentionPolicy, error) {
+	l := s.log.WithField("service_method", "GetRetentionPolicies")
+
+	policies, err := s.repo.GetRetentionPolicies(ctx, nil)
+	if err != nil {
+		l.WithError(err).Error("error get retention policies")
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (s *Service) DeleteRetentionPolicy(ctx context.Context, id uuid.UUID) error {
+	l := s.log.WithField("service_method", "DeleteRetentionPolicy")
+
+	err := s.repo.DeleteRetentionPolicy(ctx, id)
+	if err != nil {
+		l.WithError(err).Error("error delete retention policy")
+		if errors.Is(err, repo.ErrRetentionPolicyNotFound) {
+			return ErrRetentionPolicyNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ExpireDocuments moves documents whose expiry policy has passed to the trash.
+func (s *Service) ExpireDocuments(ctx context.Context) (int, error) {
+	l := s.log.WithField("service_method", "ExpireDocuments")
+
+	documents, err := s.repo.GetExpiredDocuments(ctx, time.Now())
+	if err != nil {
+		l.WithError(err).Error("error get expired documents")
+		return 0, err
+	}
+
+	expired := 0
+	for i := range documents {
+		document := &documents[i]
+		err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+			_, err := s.repo.DeleteDocument(ctx, document.ID, document.UserID)
+			if err != nil {
+				return err
+			}
+
+			return s.emitWebhookEvent(ctx, domain.WebhookDocumentDeleted, document, "")
+		})
+		if err != nil {
+			l.WithError(err).WithField("document_id", document.ID).Error("error expire document")
+			continue
+		}
+		s.cache.Delete(prepareGetDocumentKey(document.ID))
+		expired++
+	}
+
+	if expired > 0 {
+		s.notifyWebhooks()
+	}
+
+	return expired, nil
+}
+
+// checkDeletable reports whether a legal hold or a minimum retention policy protects the document.
+func (s *Service) checkDeletable(ctx context.Context, document *domain.Document) error {
+	if document.LegalHold {
+		return ErrLegalHold
+	}
+
+	policies, err := s.repo.GetRetentionPolicies(ctx, document)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, policy := range policies {
+		if policy.Kind == domain.RetentionMin && now.Before(document.CreatedAt.AddDate(0, 0, policy.Days)) {
+			return ErrRetentionPolicy
+		}
+	}
+
+	return nil
+}
+
 func (s *Service) getDocument(ctx context.Context, documentID uuid.UUID) (*domain.Document, error) {
 	key := prepareGetDocumentKey(documentID)
 	doc, exist := s.cache.Get(key)
@@ -270,6 +977,17 @@ func (s *Service) getUserID(ctx context.Context, token string) (uuid.UUID, error
 	return userID, nil
 }
 
+// isOwner reports whether the token belongs to the owner of the document.
+// A public document is readable without a token, so a missing one is not an error.
+func (s *Service) isOwner(ctx context.Context, token string, document *domain.Document) bool {
+	if token == "" {
+		return false
+	}
+
+	userID, err := s.getUserID(ctx, token)
+	return err == nil && userID == document.UserID
+}
+
 func (s *Service) getUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	key := prepareGetUserKey(id)
 	userCash, exist := s.cache.Get(key)
@@ -286,6 +1004,49 @@ func (s *Service) getUserByID(ctx context.Context, id uuid.UUID) (*domain.User,
 	return user, nil
 }
 
+// checkAccess reports whether the token holder may read, comment or edit the
+// document. Anyone may read a public document, the owner may do everything,
+// other users need a grant with the permission or a wider one.
+// The user and the login are returned as far as they were resolved.
+func (s *Service) checkAccess(ctx context.Context, document *domain.Document, token, permission string) (uuid.UUID, string, error) {
+	if document.Public && permission == domain.PermissionRead {
+		return uuid.Nil, "", nil
+	}
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		return uuid.Nil, "", ErrUserNotFound
+	}
+
+	if userID == document.UserID {
+		return userID, "", nil
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return userID, "", ErrUserNotFound
+	}
+
+	var isAccess bool
+	switch permission {
+	case domain.PermissionComment:
+		isAccess, err = s.repo.CheckCommentGrant(ctx, document.ID, user.Login)
+	case domain.PermissionEdit:
+		isAccess, err = s.repo.CheckEditGrant(ctx, document.ID, user.Login)
+	default:
+		isAccess, err = s.checkGrant(ctx, document.ID, user.Login)
+	}
+	if err != nil {
+		return userID, user.Login, err
+	}
+
+	if !isAccess {
+		return userID, user.Login, ErrNoAccess
+	}
+
+	return userID, user.Login, nil
+}
+
 func (s *Service) checkGrant(ctx context.Context, documentID uuid.UUID, login string) (bool, error) {
 	key := prepareCheckGrantKey(documentID, login)
 	isAccessCash, exist := s.cache.Get(key)
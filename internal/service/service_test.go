@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Alina9496/documents/config"
 	"github.com/Alina9496/documents/internal/domain"
 	"github.com/Alina9496/documents/internal/repo"
 	"github.com/Alina9496/documents/internal/service/dto"
@@ -14,6 +17,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type ServiceSuite struct {
@@ -27,51 +31,71 @@ func (s *ServiceSuite) SetupTest() {
 	ctrl := gomock.NewController(s.T())
 	s.repo = NewMockRepository(ctrl)
 	s.cache = NewMockCache(ctrl)
-	s.service = New(s.repo, s.cache, logger.New(""))
+	var err error
+	s.service, err = New(s.repo, s.cache, logger.New(""), &config.Config{
+		Trash: config.Trash{Retention: time.Hour},
+		Lock:  config.Lock{TTL: 15 * time.Minute, MaxTTL: time.Hour},
+	})
+	s.Require().NoError(err)
+	// the audit log is checked by Test_audit
+	s.repo.EXPECT().AddAuditEvent(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 }
 
 func TestServiceSuite(t *testing.T) {
 	suite.Run(t, new(ServiceSuite))
 }
 
-func (s *ServiceSuite) Test_isUserExists() {
+func (s *ServiceSuite) Test_checkCredentials() {
 	ctx := context.Background()
-	user := &domain.User{
-		Login:    "login345",
-		Password: "Passw_345",
-	}
 	id := uuid.New()
+	hash, err := bcrypt.GenerateFromPassword([]byte("Passw_345"), bcrypt.MinCost)
+	s.Require().NoError(err)
+	disabledAt := time.Now()
 	tests := []struct {
-		name  string
-		ctx   context.Context
-		user  *domain.User
-		want  bool
-		calls func()
+		name   string
+		stored *domain.User
+		err    error
+		calls  func()
 	}{
 		{
 			name: "user not found",
-			ctx:  ctx,
-			user: user,
-			want: false,
+			err:  ErrUserNotFound,
 			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(uuid.Nil, errors.ErrUnsupported)
+				s.repo.EXPECT().GetUserByLogin(ctx, "login345").Return(nil, repo.ErrUserNotFound)
 			},
 		},
 		{
-			name: "user found",
-			ctx:  ctx,
-			user: user,
-			want: true,
-			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(id, nil)
-			},
+			name:   "wrong password",
+			stored: &domain.User{ID: id, Login: "login345", Password: "$2a$04$" + strings.Repeat("a", 53)},
+			err:    ErrUserNotFound,
+		},
+		{
+			name:   "hashed password",
+			stored: &domain.User{ID: id, Login: "login345", Password: string(hash)},
+		},
+		{
+			name:   "password stored before hashing",
+			stored: &domain.User{ID: id, Login: "login345", Password: "Passw_345"},
+		},
+		{
+			name:   "disabled user",
+			stored: &domain.User{ID: id, Login: "login345", Password: string(hash), DisabledAt: &disabledAt},
+			err:    ErrUserDisabled,
 		},
 	}
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
-			tt.calls()
-			got := s.service.isUserExists(tt.ctx, tt.user)
-			s.Equal(tt.want, got)
+			if tt.calls != nil {
+				tt.calls()
+			} else {
+				s.repo.EXPECT().GetUserByLogin(ctx, "login345").Return(tt.stored, nil)
+			}
+			user := &domain.User{Login: "login345", Password: "Passw_345"}
+			err := s.service.checkCredentials(ctx, user)
+			s.Equal(tt.err, err)
+			if tt.err == nil || tt.err == ErrUserDisabled {
+				s.Equal(id, user.ID)
+			}
 		})
 	}
 }
@@ -128,7 +152,17 @@ func (s *ServiceSuite) Test_Registration() {
 			want: "",
 			err:  fmt.Errorf("error when check user: %w", ErrUserExists),
 			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(id, nil)
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(&domain.User{ID: id, Login: user.Login}, nil)
+			},
+		},
+		{
+			name: "error check user",
+			ctx:  ctx,
+			user: user,
+			want: "",
+			err:  fmt.Errorf("error when registration user: %w", ErrRegistrationUser),
+			calls: func() {
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(nil, errors.ErrUnsupported)
 			},
 		},
 		{
@@ -138,8 +172,8 @@ func (s *ServiceSuite) Test_Registration() {
 			want: "",
 			err:  fmt.Errorf("error when registration user: %w", ErrRegistrationUser),
 			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(uuid.Nil, nil)
-				s.repo.EXPECT().Registration(ctx, user).Return(errors.ErrUnsupported)
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(nil, repo.ErrUserNotFound)
+				s.repo.EXPECT().Registration(ctx, gomock.Any()).Return(errors.ErrUnsupported)
 			},
 		},
 		{
@@ -149,8 +183,14 @@ func (s *ServiceSuite) Test_Registration() {
 			want: user.Login,
 			err:  nil,
 			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(uuid.Nil, nil)
-				s.repo.EXPECT().Registration(ctx, user).Return(nil)
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(nil, repo.ErrUserNotFound)
+				s.repo.EXPECT().Registration(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, stored *domain.User) error {
+						s.Equal(user.Login, stored.Login)
+						s.NoError(bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte(user.Password)))
+						return nil
+					},
+				)
 			},
 		},
 	}
@@ -160,6 +200,7 @@ func (s *ServiceSuite) Test_Registration() {
 			got, err := s.service.Registration(tt.ctx, tt.user)
 			s.Equal(tt.want, got)
 			s.Equal(tt.err, err)
+			s.Equal("Passw_345", user.Password, "the password of the caller is not replaced by its hash")
 		})
 	}
 }
@@ -171,6 +212,10 @@ func (s *ServiceSuite) Test_Authentication() {
 		Password: "Passw_345",
 	}
 	id := uuid.New()
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.MinCost)
+	s.Require().NoError(err)
+	stored := &domain.User{ID: id, Login: user.Login, Password: string(hash)}
+	disabledAt := time.Now()
 	tests := []struct {
 		name  string
 		ctx   context.Context
@@ -212,7 +257,18 @@ func (s *ServiceSuite) Test_Authentication() {
 			user: user,
 			err:  fmt.Errorf("error when check user: %w", ErrUserNotFound),
 			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(uuid.Nil, nil)
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(nil, repo.ErrUserNotFound)
+			},
+		},
+		{
+			name: "error user disabled",
+			ctx:  ctx,
+			user: user,
+			err:  fmt.Errorf("error when check user: %w", ErrUserDisabled),
+			calls: func() {
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(
+					&domain.User{ID: id, Login: user.Login, Password: string(hash), DisabledAt: &disabledAt}, nil,
+				)
 			},
 		},
 		{
@@ -221,7 +277,7 @@ func (s *ServiceSuite) Test_Authentication() {
 			user: user,
 			err:  fmt.Errorf("error when authentication user: %w", ErrAuthenticationUser),
 			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(id, nil)
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(stored, nil)
 				s.repo.EXPECT().Authentication(ctx, user).Return(errors.ErrUnsupported)
 			},
 		},
@@ -231,8 +287,14 @@ func (s *ServiceSuite) Test_Authentication() {
 			user: user,
 			err:  nil,
 			calls: func() {
-				s.repo.EXPECT().CheckUser(ctx, user).Return(id, nil)
-				s.repo.EXPECT().Authentication(ctx, user).Return(nil)
+				s.repo.EXPECT().GetUserByLogin(ctx, user.Login).Return(stored, nil)
+				s.repo.EXPECT().Authentication(ctx, user).DoAndReturn(
+					func(_ context.Context, user *domain.User) error {
+						s.Equal(id, user.ID)
+						s.Nil(user.TokenExpiresAt)
+						return nil
+					},
+				)
 			},
 		},
 	}
@@ -248,6 +310,7 @@ func (s *ServiceSuite) Test_Authentication() {
 func (s *ServiceSuite) Test_LogOut() {
 	ctx := context.Background()
 	token := "CxBiwVruDAD8kp8jgeOY"
+	userID := uuid.New()
 	tests := []struct {
 		name    string
 		ctx     context.Context
@@ -261,6 +324,8 @@ func (s *ServiceSuite) Test_LogOut() {
 			token:   token,
 			wantErr: ErrTokenNotFound,
 			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey(token)).Return(nil, false)
+				s.repo.EXPECT().GetUserID(ctx, token).Return(uuid.Nil, repo.ErrTokenNotFound)
 				s.repo.EXPECT().LogOut(ctx, token).Return(repo.ErrTokenNotFound)
 			},
 		},
@@ -270,6 +335,7 @@ func (s *ServiceSuite) Test_LogOut() {
 			token:   token,
 			wantErr: fmt.Errorf("error when logout user: %w", ErrLogOutUser),
 			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey(token)).Return(userID, true)
 				s.repo.EXPECT().LogOut(ctx, token).Return(errors.ErrUnsupported)
 			},
 		},
@@ -279,7 +345,9 @@ func (s *ServiceSuite) Test_LogOut() {
 			token:   token,
 			wantErr: nil,
 			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey(token)).Return(userID, true)
 				s.repo.EXPECT().LogOut(ctx, token).Return(nil)
+				s.cache.EXPECT().Delete(prepareGetUserIDKey(token))
 			},
 		},
 	}
@@ -292,15 +360,173 @@ func (s *ServiceSuite) Test_LogOut() {
 	}
 }
 
+func (s *ServiceSuite) Test_CheckToken() {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(nil, false)
+	s.repo.EXPECT().GetUserID(ctx, "token").Return(userID, nil)
+	s.cache.EXPECT().Set(prepareGetUserIDKey("token"), userID, gomock.Any())
+	s.NoError(s.service.CheckToken(ctx, "token"))
+
+	s.cache.EXPECT().Get(prepareGetUserIDKey("expired")).Return(nil, false)
+	s.repo.EXPECT().GetUserID(ctx, "expired").Return(uuid.Nil, repo.ErrTokenNotFound)
+	s.Equal(ErrUserNotFound, s.service.CheckToken(ctx, "expired"))
+}
+
+func (s *ServiceSuite) Test_BasicAuth() {
+	ctx := context.Background()
+	login, password := "login345", "Passw_345"
+	token := "CxBiwVruDAD8kp8jgeOY"
+	userID := uuid.New()
+	key := prepareBasicAuthKey(login, password)
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		want     string
+		wantErr  error
+		calls    func()
+	}{
+		{
+			name:     "session of the credentials",
+			login:    login,
+			password: password,
+			want:     token,
+			calls: func() {
+				s.cache.EXPECT().Get(key).Return(token, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(token)).Return(userID, true)
+			},
+		},
+		{
+			name:     "token as the password",
+			login:    login,
+			password: token,
+			want:     token,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareBasicAuthKey(login, token)).Return(nil, false)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(token)).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{Login: login}, true)
+			},
+		},
+		{
+			name:     "token of another user",
+			login:    "login346",
+			password: token,
+			wantErr:  ErrUserNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareBasicAuthKey("login346", token)).Return(nil, false)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(token)).Return(userID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(userID)).Return(&domain.User{Login: login}, true)
+			},
+		},
+		{
+			name:     "expired session logs in again",
+			login:    login,
+			password: password,
+			calls: func() {
+				s.cache.EXPECT().Get(key).Return(token, true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(token)).Return(nil, false)
+				s.repo.EXPECT().GetUserID(ctx, token).Return(uuid.Nil, repo.ErrTokenNotFound)
+				s.cache.EXPECT().Delete(key)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(password)).Return(nil, false)
+				s.repo.EXPECT().GetUserID(ctx, password).Return(uuid.Nil, repo.ErrTokenNotFound)
+				s.repo.EXPECT().GetUserByLogin(ctx, login).Return(&domain.User{ID: userID, Login: login, Password: password}, nil)
+				s.repo.EXPECT().GetUserToken(ctx, userID).Return("", repo.ErrTokenNotFound)
+				s.repo.EXPECT().Authentication(ctx, gomock.Any()).Return(nil)
+				s.cache.EXPECT().Set(key, gomock.Any(), gomock.Any())
+			},
+		},
+		{
+			name:     "valid token of the user is reused",
+			login:    login,
+			password: password,
+			want:     token,
+			calls: func() {
+				s.cache.EXPECT().Get(key).Return(nil, false)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(password)).Return(nil, false)
+				s.repo.EXPECT().GetUserID(ctx, password).Return(uuid.Nil, repo.ErrTokenNotFound)
+				s.repo.EXPECT().GetUserByLogin(ctx, login).Return(&domain.User{ID: userID, Login: login, Password: password}, nil)
+				s.repo.EXPECT().GetUserToken(ctx, userID).Return(token, nil)
+				s.cache.EXPECT().Set(key, token, gomock.Any())
+			},
+		},
+		{
+			name:     "disabled user",
+			login:    login,
+			password: password,
+			wantErr:  ErrUserNotFound,
+			calls: func() {
+				disabledAt := time.Now()
+				s.cache.EXPECT().Get(key).Return(nil, false)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(password)).Return(nil, false)
+				s.repo.EXPECT().GetUserID(ctx, password).Return(uuid.Nil, repo.ErrTokenNotFound)
+				s.repo.EXPECT().GetUserByLogin(ctx, login).Return(&domain.User{ID: userID, Login: login, Password: password, DisabledAt: &disabledAt}, nil)
+			},
+		},
+		{
+			name:     "wrong password",
+			login:    login,
+			password: password,
+			wantErr:  ErrUserNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(key).Return(nil, false)
+				s.cache.EXPECT().Get(prepareGetUserIDKey(password)).Return(nil, false)
+				s.repo.EXPECT().GetUserID(ctx, password).Return(uuid.Nil, repo.ErrTokenNotFound)
+				s.repo.EXPECT().GetUserByLogin(ctx, login).Return(&domain.User{ID: userID, Login: login, Password: "Other_345"}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.BasicAuth(ctx, tt.login, tt.password)
+			s.Equal(tt.wantErr, err)
+			if tt.want != "" {
+				s.Equal(tt.want, got)
+			}
+			if tt.wantErr == nil {
+				s.NotEmpty(got)
+			}
+		})
+	}
+}
+
 func (s *ServiceSuite) Test_Upload() {
 	userID := uuid.New()
 	documentID := uuid.New()
 	ctx := context.Background()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	emptySum := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	save := func(ctx context.Context, document *domain.Document) (*domain.Document, error) {
+		saved := *document
+		saved.ID, saved.Revision, saved.Version, saved.CreatedAt = documentID, 1, 1, created
+		return &saved, nil
+	}
+	uploaded := func(name, mime string, grant []string, public bool) *domain.Document {
+		return &domain.Document{
+			ID:         documentID,
+			UserID:     userID,
+			Name:       name,
+			Mime:       mime,
+			Checksum:   emptySum,
+			Grant:      grant,
+			Revision:   1,
+			Version:    1,
+			CreatedAt:  created,
+			Public:     public,
+			Permission: domain.PermissionOwner,
+		}
+	}
+	response, err := json.Marshal(uploaded("name", "text/plain", nil, false))
+	s.Require().NoError(err)
+
 	tests := []struct {
 		name     string
 		ctx      context.Context
 		document *dto.Document
-		want     string
+		want     *domain.Document
 		err      error
 		calls    func()
 	}{
@@ -315,7 +541,7 @@ func (s *ServiceSuite) Test_Upload() {
 				Grant:   []string{"login"},
 				Public:  true,
 			},
-			want: "name",
+			want: uploaded("name", "image/jpeg", []string{"login"}, true),
 			err:  nil,
 			calls: func() {
 				s.cache.EXPECT().Get(gomock.Any()).Return(nil, false)
@@ -326,10 +552,105 @@ func (s *ServiceSuite) Test_Upload() {
 						return fn(ctx)
 					},
 				)
-				s.repo.EXPECT().Save(ctx, gomock.Any()).Return(documentID, nil)
+				s.repo.EXPECT().Save(ctx, gomock.Any()).DoAndReturn(save)
 				s.repo.EXPECT().AddGrant(ctx, gomock.Any()).Return(nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil).Times(2)
+				s.repo.EXPECT().AddTextExtraction(ctx, documentID, 1).Return(nil)
+				s.repo.EXPECT().AddThumbnailJob(ctx, documentID, 1).Return(nil)
+			},
+		},
+		{
+			name: "invalid metadata",
+			ctx:  ctx,
+			document: &dto.Document{
+				Name:     "name",
+				Token:    "token",
+				Mime:     "image/jpeg",
+				Metadata: map[string]any{"department": []any{"sales"}},
+			},
+			err:   ErrInvalidMetadata,
+			calls: func() {},
+		},
+		{
+			name:     "first request with idempotency key",
+			ctx:      ctx,
+			document: &dto.Document{Name: "name", Token: "token", Mime: "text/plain", IdempotencyKey: "key-1"},
+			want:     uploaded("name", "text/plain", nil, false),
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().ReserveIdempotencyKey(ctx, gomock.Any(), gomock.Any()).Return(true, nil)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().Save(ctx, gomock.Any()).DoAndReturn(save)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil)
+				s.repo.EXPECT().AddTextExtraction(ctx, documentID, 1).Return(nil)
+				s.repo.EXPECT().CompleteIdempotencyKey(ctx, &domain.IdempotencyKey{
+					UserID:     userID,
+					Key:        "key-1",
+					DocumentID: documentID,
+					Response:   response,
+				}).Return(nil)
+			},
+		},
+		{
+			name:     "retry replays the response",
+			ctx:      ctx,
+			document: &dto.Document{Name: "renamed", Token: "token", Mime: "text/plain", IdempotencyKey: "key-1"},
+			want:     uploaded("name", "text/plain", nil, false),
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().ReserveIdempotencyKey(ctx, gomock.Any(), gomock.Any()).Return(false, nil)
+				s.repo.EXPECT().GetIdempotencyKey(ctx, userID, "key-1").Return(&domain.IdempotencyKey{
+					UserID:     userID,
+					Key:        "key-1",
+					Status:     domain.IdempotencyDone,
+					DocumentID: documentID,
+					Response:   response,
+				}, nil)
+			},
+		},
+		{
+			name:     "first request is in flight",
+			ctx:      ctx,
+			document: &dto.Document{Name: "name", Token: "token", Mime: "text/plain", IdempotencyKey: "key-1"},
+			err:      ErrIdempotencyKeyInUse,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().ReserveIdempotencyKey(ctx, gomock.Any(), gomock.Any()).Return(false, nil)
+				s.repo.EXPECT().GetIdempotencyKey(ctx, userID, "key-1").Return(&domain.IdempotencyKey{
+					UserID: userID,
+					Key:    "key-1",
+					Status: domain.IdempotencyPending,
+				}, nil)
+			},
+		},
+		{
+			name:     "failed upload releases the key",
+			ctx:      ctx,
+			document: &dto.Document{Name: "name", Token: "token", Mime: "text/plain", IdempotencyKey: "key-1"},
+			err:      errors.ErrUnsupported,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().ReserveIdempotencyKey(ctx, gomock.Any(), gomock.Any()).Return(true, nil)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().Save(ctx, gomock.Any()).Return(nil, errors.ErrUnsupported)
+				s.repo.EXPECT().DeleteIdempotencyKey(gomock.Any(), userID, "key-1").Return(nil)
 			},
 		},
+		{
+			name:     "invalid idempotency key",
+			ctx:      ctx,
+			document: &dto.Document{Name: "name", Token: "token", Mime: "text/plain", IdempotencyKey: "ключ"},
+			err:      ErrInvalidIdempotencyKey,
+			calls:    func() {},
+		},
 	}
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
@@ -421,94 +742,123 @@ func (s *ServiceSuite) Test_GetDocuments() {
 	documentID1, documentID2, documentID3 := uuid.New(), uuid.New(), uuid.New()
 	ctx := context.Background()
 	now := time.Now()
+	otherUserID := uuid.New()
+	user := &domain.User{ID: ownerUserID, Login: "owner"}
+	documents := func() []domain.Document {
+		return []domain.Document{
+			{ID: documentID1, UserID: ownerUserID, Name: "1", Mime: "image/jpeg", Grant: []string{"login"}, CreatedAt: now},
+			{ID: documentID2, UserID: otherUserID, Name: "2", Mime: "image/jpeg", CreatedAt: now},
+			{ID: documentID3, UserID: ownerUserID, Name: "3", Mime: "image/jpeg", Grant: []string{"login"}, CreatedAt: now},
+		}
+	}
+	withPermissions := []domain.Document{
+		{ID: documentID1, UserID: ownerUserID, Name: "1", Mime: "image/jpeg", Grant: []string{"login"}, CreatedAt: now,
+			Permission: domain.PermissionOwner},
+		{ID: documentID2, UserID: otherUserID, Name: "2", Mime: "image/jpeg", CreatedAt: now,
+			Permission: domain.PermissionRead},
+		{ID: documentID3, UserID: ownerUserID, Name: "3", Mime: "image/jpeg", Grant: []string{"login"}, CreatedAt: now,
+			Permission: domain.PermissionOwner},
+	}
+	cursor := encodeCursor(&dto.Cursor{Sort: dto.SortName, Order: dto.OrderAsc, Name: "2", ID: documentID2})
 
 	tests := []struct {
 		name   string
 		ctx    context.Context
 		filter *dto.GetDocumentsRequest
-		want   []domain.Document
+		want   *dto.DocumentsPage
 		err    error
 		calls  func()
 	}{
 		{
-			name: "success",
+			name: "first page",
 			ctx:  ctx,
 			filter: &dto.GetDocumentsRequest{
 				Token: "token",
 				Login: "login",
 				Key:   "mime",
 				Value: "image/jpeg",
-				Limit: 3,
+				Limit: 2,
 			},
-			want: []domain.Document{
-				{
-					ID:        documentID3,
-					UserID:    ownerUserID,
-					Name:      "1",
-					Mime:      "image/jpeg",
-					Content:   "",
-					Grant:     []string{"login"},
-					CreatedAt: now.AddDate(0, 0, -1),
-					Public:    false,
-				},
-				{
-					ID:        documentID1,
-					UserID:    ownerUserID,
-					Name:      "1",
-					Mime:      "image/jpeg",
-					Content:   "",
-					Grant:     []string{"login"},
-					CreatedAt: now,
-					Public:    false,
-				},
-				{
-					ID:        documentID2,
-					UserID:    ownerUserID,
-					Name:      "2",
-					Mime:      "image/jpeg",
-					Content:   "",
-					Grant:     []string{"login"},
-					CreatedAt: now,
-					Public:    false,
-				},
+			want: &dto.DocumentsPage{
+				Documents:  withPermissions[:2],
+				NextCursor: cursor,
 			},
 			err: nil,
 			calls: func() {
 				s.cache.EXPECT().Get(gomock.Any()).Return(nil, false)
 				s.repo.EXPECT().GetUserID(ctx, "token").Return(ownerUserID, nil)
 				s.cache.EXPECT().Set(gomock.Any(), ownerUserID, gomock.Any())
-				s.repo.EXPECT().GetDocuments(ctx, gomock.Any()).Return([]domain.Document{
-					{
-						ID:        documentID1,
-						UserID:    ownerUserID,
-						Name:      "1",
-						Mime:      "image/jpeg",
-						Content:   "",
-						Grant:     []string{"login"},
-						CreatedAt: now,
-						Public:    false,
-					},
-					{
-						ID:        documentID2,
-						UserID:    ownerUserID,
-						Name:      "2",
-						Mime:      "image/jpeg",
-						Content:   "",
-						Grant:     []string{"login"},
-						CreatedAt: now,
-						Public:    false,
-					},
-					{
-						ID:        documentID3,
-						UserID:    ownerUserID,
-						Name:      "1",
-						Mime:      "image/jpeg",
-						Content:   "",
-						Grant:     []string{"login"},
-						CreatedAt: now.AddDate(0, 0, -1),
-						Public:    false,
-					},
-				}, nil)
+				s.cache.EXPECT().Get(gomock.Any()).Return(user, true)
+				s.repo.EXPECT().GetDocuments(ctx, &dto.GetDocuments{
+					UserID:    ownerUserID,
+					UserLogin: "owner",
+					Scope:     dto.ScopeAll,
+					GrantedTo: "login",
+					Key:       "mime",
+					Value:     "image/jpeg",
+					Sort:      dto.SortName,
+					Order:     dto.OrderAsc,
+					Limit:     3,
+				}).Return(documents(), nil)
+			},
+		},
+		{
+			name: "last page",
+			ctx:  ctx,
+			filter: &dto.GetDocumentsRequest{
+				Token:  "token",
+				Scope:  dto.ScopeOwned,
+				Sort:   dto.SortName,
+				Cursor: cursor,
+				Limit:  2,
+			},
+			want: &dto.DocumentsPage{
+				Documents: withPermissions[2:],
+			},
+			err: nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(ownerUserID, true)
+				s.cache.EXPECT().Get(gomock.Any()).Return(user, true)
+				s.repo.EXPECT().GetDocuments(ctx, &dto.GetDocuments{
+					UserID:    ownerUserID,
+					UserLogin: "owner",
+					Scope:     dto.ScopeOwned,
+					Sort:      dto.SortName,
+					Order:     dto.OrderAsc,
+					After:     &dto.Cursor{Sort: dto.SortName, Order: dto.OrderAsc, Name: "2", ID: documentID2},
+					Limit:     3,
+				}).Return(documents()[2:], nil)
+			},
+		},
+		{
+			name: "cursor of another sort order",
+			ctx:  ctx,
+			filter: &dto.GetDocumentsRequest{
+				Token:  "token",
+				Sort:   dto.SortSize,
+				Cursor: cursor,
+				Limit:  2,
+			},
+			want: nil,
+			err:  ErrInvalidCursor,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(ownerUserID, true)
+				s.cache.EXPECT().Get(gomock.Any()).Return(user, true)
+			},
+		},
+		{
+			name: "malformed cursor",
+			ctx:  ctx,
+			filter: &dto.GetDocumentsRequest{
+				Token:  "token",
+				Cursor: "not a cursor",
+				Limit:  2,
+			},
+			want: nil,
+			err:  ErrInvalidCursor,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(ownerUserID, true)
+				s.cache.EXPECT().Get(gomock.Any()).Return(user, true)
 			},
 		},
 	}
@@ -526,6 +876,12 @@ func (s *ServiceSuite) Test_DeleteDocument() {
 	ctx := context.Background()
 	id := uuid.New()
 	userID := uuid.New()
+	document := &domain.Document{
+		ID:        id,
+		UserID:    userID,
+		Mime:      "image/jpeg",
+		CreatedAt: time.Now(),
+	}
 	tests := []struct {
 		name  string
 		ctx   context.Context
@@ -546,7 +902,19 @@ func (s *ServiceSuite) Test_DeleteDocument() {
 				s.cache.EXPECT().Get(gomock.Any()).Return(nil, false)
 				s.repo.EXPECT().GetUserID(ctx, "token").Return(userID, nil)
 				s.cache.EXPECT().Set(gomock.Any(), userID, gomock.Any())
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().GetRetentionPolicies(ctx, document).Return([]domain.RetentionPolicy{
+					{Kind: domain.RetentionMin, Mime: "image/jpeg", Days: 0},
+					{Kind: domain.RetentionExpire, DocumentID: id, Days: 1},
+				}, nil)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
 				s.repo.EXPECT().DeleteDocument(ctx, id, userID).Return(id, nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil)
+				s.cache.EXPECT().Delete(prepareGetDocumentKey(id))
 			},
 		},
 		{
@@ -561,6 +929,49 @@ func (s *ServiceSuite) Test_DeleteDocument() {
 				s.repo.EXPECT().GetUserID(ctx, "token").Return(uuid.Nil, ErrUserNotFound)
 			},
 		},
+		{
+			name:  "document of another user",
+			ctx:   ctx,
+			id:    id,
+			token: "token",
+			want:  uuid.Nil,
+			err:   ErrDocumentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(uuid.New(), true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+			},
+		},
+		{
+			name:  "document under legal hold",
+			ctx:   ctx,
+			id:    id,
+			token: "token",
+			want:  uuid.Nil,
+			err:   ErrLegalHold,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(&domain.Document{
+					ID:        id,
+					UserID:    userID,
+					LegalHold: true,
+				}, nil)
+			},
+		},
+		{
+			name:  "document protected by retention policy",
+			ctx:   ctx,
+			id:    id,
+			token: "token",
+			want:  uuid.Nil,
+			err:   ErrRetentionPolicy,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().GetRetentionPolicies(ctx, document).Return([]domain.RetentionPolicy{
+					{Kind: domain.RetentionMin, Mime: "image/jpeg", Days: 365},
+				}, nil)
+			},
+		},
 		{
 			name:  "document not found",
 			ctx:   ctx,
@@ -572,6 +983,13 @@ func (s *ServiceSuite) Test_DeleteDocument() {
 				s.cache.EXPECT().Get(gomock.Any()).Return(nil, false)
 				s.repo.EXPECT().GetUserID(ctx, "token").Return(userID, nil)
 				s.cache.EXPECT().Set(gomock.Any(), userID, gomock.Any())
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().GetRetentionPolicies(ctx, document).Return(nil, nil)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
 				s.repo.EXPECT().DeleteDocument(ctx, id, userID).Return(uuid.Nil, ErrDocumentNotFound)
 			},
 		},
@@ -585,3 +1003,735 @@ func (s *ServiceSuite) Test_DeleteDocument() {
 		})
 	}
 }
+
+func (s *ServiceSuite) Test_GetTrash() {
+	ctx := context.Background()
+	userID := uuid.New()
+	deletedAt := time.Now()
+	documents := []domain.Document{
+		{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Name:      "name",
+			Mime:      "image/jpeg",
+			DeletedAt: &deletedAt,
+		},
+	}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		token string
+		want  []domain.Document
+		err   error
+		calls func()
+	}{
+		{
+			name:  "success",
+			ctx:   ctx,
+			token: "token",
+			want:  documents,
+			err:   nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetTrash(ctx, userID).Return(documents, nil)
+			},
+		},
+		{
+			name:  "error get trash",
+			ctx:   ctx,
+			token: "token",
+			want:  nil,
+			err:   ErrDocumentsNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetTrash(ctx, userID).Return(nil, errors.ErrUnsupported)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.GetTrash(tt.ctx, tt.token)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_RestoreDocument() {
+	ctx := context.Background()
+	id := uuid.New()
+	userID := uuid.New()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		id    uuid.UUID
+		token string
+		want  uuid.UUID
+		err   error
+		calls func()
+	}{
+		{
+			name:  "success",
+			ctx:   ctx,
+			id:    id,
+			token: "token",
+			want:  id,
+			err:   nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().RestoreDocument(ctx, id, userID).Return(id, nil)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(&domain.Document{ID: id, UserID: userID}, nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:  "document not in trash",
+			ctx:   ctx,
+			id:    id,
+			token: "token",
+			want:  uuid.Nil,
+			err:   ErrDocumentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().RestoreDocument(ctx, id, userID).Return(uuid.Nil, repo.ErrDocumentNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.RestoreDocument(tt.ctx, tt.id, tt.token)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_PurgeTrash() {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		want  int64
+		err   error
+		calls func()
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			want: 2,
+			err:  nil,
+			calls: func() {
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().PurgeTrash(ctx, gomock.Any()).Return(int64(2), nil)
+			},
+		},
+		{
+			name: "error purge",
+			ctx:  ctx,
+			want: 0,
+			err:  errors.ErrUnsupported,
+			calls: func() {
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().PurgeTrash(ctx, gomock.Any()).Return(int64(0), errors.ErrUnsupported)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.PurgeTrash(tt.ctx)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_ExpireDocuments() {
+	ctx := context.Background()
+	id1, id2 := uuid.New(), uuid.New()
+	userID := uuid.New()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		want  int
+		err   error
+		calls func()
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			want: 1,
+			err:  nil,
+			calls: func() {
+				s.repo.EXPECT().GetExpiredDocuments(ctx, gomock.Any()).Return([]domain.Document{
+					{ID: id1, UserID: userID},
+					{ID: id2, UserID: userID},
+				}, nil)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).Times(2).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().DeleteDocument(ctx, id1, userID).Return(id1, nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil)
+				s.cache.EXPECT().Delete(prepareGetDocumentKey(id1))
+				s.repo.EXPECT().DeleteDocument(ctx, id2, userID).Return(uuid.Nil, repo.ErrDocumentNotFound)
+			},
+		},
+		{
+			name: "error get expired documents",
+			ctx:  ctx,
+			want: 0,
+			err:  errors.ErrUnsupported,
+			calls: func() {
+				s.repo.EXPECT().GetExpiredDocuments(ctx, gomock.Any()).Return(nil, errors.ErrUnsupported)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.ExpireDocuments(tt.ctx)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_AddRetentionPolicy() {
+	ctx := context.Background()
+	id := uuid.New()
+	policy := &domain.RetentionPolicy{
+		Kind: domain.RetentionMin,
+		Mime: "application/pdf",
+		Days: 365,
+	}
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		policy *domain.RetentionPolicy
+		want   uuid.UUID
+		err    error
+		calls  func()
+	}{
+		{
+			name:   "success",
+			ctx:    ctx,
+			policy: policy,
+			want:   id,
+			err:    nil,
+			calls: func() {
+				s.repo.EXPECT().AddRetentionPolicy(ctx, policy).Return(id, nil)
+			},
+		},
+		{
+			name: "invalid policy",
+			ctx:  ctx,
+			policy: &domain.RetentionPolicy{
+				Kind: domain.RetentionMin,
+				Days: 365,
+			},
+			want:  uuid.Nil,
+			err:   ErrInvalidRetentionPolicy,
+			calls: func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.AddRetentionPolicy(tt.ctx, tt.policy)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_SetLegalHold() {
+	ctx := context.Background()
+	id := uuid.New()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		id    uuid.UUID
+		hold  bool
+		err   error
+		calls func()
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			id:   id,
+			hold: true,
+			err:  nil,
+			calls: func() {
+				s.repo.EXPECT().SetLegalHold(ctx, id, true).Return(nil)
+				s.cache.EXPECT().Delete(prepareGetDocumentKey(id))
+			},
+		},
+		{
+			name: "document not found",
+			ctx:  ctx,
+			id:   id,
+			hold: false,
+			err:  ErrDocumentNotFound,
+			calls: func() {
+				s.repo.EXPECT().SetLegalHold(ctx, id, false).Return(repo.ErrDocumentNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			err := s.service.SetLegalHold(tt.ctx, tt.id, tt.hold)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_UpdateDocument() {
+	ctx := context.Background()
+	id := uuid.New()
+	userID := uuid.New()
+	name := "report.pdf"
+	public := true
+	now := time.Now()
+	document := &domain.Document{
+		ID:        id,
+		UserID:    userID,
+		Name:      "name",
+		Mime:      "application/pdf",
+		Revision:  2,
+		CreatedAt: now,
+	}
+	updated := &domain.Document{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Mime:      "application/pdf",
+		Public:    true,
+		Revision:  2,
+		CreatedAt: now,
+	}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		req   *dto.UpdateDocumentRequest
+		want  *domain.Document
+		err   error
+		calls func()
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			req: &dto.UpdateDocumentRequest{
+				ID:       id,
+				Token:    "token",
+				Name:     &name,
+				Public:   &public,
+				Revision: 2,
+			},
+			want: updated,
+			err:  nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().UpdateDocument(ctx, updated).Return(updated, nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil)
+				s.cache.EXPECT().Delete(prepareGetDocumentKey(id))
+			},
+		},
+		{
+			name: "empty name",
+			ctx:  ctx,
+			req: &dto.UpdateDocumentRequest{
+				ID:    id,
+				Token: "token",
+				Name:  new(string),
+			},
+			want:  nil,
+			err:   dto.ErrEmptyName,
+			calls: func() {},
+		},
+		{
+			name: "stale revision",
+			ctx:  ctx,
+			req: &dto.UpdateDocumentRequest{
+				ID:       id,
+				Token:    "token",
+				Name:     &name,
+				Revision: 1,
+			},
+			want: nil,
+			err:  ErrPreconditionFailed,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+			},
+		},
+		{
+			name: "changed concurrently",
+			ctx:  ctx,
+			req: &dto.UpdateDocumentRequest{
+				ID:     id,
+				Token:  "token",
+				Public: &public,
+			},
+			want: nil,
+			err:  ErrPreconditionFailed,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().UpdateDocument(ctx, gomock.Any()).Return(nil, repo.ErrDocumentChanged)
+			},
+		},
+		{
+			name: "document of another user",
+			ctx:  ctx,
+			req: &dto.UpdateDocumentRequest{
+				ID:     id,
+				Token:  "token",
+				Public: &public,
+			},
+			want: nil,
+			err:  ErrDocumentNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(uuid.New(), true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.UpdateDocument(tt.ctx, tt.req)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_UploadVersion() {
+	ctx := context.Background()
+	id := uuid.New()
+	userID, readerID := uuid.New(), uuid.New()
+	document := &domain.Document{
+		ID:       id,
+		UserID:   userID,
+		Name:     "photo",
+		Mime:     "application/octet-stream",
+		Content:  "b2xk",
+		Size:     3,
+		Revision: 2,
+		Version:  1,
+	}
+	replaced := &domain.Document{
+		ID:       id,
+		UserID:   userID,
+		Name:     "photo",
+		Mime:     "image/png",
+		Content:  "bmV3IQ==",
+		Size:     4,
+		Checksum: "bdd1e524e5c90bee91a4f1ac4a087ca0012e36235ab24b5136d2a6388e7ad58b",
+		Revision: 2,
+		Version:  1,
+	}
+	updated := &domain.Document{
+		ID:       id,
+		UserID:   userID,
+		Name:     "photo",
+		Mime:     "image/png",
+		Content:  "bmV3IQ==",
+		Size:     4,
+		Revision: 3,
+		Version:  2,
+	}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		req   *dto.DocumentContentRequest
+		want  *domain.Document
+		err   error
+		calls func()
+	}{
+		{
+			name: "new image version",
+			ctx:  ctx,
+			req: &dto.DocumentContentRequest{
+				ID:       id,
+				Token:    "token",
+				Mime:     "image/png",
+				Content:  []byte("new!"),
+				Revision: 2,
+			},
+			want: updated,
+			err:  nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().GetLock(ctx, id, gomock.Any()).Return(nil, repo.ErrLockNotFound)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().ReplaceContent(ctx, replaced).Return(updated, nil)
+				s.repo.EXPECT().AddTextExtraction(ctx, id, 2).Return(nil)
+				s.repo.EXPECT().AddThumbnailJob(ctx, id, 2).Return(nil)
+				s.repo.EXPECT().AddWebhookEvent(ctx, gomock.Any()).Return(nil)
+				s.cache.EXPECT().Delete(prepareGetDocumentKey(id))
+			},
+		},
+		{
+			name: "stale revision",
+			ctx:  ctx,
+			req: &dto.DocumentContentRequest{
+				ID:       id,
+				Token:    "token",
+				Content:  []byte("new!"),
+				Revision: 1,
+			},
+			want: nil,
+			err:  ErrPreconditionFailed,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+			},
+		},
+		{
+			name: "changed concurrently",
+			ctx:  ctx,
+			req: &dto.DocumentContentRequest{
+				ID:      id,
+				Token:   "token",
+				Content: []byte("new!"),
+			},
+			want: nil,
+			err:  ErrPreconditionFailed,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().GetLock(ctx, id, gomock.Any()).Return(nil, repo.ErrLockNotFound)
+				s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				s.repo.EXPECT().ReplaceContent(ctx, gomock.Any()).Return(nil, repo.ErrDocumentChanged)
+			},
+		},
+		{
+			name: "locked by another user",
+			ctx:  ctx,
+			req: &dto.DocumentContentRequest{
+				ID:      id,
+				Token:   "token",
+				Content: []byte("new!"),
+			},
+			want: nil,
+			err:  ErrLocked,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().GetLock(ctx, id, gomock.Any()).Return(&domain.DocumentLock{DocumentID: id, UserID: uuid.New()}, nil)
+			},
+		},
+		{
+			name: "reader of the document",
+			ctx:  ctx,
+			req: &dto.DocumentContentRequest{
+				ID:      id,
+				Token:   "token",
+				Content: []byte("new!"),
+			},
+			want: nil,
+			err:  ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(readerID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(readerID)).Return(&domain.User{ID: readerID, Login: "reader42"}, true)
+				s.repo.EXPECT().GetDocument(ctx, id).Return(document, nil)
+				s.repo.EXPECT().CheckEditGrant(ctx, id, "reader42").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.UploadVersion(tt.ctx, tt.req)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_Search() {
+	ctx := context.Background()
+	userID := uuid.New()
+	results := []domain.SearchResult{
+		{
+			Document: domain.Document{ID: uuid.New(), Name: "invoice.txt"},
+			Rank:     0.6,
+			Snippet:  "<mark>invoice</mark> for march",
+		},
+	}
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		req   *dto.SearchRequest
+		want  []domain.SearchResult
+		err   error
+		calls func()
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			req:  &dto.SearchRequest{Token: "token", Query: " invoice ", Limit: 10, Offset: 20},
+			want: results,
+			err:  nil,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.cache.EXPECT().Get(gomock.Any()).Return(&domain.User{ID: userID, Login: "login345"}, true)
+				s.repo.EXPECT().SearchDocuments(ctx, &dto.Search{
+					UserID: userID,
+					Login:  "login345",
+					Query:  "invoice",
+					Limit:  10,
+					Offset: 20,
+				}).Return(results, nil)
+			},
+		},
+		{
+			name: "user not found",
+			ctx:  ctx,
+			req:  &dto.SearchRequest{Token: "token", Query: "invoice", Limit: 10},
+			want: nil,
+			err:  ErrUserNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(nil, false)
+				s.repo.EXPECT().GetUserID(ctx, "token").Return(uuid.Nil, repo.ErrTokenNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.Search(tt.ctx, tt.req)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_GetDocumentMeta() {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	readerID := uuid.New()
+	documentID := uuid.New()
+	document := func(public bool) *domain.Document {
+		return &domain.Document{
+			ID:       documentID,
+			UserID:   ownerID,
+			Name:     "report.pdf",
+			Mime:     "application/pdf",
+			Content:  "JVBERi0=",
+			Size:     5,
+			Checksum: "abc",
+			Version:  3,
+			Public:   public,
+		}
+	}
+	meta := func(public bool, permission string, grant []string) *dto.DocumentMeta {
+		want := document(public)
+		want.Content, want.Permission, want.Grant = "", permission, grant
+		return &dto.DocumentMeta{Document: want, Owner: "owner"}
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  *dto.DocumentMeta
+		err   error
+		calls func()
+	}{
+		{
+			name:  "owner sees the grants",
+			token: "owner-token",
+			want:  meta(false, domain.PermissionOwner, []string{"reader"}),
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document(false), true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("owner-token")).Return(ownerID, true).Times(2)
+				s.cache.EXPECT().Get(prepareGetUserKey(ownerID)).Return(&domain.User{ID: ownerID, Login: "owner"}, true)
+				s.repo.EXPECT().GetGrantLogins(ctx, documentID).Return([]string{"reader"}, nil)
+			},
+		},
+		{
+			name:  "grantee does not see the grants",
+			token: "reader-token",
+			want:  meta(false, domain.PermissionRead, nil),
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document(false), true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("reader-token")).Return(readerID, true).Times(2)
+				s.cache.EXPECT().Get(prepareGetUserKey(readerID)).Return(&domain.User{ID: readerID, Login: "reader"}, true)
+				s.cache.EXPECT().Get(prepareCheckGrantKey(documentID, "reader")).Return(true, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(ownerID)).Return(&domain.User{ID: ownerID, Login: "owner"}, true)
+			},
+		},
+		{
+			name: "public document without a token",
+			want: meta(true, domain.PermissionRead, nil),
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document(true), true)
+				s.cache.EXPECT().Get(prepareGetUserKey(ownerID)).Return(&domain.User{ID: ownerID, Login: "owner"}, true)
+			},
+		},
+		{
+			name:  "no access",
+			token: "reader-token",
+			err:   ErrNoAccess,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetDocumentKey(documentID)).Return(document(false), true)
+				s.cache.EXPECT().Get(prepareGetUserIDKey("reader-token")).Return(readerID, true)
+				s.cache.EXPECT().Get(prepareGetUserKey(readerID)).Return(&domain.User{ID: readerID, Login: "reader"}, true)
+				s.cache.EXPECT().Get(prepareCheckGrantKey(documentID, "reader")).Return(false, true)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.GetDocumentMeta(ctx, documentID, tt.token)
+			s.Equal(tt.err, err)
+			s.Equal(tt.want, got)
+		})
+	}
+}
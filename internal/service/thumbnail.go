@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/thumbnail"
+	"github.com/google/uuid"
+)
+
+// ThumbnailWake signals that new images are waiting for thumbnails.
+func (s *Service) ThumbnailWake() <-chan struct{} {
+	return s.thumbnailWake
+}
+
+func (s *Service) notifyThumbnails() {
+	select {
+	case s.thumbnailWake <- struct{}{}:
+	default:
+	}
+}
+
+// GenerateThumbnails runs the due thumbnail jobs and returns how many of them are finished.
+// Once the thumbnails of a version are stored the renditions of older versions are removed.
+func (s *Service) GenerateThumbnails(ctx context.Context) (int, error) {
+	l := s.log.WithField("service_method", "GenerateThumbnails")
+
+	jobs, err := s.repo.ClaimThumbnailJobs(ctx, time.Now(), jobLease, s.thumbnails.batch)
+	if err != nil {
+		l.WithError(err).Error("error claim thumbnail jobs")
+		return 0, err
+	}
+
+	finished := 0
+	for i := range jobs {
+		job := &jobs[i]
+		thumbnails := s.renderThumbnails(ctx, job)
+
+		err = s.repo.ExecTx(ctx, func(ctx context.Context) error {
+			for _, rendition := range thumbnails {
+				err := s.repo.SaveRendition(ctx, toRendition(job, rendition))
+				if err != nil {
+					return err
+				}
+			}
+
+			if job.Status == domain.ThumbnailDone {
+				err := s.repo.DeleteOutdatedRenditions(ctx, job.DocumentID, job.Version)
+				if err != nil {
+					return err
+				}
+			}
+
+			return s.repo.UpdateThumbnailJob(ctx, job)
+		})
+		if err != nil {
+			l.WithError(err).WithField("document_id", job.DocumentID).Error("error save thumbnails")
+			continue
+		}
+
+		if job.Status != domain.ThumbnailPending {
+			finished++
+		}
+	}
+
+	return finished, nil
+}
+
+// renderThumbnails renders the thumbnails of the job's version or schedules a retry.
+// Images that can not be decoded fail at once, another attempt would not help.
+func (s *Service) renderThumbnails(ctx context.Context, job *domain.ThumbnailJob) []thumbnail.Thumbnail {
+	document, err := s.repo.GetDocument(ctx, job.DocumentID)
+	if err != nil {
+		s.thumbnails.retry(&job.Job, err)
+		return nil
+	}
+
+	if document.Version != job.Version {
+		job.Status = domain.ThumbnailFailed
+		job.Error = errOutdatedVersion.Error()
+		return nil
+	}
+
+	content, err := base64.StdEncoding.DecodeString(document.Content)
+	if err != nil {
+		s.thumbnails.retry(&job.Job, err)
+		return nil
+	}
+
+	thumbnails, err := thumbnail.Generate(document.Mime, content)
+	switch {
+	case errors.Is(err, thumbnail.ErrUnsupported):
+		job.Status = domain.ThumbnailUnsupported
+		job.Error = ""
+	case err != nil:
+		job.Status = domain.ThumbnailFailed
+		job.Error = err.Error()
+	default:
+		job.Status = domain.ThumbnailDone
+		job.Error = ""
+	}
+
+	return thumbnails
+}
+
+// GetThumbnail returns the thumbnail of the current version of a document the caller can read.
+// An empty size means the default one.
+func (s *Service) GetThumbnail(ctx context.Context, documentID uuid.UUID, token, size string) (*domain.Rendition, error) {
+	l := s.log.WithField("service_method", "GetThumbnail")
+
+	if size == "" {
+		size = thumbnail.DefaultSize
+	}
+	if _, ok := thumbnail.Sizes[size]; !ok {
+		l.Warn(ErrInvalidThumbnailSize.Error())
+		return nil, ErrInvalidThumbnailSize
+	}
+
+	document, err := s.GetDocument(ctx, documentID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	rendition, err := s.repo.GetRendition(ctx, document.ID, document.Version, size)
+	if err != nil {
+		l.WithError(err).Error("error get rendition")
+		if errors.Is(err, repo.ErrRenditionNotFound) {
+			return nil, ErrThumbnailNotFound
+		}
+		return nil, err
+	}
+
+	return rendition, nil
+}
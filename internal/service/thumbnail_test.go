@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/thumbnail"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func (s *ServiceSuite) Test_GenerateThumbnails() {
+	ctx := context.Background()
+	documentID := uuid.New()
+
+	var buf bytes.Buffer
+	s.Require().NoError(png.Encode(&buf, image.NewGray(image.Rect(0, 0, 600, 300))))
+	content := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	tests := []struct {
+		name       string
+		document   *domain.Document
+		err        error
+		want       *domain.ThumbnailJob
+		renditions int
+		finished   int
+		calls      func()
+	}{
+		{
+			name:     "image",
+			document: &domain.Document{ID: documentID, Mime: "image/png", Content: content, Version: 2},
+			want: &domain.ThumbnailJob{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    2,
+					Status:     domain.ThumbnailDone,
+				},
+			},
+			renditions: len(thumbnail.Sizes),
+			finished:   1,
+			calls: func() {
+				s.repo.EXPECT().DeleteOutdatedRenditions(gomock.Any(), documentID, 2).Return(nil)
+			},
+		},
+		{
+			name:     "mime changed to a non image",
+			document: &domain.Document{ID: documentID, Mime: "text/plain", Content: content, Version: 2},
+			want: &domain.ThumbnailJob{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    2,
+					Status:     domain.ThumbnailUnsupported,
+				},
+			},
+			finished: 1,
+			calls:    func() {},
+		},
+		{
+			name:     "broken image is not retried",
+			document: &domain.Document{ID: documentID, Mime: "image/png", Content: "YnJva2Vu", Version: 2},
+			want: &domain.ThumbnailJob{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    2,
+					Status:     domain.ThumbnailFailed,
+					Error:      "error decode image config: image: unknown format",
+				},
+			},
+			finished: 1,
+			calls:    func() {},
+		},
+		{
+			name:     "outdated version",
+			document: &domain.Document{ID: documentID, Mime: "image/png", Content: content, Version: 3},
+			want: &domain.ThumbnailJob{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    2,
+					Status:     domain.ThumbnailFailed,
+					Error:      errOutdatedVersion.Error(),
+				},
+			},
+			finished: 1,
+			calls:    func() {},
+		},
+		{
+			name: "document is not available yet",
+			err:  errors.New("connection refused"),
+			want: &domain.ThumbnailJob{
+				Job: domain.Job{
+					DocumentID: documentID,
+					Version:    2,
+					Status:     domain.ThumbnailPending,
+					Attempts:   1,
+					Error:      "connection refused",
+				},
+			},
+			finished: 0,
+			calls:    func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			var saved *domain.ThumbnailJob
+			renditions := 0
+			s.repo.EXPECT().ClaimThumbnailJobs(ctx, gomock.Any(), jobLease, defaultJobBatch).Return(
+				[]domain.ThumbnailJob{{Job: domain.Job{DocumentID: documentID, Version: 2, Status: domain.ThumbnailPending}}}, nil)
+			s.repo.EXPECT().GetDocument(ctx, documentID).Return(tt.document, tt.err)
+			s.repo.EXPECT().ExecTx(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				},
+			)
+			s.repo.EXPECT().SaveRendition(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, rendition *domain.Rendition) error {
+					s.Equal(documentID, rendition.DocumentID)
+					s.Equal(2, rendition.Version)
+					s.Equal("image/png", rendition.Mime)
+					renditions++
+					return nil
+				},
+			).AnyTimes()
+			s.repo.EXPECT().UpdateThumbnailJob(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, job *domain.ThumbnailJob) error {
+					saved = job
+					return nil
+				},
+			)
+			tt.calls()
+
+			finished, err := s.service.GenerateThumbnails(ctx)
+			s.NoError(err)
+			s.Equal(tt.finished, finished)
+			s.Equal(tt.renditions, renditions)
+
+			if tt.want.Status == domain.ThumbnailPending {
+				s.True(saved.NextAttemptAt.After(time.Now()))
+			}
+			saved.NextAttemptAt = time.Time{}
+			s.Equal(tt.want, saved)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_GetThumbnail() {
+	ctx := context.Background()
+	documentID := uuid.New()
+	userID := uuid.New()
+	document := &domain.Document{ID: documentID, UserID: userID, Mime: "image/png", Version: 2}
+	rendition := &domain.Rendition{DocumentID: documentID, Version: 2, Size: thumbnail.SizeMedium, Mime: "image/png"}
+
+	tests := []struct {
+		name  string
+		size  string
+		want  *domain.Rendition
+		err   error
+		calls func()
+	}{
+		{
+			name: "default size",
+			size: "",
+			want: rendition,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(document, true)
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetRendition(ctx, documentID, 2, thumbnail.SizeMedium).Return(rendition, nil)
+			},
+		},
+		{
+			name: "not generated yet",
+			size: thumbnail.SizeLarge,
+			err:  ErrThumbnailNotFound,
+			calls: func() {
+				s.cache.EXPECT().Get(gomock.Any()).Return(document, true)
+				s.cache.EXPECT().Get(gomock.Any()).Return(userID, true)
+				s.repo.EXPECT().GetRendition(ctx, documentID, 2, thumbnail.SizeLarge).Return(nil, repo.ErrRenditionNotFound)
+			},
+		},
+		{
+			name:  "unknown size",
+			size:  "huge",
+			err:   ErrInvalidThumbnailSize,
+			calls: func() {},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			got, err := s.service.GetThumbnail(ctx, documentID, "token", tt.size)
+			s.Equal(tt.want, got)
+			s.Equal(tt.err, err)
+		})
+	}
+}
@@ -1,12 +1,28 @@
 package service
 
 import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/url"
+	"path"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 	"unicode"
 
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func checkLogin(login string) bool {
@@ -36,6 +52,296 @@ func checkPassword(password string) bool {
 	return false
 }
 
+// hashPassword returns the bcrypt hash the password is stored as.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// isPasswordHash tells a bcrypt hash from a password stored before the
+// passwords were hashed.
+func isPasswordHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+func verifyPassword(stored, password string) bool {
+	if isPasswordHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
+
+func isValidRetentionPolicy(policy *domain.RetentionPolicy) bool {
+	if policy == nil || policy.Days < 1 {
+		return false
+	}
+
+	if policy.Kind != domain.RetentionMin && policy.Kind != domain.RetentionExpire {
+		return false
+	}
+
+	// a policy is attached to exactly one of: MIME type, tag or a single document
+	targets := 0
+	if policy.Mime != "" {
+		targets++
+	}
+	if policy.Tag != "" {
+		targets++
+	}
+	if policy.DocumentID != uuid.Nil {
+		targets++
+	}
+
+	return targets == 1
+}
+
+// normalizeTags trims tags and drops empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if _, ok := seen[tag]; ok || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+// isValidMetadata accepts flat metadata whose values are strings, numbers or booleans.
+func isValidMetadata(metadata map[string]any) bool {
+	for key, value := range metadata {
+		if strings.TrimSpace(key) == "" {
+			return false
+		}
+
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// checksum returns the hex SHA-256 of the content.
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// isValidIdempotencyKey accepts up to 255 printable ASCII characters.
+func isValidIdempotencyKey(key string) bool {
+	if key == "" || len(key) > 255 {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkFolderName(name string) bool {
+	name = strings.TrimSpace(name)
+	return name != "" && name != "." && name != ".." && !strings.Contains(name, "/")
+}
+
+func splitPath(path string) []string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
+
+// maxRetryBackoff caps the delay between two attempts of a background job.
+const maxRetryBackoff = 6 * time.Hour
+
+// retryBackoff doubles the base delay for every failed attempt.
+func retryBackoff(base time.Duration, attempts int) time.Duration {
+	if base <= 0 {
+		base = time.Minute
+	}
+
+	backoff := base
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxRetryBackoff)
+}
+
+func isValidWebhookURL(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// normalizeWebhookEvents drops duplicate events and reports whether all of
+// them are known. No events subscribe a webhook to every event.
+func normalizeWebhookEvents(events []string) ([]string, bool) {
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(domain.WebhookEvents, event) {
+			return nil, false
+		}
+		if !slices.Contains(normalized, event) {
+			normalized = append(normalized, event)
+		}
+	}
+
+	return normalized, true
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := crand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// signWebhook returns the X-Webhook-Signature of a delivery: the hex
+// HMAC-SHA256 of the timestamp, a dot and the body keyed with the secret.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func encodeCursor(cursor *dto.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns nil for an empty cursor, the first page.
+func decodeCursor(value string) (*dto.Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor dto.Cursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// permission is the access the user has to a document returned by a listing:
+// the owner manages it, everyone else can only read it.
+func permission(userID uuid.UUID, document *domain.Document) string {
+	if document.UserID == userID {
+		return domain.PermissionOwner
+	}
+	return domain.PermissionRead
+}
+
+// grantPermission checks the permission of a grant, empty means read.
+func grantPermission(permission string) (string, error) {
+	switch permission {
+	case "":
+		return domain.PermissionRead, nil
+	case domain.PermissionRead, domain.PermissionComment, domain.PermissionEdit:
+		return permission, nil
+	default:
+		return "", ErrInvalidPermission
+	}
+}
+
+// pruneComments drops the deleted comments nobody replied to. The comments
+// are in the order they were written in, so replies follow their parents.
+func pruneComments(comments []domain.Comment) []domain.Comment {
+	replied := make(map[uuid.UUID]bool)
+	kept := make([]domain.Comment, 0, len(comments))
+	for i := len(comments) - 1; i >= 0; i-- {
+		comment := comments[i]
+		if comment.DeletedAt != nil && !replied[comment.ID] {
+			continue
+		}
+		replied[comment.ParentID] = true
+		kept = append(kept, comment)
+	}
+	slices.Reverse(kept)
+
+	return kept
+}
+
+// archiveName turns a document name into a flat entry name that can not
+// escape the extraction directory.
+func archiveName(name string, id uuid.UUID) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return id.String()
+	}
+
+	return name
+}
+
+// uniqueArchiveName appends " (2)", " (3)", ... before the extension of a taken name.
+func uniqueArchiveName(names map[string]bool, name string) string {
+	unique := name
+	ext := path.Ext(name)
+	for i := 2; names[strings.ToLower(unique)]; i++ {
+		unique = strings.TrimSuffix(name, ext) + " (" + strconv.Itoa(i) + ")" + ext
+	}
+	names[strings.ToLower(unique)] = true
+
+	return unique
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
+
 func generateToken() string {
 	var token string
 	for len(token) < 20 {
@@ -70,3 +376,9 @@ func prepareGetUserKey(id uuid.UUID) string {
 func prepareCheckGrantKey(documentID uuid.UUID, login string) string {
 	return fmt.Sprintf("document_ID:%s:login:%s", documentID.String(), login)
 }
+
+// prepareBasicAuthKey does not keep the password in the cache.
+func prepareBasicAuthKey(login, password string) string {
+	sum := sha256.Sum256([]byte(login + ":" + password))
+	return fmt.Sprintf("basic_auth:%s", hex.EncodeToString(sum[:]))
+}
@@ -2,7 +2,11 @@ package service
 
 import (
 	"testing"
+	"time"
 
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -85,3 +89,401 @@ func Test_checkPassword(t *testing.T) {
 		})
 	}
 }
+
+func Test_isValidRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *domain.RetentionPolicy
+		want   bool
+	}{
+		{
+			name:   "policy is nil",
+			policy: nil,
+			want:   false,
+		},
+		{
+			name:   "unknown kind",
+			policy: &domain.RetentionPolicy{Kind: "forever", Mime: "image/jpeg", Days: 1},
+			want:   false,
+		},
+		{
+			name:   "days < 1",
+			policy: &domain.RetentionPolicy{Kind: domain.RetentionMin, Mime: "image/jpeg"},
+			want:   false,
+		},
+		{
+			name:   "no target",
+			policy: &domain.RetentionPolicy{Kind: domain.RetentionMin, Days: 1},
+			want:   false,
+		},
+		{
+			name:   "both mime and document",
+			policy: &domain.RetentionPolicy{Kind: domain.RetentionMin, Mime: "image/jpeg", DocumentID: uuid.New(), Days: 1},
+			want:   false,
+		},
+		{
+			name:   "policy by mime",
+			policy: &domain.RetentionPolicy{Kind: domain.RetentionExpire, Mime: "image/jpeg", Days: 30},
+			want:   true,
+		},
+		{
+			name:   "policy by tag",
+			policy: &domain.RetentionPolicy{Kind: domain.RetentionMin, Tag: "invoice", Days: 1825},
+			want:   true,
+		},
+		{
+			name:   "both tag and mime",
+			policy: &domain.RetentionPolicy{Kind: domain.RetentionMin, Tag: "invoice", Mime: "application/pdf", Days: 1825},
+			want:   false,
+		},
+		{
+			name:   "policy by document",
+			policy: &domain.RetentionPolicy{Kind: domain.RetentionMin, DocumentID: uuid.New(), Days: 3650},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isValidRetentionPolicy(tt.policy)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_normalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{
+			name: "tags are nil",
+			tags: nil,
+			want: nil,
+		},
+		{
+			name: "trim, drop empty and repeated tags",
+			tags: []string{" invoice", "", "2024", "invoice ", "  "},
+			want: []string{"invoice", "2024"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeTags(tt.tags)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_isValidMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]any
+		want     bool
+	}{
+		{
+			name:     "metadata is nil",
+			metadata: nil,
+			want:     true,
+		},
+		{
+			name: "scalar values",
+			metadata: map[string]any{
+				"invoice_number": "INV-42",
+				"amount":         float64(100),
+				"paid":           true,
+			},
+			want: true,
+		},
+		{
+			name:     "empty key",
+			metadata: map[string]any{" ": "value"},
+			want:     false,
+		},
+		{
+			name:     "nested value",
+			metadata: map[string]any{"department": map[string]any{"name": "sales"}},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isValidMetadata(tt.metadata)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_checkFolderName(t *testing.T) {
+	tests := []struct {
+		name       string
+		folderName string
+		want       bool
+	}{
+		{
+			name:       "valid name",
+			folderName: "reports 2024",
+			want:       true,
+		},
+		{
+			name:       "empty name",
+			folderName: "  ",
+			want:       false,
+		},
+		{
+			name:       "dot name",
+			folderName: "..",
+			want:       false,
+		},
+		{
+			name:       "name with slash",
+			folderName: "reports/2024",
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkFolderName(tt.folderName)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_splitPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			name: "root",
+			path: "/",
+			want: []string{},
+		},
+		{
+			name: "nested path",
+			path: "/reports/2024/q1.pdf",
+			want: []string{"reports", "2024", "q1.pdf"},
+		},
+		{
+			name: "repeated slashes",
+			path: "//reports///q1.pdf/",
+			want: []string{"reports", "q1.pdf"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitPath(tt.path)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_archiveName(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{
+			name:     "plain name",
+			document: "report.pdf",
+			want:     "report.pdf",
+		},
+		{
+			name:     "path traversal",
+			document: "../../etc/passwd",
+			want:     "_.._etc_passwd",
+		},
+		{
+			name:     "windows separators and control characters",
+			document: "C:\\tmp\\a\tb.txt",
+			want:     "C:_tmp_a_b.txt",
+		},
+		{
+			name:     "only dots",
+			document: " .. ",
+			want:     id.String(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := archiveName(tt.document, id)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_uniqueArchiveName(t *testing.T) {
+	names := map[string]bool{"manifest.json": true}
+	got := make([]string, 0)
+	for _, name := range []string{"a.pdf", "A.pdf", "a.pdf", "manifest.json", "notes"} {
+		got = append(got, uniqueArchiveName(names, name))
+	}
+
+	assert.Equal(t, []string{"a.pdf", "A (2).pdf", "a (3).pdf", "manifest (2).json", "notes"}, got)
+}
+
+func Test_retryBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{
+			name:     "first retry",
+			base:     time.Minute,
+			attempts: 1,
+			want:     time.Minute,
+		},
+		{
+			name:     "doubles per attempt",
+			base:     time.Minute,
+			attempts: 4,
+			want:     8 * time.Minute,
+		},
+		{
+			name:     "capped",
+			base:     time.Hour,
+			attempts: 20,
+			want:     maxRetryBackoff,
+		},
+		{
+			name:     "default base",
+			base:     0,
+			attempts: 2,
+			want:     2 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retryBackoff(tt.base, tt.attempts)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_decodeCursor(t *testing.T) {
+	cursor := &dto.Cursor{
+		Sort:    dto.SortCreated,
+		Order:   dto.OrderDesc,
+		Created: time.Date(2024, 3, 31, 12, 0, 0, 123000, time.UTC),
+		ID:      uuid.New(),
+	}
+	tests := []struct {
+		name    string
+		value   string
+		want    *dto.Cursor
+		wantErr bool
+	}{
+		{
+			name:  "first page",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "round trip",
+			value: encodeCursor(cursor),
+			want:  cursor,
+		},
+		{
+			name:    "not base64",
+			value:   "not a cursor",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.value)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_normalizeWebhookEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   []string
+		ok     bool
+	}{
+		{
+			name:   "all events",
+			events: nil,
+			want:   []string{},
+			ok:     true,
+		},
+		{
+			name:   "duplicates dropped",
+			events: []string{domain.WebhookDocumentShared, domain.WebhookDocumentDeleted, domain.WebhookDocumentShared},
+			want:   []string{domain.WebhookDocumentShared, domain.WebhookDocumentDeleted},
+			ok:     true,
+		},
+		{
+			name:   "unknown event",
+			events: []string{domain.WebhookDocumentUploaded, "document.viewed"},
+			want:   nil,
+			ok:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := normalizeWebhookEvents(tt.events)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func Test_signWebhook(t *testing.T) {
+	// echo -n '1700000000.{"event":"document.deleted"}' | openssl dgst -sha256 -hmac secret
+	got := signWebhook("secret", "1700000000", []byte(`{"event":"document.deleted"}`))
+	assert.Equal(t, "sha256=99d73883b0a688dd3515a5093fa537f6b4eb9d4bc4d13460ec302adf2b435bd9", got)
+}
+
+func Test_pruneComments(t *testing.T) {
+	deleted := time.Now()
+	root, reply, nested, lone := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		comments []domain.Comment
+		want     []uuid.UUID
+	}{
+		{
+			name:     "no comments",
+			comments: nil,
+			want:     []uuid.UUID{},
+		},
+		{
+			name: "deleted without replies dropped",
+			comments: []domain.Comment{
+				{ID: root},
+				{ID: lone, DeletedAt: &deleted},
+			},
+			want: []uuid.UUID{root},
+		},
+		{
+			name: "deleted with a reply kept",
+			comments: []domain.Comment{
+				{ID: root, DeletedAt: &deleted},
+				{ID: reply, ParentID: root, DeletedAt: &deleted},
+				{ID: nested, ParentID: reply},
+				{ID: lone, ParentID: root, DeletedAt: &deleted},
+			},
+			want: []uuid.UUID{root, reply, nested},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]uuid.UUID, 0)
+			for _, comment := range pruneComments(tt.comments) {
+				got = append(got, comment.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/repo"
+	"github.com/Alina9496/documents/internal/service/dto"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWebhookBatch       = 20
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookTimeout     = 10 * time.Second
+
+	// maxWebhookDeliveries limits the delivery history returned at once.
+	maxWebhookDeliveries = 100
+	// webhookResponseLimit is how much of a response is read to reuse the connection.
+	webhookResponseLimit = 64 << 10
+)
+
+// errWebhookAddress is the delivery error of a webhook on the internal network.
+var errWebhookAddress = errors.New("webhook address is not allowed")
+
+// WebhookWake signals that new events are waiting in the outbox.
+func (s *Service) WebhookWake() <-chan struct{} {
+	return s.webhookWake
+}
+
+func (s *Service) notifyWebhooks() {
+	select {
+	case s.webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// CreateWebhook registers a webhook of the caller. The secret that signs the
+// deliveries is only returned here.
+func (s *Service) CreateWebhook(ctx context.Context, token string, webhook *domain.Webhook) (*domain.Webhook, error) {
+	l := s.log.WithField("service_method", "CreateWebhook")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+
+	webhook.UserID = userID
+	return s.createWebhook(ctx, webhook)
+}
+
+// CreateAdminWebhook registers a webhook that receives the events of every document.
+func (s *Service) CreateAdminWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	webhook.UserID = uuid.Nil
+	return s.createWebhook(ctx, webhook)
+}
+
+func (s *Service) createWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	l := s.log.WithField("service_method", "CreateWebhook")
+
+	if !isValidWebhookURL(webhook.URL) {
+		l.Warn(ErrInvalidWebhookURL.Error())
+		return nil, ErrInvalidWebhookURL
+	}
+
+	events, ok := normalizeWebhookEvents(webhook.Events)
+	if !ok {
+		l.Warn(ErrInvalidWebhookEvent.Error())
+		return nil, ErrInvalidWebhookEvent
+	}
+	webhook.Events = events
+
+	secret, err := generateSecret()
+	if err != nil {
+		l.WithError(err).Error("error generate secret")
+		return nil, err
+	}
+	webhook.Secret = secret
+
+	created, err := s.repo.CreateWebhook(ctx, webhook)
+	if err != nil {
+		l.WithError(err).Error("error create webhook")
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *Service) GetWebhooks(ctx context.Context, token string) ([]domain.Webhook, error) {
+	l := s.log.WithField("service_method", "GetWebhooks")
+
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		l.WithError(err).Error("error get user id")
+		return nil, ErrUserNotFound
+	}
+
+	webhooks, err := s.repo.GetWebhooks(ctx, userID)
+	if err != nil {
+		l.WithError(err).Error("error get webhooks")
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetAdminWebhooks returns the webhooks of all users and of the admin.
+func (s *Service) GetAdminWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	l := s.log.WithField("service_method", "GetAdminWebhooks")
+
+	webhooks, err := s.repo.GetWebhooks(ctx, uuid.Nil)
+	if err != nil {
+		l.WithError(err).Error("error get webhooks")
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, id uuid.UUID, token string) error {
+	l := s.log.WithField("service_method", "DeleteWebhook")
+
+	_, err := s.getOwnWebhook(ctx, id, token)
+	if err != nil {
+		l.WithError(err).Warn("error get webhook")
+		return err
+	}
+
+	return s.deleteWebhook(ctx, id)
+}
+
+// DeleteAdminWebhook removes any webhook with its delivery history.
+func (s *Service) DeleteAdminWebhook(ctx context.Context, id uuid.UUID) error {
+	return s.deleteWebhook(ctx, id)
+}
+
+func (s *Service) deleteWebhook(ctx context.Context, id uuid.UUID) error {
+	l := s.log.WithField("service_method", "DeleteWebhook")
+
+	err := s.repo.DeleteWebhook(ctx, id)
+	if err != nil {
+		l.WithError(err).Error("error delete webhook")
+		if errors.Is(err, repo.ErrWebhookNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a webhook of the caller.
+func (s *Service) GetWebhookDeliveries(ctx context.Context, id uuid.UUID, token string) ([]domain.WebhookDelivery, error) {
+	l := s.log.WithField("service_method", "GetWebhookDeliveries")
+
+	_, err := s.getOwnWebhook(ctx, id, token)
+	if err != nil {
+		l.WithError(err).Warn("error get webhook")
+		return nil, err
+	}
+
+	return s.getWebhookDeliveries(ctx, id)
+}
+
+// GetAdminWebhookDeliveries returns the latest deliveries of any webhook.
+func (s *Service) GetAdminWebhookDeliveries(ctx context.Context, id uuid.UUID) ([]domain.WebhookDelivery, error) {
+	return s.getWebhookDeliveries(ctx, id)
+}
+
+func (s *Service) getWebhookDeliveries(ctx context.Context, id uuid.UUID) ([]domain.WebhookDelivery, error) {
+	l := s.log.WithField("service_method", "GetWebhookDeliveries")
+
+	deliveries, err := s.repo.GetWebhookDeliveries(ctx, id, maxWebhookDeliveries)
+	if err != nil {
+		l.WithError(err).Error("error get webhook deliveries")
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// getOwnWebhook returns the webhook when it belongs to the caller. The
+// webhooks of others are not found, so their IDs are not disclosed.
+func (s *Service) getOwnWebhook(ctx context.Context, id uuid.UUID, token string) (*domain.Webhook, error) {
+	userID, err := s.getUserID(ctx, token)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	webhook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil || webhook.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+// emitWebhookEvent writes an event about the document to the outbox. It runs
+// in the transaction of the change, so the event is kept exactly when the
+// change is. login is the user the document was shared with or unshared from.
+func (s *Service) emitWebhookEvent(ctx context.Context, event string, document *domain.Document, login string) error {
+	payload, err := json.Marshal(toWebhookPayload(event, document, login))
+	if err != nil {
+		return err
+	}
+
+	return s.repo.AddWebhookEvent(ctx, &domain.WebhookEvent{
+		Event:       event,
+		DocumentID:  document.ID,
+		UserID:      document.UserID,
+		TargetLogin: login,
+		Payload:     payload,
+	})
+}
+
+// DeliverWebhooks fans the outbox out to the subscribed webhooks and sends the
+// due deliveries. It returns how many deliveries are finished. Failed
+// deliveries are retried with exponential backoff up to the configured number
+// of attempts.
+func (s *Service) DeliverWebhooks(ctx context.Context) (int, error) {
+	l := s.log.WithField("service_method", "DeliverWebhooks")
+
+	batch := s.webhooks.BatchSize
+	if batch <= 0 {
+		batch = defaultWebhookBatch
+	}
+
+	now := time.Now()
+	dispatched, err := s.repo.DispatchWebhookEvents(ctx, now, batch)
+	if err != nil {
+		l.WithError(err).Error("error dispatch webhook events")
+		return 0, err
+	}
+
+	// a claimed delivery stays hidden while the whole batch may be sent
+	lease := time.Duration(batch)*s.webhookClient.Timeout + time.Minute
+	deliveries, err := s.repo.ClaimWebhookDeliveries(ctx, now, lease, batch)
+	if err != nil {
+		l.WithError(err).Error("error claim webhook deliveries")
+		return 0, err
+	}
+
+	finished := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		s.deliverWebhook(ctx, delivery)
+
+		err = s.repo.UpdateWebhookDelivery(ctx, delivery)
+		if err != nil {
+			l.WithError(err).WithField("delivery_id", delivery.ID).Error("error update webhook delivery")
+			continue
+		}
+
+		if delivery.Status != domain.DeliveryPending {
+			finished++
+		}
+	}
+
+	// the outbox or the due deliveries may hold more than one batch
+	if dispatched == batch || len(deliveries) == batch {
+		s.notifyWebhooks()
+	}
+
+	return finished, nil
+}
+
+// deliverWebhook posts the payload signed with the secret of the webhook and
+// marks the delivery delivered on a 2xx response or schedules a retry.
+func (s *Service) deliverWebhook(ctx context.Context, delivery *domain.WebhookDelivery) {
+	delivery.Attempts++
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		s.retryWebhookDelivery(delivery, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "documents-webhook")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", signWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		delivery.ResponseCode = 0
+		s.retryWebhookDelivery(delivery, err)
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseLimit))
+
+	delivery.ResponseCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		s.retryWebhookDelivery(delivery, fmt.Errorf("unexpected status %s", resp.Status))
+		return
+	}
+
+	deliveredAt := time.Now()
+	delivery.Status = domain.DeliveryDelivered
+	delivery.Error = ""
+	delivery.DeliveredAt = &deliveredAt
+}
+
+func (s *Service) retryWebhookDelivery(delivery *domain.WebhookDelivery, err error) {
+	maxAttempts := s.webhooks.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+
+	delivery.Error = err.Error()
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = domain.DeliveryFailed
+		return
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(retryBackoff(s.webhooks.RetryBackoff, delivery.Attempts))
+}
+
+// newWebhookClient does not follow redirects, a webhook has to answer itself.
+// It connects to public addresses only: the address is checked after the host
+// is resolved, so a name pointing to the internal network is refused too.
+func newWebhookClient(timeout time.Duration, allowed []netip.Prefix) *http.Client {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkWebhookAddress(address, allowed)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be the only address checked
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkWebhookAddress refuses the loopback, link-local, private, multicast
+// and unspecified addresses unless they are in the allowed networks.
+func checkWebhookAddress(address string, allowed []netip.Prefix) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := addrPort.Addr().Unmap()
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: %s", errWebhookAddress, ip)
+	}
+
+	return nil
+}
+
+// parseNetworks parses the networks given as CIDR or as a single IP.
+func parseNetworks(values []string) ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		network, err := netip.ParsePrefix(value)
+		if err != nil {
+			ip, ipErr := netip.ParseAddr(value)
+			if ipErr != nil {
+				return nil, err
+			}
+			network = netip.PrefixFrom(ip, ip.BitLen())
+		}
+		networks = append(networks, network.Masked())
+	}
+
+	return networks, nil
+}
+
+func toWebhookPayload(event string, document *domain.Document, login string) *dto.WebhookPayload {
+	payload := &dto.WebhookPayload{
+		Event: event,
+		Document: dto.WebhookDocument{
+			ID:       document.ID,
+			OwnerID:  document.UserID,
+			Name:     document.Name,
+			Mime:     document.Mime,
+			Size:     document.Size,
+			Version:  document.Version,
+			Checksum: document.Checksum,
+			Public:   document.Public,
+		},
+		Login:      login,
+		OccurredAt: time.Now().UTC(),
+	}
+	if document.FolderID != uuid.Nil {
+		payload.Document.FolderID = document.FolderID.String()
+	}
+
+	return payload
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *ServiceSuite) Test_CreateWebhook() {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		webhook *domain.Webhook
+		err     error
+		calls   func()
+	}{
+		{
+			name:    "success",
+			webhook: &domain.Webhook{URL: "https://hooks.example.com/documents", Events: []string{domain.WebhookDocumentShared}},
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+				s.repo.EXPECT().CreateWebhook(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+						s.Equal(userID, webhook.UserID)
+						s.Len(webhook.Secret, 64)
+						return webhook, nil
+					},
+				)
+			},
+		},
+		{
+			name:    "relative url",
+			webhook: &domain.Webhook{URL: "/documents"},
+			err:     ErrInvalidWebhookURL,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+			},
+		},
+		{
+			name:    "unknown event",
+			webhook: &domain.Webhook{URL: "http://localhost:9000", Events: []string{"document.viewed"}},
+			err:     ErrInvalidWebhookEvent,
+			calls: func() {
+				s.cache.EXPECT().Get(prepareGetUserIDKey("token")).Return(userID, true)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.calls()
+			_, err := s.service.CreateWebhook(ctx, "token", tt.webhook)
+			s.Equal(tt.err, err)
+		})
+	}
+}
+
+func (s *ServiceSuite) Test_DeliverWebhooks() {
+	ctx := context.Background()
+	payload, err := json.Marshal(toWebhookPayload(domain.WebhookDocumentShared, &domain.Document{ID: uuid.New()}, "reader42"))
+	s.Require().NoError(err)
+
+	// the receiver answers with the status of the test and checks the signature
+	status := http.StatusOK
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.Equal(string(payload), string(body))
+		s.Equal(domain.WebhookDocumentShared, r.Header.Get("X-Webhook-Event"))
+		s.Equal("7", r.Header.Get("X-Webhook-Delivery"))
+		s.Equal(signWebhook("secret", r.Header.Get("X-Webhook-Timestamp"), body), r.Header.Get("X-Webhook-Signature"))
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+	// the receiver listens on the loopback
+	s.service.webhookClient = newWebhookClient(time.Second, []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+
+	delivery := func(attempts int) []domain.WebhookDelivery {
+		return []domain.WebhookDelivery{{
+			ID:       7,
+			Event:    domain.WebhookDocumentShared,
+			URL:      receiver.URL,
+			Secret:   "secret",
+			Payload:  payload,
+			Status:   domain.DeliveryPending,
+			Attempts: attempts,
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+		want     int
+		check    func(delivery *domain.WebhookDelivery)
+	}{
+		{
+			name:   "delivered",
+			status: http.StatusNoContent,
+			want:   1,
+			check: func(delivery *domain.WebhookDelivery) {
+				s.Equal(domain.DeliveryDelivered, delivery.Status)
+				s.Equal(1, delivery.Attempts)
+				s.Equal(http.StatusNoContent, delivery.ResponseCode)
+				s.NotNil(delivery.DeliveredAt)
+			},
+		},
+		{
+			name:     "retried",
+			status:   http.StatusInternalServerError,
+			attempts: 2,
+			want:     0,
+			check: func(delivery *domain.WebhookDelivery) {
+				s.Equal(domain.DeliveryPending, delivery.Status)
+				s.Equal(3, delivery.Attempts)
+				s.Equal("unexpected status 500 Internal Server Error", delivery.Error)
+				s.WithinDuration(time.Now().Add(4*time.Minute), delivery.NextAttemptAt, time.Minute)
+				s.Nil(delivery.DeliveredAt)
+			},
+		},
+		{
+			name:     "attempts exhausted",
+			status:   http.StatusBadGateway,
+			attempts: defaultWebhookMaxAttempts - 1,
+			want:     1,
+			check: func(delivery *domain.WebhookDelivery) {
+				s.Equal(domain.DeliveryFailed, delivery.Status)
+				s.Equal(defaultWebhookMaxAttempts, delivery.Attempts)
+				s.Equal(http.StatusBadGateway, delivery.ResponseCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			status = tt.status
+			s.repo.EXPECT().DispatchWebhookEvents(ctx, gomock.Any(), defaultWebhookBatch).Return(1, nil)
+			s.repo.EXPECT().ClaimWebhookDeliveries(ctx, gomock.Any(), gomock.Any(), defaultWebhookBatch).Return(delivery(tt.attempts), nil)
+			s.repo.EXPECT().UpdateWebhookDelivery(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, delivery *domain.WebhookDelivery) error {
+					tt.check(delivery)
+					return nil
+				},
+			)
+
+			got, err := s.service.DeliverWebhooks(ctx)
+			s.NoError(err)
+			s.Equal(tt.want, got)
+		})
+	}
+
+	s.T().Run("internal address", func(t *testing.T) {
+		s.service.webhookClient = newWebhookClient(time.Second, nil)
+		s.repo.EXPECT().DispatchWebhookEvents(ctx, gomock.Any(), defaultWebhookBatch).Return(1, nil)
+		s.repo.EXPECT().ClaimWebhookDeliveries(ctx, gomock.Any(), gomock.Any(), defaultWebhookBatch).Return(delivery(0), nil)
+		s.repo.EXPECT().UpdateWebhookDelivery(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, delivery *domain.WebhookDelivery) error {
+				s.Equal(domain.DeliveryPending, delivery.Status)
+				s.Contains(delivery.Error, errWebhookAddress.Error())
+				s.Zero(delivery.ResponseCode)
+				return nil
+			},
+		)
+
+		got, err := s.service.DeliverWebhooks(ctx)
+		s.NoError(err)
+		s.Equal(0, got)
+	})
+}
+
+func Test_checkWebhookAddress(t *testing.T) {
+	allowed, err := parseNetworks([]string{"10.1.0.0/16", "::1"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{address: "93.184.216.34:443", allowed: true},
+		{address: "[2606:2800:220:1::]:443", allowed: true},
+		{address: "127.0.0.1:80"},
+		{address: "169.254.169.254:80"},
+		{address: "192.168.1.10:8080"},
+		{address: "172.16.0.1:80"},
+		{address: "0.0.0.0:80"},
+		{address: "[::ffff:127.0.0.1]:80"},
+		{address: "[fe80::1]:80"},
+		{address: "[fd00::1]:80"},
+		{address: "10.1.2.3:80", allowed: true},
+		{address: "10.2.0.1:80"},
+		{address: "[::1]:80", allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkWebhookAddress(tt.address, allowed)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errWebhookAddress)
+			}
+		})
+	}
+
+	_, err = parseNetworks([]string{"localhost"})
+	assert.Error(t, err)
+
+	// the service does not start with a network it can not parse
+	_, err = New(nil, nil, logger.New(""), &config.Config{Webhook: config.Webhook{AllowedNetworks: []string{"10.1.0.0/33"}}})
+	assert.ErrorContains(t, err, "webhook.allowed_networks")
+}
+
+func Test_toWebhookPayload(t *testing.T) {
+	id, ownerID := uuid.New(), uuid.New()
+	payload := toWebhookPayload(domain.WebhookDocumentUnshared, &domain.Document{
+		ID:      id,
+		UserID:  ownerID,
+		Name:    "report.pdf",
+		Content: "c2VjcmV0",
+		Version: 2,
+	}, "reader42")
+
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, domain.WebhookDocumentUnshared, got["event"])
+	assert.Equal(t, "reader42", got["login"])
+	assert.Equal(t, map[string]any{
+		"id":       id.String(),
+		"owner_id": ownerID.String(),
+		"name":     "report.pdf",
+		"mime":     "",
+		"size":     float64(0),
+		"version":  float64(2),
+		"checksum": "",
+		"public":   false,
+	}, got["document"])
+}
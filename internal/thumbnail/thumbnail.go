@@ -0,0 +1,167 @@
+// Package thumbnail renders scaled down previews of JPEG, PNG and GIF images.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	// register the GIF decoder for image.Decode
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"strings"
+)
+
+const (
+	SizeSmall  = "small"
+	SizeMedium = "medium"
+	SizeLarge  = "large"
+
+	// DefaultSize is served when the size is not requested.
+	DefaultSize = SizeMedium
+
+	// MaxPixels bounds the decoded image so a small file can not expand into gigabytes of memory.
+	MaxPixels = 25_000_000
+
+	jpegQuality = 80
+)
+
+var (
+	ErrUnsupported = errors.New("image format is not supported")
+	ErrTooLarge    = errors.New("image is too large")
+)
+
+// Sizes maps a size name to the longest edge of the thumbnail in pixels.
+var Sizes = map[string]int{
+	SizeSmall:  128,
+	SizeMedium: 256,
+	SizeLarge:  512,
+}
+
+// Thumbnail is one rendition of an image.
+type Thumbnail struct {
+	Size   string
+	Mime   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Supported reports whether thumbnails can be rendered for the MIME type.
+func Supported(mimeType string) bool {
+	switch normalizeMime(mimeType) {
+	case "image/jpeg", "image/jpg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Generate renders the image in every size of Sizes. Images smaller than a size
+// are not enlarged. JPEG images stay JPEG, PNG and GIF become PNG to keep transparency.
+func Generate(mimeType string, content []byte) ([]Thumbnail, error) {
+	if !Supported(mimeType) {
+		return nil, ErrUnsupported
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("error decode image config: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("error decode image: %w", err)
+	}
+
+	rgba := image.NewNRGBA(image.Rect(0, 0, config.Width, config.Height))
+	draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	thumbnails := make([]Thumbnail, 0, len(Sizes))
+	for _, size := range []string{SizeSmall, SizeMedium, SizeLarge} {
+		img := scale(rgba, Sizes[size])
+		thumbnail := Thumbnail{
+			Size:   size,
+			Width:  img.Bounds().Dx(),
+			Height: img.Bounds().Dy(),
+		}
+
+		var buf bytes.Buffer
+		if format == "jpeg" {
+			thumbnail.Mime = "image/jpeg"
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			thumbnail.Mime = "image/png"
+			err = png.Encode(&buf, img)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error encode %s thumbnail: %w", size, err)
+		}
+
+		thumbnail.Data = buf.Bytes()
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	return thumbnails, nil
+}
+
+// scale fits the image into a square of edge pixels averaging the source
+// pixels covered by every destination pixel.
+func scale(rgba *image.NRGBA, edge int) *image.NRGBA {
+	width, height := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	if width <= edge && height <= edge {
+		return rgba
+	}
+
+	dstWidth, dstHeight := edge, edge
+	if width > height {
+		dstHeight = max(1, height*edge/width)
+	} else {
+		dstWidth = max(1, width*edge/height)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, max((y+1)*height/dstHeight, y*height/dstHeight+1)
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, max((x+1)*width/dstWidth, x*width/dstWidth+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					// weight colors by alpha so transparent pixels do not darken the edges
+					r += int(p[0]) * int(p[3])
+					g += int(p[1]) * int(p[3])
+					b += int(p[2]) * int(p[3])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+func normalizeMime(value string) string {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+	return mediaType
+}
@@ -0,0 +1,118 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Generate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mime    string
+		content []byte
+		want    string
+		sizes   [][2]int
+		err     error
+	}{
+		{
+			name:    "jpeg stays jpeg",
+			mime:    "image/jpeg",
+			content: encode(t, "jpeg", 1024, 512),
+			want:    "image/jpeg",
+			sizes:   [][2]int{{128, 64}, {256, 128}, {512, 256}},
+		},
+		{
+			name:    "small png is not enlarged",
+			mime:    "image/png",
+			content: encode(t, "png", 100, 300),
+			want:    "image/png",
+			sizes:   [][2]int{{42, 128}, {85, 256}, {100, 300}},
+		},
+		{
+			name:    "gif becomes png",
+			mime:    "image/gif; charset=binary",
+			content: encode(t, "gif", 300, 300),
+			want:    "image/png",
+			sizes:   [][2]int{{128, 128}, {256, 256}, {300, 300}},
+		},
+		{
+			name:    "unsupported mime",
+			mime:    "application/pdf",
+			content: []byte("%PDF-1.4"),
+			err:     ErrUnsupported,
+		},
+		{
+			name:    "too many pixels",
+			mime:    "image/png",
+			content: encode(t, "png", 10000, 2501),
+			err:     ErrTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.mime, tt.content)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got, len(tt.sizes))
+
+			for i, thumbnail := range got {
+				assert.Equal(t, tt.want, thumbnail.Mime)
+				assert.Equal(t, tt.sizes[i], [2]int{thumbnail.Width, thumbnail.Height})
+
+				config, _, err := image.DecodeConfig(bytes.NewReader(thumbnail.Data))
+				assert.NoError(t, err)
+				assert.Equal(t, tt.sizes[i], [2]int{config.Width, config.Height})
+			}
+		})
+	}
+}
+
+func Test_Generate_brokenImage(t *testing.T) {
+	_, err := Generate("image/png", []byte("not an image"))
+	assert.Error(t, err)
+}
+
+func Test_scale_keepsColor(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for i := 0; i < len(src.Pix); i += 4 {
+		copy(src.Pix[i:], []byte{200, 100, 50, 255})
+	}
+	// a transparent column must not darken the average
+	for y := 0; y < 20; y++ {
+		src.SetNRGBA(0, y, color.NRGBA{})
+	}
+
+	dst := scale(src, 4)
+	assert.Equal(t, image.Rect(0, 0, 4, 2), dst.Bounds())
+	assert.Equal(t, color.NRGBA{R: 200, G: 100, B: 50, A: 229}, dst.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{R: 200, G: 100, B: 50, A: 255}, dst.NRGBAAt(3, 1))
+}
+
+func encode(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	assert.NoError(t, err)
+
+	return buf.Bytes()
+}
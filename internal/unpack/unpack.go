@@ -0,0 +1,216 @@
+// Package unpack reads the regular files of ZIP and tar archives, optionally
+// gzip compressed, with limits against archive bombs and unsafe paths.
+package unpack
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrUnsupported    = errors.New("archive format is not supported, use ZIP or tar.gz")
+	ErrTooManyEntries = errors.New("archive has too many entries")
+	ErrTooLarge       = errors.New("archive is too large when unpacked")
+	ErrEntryTooLarge  = errors.New("entry is too large")
+	ErrUnsafePath     = errors.New("entry path is unsafe")
+	ErrNotRegular     = errors.New("entry is not a regular file")
+)
+
+// Limits bound what an archive may unpack to. The sizes are checked against
+// the bytes actually read, the sizes declared in the headers are not trusted.
+type Limits struct {
+	MaxEntries   int
+	MaxEntrySize int64
+	MaxTotalSize int64
+}
+
+// Entry is a file of an archive. An entry that can not be unpacked has Err
+// set and no content, the other entries are still read.
+type Entry struct {
+	Path    string
+	Content []byte
+	Err     error
+}
+
+// Walk calls fn for every file of the archive in the order they are stored.
+// Directories and system metadata are skipped. Walk stops on the first error
+// returned by fn or when a limit of the whole archive is exceeded.
+func Walk(data []byte, limits Limits, fn func(entry Entry) error) error {
+	w := &walker{limits: limits, fn: fn}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return w.zip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("error open gzip: %w", err)
+		}
+		defer gz.Close()
+		return w.tar(gz)
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		return w.tar(bytes.NewReader(data))
+	default:
+		return ErrUnsupported
+	}
+}
+
+type walker struct {
+	limits  Limits
+	fn      func(entry Entry) error
+	entries int
+	total   int64
+}
+
+func (w *walker) zip(data []byte) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("error open zip: %w", err)
+	}
+
+	if w.limits.MaxEntries > 0 && len(archive.File) > w.limits.MaxEntries {
+		return ErrTooManyEntries
+	}
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || skipped(file.Name) {
+			continue
+		}
+
+		entry := Entry{Path: file.Name}
+		switch {
+		case !file.Mode().IsRegular():
+			entry.Err = ErrNotRegular
+		default:
+			entry.Path, entry.Err = cleanPath(file.Name)
+		}
+		if entry.Err == nil {
+			entry.Content, entry.Err = w.readZip(file)
+		}
+
+		if err := w.emit(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) readZip(file *zip.File) ([]byte, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return w.read(r)
+}
+
+func (w *walker) tar(r io.Reader) error {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error read tar: %w", err)
+		}
+
+		w.entries++
+		if w.limits.MaxEntries > 0 && w.entries > w.limits.MaxEntries {
+			return ErrTooManyEntries
+		}
+
+		// global pax headers carry no file
+		if header.Typeflag == tar.TypeDir || header.Typeflag == tar.TypeXGlobalHeader || skipped(header.Name) {
+			continue
+		}
+
+		entry := Entry{Path: header.Name}
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			entry.Path, entry.Err = cleanPath(header.Name)
+		default:
+			entry.Err = ErrNotRegular
+		}
+		if entry.Err == nil {
+			entry.Content, entry.Err = w.read(archive)
+		}
+
+		if err := w.emit(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// read reads an entry up to the limits. An entry over its limit is reported
+// on its own, an archive over the total limit stops the walk.
+func (w *walker) read(r io.Reader) ([]byte, error) {
+	limit := w.limits.MaxEntrySize
+	if w.limits.MaxTotalSize > 0 {
+		remaining := w.limits.MaxTotalSize - w.total
+		// nothing is left for the entry, even an empty one is not read
+		if remaining <= 0 {
+			return nil, ErrTooLarge
+		}
+		if limit <= 0 || remaining < limit {
+			limit = remaining
+		}
+	}
+	if limit > 0 {
+		// one byte over the limit is enough to tell the entry is too large
+		r = io.LimitReader(r, limit+1)
+	}
+
+	content, err := io.ReadAll(r)
+	w.total += int64(len(content))
+	if err != nil {
+		return nil, err
+	}
+
+	if w.limits.MaxTotalSize > 0 && w.total > w.limits.MaxTotalSize {
+		return nil, ErrTooLarge
+	}
+	if w.limits.MaxEntrySize > 0 && int64(len(content)) > w.limits.MaxEntrySize {
+		return nil, ErrEntryTooLarge
+	}
+
+	return content, nil
+}
+
+func (w *walker) emit(entry Entry) error {
+	if errors.Is(entry.Err, ErrTooLarge) {
+		return ErrTooLarge
+	}
+	return w.fn(entry)
+}
+
+// cleanPath returns the slash separated relative path of an entry or
+// ErrUnsafePath for absolute paths and paths leaving the archive root.
+func cleanPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, ":") || strings.ContainsRune(name, 0) {
+		return name, ErrUnsafePath
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return name, ErrUnsafePath
+		}
+	}
+
+	return path.Clean(name), nil
+}
+
+// skipped reports system metadata added by archivers.
+func skipped(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || path.Base(name) == ".DS_Store"
+}
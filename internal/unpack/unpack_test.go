@@ -0,0 +1,225 @@
+package unpack
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type file struct {
+	name    string
+	content string
+	link    bool
+}
+
+func Test_Walk(t *testing.T) {
+	files := []file{
+		{name: "docs/"},
+		{name: "docs/report.txt", content: "report"},
+		{name: "__MACOSX/docs/._report.txt", content: "meta"},
+		{name: "../../etc/passwd", content: "root"},
+		{name: "/abs.txt", content: "abs"},
+		{name: "./docs//big.bin", content: strings.Repeat("a", 11)},
+		{name: "link", content: "docs/report.txt", link: true},
+	}
+	want := []Entry{
+		{Path: "docs/report.txt", Content: []byte("report")},
+		{Path: "../../etc/passwd", Err: ErrUnsafePath},
+		{Path: "/abs.txt", Err: ErrUnsafePath},
+		{Path: "docs/big.bin", Err: ErrEntryTooLarge},
+		{Path: "link", Err: ErrNotRegular},
+	}
+
+	formats := map[string][]byte{
+		"zip":    zipArchive(t, files),
+		"tar":    tarArchive(t, files),
+		"tar.gz": gzipped(t, tarArchive(t, files)),
+	}
+	for name, data := range formats {
+		t.Run(name, func(t *testing.T) {
+			var got []Entry
+			err := Walk(data, Limits{MaxEntries: 10, MaxEntrySize: 10, MaxTotalSize: 100}, func(entry Entry) error {
+				got = append(got, entry)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func Test_Walk_limits(t *testing.T) {
+	files := []file{
+		{name: "a.txt", content: strings.Repeat("a", 60)},
+		{name: "b.txt", content: strings.Repeat("b", 60)},
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		limits Limits
+		err    error
+	}{
+		{
+			name:   "zip entries",
+			data:   zipArchive(t, files),
+			limits: Limits{MaxEntries: 1},
+			err:    ErrTooManyEntries,
+		},
+		{
+			name:   "tar entries",
+			data:   gzipped(t, tarArchive(t, files)),
+			limits: Limits{MaxEntries: 1},
+			err:    ErrTooManyEntries,
+		},
+		{
+			name:   "zip total size",
+			data:   zipArchive(t, files),
+			limits: Limits{MaxTotalSize: 100},
+			err:    ErrTooLarge,
+		},
+		{
+			name:   "tar total size",
+			data:   gzipped(t, tarArchive(t, files)),
+			limits: Limits{MaxEntrySize: 80, MaxTotalSize: 100},
+			err:    ErrTooLarge,
+		},
+		{
+			name:   "within limits",
+			data:   zipArchive(t, files),
+			limits: Limits{MaxEntries: 2, MaxEntrySize: 60, MaxTotalSize: 120},
+		},
+		{
+			name: "not an archive",
+			data: []byte("%PDF-1.4"),
+			err:  ErrUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Walk(tt.data, tt.limits, func(entry Entry) error {
+				assert.NoError(t, entry.Err)
+				return nil
+			})
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func Test_Walk_bomb(t *testing.T) {
+	// a megabyte of zeros compresses to about a kilobyte
+	data := zipArchive(t, []file{{name: "zeros", content: string(make([]byte, 1<<20))}})
+
+	err := Walk(data, Limits{MaxEntrySize: 1 << 20, MaxTotalSize: 1 << 16}, func(entry Entry) error {
+		t.Fatalf("entry %s is returned", entry.Path)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func Test_Walk_bomb_after_total(t *testing.T) {
+	// the first entries use up the whole total, the bomb must not be read
+	// without a limit
+	files := []file{
+		{name: "a.txt", content: strings.Repeat("a", 60)},
+		{name: "b.txt", content: strings.Repeat("b", 40)},
+		{name: "zeros", content: string(make([]byte, 1<<20))},
+	}
+
+	for name, data := range map[string][]byte{
+		"zip": zipArchive(t, files),
+		"tar": gzipped(t, tarArchive(t, files)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var paths []string
+			err := Walk(data, Limits{MaxTotalSize: 100}, func(entry Entry) error {
+				assert.NoError(t, entry.Err)
+				paths = append(paths, entry.Path)
+				return nil
+			})
+			assert.ErrorIs(t, err, ErrTooLarge)
+			assert.Equal(t, []string{"a.txt", "b.txt"}, paths)
+		})
+	}
+
+	// the entry after the total is not read at all
+	zeros := &countingReader{r: bytes.NewReader(make([]byte, 1<<20))}
+	w := &walker{limits: Limits{MaxTotalSize: 100}, total: 100}
+	_, err := w.read(zeros)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.Zero(t, zeros.n)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func zipArchive(t *testing.T, files []file) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, f := range files {
+		header := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		if f.link {
+			header.SetMode(0o777 | fs.ModeSymlink)
+		}
+		w, err := archive.CreateHeader(header)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(f.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+func tarArchive(t *testing.T, files []file) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	for _, f := range files {
+		header := &tar.Header{Name: f.name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(f.content))}
+		switch {
+		case f.link:
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, f.content, 0
+		case strings.HasSuffix(f.name, "/"):
+			header.Typeflag = tar.TypeDir
+		}
+		assert.NoError(t, archive.WriteHeader(header))
+		if header.Size > 0 {
+			_, err := archive.Write([]byte(f.content))
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
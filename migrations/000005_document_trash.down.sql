@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS document_deleted_at_idx;
+ALTER TABLE document DROP COLUMN IF EXISTS deleted_at;
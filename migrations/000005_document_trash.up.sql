@@ -0,0 +1,2 @@
+ALTER TABLE document ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+CREATE INDEX IF NOT EXISTS document_deleted_at_idx ON document (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS retention_policy(
+    id uuid DEFAULT uuid_generate_v4() PRIMARY KEY,
+    kind text not null,
+    mime text,
+    document_id uuid,
+    days integer not null,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS retention_policy_document_id_idx ON retention_policy (document_id);
+CREATE INDEX IF NOT EXISTS retention_policy_mime_idx ON retention_policy (mime);
+
+ALTER TABLE document ADD COLUMN IF NOT EXISTS legal_hold boolean not null DEFAULT false;
@@ -0,0 +1,3 @@
+ALTER TABLE document DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE document DROP COLUMN IF EXISTS revision;
+ALTER TABLE document DROP COLUMN IF EXISTS description;
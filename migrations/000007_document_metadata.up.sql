@@ -0,0 +1,3 @@
+ALTER TABLE document ADD COLUMN IF NOT EXISTS description text not null DEFAULT '';
+ALTER TABLE document ADD COLUMN IF NOT EXISTS revision integer not null DEFAULT 1;
+ALTER TABLE document ADD COLUMN IF NOT EXISTS updated_at timestamp not null DEFAULT CURRENT_TIMESTAMP;
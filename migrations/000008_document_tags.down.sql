@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS retention_policy_tag_idx;
+ALTER TABLE retention_policy DROP COLUMN IF EXISTS tag;
+
+DROP INDEX IF EXISTS document_metadata_idx;
+DROP INDEX IF EXISTS document_tags_idx;
+ALTER TABLE document DROP COLUMN IF EXISTS metadata;
+ALTER TABLE document DROP COLUMN IF EXISTS tags;
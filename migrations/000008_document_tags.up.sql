@@ -0,0 +1,7 @@
+ALTER TABLE document ADD COLUMN IF NOT EXISTS tags text[] not null DEFAULT '{}';
+ALTER TABLE document ADD COLUMN IF NOT EXISTS metadata jsonb not null DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS document_tags_idx ON document USING GIN (tags);
+CREATE INDEX IF NOT EXISTS document_metadata_idx ON document USING GIN (metadata jsonb_path_ops);
+
+ALTER TABLE retention_policy ADD COLUMN IF NOT EXISTS tag text;
+CREATE INDEX IF NOT EXISTS retention_policy_tag_idx ON retention_policy (tag);
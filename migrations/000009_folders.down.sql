@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS grants_document_login_idx;
+DROP INDEX IF EXISTS document_folder_id_idx;
+ALTER TABLE document DROP COLUMN IF EXISTS folder_id;
+
+DROP TABLE IF EXISTS folder_grants;
+DROP TABLE IF EXISTS folder;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS folder(
+    id uuid DEFAULT uuid_generate_v4() PRIMARY KEY,
+    user_id uuid not null,
+    parent_id uuid REFERENCES folder(id),
+    name text not null,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS folder_user_parent_name_idx
+    ON folder (user_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), name);
+CREATE INDEX IF NOT EXISTS folder_parent_id_idx ON folder (parent_id);
+
+CREATE TABLE IF NOT EXISTS folder_grants(
+    id uuid DEFAULT uuid_generate_v4() PRIMARY KEY,
+    user_id uuid not null,
+    folder_id uuid not null REFERENCES folder(id) ON DELETE CASCADE,
+    grant_user_login text not null,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS folder_grants_folder_login_idx ON folder_grants (folder_id, grant_user_login);
+
+ALTER TABLE document ADD COLUMN IF NOT EXISTS folder_id uuid REFERENCES folder(id);
+CREATE INDEX IF NOT EXISTS document_folder_id_idx ON document (folder_id);
+CREATE INDEX IF NOT EXISTS grants_document_login_idx ON grants (document_id, grant_user_login);
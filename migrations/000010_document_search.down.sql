@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS document_search_vector_idx;
+ALTER TABLE document DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE document DROP COLUMN IF EXISTS content_text;
@@ -0,0 +1,7 @@
+ALTER TABLE document ADD COLUMN IF NOT EXISTS content_text text not null DEFAULT '';
+ALTER TABLE document ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('simple', content_text), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS document_search_vector_idx ON document USING GIN (search_vector);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS document_text;
+ALTER TABLE document DROP COLUMN IF EXISTS version;
@@ -0,0 +1,18 @@
+ALTER TABLE document ADD COLUMN IF NOT EXISTS version int not null DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS document_text(
+    document_id uuid not null REFERENCES document(id) ON DELETE CASCADE,
+    version int not null,
+    status text not null,
+    text text not null DEFAULT '',
+    attempts int not null DEFAULT 0,
+    last_error text not null DEFAULT '',
+    next_attempt_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (document_id, version)
+);
+CREATE INDEX IF NOT EXISTS document_text_pending_idx ON document_text (next_attempt_at) WHERE status = 'pending';
+
+INSERT INTO document_text (document_id, version, status)
+SELECT id, version, 'pending' FROM document WHERE deleted_at IS NULL
+ON CONFLICT DO NOTHING;
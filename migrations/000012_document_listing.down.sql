@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS document_mime_idx;
+DROP INDEX IF EXISTS document_size_id_idx;
+DROP INDEX IF EXISTS document_created_at_id_idx;
+DROP INDEX IF EXISTS document_name_id_idx;
+ALTER TABLE document DROP COLUMN IF EXISTS size;
@@ -0,0 +1,7 @@
+ALTER TABLE document ADD COLUMN IF NOT EXISTS size bigint not null DEFAULT 0;
+UPDATE document SET size = length(decode(file, 'base64')) WHERE size = 0;
+
+CREATE INDEX IF NOT EXISTS document_name_id_idx ON document (name, id);
+CREATE INDEX IF NOT EXISTS document_created_at_id_idx ON document (created_at, id);
+CREATE INDEX IF NOT EXISTS document_size_id_idx ON document (size, id);
+CREATE INDEX IF NOT EXISTS document_mime_idx ON document (mime text_pattern_ops);
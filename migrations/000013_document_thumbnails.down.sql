@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS document_rendition;
+DROP TABLE IF EXISTS document_thumbnail_job;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS document_thumbnail_job(
+    document_id uuid not null REFERENCES document(id) ON DELETE CASCADE,
+    version int not null,
+    status text not null,
+    attempts int not null DEFAULT 0,
+    last_error text not null DEFAULT '',
+    next_attempt_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (document_id, version)
+);
+CREATE INDEX IF NOT EXISTS document_thumbnail_job_pending_idx ON document_thumbnail_job (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS document_rendition(
+    document_id uuid not null REFERENCES document(id) ON DELETE CASCADE,
+    version int not null,
+    size text not null,
+    mime text not null,
+    width int not null,
+    height int not null,
+    data bytea not null,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (document_id, version, size)
+);
+
+INSERT INTO document_thumbnail_job (document_id, version, status)
+SELECT id, version, 'pending' FROM document
+WHERE deleted_at IS NULL AND lower(split_part(mime, ';', 1)) IN ('image/jpeg', 'image/jpg', 'image/png', 'image/gif')
+ON CONFLICT DO NOTHING;
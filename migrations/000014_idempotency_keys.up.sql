@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_key(
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    key text not null,
+    status text not null,
+    document_id uuid,
+    response bytea,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp not null,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_key_expires_at_idx ON idempotency_key (expires_at);
@@ -0,0 +1 @@
+ALTER TABLE document DROP COLUMN IF EXISTS checksum;
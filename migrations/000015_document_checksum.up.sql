@@ -0,0 +1,3 @@
+ALTER TABLE document ADD COLUMN IF NOT EXISTS checksum text not null DEFAULT '';
+
+UPDATE document SET checksum = encode(sha256(decode(file, 'base64')), 'hex') WHERE checksum = '';
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS audit_event(
+    id bigserial PRIMARY KEY,
+    created_at timestamp not null,
+    actor_id uuid,
+    actor_login text not null DEFAULT '',
+    action text not null,
+    document_id uuid,
+    folder_id uuid,
+    target_login text not null DEFAULT '',
+    outcome text not null,
+    reason text not null DEFAULT '',
+    ip text not null DEFAULT '',
+    user_agent text not null DEFAULT '',
+    prev_hash text not null,
+    hash text not null
+);
+CREATE INDEX IF NOT EXISTS audit_event_created_at_idx ON audit_event (created_at);
+CREATE INDEX IF NOT EXISTS audit_event_actor_id_idx ON audit_event (actor_id);
+CREATE INDEX IF NOT EXISTS audit_event_document_id_idx ON audit_event (document_id);
+
+CREATE OR REPLACE FUNCTION audit_event_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_event_append_only ON audit_event;
+CREATE TRIGGER audit_event_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_event
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_event_append_only();
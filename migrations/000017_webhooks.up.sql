@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS webhook(
+    id uuid DEFAULT uuid_generate_v4() PRIMARY KEY,
+    user_id uuid REFERENCES users(id) ON DELETE CASCADE,
+    url text not null,
+    secret text not null,
+    events text[] not null DEFAULT '{}',
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS webhook_user_id_idx ON webhook (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_event(
+    id bigserial PRIMARY KEY,
+    event text not null,
+    document_id uuid not null,
+    user_id uuid not null,
+    target_login text not null DEFAULT '',
+    payload bytea not null,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at timestamp
+);
+CREATE INDEX IF NOT EXISTS webhook_event_undispatched_idx ON webhook_event (id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_delivery(
+    id bigserial PRIMARY KEY,
+    webhook_id uuid not null REFERENCES webhook(id) ON DELETE CASCADE,
+    event_id bigint not null REFERENCES webhook_event(id) ON DELETE CASCADE,
+    status text not null,
+    attempts int not null DEFAULT 0,
+    response_code int not null DEFAULT 0,
+    last_error text not null DEFAULT '',
+    next_attempt_at timestamp not null,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    delivered_at timestamp,
+    UNIQUE (webhook_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending_idx ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS webhook_event_notify ON webhook_event;
+DROP FUNCTION IF EXISTS webhook_event_notify();
+
+DROP INDEX IF EXISTS webhook_event_target_login_idx;
+DROP INDEX IF EXISTS webhook_event_user_id_idx;
@@ -0,0 +1,17 @@
+CREATE INDEX IF NOT EXISTS webhook_event_user_id_idx ON webhook_event (user_id, id);
+CREATE INDEX IF NOT EXISTS webhook_event_target_login_idx ON webhook_event (target_login, id) WHERE target_login <> '';
+
+CREATE OR REPLACE FUNCTION webhook_event_notify() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('document_events', json_build_object(
+        'id', NEW.id,
+        'user_id', NEW.user_id,
+        'target_login', NEW.target_login
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS webhook_event_notify ON webhook_event;
+CREATE TRIGGER webhook_event_notify AFTER INSERT ON webhook_event
+    FOR EACH ROW EXECUTE FUNCTION webhook_event_notify();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS comment;
+
+ALTER TABLE folder_grants DROP COLUMN IF EXISTS permission;
+ALTER TABLE grants DROP COLUMN IF EXISTS permission;
@@ -0,0 +1,17 @@
+ALTER TABLE grants ADD COLUMN IF NOT EXISTS permission text not null DEFAULT 'read';
+ALTER TABLE folder_grants ADD COLUMN IF NOT EXISTS permission text not null DEFAULT 'read';
+
+CREATE TABLE IF NOT EXISTS comment(
+    id uuid DEFAULT uuid_generate_v4() PRIMARY KEY,
+    document_id uuid not null REFERENCES document(id) ON DELETE CASCADE,
+    parent_id uuid REFERENCES comment(id) ON DELETE CASCADE,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    body text not null,
+    page int not null DEFAULT 0,
+    range_start int not null DEFAULT 0,
+    range_end int not null DEFAULT 0,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    deleted_at timestamp
+);
+CREATE INDEX IF NOT EXISTS comment_document_id_idx ON comment (document_id, created_at);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS document_lock(
+    document_id uuid PRIMARY KEY REFERENCES document(id) ON DELETE CASCADE,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp not null
+);
+CREATE INDEX IF NOT EXISTS document_lock_expires_at_idx ON document_lock (expires_at);
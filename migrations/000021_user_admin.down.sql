@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS token_expires_at_idx;
+DROP INDEX IF EXISTS token_token_idx;
+ALTER TABLE token DROP COLUMN IF EXISTS expires_at;
+
+DROP INDEX IF EXISTS users_login_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
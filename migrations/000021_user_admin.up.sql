@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at timestamp;
+CREATE INDEX IF NOT EXISTS users_login_idx ON users (login);
+
+ALTER TABLE token ADD COLUMN IF NOT EXISTS expires_at timestamp;
+CREATE INDEX IF NOT EXISTS token_token_idx ON token (token);
+CREATE INDEX IF NOT EXISTS token_expires_at_idx ON token (expires_at) WHERE expires_at IS NOT NULL;
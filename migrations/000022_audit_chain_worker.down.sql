@@ -0,0 +1,14 @@
+-- the hashes are required again, so the events written since must be chained
+-- by the service before reverting
+DROP TRIGGER IF EXISTS audit_event_chain_only ON audit_event;
+DROP FUNCTION IF EXISTS audit_event_chain_only();
+
+ALTER TABLE audit_event ALTER COLUMN hash SET NOT NULL;
+ALTER TABLE audit_event ALTER COLUMN prev_hash SET NOT NULL;
+DROP INDEX IF EXISTS audit_event_unchained_idx;
+DROP INDEX IF EXISTS audit_event_chain_seq_idx;
+ALTER TABLE audit_event DROP COLUMN IF EXISTS chain_seq;
+
+DROP TRIGGER IF EXISTS audit_event_append_only ON audit_event;
+CREATE TRIGGER audit_event_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_event
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_event_append_only();
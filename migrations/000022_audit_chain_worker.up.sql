@@ -0,0 +1,32 @@
+-- the events are written without hashes, a single worker chains them later
+DROP TRIGGER IF EXISTS audit_event_append_only ON audit_event;
+
+ALTER TABLE audit_event ALTER COLUMN prev_hash DROP NOT NULL;
+ALTER TABLE audit_event ALTER COLUMN hash DROP NOT NULL;
+ALTER TABLE audit_event ADD COLUMN IF NOT EXISTS chain_seq bigint;
+UPDATE audit_event SET chain_seq = id;
+CREATE UNIQUE INDEX IF NOT EXISTS audit_event_chain_seq_idx ON audit_event (chain_seq);
+CREATE INDEX IF NOT EXISTS audit_event_unchained_idx ON audit_event (id) WHERE chain_seq IS NULL;
+
+-- an event is chained once, its other fields never change
+CREATE OR REPLACE FUNCTION audit_event_chain_only() RETURNS trigger AS $$
+DECLARE
+    unchained audit_event%ROWTYPE;
+BEGIN
+    unchained := NEW;
+    unchained.prev_hash := NULL;
+    unchained.hash := NULL;
+    unchained.chain_seq := NULL;
+    IF OLD.chain_seq IS NULL AND NEW.chain_seq IS NOT NULL AND NEW.prev_hash IS NOT NULL AND NEW.hash IS NOT NULL
+        AND unchained IS NOT DISTINCT FROM OLD THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_event is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_event_append_only BEFORE DELETE OR TRUNCATE ON audit_event
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_event_append_only();
+DROP TRIGGER IF EXISTS audit_event_chain_only ON audit_event;
+CREATE TRIGGER audit_event_chain_only BEFORE UPDATE ON audit_event
+    FOR EACH ROW EXECUTE FUNCTION audit_event_chain_only();
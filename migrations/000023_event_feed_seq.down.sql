@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS webhook_event_target_login_idx;
+DROP INDEX IF EXISTS webhook_event_user_id_idx;
+CREATE INDEX IF NOT EXISTS webhook_event_user_id_idx ON webhook_event (user_id, id);
+CREATE INDEX IF NOT EXISTS webhook_event_target_login_idx ON webhook_event (target_login, id) WHERE target_login <> '';
+
+DROP INDEX IF EXISTS webhook_event_unsequenced_idx;
+DROP INDEX IF EXISTS webhook_event_feed_seq_idx;
+ALTER TABLE webhook_event DROP COLUMN IF EXISTS feed_seq;
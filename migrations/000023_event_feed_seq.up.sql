@@ -0,0 +1,11 @@
+-- the ids are taken at insert and become visible at commit, so the change feed
+-- follows feed_seq, given to the events by one instance in the order they commit
+ALTER TABLE webhook_event ADD COLUMN IF NOT EXISTS feed_seq bigint;
+UPDATE webhook_event SET feed_seq = id;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_event_feed_seq_idx ON webhook_event (feed_seq);
+CREATE INDEX IF NOT EXISTS webhook_event_unsequenced_idx ON webhook_event (id) WHERE feed_seq IS NULL;
+
+DROP INDEX IF EXISTS webhook_event_target_login_idx;
+DROP INDEX IF EXISTS webhook_event_user_id_idx;
+CREATE INDEX IF NOT EXISTS webhook_event_user_id_idx ON webhook_event (user_id, feed_seq);
+CREATE INDEX IF NOT EXISTS webhook_event_target_login_idx ON webhook_event (target_login, feed_seq) WHERE target_login <> '';
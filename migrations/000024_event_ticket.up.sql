@@ -0,0 +1,8 @@
+-- a ticket opens one change feed in a browser, which can not send the token
+-- header, without putting the token into the URL
+CREATE TABLE IF NOT EXISTS event_ticket(
+    ticket text PRIMARY KEY,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    expires_at timestamp not null
+);
+CREATE INDEX IF NOT EXISTS event_ticket_expires_at_idx ON event_ticket (expires_at);
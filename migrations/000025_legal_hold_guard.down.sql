@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS users_legal_hold_delete ON users;
+DROP FUNCTION IF EXISTS users_legal_hold_guard();
+DROP INDEX IF EXISTS document_legal_hold_idx;
+DROP TRIGGER IF EXISTS document_legal_hold_trash ON document;
+DROP TRIGGER IF EXISTS document_legal_hold_delete ON document;
+DROP FUNCTION IF EXISTS document_legal_hold_guard();
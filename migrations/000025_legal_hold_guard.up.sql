@@ -0,0 +1,37 @@
+-- a document on legal hold stays even when a query bypasses the checks of
+-- the service: it can not be trashed, purged or lose its owner's account
+CREATE OR REPLACE FUNCTION document_legal_hold_guard() RETURNS trigger AS $$
+BEGIN
+    IF OLD.legal_hold THEN
+        RAISE EXCEPTION 'document % is under legal hold', OLD.id USING ERRCODE = 'restrict_violation';
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS document_legal_hold_delete ON document;
+CREATE TRIGGER document_legal_hold_delete BEFORE DELETE ON document
+    FOR EACH ROW EXECUTE FUNCTION document_legal_hold_guard();
+
+DROP TRIGGER IF EXISTS document_legal_hold_trash ON document;
+CREATE TRIGGER document_legal_hold_trash BEFORE UPDATE OF deleted_at ON document
+    FOR EACH ROW WHEN (OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL)
+    EXECUTE FUNCTION document_legal_hold_guard();
+
+CREATE INDEX IF NOT EXISTS document_legal_hold_idx ON document (user_id) WHERE legal_hold;
+
+CREATE OR REPLACE FUNCTION users_legal_hold_guard() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM document WHERE user_id = OLD.id AND legal_hold) THEN
+        RAISE EXCEPTION 'user % owns documents under legal hold', OLD.login USING ERRCODE = 'restrict_violation';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS users_legal_hold_delete ON users;
+CREATE TRIGGER users_legal_hold_delete BEFORE DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION users_legal_hold_guard();
@@ -23,12 +23,16 @@ type UploadReq struct {
 }
 
 type Meta struct {
-	Name   string   `json:"name"`
-	Token  string   `json:"token"`
-	Mime   string   `json:"mime"`
-	Grant  []string `json:"grant"`
-	File   bool     `json:"file"`
-	Public bool     `json:"public"`
+	Name        string         `json:"name"`
+	Token       string         `json:"token"`
+	Mime        string         `json:"mime"`
+	Description string         `json:"description"`
+	Grant       []string       `json:"grant"`
+	Tags        []string       `json:"tags"`
+	Metadata    map[string]any `json:"metadata"`
+	FolderID    string         `json:"folder_id"`
+	File        bool           `json:"file"`
+	Public      bool           `json:"public"`
 }
 
 func (m *Meta) IsValid() bool {
@@ -39,28 +43,351 @@ func (m *Meta) IsValid() bool {
 	return true
 }
 
+// ArchiveReq selects the documents of an archive by exactly one of the fields.
+type ArchiveReq struct {
+	IDs      []string       `json:"ids"`
+	FolderID string         `json:"folder_id"`
+	Filter   *ArchiveFilter `json:"filter"`
+}
+
+// ArchiveFilter has the meaning of the listing query parameters of the same names.
+type ArchiveFilter struct {
+	Scope        string         `json:"scope"`
+	Login        string         `json:"login"`
+	Tags         []string       `json:"tags"`
+	Metadata     map[string]any `json:"metadata"`
+	NamePrefix   string         `json:"name_prefix"`
+	NameContains string         `json:"name_contains"`
+	Mime         string         `json:"mime"`
+	Public       *bool          `json:"public"`
+	Owner        string         `json:"owner"`
+	CreatedFrom  string         `json:"created_from"`
+	CreatedTo    string         `json:"created_to"`
+}
+
+// ImportMeta is shared by all the documents of an import. Token falls back
+// to the token header.
+type ImportMeta struct {
+	Token       string         `json:"token"`
+	Description string         `json:"description"`
+	Grant       []string       `json:"grant"`
+	Tags        []string       `json:"tags"`
+	Metadata    map[string]any `json:"metadata"`
+	FolderID    string         `json:"folder_id"`
+	Public      bool           `json:"public"`
+}
+
+type ImportResp struct {
+	Data ImportReport `json:"data"`
+}
+
+type ImportReport struct {
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Entries []ImportEntry `json:"entries"`
+}
+
+type ImportEntry struct {
+	Path  string `json:"path"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Mime  string `json:"mime,omitempty"`
+	Size  int64  `json:"size,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
 type UploadResponse struct {
 	Data Data `json:"data"`
 }
 
+// Data describes an uploaded document. File repeats the name for the
+// clients written before the other fields were added.
 type Data struct {
-	File string `json:"file"`
+	File     string   `json:"file"`
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Mime     string   `json:"mime"`
+	Size     int64    `json:"size"`
+	Checksum string   `json:"checksum"`
+	Created  string   `json:"created"`
+	Public   bool     `json:"public"`
+	Grant    []string `json:"grant"`
+	URL      string   `json:"url"`
 }
 
 type GetDocumentsResp struct {
 	DataDocuments DataDocuments `json:"data"`
+	NextCursor    string        `json:"next_cursor,omitempty"`
 }
 
 type Document struct {
-	ID      string   `json:"id"`
-	Name    string   `json:"name"`
-	Mime    string   `json:"mime"`
-	File    bool     `json:"file"`
-	Public  bool     `json:"public"`
-	Created string   `json:"created"`
-	Grant   []string `json:"grant"`
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Mime        string         `json:"mime"`
+	Description string         `json:"description,omitempty"`
+	Size        int64          `json:"size"`
+	Checksum    string         `json:"checksum,omitempty"`
+	File        bool           `json:"file"`
+	Public      bool           `json:"public"`
+	Created     string         `json:"created"`
+	Deleted     string         `json:"deleted,omitempty"`
+	Grant       []string       `json:"grant"`
+	Tags        []string       `json:"tags,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	FolderID    string         `json:"folder_id,omitempty"`
+	Permission  string         `json:"permission,omitempty"`
+	Thumbnail   bool           `json:"thumbnail"`
+}
+
+// DocumentMeta describes a document without its content. Versions counts
+// the uploaded contents, Grant lists the grants for the owner only.
+type DocumentMeta struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Mime       string   `json:"mime"`
+	Size       int64    `json:"size"`
+	Checksum   string   `json:"checksum"`
+	Owner      string   `json:"owner"`
+	Public     bool     `json:"public"`
+	Created    string   `json:"created"`
+	Updated    string   `json:"updated"`
+	Versions   int      `json:"versions"`
+	Revision   int      `json:"revision"`
+	Grant      []string `json:"grant"`
+	Permission string   `json:"permission"`
+	FolderID   string   `json:"folder_id,omitempty"`
+	URL        string   `json:"url"`
+}
+
+type DocumentMetaResp struct {
+	Data DocumentMeta `json:"data"`
+}
+
+type UpdateDocumentReq struct {
+	Name        *string        `json:"name"`
+	Mime        *string        `json:"mime"`
+	Description *string        `json:"description"`
+	Public      *bool          `json:"public"`
+	Tags        []string       `json:"tags"`
+	Metadata    map[string]any `json:"metadata"`
+	FolderID    *string        `json:"folder_id"`
+}
+
+type UpdateDocumentResp struct {
+	Data Document `json:"data"`
 }
 
 type DataDocuments struct {
 	Docs []Document `json:"docs"`
 }
+
+type DocumentTextResp struct {
+	Data DocumentText `json:"data"`
+}
+
+type DocumentText struct {
+	DocumentID string `json:"document_id"`
+	Version    int    `json:"version"`
+	Status     string `json:"status"`
+	Text       string `json:"text"`
+	Attempts   int    `json:"attempts"`
+	Error      string `json:"error,omitempty"`
+	Updated    string `json:"updated"`
+}
+
+type SearchResp struct {
+	Data SearchData `json:"data"`
+}
+
+type SearchData struct {
+	Results []SearchResult `json:"results"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+}
+
+type SearchResult struct {
+	Document
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type RetentionPolicy struct {
+	ID         string `json:"id,omitempty"`
+	Kind       string `json:"kind"`
+	Mime       string `json:"mime,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	DocumentID string `json:"document_id,omitempty"`
+	Days       int    `json:"days"`
+	Created    string `json:"created,omitempty"`
+}
+
+type RetentionPoliciesResp struct {
+	Policies []RetentionPolicy `json:"policies"`
+}
+
+// AuditEvent is an entry of the audit log. Hash chains it to the previous
+// entry, PrevHash is empty for the first one. Both are empty until the entry
+// is chained shortly after it is written.
+type AuditEvent struct {
+	ID          int64  `json:"id"`
+	Created     string `json:"created"`
+	ActorID     string `json:"actor_id,omitempty"`
+	ActorLogin  string `json:"actor_login,omitempty"`
+	Action      string `json:"action"`
+	DocumentID  string `json:"document_id,omitempty"`
+	FolderID    string `json:"folder_id,omitempty"`
+	TargetLogin string `json:"target_login,omitempty"`
+	Outcome     string `json:"outcome"`
+	Reason      string `json:"reason,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	UserAgent   string `json:"user_agent,omitempty"`
+	PrevHash    string `json:"prev_hash"`
+	Hash        string `json:"hash"`
+}
+
+// AuditEventsResp is one page of the audit log, Next is the "before" of the
+// next page and is omitted on the last one.
+type AuditEventsResp struct {
+	Events []AuditEvent `json:"events"`
+	Next   int64        `json:"next,omitempty"`
+}
+
+type AuditVerificationResp struct {
+	Valid    bool  `json:"valid"`
+	Checked  int64 `json:"checked"`
+	BrokenID int64 `json:"broken_id,omitempty"`
+}
+
+type WebhookReq struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// Webhook is a registered endpoint. Secret signs the deliveries and is only
+// returned when the webhook is created.
+type Webhook struct {
+	ID      string   `json:"id"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Secret  string   `json:"secret,omitempty"`
+	Created string   `json:"created"`
+}
+
+type WebhookResp struct {
+	Data Webhook `json:"data"`
+}
+
+type WebhooksResp struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type WebhookDelivery struct {
+	ID           int64  `json:"id"`
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	ResponseCode int    `json:"response_code,omitempty"`
+	Error        string `json:"error,omitempty"`
+	NextAttempt  string `json:"next_attempt,omitempty"`
+	Created      string `json:"created"`
+	Delivered    string `json:"delivered,omitempty"`
+}
+
+type WebhookDeliveriesResp struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+type Folder struct {
+	ID       string `json:"id,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+	Name     string `json:"name"`
+	Created  string `json:"created,omitempty"`
+}
+
+type UpdateFolderReq struct {
+	Name     *string `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+type FolderResp struct {
+	Data Folder `json:"data"`
+}
+
+type FolderContentsResp struct {
+	Data FolderContents `json:"data"`
+}
+
+type FolderContents struct {
+	Folder  *Folder    `json:"folder,omitempty"`
+	Folders []Folder   `json:"folders"`
+	Docs    []Document `json:"docs"`
+}
+
+// GrantReq shares a document or a folder. Permission is read, comment or
+// edit, read by default.
+type GrantReq struct {
+	Login      string `json:"login"`
+	Permission string `json:"permission,omitempty"`
+}
+
+// CommentReq adds a comment, a reply when ParentID is set. Page and the range
+// anchor it to the document; when a comment is edited only Body is used.
+type CommentReq struct {
+	ParentID   string `json:"parent_id,omitempty"`
+	Body       string `json:"body"`
+	Page       int    `json:"page,omitempty"`
+	RangeStart int    `json:"range_start,omitempty"`
+	RangeEnd   int    `json:"range_end,omitempty"`
+}
+
+// Comment is a comment of a document. A deleted comment has no body and stays
+// in the list while it has replies.
+type Comment struct {
+	ID         string `json:"id"`
+	ParentID   string `json:"parent_id,omitempty"`
+	Login      string `json:"login"`
+	Body       string `json:"body"`
+	Page       int    `json:"page,omitempty"`
+	RangeStart int    `json:"range_start,omitempty"`
+	RangeEnd   int    `json:"range_end,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+	Created    string `json:"created"`
+	Updated    string `json:"updated"`
+}
+
+type CommentResp struct {
+	Data Comment `json:"data"`
+}
+
+type CommentsResp struct {
+	Comments []Comment `json:"comments"`
+}
+
+// LockReq checks a document out for TTL seconds, the server default when it
+// is zero.
+type LockReq struct {
+	TTL int `json:"ttl,omitempty"`
+}
+
+// Lock is a check-out of a document by the user with Login.
+type Lock struct {
+	DocumentID string `json:"document_id"`
+	Login      string `json:"login"`
+	Created    string `json:"created"`
+	Expires    string `json:"expires"`
+}
+
+type LockResp struct {
+	Data Lock `json:"data"`
+}
+
+// EventTicket opens the change feed once as ?ticket= until Expires, for
+// clients that can not send the token header.
+type EventTicket struct {
+	Ticket  string `json:"ticket"`
+	Expires string `json:"expires"`
+}
+
+type EventTicketResp struct {
+	Data EventTicket `json:"data"`
+}
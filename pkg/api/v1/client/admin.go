@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+)
+
+// AuditFilter has the meaning of the query parameters of GET
+// /api/admin/audit, the zero values are not sent.
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	Outcome    string
+	DocumentID string
+	From       time.Time
+	To         time.Time
+	Before     int64
+	Limit      int
+}
+
+// SetLegalHold puts the document on hold or lifts the hold.
+func (c *Client) SetLegalHold(ctx context.Context, id string, hold bool) error {
+	method := http.MethodPut
+	if !hold {
+		method = http.MethodDelete
+	}
+	return c.call(ctx, adminRequest(method, endpoint("/api/admin/docs/%s/hold", id)), nil)
+}
+
+// BreakLock releases the lock of the document whoever holds it.
+func (c *Client) BreakLock(ctx context.Context, id string) error {
+	return c.call(ctx, adminRequest(http.MethodDelete, endpoint("/api/admin/docs/%s/lock", id)), nil)
+}
+
+// AddRetentionPolicy returns the id of the new policy.
+func (c *Client) AddRetentionPolicy(ctx context.Context, policy v1.RetentionPolicy) (string, error) {
+	var resp response[struct {
+		ID string `json:"id"`
+	}]
+	err := c.call(ctx, adminRequest(http.MethodPost, "/api/admin/retention").withJSON(policy), &resp)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Response.ID, nil
+}
+
+func (c *Client) GetRetentionPolicies(ctx context.Context) ([]v1.RetentionPolicy, error) {
+	var resp v1.RetentionPoliciesResp
+	err := c.call(ctx, adminRequest(http.MethodGet, "/api/admin/retention"), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Policies, nil
+}
+
+func (c *Client) DeleteRetentionPolicy(ctx context.Context, id string) error {
+	return c.call(ctx, adminRequest(http.MethodDelete, endpoint("/api/admin/retention/%s", id)), nil)
+}
+
+// GetAuditEvents returns a page of the audit log, newest first. Next of the
+// page is the Before of the next one.
+func (c *Client) GetAuditEvents(ctx context.Context, filter AuditFilter) (*v1.AuditEventsResp, error) {
+	req := adminRequest(http.MethodGet, "/api/admin/audit")
+	req.query = filter.query()
+
+	var resp v1.AuditEventsResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// VerifyAudit checks the hash chain of the audit log.
+func (c *Client) VerifyAudit(ctx context.Context) (*v1.AuditVerificationResp, error) {
+	var resp v1.AuditVerificationResp
+	err := c.call(ctx, adminRequest(http.MethodGet, "/api/admin/audit/verify"), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// CreateAdminWebhook registers a webhook that receives the events of every document.
+func (c *Client) CreateAdminWebhook(ctx context.Context, webhook v1.WebhookReq) (*v1.Webhook, error) {
+	return c.createWebhook(ctx, adminRequest(http.MethodPost, "/api/admin/webhooks").withJSON(webhook))
+}
+
+func (c *Client) GetAdminWebhooks(ctx context.Context) ([]v1.Webhook, error) {
+	return c.getWebhooks(ctx, adminRequest(http.MethodGet, "/api/admin/webhooks"))
+}
+
+func (c *Client) DeleteAdminWebhook(ctx context.Context, id string) error {
+	return c.call(ctx, adminRequest(http.MethodDelete, endpoint("/api/admin/webhooks/%s", id)), nil)
+}
+
+func (c *Client) GetAdminWebhookDeliveries(ctx context.Context, id string) ([]v1.WebhookDelivery, error) {
+	return c.getWebhookDeliveries(ctx, adminRequest(http.MethodGet, endpoint("/api/admin/webhooks/%s/deliveries", id)))
+}
+
+func adminRequest(method, path string) *request {
+	req := newRequest(method, path)
+	req.admin = true
+	return req
+}
+
+func (f *AuditFilter) query() url.Values {
+	query := make(url.Values)
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	set("actor", f.Actor)
+	set("action", f.Action)
+	set("outcome", f.Outcome)
+	set("document_id", f.DocumentID)
+	if !f.From.IsZero() {
+		query.Set("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		query.Set("to", f.To.Format(time.RFC3339))
+	}
+	if f.Before > 0 {
+		query.Set("before", strconv.FormatInt(f.Before, 10))
+	}
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+
+	return query
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+)
+
+// Register creates a user, it needs the admin token.
+func (c *Client) Register(ctx context.Context, login, password string) (string, error) {
+	r := newRequest(http.MethodPost, "/api/register").withForm(url.Values{"login": {login}, "pswd": {password}})
+	r.admin = true
+
+	var resp response[v1.RespLogin]
+	err := c.call(ctx, r, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Response.Login, nil
+}
+
+// Authenticate logs the user in and returns a new token, use WithToken to
+// make calls with it.
+func (c *Client) Authenticate(ctx context.Context, login, password string) (string, error) {
+	r := newRequest(http.MethodPost, "/api/auth").withForm(url.Values{"login": {login}, "pswd": {password}})
+
+	var resp response[v1.RespToken]
+	err := c.call(ctx, r, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Response.Token, nil
+}
+
+// LogOut revokes the token.
+func (c *Client) LogOut(ctx context.Context, token string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/auth/%s", token)), nil)
+}
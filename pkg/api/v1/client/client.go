@@ -0,0 +1,243 @@
+// Package client is a Go client of the REST API of the documents service.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries = 2
+	defaultBackoff = 200 * time.Millisecond
+)
+
+// Client calls the API with the token of a user and, for the admin
+// endpoints, the admin token. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	token      string
+	adminToken string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// Token sets the token of the user the calls are made as.
+func Token(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// AdminToken sets the token of the admin endpoints and of Register.
+func AdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// HTTPClient replaces http.DefaultClient.
+func HTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Retries sets how many times a request is repeated after a network error or
+// a 429, 502, 503 or 504 response, with the backoff doubled every time.
+// Requests that change state are only repeated when they are safe to repeat.
+// A repeated DELETE that finds nothing succeeds, the attempt before may have
+// deleted it.
+func Retries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client of the service at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithToken returns a copy of the client that calls the API with the token.
+func (c *Client) WithToken(token string) *Client {
+	copied := *c
+	copied.token = token
+	return &copied
+}
+
+// request describes a call. body is called for every attempt, a request
+// without replay can not be repeated.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   func() (io.Reader, string, error)
+	admin  bool
+	replay bool
+}
+
+func newRequest(method, path string) *request {
+	return &request{
+		method: method,
+		path:   path,
+		header: make(http.Header),
+		replay: method == http.MethodGet || method == http.MethodHead ||
+			method == http.MethodPut || method == http.MethodDelete,
+	}
+}
+
+func (r *request) withJSON(value any) *request {
+	data, err := json.Marshal(value)
+	r.body = func() (io.Reader, string, error) {
+		return bytes.NewReader(data), "application/json", err
+	}
+	return r
+}
+
+func (r *request) withForm(form url.Values) *request {
+	data := form.Encode()
+	r.body = func() (io.Reader, string, error) {
+		return strings.NewReader(data), "application/x-www-form-urlencoded", nil
+	}
+	return r
+}
+
+// do sends the request and returns the response of a successful call, the
+// caller closes its body. An error response is returned as *Error.
+func (c *Client) do(ctx context.Context, r *request) (*http.Response, error) {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, r)
+		if attempt >= c.retries || !r.replay || !retryable(resp, err) || ctx.Err() != nil {
+			if err != nil {
+				return nil, err
+			}
+			if attempt > 0 && r.method == http.MethodDelete && resp.StatusCode == http.StatusNotFound {
+				return resp, nil
+			}
+			if resp.StatusCode >= http.StatusBadRequest {
+				defer resp.Body.Close()
+				return nil, decodeError(resp)
+			}
+			return resp, nil
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, r *request) (*http.Response, error) {
+	u := c.baseURL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	var body io.Reader
+	contentType := ""
+	if r.body != nil {
+		var err error
+		body, contentType, err = r.body()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("token", c.token)
+	}
+	if r.admin {
+		req.Header.Set("admin_token", c.adminToken)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// call sends the request and decodes the JSON response into out.
+func (c *Client) call(ctx context.Context, r *request, out any) error {
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// response is the envelope of the calls that answer {"response": ...}.
+type response[T any] struct {
+	Response T `json:"response"`
+}
+
+// endpoint fills the path format with the escaped segments.
+func endpoint(format string, segments ...string) string {
+	args := make([]any, 0, len(segments))
+	for _, segment := range segments {
+		args = append(args, url.PathEscape(segment))
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,377 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Alina9496/documents/config"
+	"github.com/Alina9496/documents/internal/api"
+	"github.com/Alina9496/documents/internal/domain"
+	"github.com/Alina9496/documents/internal/service"
+	"github.com/Alina9496/documents/internal/service/dto"
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+	"github.com/Alina9496/tool/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testToken  = "CxBiwVruDAD8kp8jgeOY"
+	adminToken = "admin_token"
+)
+
+// fakeService keeps the documents of one user in memory, the calls it does
+// not implement panic.
+type fakeService struct {
+	api.Service
+	documents map[uuid.UUID]*domain.Document
+	filter    *dto.GetDocumentsRequest
+	uploads   atomic.Int32
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{documents: make(map[uuid.UUID]*domain.Document)}
+}
+
+func (f *fakeService) Registration(_ context.Context, user *domain.User) (string, error) {
+	return user.Login, nil
+}
+
+func (f *fakeService) Authentication(_ context.Context, user *domain.User) (string, error) {
+	if user.Password != "Passw_345" {
+		return "", service.ErrUserNotFound
+	}
+	return testToken, nil
+}
+
+func (f *fakeService) Upload(_ context.Context, document *dto.Document) (*domain.Document, error) {
+	f.uploads.Add(1)
+	if document.Token != testToken {
+		return nil, service.ErrUserNotFound
+	}
+	id := uuid.New()
+	f.documents[id] = &domain.Document{
+		ID:        id,
+		Name:      document.Name,
+		Mime:      document.Mime,
+		Size:      int64(len(document.Content)),
+		Content:   base64.StdEncoding.EncodeToString(document.Content),
+		Revision:  1,
+		Version:   1,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	return f.documents[id], nil
+}
+
+func (f *fakeService) GetDocument(_ context.Context, id uuid.UUID, token string) (*domain.Document, error) {
+	document, ok := f.documents[id]
+	if !ok {
+		return nil, service.ErrDocumentNotFound
+	}
+	if token != testToken {
+		return nil, service.ErrNoAccess
+	}
+	return document, nil
+}
+
+func (f *fakeService) UpdateDocument(_ context.Context, req *dto.UpdateDocumentRequest) (*domain.Document, error) {
+	document, ok := f.documents[req.ID]
+	if !ok {
+		return nil, service.ErrDocumentNotFound
+	}
+	if req.Revision != 0 && req.Revision != document.Revision {
+		return nil, service.ErrPreconditionFailed
+	}
+	if req.Name != nil {
+		document.Name = *req.Name
+	}
+	document.Revision++
+	return document, nil
+}
+
+func (f *fakeService) GetDocuments(_ context.Context, filter *dto.GetDocumentsRequest) (*dto.DocumentsPage, error) {
+	f.filter = filter
+	page := &dto.DocumentsPage{}
+	for _, document := range f.documents {
+		page.Documents = append(page.Documents, *document)
+	}
+	return page, nil
+}
+
+func (f *fakeService) UploadVersion(_ context.Context, req *dto.DocumentContentRequest) (*domain.Document, error) {
+	document, ok := f.documents[req.ID]
+	if !ok {
+		return nil, service.ErrDocumentNotFound
+	}
+	if req.Revision != 0 && req.Revision != document.Revision {
+		return nil, service.ErrPreconditionFailed
+	}
+	document.Content = base64.StdEncoding.EncodeToString(req.Content)
+	document.Size = int64(len(req.Content))
+	document.Version++
+	document.Revision++
+	return document, nil
+}
+
+func (f *fakeService) DeleteDocument(_ context.Context, id uuid.UUID, _ string) (uuid.UUID, error) {
+	if _, ok := f.documents[id]; !ok {
+		return uuid.Nil, service.ErrDocumentNotFound
+	}
+	delete(f.documents, id)
+	return id, nil
+}
+
+func (f *fakeService) SetLegalHold(_ context.Context, id uuid.UUID, _ bool) error {
+	if _, ok := f.documents[id]; !ok {
+		return service.ErrDocumentNotFound
+	}
+	return nil
+}
+
+// newTestServer serves the api, the first failures requests are answered
+// with 503 before they reach it.
+func newTestServer(t *testing.T, s api.Service, failures int32) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	router := gin.New()
+	api.NewServer(router, logger.New("error"), s, &config.Config{AdminToken: adminToken})
+
+	var failed atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failed.Add(1) <= failures {
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newLossyServer serves the api, the responses to the first lost requests are
+// replaced with 502 as by a gateway that lost them.
+func newLossyServer(t *testing.T, s api.Service, lost int32) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	router := gin.New()
+	api.NewServer(router, logger.New("error"), s, &config.Config{AdminToken: adminToken})
+
+	var served atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1) <= lost {
+			router.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// onlyReader hides the io.Seeker of a reader.
+type onlyReader struct {
+	io.Reader
+}
+
+func Test_Authentication(t *testing.T) {
+	server := newTestServer(t, newFakeService(), 0)
+	ctx := context.Background()
+	c := New(server.URL, AdminToken(adminToken))
+
+	login, err := c.Register(ctx, "login345", "Passw_345")
+	require.NoError(t, err)
+	assert.Equal(t, "login345", login)
+
+	_, err = New(server.URL).Register(ctx, "login345", "Passw_345")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	token, err := c.Authenticate(ctx, "login345", "Passw_345")
+	require.NoError(t, err)
+	assert.Equal(t, testToken, token)
+
+	_, err = c.Authenticate(ctx, "login345", "wrong")
+	assert.ErrorIs(t, err, ErrNotFound)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, service.ErrUserNotFound.Error(), apiErr.Text)
+}
+
+func Test_UploadDownload(t *testing.T) {
+	s := newFakeService()
+	server := newTestServer(t, s, 0)
+	ctx := context.Background()
+	c := New(server.URL, Token(testToken))
+
+	content := strings.Repeat("0123456789", 100000)
+	uploaded, err := c.Upload(ctx, v1.Meta{Name: "report.txt", Mime: "text/plain"}, onlyReader{strings.NewReader(content)}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "report.txt", uploaded.Name)
+	assert.Equal(t, int64(len(content)), uploaded.Size)
+
+	var buf bytes.Buffer
+	info, err := c.Download(ctx, uploaded.ID, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, content, buf.String())
+	assert.Equal(t, uploaded.ID, info.ID)
+	assert.Equal(t, "report.txt", info.Name)
+	assert.Equal(t, 1, info.Revision)
+
+	_, err = c.WithToken("other").Download(ctx, uploaded.ID, io.Discard)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = c.Download(ctx, uuid.NewString(), io.Discard)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func Test_UpdateDocument(t *testing.T) {
+	s := newFakeService()
+	server := newTestServer(t, s, 0)
+	ctx := context.Background()
+	c := New(server.URL, Token(testToken))
+
+	uploaded, err := c.Upload(ctx, v1.Meta{Name: "report.txt", Mime: "text/plain"}, strings.NewReader("report"), "")
+	require.NoError(t, err)
+
+	name := "final.txt"
+	document, err := c.UpdateDocument(ctx, uploaded.ID, v1.UpdateDocumentReq{Name: &name}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "final.txt", document.Name)
+
+	_, err = c.UpdateDocument(ctx, uploaded.ID, v1.UpdateDocumentReq{Name: &name}, 1)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+}
+
+func Test_GetDocuments(t *testing.T) {
+	s := newFakeService()
+	server := newTestServer(t, s, 0)
+	ctx := context.Background()
+	c := New(server.URL, Token(testToken))
+
+	_, err := c.Upload(ctx, v1.Meta{Name: "report.txt", Mime: "text/plain"}, strings.NewReader("report"), "")
+	require.NoError(t, err)
+
+	public := true
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	page, err := c.GetDocuments(ctx, DocumentsFilter{
+		Scope:       dto.ScopeOwned,
+		Tags:        []string{"a", "b"},
+		Metadata:    map[string]any{"client": "acme", "year": 2024},
+		Public:      &public,
+		CreatedFrom: from,
+		Limit:       10,
+	})
+	require.NoError(t, err)
+	assert.Len(t, page.DataDocuments.Docs, 1)
+
+	assert.Equal(t, dto.ScopeOwned, s.filter.Scope)
+	assert.Equal(t, []string{"a", "b"}, s.filter.Tags)
+	assert.Equal(t, map[string]any{"client": "acme", "year": float64(2024)}, s.filter.Metadata)
+	assert.Equal(t, &public, s.filter.Public)
+	assert.True(t, from.Equal(*s.filter.CreatedFrom))
+	assert.Equal(t, 10, s.filter.Limit)
+}
+
+func Test_Retries(t *testing.T) {
+	ctx := context.Background()
+
+	var apiErr *Error
+
+	// a POST is not repeated
+	server := newTestServer(t, newFakeService(), 1)
+	c := New(server.URL, Retries(2, time.Millisecond))
+	_, err := c.Authenticate(ctx, "login345", "Passw_345")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+
+	// a PUT reaches the api on the third attempt
+	server = newTestServer(t, newFakeService(), 2)
+	c = New(server.URL, AdminToken(adminToken), Retries(2, time.Millisecond))
+	err = c.SetLegalHold(ctx, uuid.NewString(), true)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// an upload is repeated only with a key and a content that can be rewound
+	s := newFakeService()
+	server = newTestServer(t, s, 1)
+	c = New(server.URL, Token(testToken), Retries(2, time.Millisecond))
+	_, err = c.Upload(ctx, v1.Meta{Name: "a.txt", Mime: "text/plain"}, strings.NewReader("a"), "")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+
+	server = newTestServer(t, s, 1)
+	c = New(server.URL, Token(testToken), Retries(2, time.Millisecond))
+	_, err = c.Upload(ctx, v1.Meta{Name: "a.txt", Mime: "text/plain"}, onlyReader{strings.NewReader("a")}, "key-1")
+	require.ErrorAs(t, err, &apiErr)
+
+	server = newTestServer(t, s, 1)
+	c = New(server.URL, Token(testToken), Retries(2, time.Millisecond))
+	uploaded, err := c.Upload(ctx, v1.Meta{Name: "a.txt", Mime: "text/plain"}, strings.NewReader("content"), "key-2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("content")), uploaded.Size)
+	assert.Equal(t, int32(1), s.uploads.Load())
+}
+
+func Test_Retries_lostResponse(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeService()
+	document, err := s.Upload(ctx, &dto.Document{Token: testToken, Name: "a.txt", Mime: "text/plain"})
+	require.NoError(t, err)
+	id := document.ID.String()
+
+	var apiErr *Error
+
+	// a new version without If-Match is not repeated, it would be stored twice
+	server := newLossyServer(t, s, 1)
+	c := New(server.URL, Token(testToken), Retries(2, time.Millisecond))
+	_, err = c.UploadVersion(ctx, id, "", strings.NewReader("v2"), 0)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, 2, document.Version)
+
+	// with If-Match the repeated attempt is refused
+	server = newLossyServer(t, s, 1)
+	c = New(server.URL, Token(testToken), Retries(2, time.Millisecond))
+	_, err = c.UploadVersion(ctx, id, "", strings.NewReader("v3"), document.Revision)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.Equal(t, 3, document.Version)
+
+	// a repeated DELETE that finds nothing succeeds
+	server = newLossyServer(t, s, 1)
+	c = New(server.URL, Token(testToken), Retries(2, time.Millisecond))
+	require.NoError(t, c.DeleteDocument(ctx, id))
+	assert.Empty(t, s.documents)
+
+	// a DELETE of a missing document still fails
+	server = newLossyServer(t, s, 0)
+	c = New(server.URL, Token(testToken), Retries(2, time.Millisecond))
+	assert.ErrorIs(t, c.DeleteDocument(ctx, id), ErrNotFound)
+}
+
+func Test_Cancel(t *testing.T) {
+	server := newTestServer(t, newFakeService(), 100)
+	c := New(server.URL, Retries(10, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetDocuments(ctx, DocumentsFilter{Limit: 10})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+)
+
+func (c *Client) GetComments(ctx context.Context, id string) ([]v1.Comment, error) {
+	var resp v1.CommentsResp
+	err := c.call(ctx, newRequest(http.MethodGet, endpoint("/api/docs/%s/comments", id)), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Comments, nil
+}
+
+// AddComment comments the document, a reply when comment.ParentID is set.
+func (c *Client) AddComment(ctx context.Context, id string, comment v1.CommentReq) (*v1.Comment, error) {
+	var resp v1.CommentResp
+	err := c.call(ctx, newRequest(http.MethodPost, endpoint("/api/docs/%s/comments", id)).withJSON(comment), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// UpdateComment replaces the body of an own comment.
+func (c *Client) UpdateComment(ctx context.Context, id, commentID, body string) (*v1.Comment, error) {
+	req := newRequest(http.MethodPatch, endpoint("/api/docs/%s/comments/%s", id, commentID)).
+		withJSON(v1.CommentReq{Body: body})
+	req.replay = true
+
+	var resp v1.CommentResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+func (c *Client) DeleteComment(ctx context.Context, id, commentID string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/docs/%s/comments/%s", id, commentID)), nil)
+}
+
+// LockDocument checks the document out for the ttl, the server default when
+// it is 0. The holder of the lock extends it by locking again.
+func (c *Client) LockDocument(ctx context.Context, id string, ttl time.Duration) (*v1.Lock, error) {
+	req := newRequest(http.MethodPost, endpoint("/api/docs/%s/lock", id)).
+		withJSON(v1.LockReq{TTL: int(ttl / time.Second)})
+	req.replay = true
+
+	var resp v1.LockResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+func (c *Client) GetLock(ctx context.Context, id string) (*v1.Lock, error) {
+	var resp v1.LockResp
+	err := c.call(ctx, newRequest(http.MethodGet, endpoint("/api/docs/%s/lock", id)), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+func (c *Client) UnlockDocument(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/docs/%s/lock", id)), nil)
+}
@@ -0,0 +1,392 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+)
+
+// DocumentInfo is what the headers of a download tell about the document.
+// Owner is only known from Head.
+type DocumentInfo struct {
+	ID       string
+	Name     string
+	Mime     string
+	Size     int64
+	Checksum string
+	Owner    string
+	Version  int
+	Revision int
+	Public   bool
+	Modified time.Time
+}
+
+// DocumentsFilter has the meaning of the query parameters of GET /api/docs,
+// the zero values are not sent.
+type DocumentsFilter struct {
+	Scope        string
+	Login        string
+	Key          string
+	Value        string
+	Tags         []string
+	Metadata     map[string]any
+	NamePrefix   string
+	NameContains string
+	Mime         string
+	Public       *bool
+	Owner        string
+	CreatedFrom  time.Time
+	CreatedTo    time.Time
+	Sort         string
+	Order        string
+	Cursor       string
+	Limit        int
+}
+
+// Upload stores a new document with the content read from r. An empty
+// meta.Token is the token of the client. A non empty idempotencyKey makes a
+// repeated upload return the first document, the upload is then retried when
+// r is an io.Seeker.
+func (c *Client) Upload(ctx context.Context, meta v1.Meta, r io.Reader, idempotencyKey string) (*v1.Data, error) {
+	if meta.Token == "" {
+		meta.Token = c.token
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newRequest(http.MethodPost, "/api/docs")
+	var replay bool
+	req.body, replay = multipartBody([]formField{{name: "meta", value: string(data)}}, meta.Name, r)
+	if idempotencyKey != "" {
+		req.header.Set("Idempotency-Key", idempotencyKey)
+		req.replay = replay
+	}
+
+	var resp v1.UploadResponse
+	err = c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// UploadVersion replaces the content of the document. An empty mime keeps the
+// current one, a revision other than 0 is sent as If-Match. Only a request
+// with If-Match is repeated: the first attempt may have stored the version, a
+// repeated one is then refused instead of storing it again.
+func (c *Client) UploadVersion(ctx context.Context, id, mimeType string, r io.Reader, revision int) (*v1.Document, error) {
+	fields := make([]formField, 0, 1)
+	if mimeType != "" {
+		fields = append(fields, formField{name: "mime", value: mimeType})
+	}
+
+	req := newRequest(http.MethodPut, endpoint("/api/docs/%s/content", id))
+	var replay bool
+	req.body, replay = multipartBody(fields, id, r)
+	req.replay = replay && revision > 0
+	setIfMatch(req, revision)
+
+	var resp v1.UpdateDocumentResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// Download writes the content of the document to w.
+func (c *Client) Download(ctx context.Context, id string, w io.Writer) (*DocumentInfo, error) {
+	resp, err := c.do(ctx, newRequest(http.MethodGet, endpoint("/api/docs/%s", id)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return documentInfo(resp), nil
+}
+
+// Head describes the document without downloading the content.
+func (c *Client) Head(ctx context.Context, id string) (*DocumentInfo, error) {
+	resp, err := c.do(ctx, newRequest(http.MethodHead, endpoint("/api/docs/%s", id)))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return documentInfo(resp), nil
+}
+
+func (c *Client) GetDocumentMeta(ctx context.Context, id string) (*v1.DocumentMeta, error) {
+	var resp v1.DocumentMetaResp
+	err := c.call(ctx, newRequest(http.MethodGet, endpoint("/api/docs/%s/meta", id)), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+func (c *Client) GetDocumentText(ctx context.Context, id string) (*v1.DocumentText, error) {
+	var resp v1.DocumentTextResp
+	err := c.call(ctx, newRequest(http.MethodGet, endpoint("/api/docs/%s/text", id)), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// GetThumbnail writes the thumbnail of the size ("small", "medium" or
+// "large") to w and returns its type.
+func (c *Client) GetThumbnail(ctx context.Context, id, size string, w io.Writer) (string, error) {
+	req := newRequest(http.MethodGet, endpoint("/api/docs/%s/thumbnail", id))
+	req.query = url.Values{"size": {size}}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Header.Get("Content-Type"), nil
+}
+
+// GetDocuments returns a page of the documents, NextCursor of the page is the
+// Cursor of the next one. The server requires a Limit.
+func (c *Client) GetDocuments(ctx context.Context, filter DocumentsFilter) (*v1.GetDocumentsResp, error) {
+	req := newRequest(http.MethodGet, "/api/docs")
+	req.query = filter.query()
+
+	var resp v1.GetDocumentsResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// UpdateDocument changes the metadata of the document, a revision other than
+// 0 is sent as If-Match.
+func (c *Client) UpdateDocument(ctx context.Context, id string, update v1.UpdateDocumentReq, revision int) (*v1.Document, error) {
+	req := newRequest(http.MethodPatch, endpoint("/api/docs/%s", id)).withJSON(update)
+	setIfMatch(req, revision)
+
+	var resp v1.UpdateDocumentResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteDocument moves the document to the trash.
+func (c *Client) DeleteDocument(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/docs/%s", id)), nil)
+}
+
+func (c *Client) GetTrash(ctx context.Context) ([]v1.Document, error) {
+	var resp v1.GetDocumentsResp
+	err := c.call(ctx, newRequest(http.MethodGet, "/api/trash"), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.DataDocuments.Docs, nil
+}
+
+func (c *Client) RestoreDocument(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodPost, endpoint("/api/trash/%s/restore", id)), nil)
+}
+
+// Search finds documents by their text, a limit of 0 is the server default.
+func (c *Client) Search(ctx context.Context, query string, limit, offset int) (*v1.SearchData, error) {
+	req := newRequest(http.MethodGet, "/api/search")
+	req.query = url.Values{"q": {query}}
+	if limit > 0 {
+		req.query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		req.query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var resp v1.SearchResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// Archive writes a ZIP of the selected documents to w. An error after the
+// first byte leaves w with a cut archive.
+func (c *Client) Archive(ctx context.Context, archive v1.ArchiveReq, w io.Writer) error {
+	req := newRequest(http.MethodPost, "/api/docs/archive").withJSON(archive)
+	req.replay = true
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Import creates a document for every file of the archive read from r.
+func (c *Client) Import(ctx context.Context, meta v1.ImportMeta, fileName string, r io.Reader) (*v1.ImportReport, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newRequest(http.MethodPost, "/api/docs/import")
+	req.body, _ = multipartBody([]formField{{name: "meta", value: string(data)}}, fileName, r)
+
+	var resp v1.ImportResp
+	err = c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// ResolvePath addresses a folder or a document of the user by its path. The
+// content of a document is written to w and its info returned, the listing
+// of a folder is returned otherwise.
+func (c *Client) ResolvePath(ctx context.Context, path string, w io.Writer) (*v1.FolderContents, *DocumentInfo, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	resp, err := c.do(ctx, newRequest(http.MethodGet, "/api/fs/"+strings.Join(segments, "/")))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("X-Document-Id") != "" {
+		_, err = io.Copy(w, resp.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, documentInfo(resp), nil
+	}
+
+	var contents v1.FolderContentsResp
+	err = json.NewDecoder(resp.Body).Decode(&contents)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &contents.Data, nil, nil
+}
+
+func (f *DocumentsFilter) query() url.Values {
+	query := make(url.Values)
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	set("scope", f.Scope)
+	set("login", f.Login)
+	set("key", f.Key)
+	set("value", f.Value)
+	set("name_prefix", f.NamePrefix)
+	set("name_contains", f.NameContains)
+	set("mime", f.Mime)
+	set("owner", f.Owner)
+	set("sort", f.Sort)
+	set("order", f.Order)
+	set("cursor", f.Cursor)
+	query.Set("limit", strconv.Itoa(f.Limit))
+
+	for _, tag := range f.Tags {
+		query.Add("tag", tag)
+	}
+	for field, value := range f.Metadata {
+		query.Set("meta."+field, metadataValue(value))
+	}
+	if f.Public != nil {
+		query.Set("public", strconv.FormatBool(*f.Public))
+	}
+	if !f.CreatedFrom.IsZero() {
+		query.Set("created_from", f.CreatedFrom.Format(time.RFC3339))
+	}
+	if !f.CreatedTo.IsZero() {
+		query.Set("created_to", f.CreatedTo.Format(time.RFC3339))
+	}
+
+	return query
+}
+
+// metadataValue sends strings as they are and the other values as JSON, the
+// way the server reads "meta.<field>" parameters.
+func metadataValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func setIfMatch(req *request, revision int) {
+	if revision > 0 {
+		req.header.Set("If-Match", `"`+strconv.Itoa(revision)+`"`)
+	}
+}
+
+func documentInfo(resp *http.Response) *DocumentInfo {
+	header := resp.Header
+	info := &DocumentInfo{
+		ID:       header.Get("X-Document-Id"),
+		Mime:     header.Get("Content-Type"),
+		Size:     resp.ContentLength,
+		Checksum: header.Get("X-Document-Checksum"),
+		Owner:    header.Get("X-Document-Owner"),
+	}
+
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		info.Name = params["filename"]
+	}
+	info.Version, _ = strconv.Atoi(header.Get("X-Document-Version"))
+	info.Revision, _ = strconv.Atoi(strings.Trim(strings.TrimPrefix(header.Get("ETag"), "W/"), `"`))
+	info.Public, _ = strconv.ParseBool(header.Get("X-Document-Public"))
+	info.Modified, _ = http.ParseTime(header.Get("Last-Modified"))
+
+	return info
+}
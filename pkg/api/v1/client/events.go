@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Event is a change of a document, Data is the JSON body a webhook receives.
+type Event struct {
+	ID    string
+	Event string
+	Data  []byte
+}
+
+// Events streams the changes of the documents of the user to handle until the
+// context is done, the server closes the stream or handle returns an error.
+// Passing the ID of the last handled event resumes the stream after it.
+func (c *Client) Events(ctx context.Context, lastEventID string, handle func(Event) error) error {
+	req := newRequest(http.MethodGet, "/api/events")
+	req.header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var event Event
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "":
+			// a blank line ends an event, a line starting with ":" is a comment
+			if line != "" || event.Event == "" {
+				continue
+			}
+			err = handle(event)
+			if err != nil {
+				return err
+			}
+			event = Event{}
+		case "id":
+			event.ID = value
+		case "event":
+			event.Event = value
+		case "data":
+			if event.Data != nil {
+				event.Data = append(event.Data, '\n')
+			}
+			event.Data = append(event.Data, value...)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
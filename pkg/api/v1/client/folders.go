@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+)
+
+// CreateFolder creates a folder, an empty ParentID is the top level.
+func (c *Client) CreateFolder(ctx context.Context, folder v1.Folder) (*v1.Folder, error) {
+	var resp v1.FolderResp
+	err := c.call(ctx, newRequest(http.MethodPost, "/api/folders").withJSON(folder), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// GetFolderContents lists the folder, an empty id is the top level.
+func (c *Client) GetFolderContents(ctx context.Context, id string) (*v1.FolderContents, error) {
+	path := "/api/folders"
+	if id != "" {
+		path = endpoint("/api/folders/%s", id)
+	}
+
+	var resp v1.FolderContentsResp
+	err := c.call(ctx, newRequest(http.MethodGet, path), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// UpdateFolder renames or moves the folder.
+func (c *Client) UpdateFolder(ctx context.Context, id string, update v1.UpdateFolderReq) (*v1.Folder, error) {
+	var resp v1.FolderResp
+	err := c.call(ctx, newRequest(http.MethodPatch, endpoint("/api/folders/%s", id)).withJSON(update), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteFolder deletes an empty folder.
+func (c *Client) DeleteFolder(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/folders/%s", id)), nil)
+}
+
+// AddGrant shares the document with the login, an empty permission is read.
+func (c *Client) AddGrant(ctx context.Context, id, login, permission string) error {
+	req := newRequest(http.MethodPost, endpoint("/api/docs/%s/grants", id)).
+		withJSON(v1.GrantReq{Login: login, Permission: permission})
+	req.replay = true
+	return c.call(ctx, req, nil)
+}
+
+func (c *Client) RemoveGrant(ctx context.Context, id, login string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/docs/%s/grants/%s", id, login)), nil)
+}
+
+// AddFolderGrant shares every document of the folder and its subfolders.
+func (c *Client) AddFolderGrant(ctx context.Context, id, login, permission string) error {
+	req := newRequest(http.MethodPost, endpoint("/api/folders/%s/grants", id)).
+		withJSON(v1.GrantReq{Login: login, Permission: permission})
+	req.replay = true
+	return c.call(ctx, req, nil)
+}
+
+func (c *Client) RemoveFolderGrant(ctx context.Context, id, login string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/folders/%s/grants/%s", id, login)), nil)
+}
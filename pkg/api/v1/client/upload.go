@@ -0,0 +1,83 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"sync"
+)
+
+var errNotReplayable = errors.New("the content can not be sent again")
+
+type formField struct {
+	name  string
+	value string
+}
+
+// multipartBody streams the fields and the content as the "file" field of a
+// form without buffering the content. The body can be sent again only when
+// the content is an io.Seeker, it is rewound to where it started.
+func multipartBody(fields []formField, fileName string, content io.Reader) (func() (io.Reader, string, error), bool) {
+	seeker, replay := content.(io.Seeker)
+	var start int64
+	if replay {
+		var err error
+		start, err = seeker.Seek(0, io.SeekCurrent)
+		replay = err == nil
+	}
+
+	var (
+		mu      sync.Mutex
+		reader  *io.PipeReader
+		written chan struct{}
+	)
+
+	return func() (io.Reader, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if reader != nil {
+			if !replay {
+				return nil, "", errNotReplayable
+			}
+			// the writer of the previous attempt must be done with the content
+			reader.Close()
+			<-written
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, "", err
+			}
+		}
+
+		pr, pw := io.Pipe()
+		form := multipart.NewWriter(pw)
+		reader, written = pr, make(chan struct{})
+
+		go func(done chan struct{}) {
+			defer close(done)
+			pw.CloseWithError(writeForm(form, fields, fileName, content))
+		}(written)
+
+		return pr, form.FormDataContentType(), nil
+	}, replay
+}
+
+func writeForm(form *multipart.Writer, fields []formField, fileName string, content io.Reader) error {
+	for _, field := range fields {
+		err := form.WriteField(field.name, field.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	part, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, content)
+	if err != nil {
+		return err
+	}
+
+	return form.Close()
+}
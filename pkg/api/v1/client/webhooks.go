@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	v1 "github.com/Alina9496/documents/pkg/api/v1"
+)
+
+// CreateWebhook registers a webhook of the user, the returned Secret signs
+// the deliveries and is not shown again.
+func (c *Client) CreateWebhook(ctx context.Context, webhook v1.WebhookReq) (*v1.Webhook, error) {
+	return c.createWebhook(ctx, newRequest(http.MethodPost, "/api/webhooks").withJSON(webhook))
+}
+
+func (c *Client) GetWebhooks(ctx context.Context) ([]v1.Webhook, error) {
+	return c.getWebhooks(ctx, newRequest(http.MethodGet, "/api/webhooks"))
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, endpoint("/api/webhooks/%s", id)), nil)
+}
+
+func (c *Client) GetWebhookDeliveries(ctx context.Context, id string) ([]v1.WebhookDelivery, error) {
+	return c.getWebhookDeliveries(ctx, newRequest(http.MethodGet, endpoint("/api/webhooks/%s/deliveries", id)))
+}
+
+func (c *Client) createWebhook(ctx context.Context, req *request) (*v1.Webhook, error) {
+	var resp v1.WebhookResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+func (c *Client) getWebhooks(ctx context.Context, req *request) ([]v1.Webhook, error) {
+	var resp v1.WebhooksResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Webhooks, nil
+}
+
+func (c *Client) getWebhookDeliveries(ctx context.Context, req *request) ([]v1.WebhookDelivery, error) {
+	var resp v1.WebhookDeliveriesResp
+	err := c.call(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Deliveries, nil
+}